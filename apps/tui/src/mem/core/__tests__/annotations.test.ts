@@ -0,0 +1,81 @@
+// @ts-nocheck
+import { describe, expect, it } from "bun:test";
+import { SymbolIndex } from "../symbol-index.ts";
+
+const SOURCE = `package sample
+
+func Greet(name string) string {
+	return "hi " + name
+}
+
+func Count() int {
+	return 1
+}
+`;
+
+const WITHOUT_GREET = `package sample
+
+func Count() int {
+	return 1
+}
+`;
+
+function greetId(index: SymbolIndex): string {
+	const symbols = index.getFileSymbols("/virtual/a.go");
+	return symbols.find((s) => s.name === "Greet").id;
+}
+
+describe("symbol annotations", () => {
+	it("should keep annotations across updateFile while the id holds", async () => {
+		const index = new SymbolIndex({ root: "/virtual" });
+		await index.updateFile("/virtual/a.go", SOURCE);
+		const id = greetId(index);
+
+		index.annotate(id, "owner", "team-x");
+		index.annotate(id, "hot", "true");
+		await index.updateFile(
+			"/virtual/a.go",
+			SOURCE.replace('"hi "', '"hello "')
+		);
+
+		expect(greetId(index)).toBe(id);
+		expect(index.annotationsFor(id)).toEqual({ hot: "true", owner: "team-x" });
+		expect(index.getFileSymbols("/virtual/a.go")[0]).not.toHaveProperty(
+			"annotations"
+		);
+	});
+
+	it("should restore orphaned annotations when the symbol comes back", async () => {
+		const index = new SymbolIndex({ root: "/virtual" });
+		await index.updateFile("/virtual/a.go", SOURCE);
+		const id = greetId(index);
+		index.annotate(id, "status", "deprecated");
+
+		await index.updateFile("/virtual/a.go", WITHOUT_GREET);
+		expect(index.annotationsFor(id)).toEqual({});
+		expect(index.annotations().orphaned().get(id)?.annotations).toEqual({
+			status: "deprecated",
+		});
+
+		await index.updateFile("/virtual/a.go", SOURCE);
+		expect(index.annotationsFor(id)).toEqual({ status: "deprecated" });
+		expect(index.annotations().orphaned().size).toBe(0);
+	});
+
+	it("should drop orphaned annotations after the grace period", async () => {
+		let now = 0;
+		const index = new SymbolIndex({
+			annotations: { gracePeriodMs: 1000, now: () => now },
+			root: "/virtual",
+		});
+		await index.updateFile("/virtual/a.go", SOURCE);
+		const id = greetId(index);
+		index.annotate(id, "status", "deprecated");
+
+		await index.updateFile("/virtual/a.go", WITHOUT_GREET);
+		now = 5000;
+		await index.updateFile("/virtual/a.go", SOURCE);
+
+		expect(index.annotationsFor(id)).toEqual({});
+	});
+});
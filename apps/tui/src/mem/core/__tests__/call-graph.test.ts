@@ -0,0 +1,73 @@
+// @ts-nocheck
+import { describe, expect, it } from "bun:test";
+import { buildCallGraph } from "../call-graph.ts";
+import { GoParser } from "../parsers/go-parser.ts";
+import { symbolId } from "../symbol-id.ts";
+
+const SOURCE = `package shop
+
+import "fmt"
+
+func helper(n int) int {
+	return n * 2
+}
+
+type Cart struct {
+	items []int
+}
+
+func (c *Cart) Total() int {
+	sum := 0
+	for _, item := range c.items {
+		sum += helper(item)
+	}
+	return sum
+}
+
+func (c *Cart) Print() {
+	fmt.Println(c.Total())
+}
+
+func Checkout(c *Cart) string {
+	return fmt.Sprintf("%d", c.Total())
+}
+`;
+
+describe("buildCallGraph", () => {
+	it("should record structured call references in Go bodies", async () => {
+		const symbols = await new GoParser().parseSource("/virtual/shop.go", SOURCE);
+		const print = symbols.find((s) => s.name === "Cart.Print");
+
+		expect(print?.metadata?.receiverName).toBe("c");
+		expect(print?.metadata?.callRefs).toEqual([
+			{ name: "Println", qualifier: "fmt", importPath: "fmt", line: 22 },
+			{ name: "Total", qualifier: "c", line: 22 },
+		]);
+	});
+
+	it("should resolve package-local functions and receiver methods", async () => {
+		const symbols = await new GoParser().parseSource("/virtual/shop.go", SOURCE);
+		const graph = buildCallGraph(symbols);
+		const id = (name: string) =>
+			symbolId(symbols.find((s) => s.name === name));
+
+		expect(graph.callersOf(id("helper")).map((e) => e.caller)).toEqual([
+			id("Cart.Total"),
+		]);
+		expect(graph.callersOf(id("Cart.Total")).map((e) => e.caller)).toEqual([
+			id("Cart.Print"),
+		]);
+	});
+
+	it("should keep unresolved calls as string references", async () => {
+		const symbols = await new GoParser().parseSource("/virtual/shop.go", SOURCE);
+		const graph = buildCallGraph(symbols);
+		const checkout = symbols.find((s) => s.name === "Checkout");
+
+		const callees = graph.calleesOf(symbolId(checkout));
+		expect(callees.map((e) => [e.reference, e.callee])).toEqual([
+			["fmt.Sprintf", null],
+			["c.Total", null],
+		]);
+	});
+});
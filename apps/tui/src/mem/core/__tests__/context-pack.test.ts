@@ -0,0 +1,116 @@
+// @ts-nocheck
+import { describe, expect, it } from "bun:test";
+import { SymbolIndex } from "../symbol-index.ts";
+
+const SOURCE = `package shop
+
+// Cart holds items
+type Cart struct {
+	ID    string
+	Items []string
+	Total int
+	Owner string
+}
+
+// Add appends an item
+// and returns the new count.
+func (c *Cart) Add(item string) int {
+	c.Items = append(c.Items, item)
+	return len(c.Items)
+}
+
+func helper() {}
+`;
+
+// Оценка в строках: так бюджеты в тестах легко посчитать вручную
+const lines = (text: string) => text.split("\n").length;
+
+async function setup() {
+	const index = new SymbolIndex();
+	await index.updateFile("/virtual/shop/cart.go", SOURCE);
+	const id = (name: string) =>
+		index.getSymbols().find((s) => s.name === name)?.id as string;
+	return { add: id("Cart.Add"), cart: id("Cart"), helper: id("helper"), index };
+}
+
+describe("packContext", () => {
+	it("should include full bodies when the budget allows", async () => {
+		const { add, cart, helper, index } = await setup();
+		const packed = index.packContext([add, cart, helper], 100, {
+			estimateTokens: lines,
+		});
+
+		expect(packed.included).toEqual([add, cart, helper]);
+		expect(packed.truncated).toEqual([]);
+		expect(packed.dropped).toEqual([]);
+		expect(packed.text).toContain("\tc.Items = append(c.Items, item)");
+		expect(packed.tokens).toBe(lines(packed.text));
+	});
+
+	it("should prefer signatures of many symbols over one full body", async () => {
+		const { add, cart, helper, index } = await setup();
+		// Компактные фрагменты с разделителем: Add — 7, Cart — 10, helper — 4
+		const packed = index.packContext([add, cart, helper], 21, {
+			estimateTokens: lines,
+		});
+
+		expect(packed.truncated).toEqual([add]);
+		expect(packed.included).toEqual([cart, helper]);
+		expect(packed.text).toContain("func (c *Cart) Add(item string) int");
+		expect(packed.text).not.toContain("append(c.Items, item)");
+		expect(packed.tokens).toBeLessThanOrEqual(21);
+
+		const tight = index.packContext([add, cart, helper], 17, {
+			estimateTokens: lines,
+		});
+		expect(tight.truncated).toEqual([add]);
+		expect(tight.included).toEqual([cart]);
+		expect(tight.dropped).toEqual([helper]);
+	});
+
+	it("should order by relevance scores and drop unknown ids", async () => {
+		const { add, helper, index } = await setup();
+		const packed = index.packContext([add, helper, "missing"], 100, {
+			scores: { [helper]: 2, [add]: 1 },
+		});
+
+		expect(packed.included).toEqual([helper, add]);
+		expect(packed.dropped).toEqual(["missing"]);
+		expect(packed.text.indexOf("func helper")).toBeLessThan(
+			packed.text.indexOf("func (c *Cart) Add")
+		);
+	});
+
+	it("should add scoring bonuses to relevance", async () => {
+		const { add, cart, helper, index } = await setup();
+
+		expect(index.packContext([helper, cart, add], 100).included).toEqual([
+			helper,
+			cart,
+			add,
+		]);
+		expect(
+			index.packContext([helper, cart, add], 100, {
+				scoring: { exported: 1, kind: { struct: 0.5 } },
+			}).included
+		).toEqual([cart, add, helper]);
+	});
+
+	it("should prefer recently edited files with the recency weight", async () => {
+		const index = new SymbolIndex();
+		await index.updateFile("/virtual/old.go", "package a\n\nfunc Old() {}\n");
+		await Bun.sleep(5);
+		await index.updateFile("/virtual/new.go", "package a\n\nfunc New() {}\n");
+		const symbols = index.getSymbols();
+		const nameOf = (id) => symbols.find((s) => s.id === id)?.name;
+		const ids = symbols.map((s) => s.id);
+		const names = (options) =>
+			index.packContext(ids, 100, options).included.map(nameOf);
+
+		expect(names()).toEqual(["Old", "New"]);
+		expect(names({ scoring: { recency: 1, recencyHalfLife: 1 } })).toEqual([
+			"New",
+			"Old",
+		]);
+	});
+});
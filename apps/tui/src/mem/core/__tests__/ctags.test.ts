@@ -0,0 +1,67 @@
+// @ts-nocheck
+import { describe, expect, it } from "bun:test";
+import { join } from "node:path";
+import { toCtags } from "../ctags.ts";
+import { GoParser } from "../parsers/go-parser.ts";
+
+const fixtureDir = join(
+	import.meta.dir,
+	"..",
+	"parsers",
+	"__tests__",
+	"fixtures",
+	"go"
+);
+const fixturePath = join(fixtureDir, "sample.go");
+
+describe("toCtags", () => {
+	it("should emit extended format headers", async () => {
+		const symbols = await new GoParser().parse(fixturePath);
+		const lines = toCtags(symbols).split("\n");
+
+		expect(lines[0]).toStartWith("!_TAG_FILE_FORMAT\t2\t");
+		expect(lines[1]).toStartWith("!_TAG_FILE_SORTED\t0\t");
+
+		const sorted = toCtags(symbols, { order: "name" }).split("\n");
+		expect(sorted[1]).toStartWith("!_TAG_FILE_SORTED\t1\t");
+	});
+
+	it("should emit Go kinds, access and receiver scope", async () => {
+		const symbols = await new GoParser().parse(fixturePath);
+		const tags = toCtags(symbols, { baseDir: fixtureDir });
+		const getName = symbols.find((s) => s.name === "User.GetName");
+		const byName = (name: string) =>
+			tags.split("\n").find((l) => l.startsWith(`${name}\t`));
+
+		expect(byName("GetName")).toBe(
+			`GetName\tsample.go\t/^func (u *User) GetName() string {$/;"\tm\tline:${getName.startLine}\taccess:public\tstruct:User`
+		);
+		expect(byName("NewUser")?.split("\t")[3]).toBe("f");
+		expect(byName("User")?.split("\t")[3]).toBe("s");
+		expect(byName("Service")?.split("\t")[3]).toBe("i");
+		expect(byName("privateMethod")).toContain("\taccess:private\t");
+	});
+
+	it("should sort tag lines by name", async () => {
+		const symbols = await new GoParser().parse(fixturePath);
+		const names = toCtags(symbols, { order: "name" })
+			.trimEnd()
+			.split("\n")
+			.filter((l) => !l.startsWith("!_TAG_"))
+			.map((l) => l.split("\t")[0]);
+
+		expect(names).toEqual([...names].sort());
+	});
+
+	it("should group tag lines by kind by default", async () => {
+		const symbols = await new GoParser().parse(fixturePath);
+		const kinds = toCtags([...symbols].reverse())
+			.trimEnd()
+			.split("\n")
+			.filter((l) => !l.startsWith("!_TAG_"))
+			.map((l) => l.split("\t")[3]);
+
+		expect(kinds.indexOf("m")).toBeGreaterThan(kinds.lastIndexOf("f"));
+		expect(toCtags([...symbols].reverse())).toBe(toCtags(symbols));
+	});
+});
@@ -0,0 +1,215 @@
+// @ts-nocheck
+import { describe, expect, it } from "bun:test";
+import { join } from "node:path";
+import {
+	fieldsFor,
+	implementationsOf,
+	methodSetsFor,
+} from "../implementations.ts";
+import { GoParser } from "../parsers/go-parser.ts";
+
+const fixturePath = join(
+	import.meta.dir,
+	"..",
+	"parsers",
+	"__tests__",
+	"fixtures",
+	"go",
+	"sample.go"
+);
+
+const SOURCE = `package svc
+
+import "io"
+
+type Closer interface {
+	Close() error
+}
+
+type Service interface {
+	Closer
+	Process(data string) error
+}
+
+type Worker struct{}
+
+func (w Worker) Process(input string) error { return nil }
+func (w Worker) Close() error { return nil }
+
+type Pool struct{}
+
+func (p *Pool) Process(data string) error { return nil }
+func (p Pool) Close() error { return nil }
+
+type Broken struct{}
+
+func (b Broken) Process(data []byte) error { return nil }
+func (b Broken) Close() error { return nil }
+
+type Partial struct{}
+
+func (p Partial) Close() error { return nil }
+
+type Stream struct{}
+
+func (s Stream) Read(p []byte) (int, error) { return 0, nil }
+`;
+
+describe("implementationsOf", () => {
+	it("should match types whose method sets cover the interface", async () => {
+		const symbols = await new GoParser().parseSource("/virtual/svc.go", SOURCE);
+		const impls = implementationsOf(symbols, "Service");
+
+		expect(impls.map((i) => [i.type.name, i.pointerOnly])).toEqual([
+			["Worker", false],
+			["Pool", true],
+		]);
+	});
+
+	it("should accept package-qualified interface names", async () => {
+		const symbols = await new GoParser().parseSource("/virtual/svc.go", SOURCE);
+
+		expect(
+			implementationsOf(symbols, "svc.Closer").map((i) => i.type.name)
+		).toEqual(["Worker", "Pool", "Broken", "Partial"]);
+		expect(implementationsOf(symbols, "other.Closer")).toEqual([]);
+		expect(implementationsOf(symbols, "Missing")).toEqual([]);
+	});
+});
+
+describe("methodSetsFor", () => {
+	it("should put value receivers in both sets and pointer receivers in *T only", async () => {
+		const symbols = await new GoParser().parse(fixturePath);
+		const sets = methodSetsFor(symbols, "User");
+
+		expect(sets?.type.name).toBe("User");
+		expect(sets?.pointerMethodSet.map((m) => m.name)).toEqual([
+			"User.GetName",
+			"User.SetAge",
+			"User.privateMethod",
+		]);
+		expect(sets?.valueMethodSet.map((m) => m.name)).toEqual(["User.SetAge"]);
+	});
+
+	it("should resolve qualified names and skip interfaces", async () => {
+		const symbols = await new GoParser().parseSource("/virtual/svc.go", SOURCE);
+
+		const pool = methodSetsFor(symbols, "svc.Pool");
+		expect(pool?.valueMethodSet.map((m) => m.name)).toEqual(["Pool.Close"]);
+		expect(pool?.pointerMethodSet.map((m) => m.name)).toEqual([
+			"Pool.Process",
+			"Pool.Close",
+		]);
+		expect(methodSetsFor(symbols, "Service")).toBeNull();
+		expect(methodSetsFor(symbols, "other.Pool")).toBeNull();
+	});
+});
+
+const SHOP_USERS = `package shop
+
+type Base struct {
+	ID int
+}
+
+func (b Base) Key() string { return "" }
+
+type User struct {
+	Base
+	Name string
+}
+
+func (u *User) GetName() string { return u.Name }
+func (u User) Describe() string { return "" }
+
+type Log struct {
+	Name string
+}
+`;
+
+const SHOP_ADMINS = `package shop
+
+type Describer interface {
+	Describe() string
+}
+
+type Admin struct {
+	User
+	Level int
+	ID    string
+}
+
+type Guest struct {
+	*User
+}
+
+type Pair struct {
+	User
+	Log
+}
+`;
+
+describe("embedded struct promotion", () => {
+	const parseShop = async () => {
+		const parser = new GoParser();
+		return [
+			...(await parser.parseSource("/virtual/shop/users.go", SHOP_USERS)),
+			...(await parser.parseSource("/virtual/shop/admins.go", SHOP_ADMINS)),
+		];
+	};
+	const promotion = (members) =>
+		members.map((m) => [m.name, m.promotedFrom ?? m.metadata?.promotedFrom]);
+
+	it("should promote fields across files with the shallower name winning", async () => {
+		const symbols = await parseShop();
+
+		expect(promotion(fieldsFor(symbols, "Admin"))).toEqual([
+			[undefined, undefined],
+			["Level", undefined],
+			["ID", undefined],
+			[undefined, "User"],
+			["Name", "User"],
+		]);
+		const admin = fieldsFor(symbols, "shop.Admin");
+		expect(admin?.[3]).toMatchObject({ embedded: true, type: "Base" });
+		// Admin.ID затеняет Base.ID с глубины 2
+		expect(admin?.filter((f) => f.name === "ID")).toEqual([
+			expect.objectContaining({ type: "string" }),
+		]);
+		expect(fieldsFor(symbols, "Describer")).toBeNull();
+	});
+
+	it("should promote methods into value and pointer method sets", async () => {
+		const symbols = await parseShop();
+
+		const admin = methodSetsFor(symbols, "Admin");
+		expect(promotion(admin?.pointerMethodSet)).toEqual([
+			["User.GetName", "User"],
+			["User.Describe", "User"],
+			["Base.Key", "Base"],
+		]);
+		expect(promotion(admin?.valueMethodSet)).toEqual([
+			["User.Describe", "User"],
+			["Base.Key", "Base"],
+		]);
+		// Встраивание *User даёт и pointer receiver методы значению
+		expect(
+			methodSetsFor(symbols, "Guest")?.valueMethodSet.map((m) => m.name)
+		).toEqual(["User.GetName", "User.Describe", "Base.Key"]);
+		expect(
+			implementationsOf(symbols, "Describer").map((i) => i.type.name)
+		).toEqual(["User", "Admin", "Guest", "Pair"]);
+	});
+
+	it("should not promote names that are ambiguous at the same depth", async () => {
+		const symbols = await parseShop();
+
+		const fields = fieldsFor(symbols, "Pair")?.filter((f) => f.promotedFrom);
+		expect(fields?.map((f) => [f.name ?? f.type, f.promotedFrom])).toEqual([
+			["Base", "User"],
+			["ID", "Base"],
+		]);
+		expect(
+			methodSetsFor(symbols, "Pair")?.pointerMethodSet.map((m) => m.name)
+		).toEqual(["User.GetName", "User.Describe", "Base.Key"]);
+	});
+});
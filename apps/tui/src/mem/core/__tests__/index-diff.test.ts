@@ -0,0 +1,120 @@
+// @ts-nocheck
+import { describe, expect, it } from "bun:test";
+import { diffIndex } from "../index-diff.ts";
+import { SymbolIndex } from "../symbol-index.ts";
+
+const BEFORE = `package shop
+
+// Total sums the cart
+func Total(prices []int) int {
+	sum := 0
+	for _, p := range prices {
+		sum += p
+	}
+	return sum
+}
+
+func Discount(total int) int {
+	return total / 10
+}
+
+func Tax(total int) int {
+	return total / 5
+}
+
+func oldHelper(n int) int {
+	return n * 2
+}
+`;
+
+const AFTER = `package shop
+
+// Total sums the cart
+func Total(prices []int) int {
+	sum := 1
+	for _, p := range prices {
+		sum += p
+	}
+	return sum
+}
+
+func Discount(total int, rate int) int {
+	return total / rate
+}
+
+func Tax(total int) int {
+	return total / 5
+}
+
+func newHelper(n int) int {
+	return n * 2
+}
+
+func Refund(total int) int {
+	return -total
+}
+`;
+
+async function snapshots() {
+	const index = new SymbolIndex();
+	await index.updateFile("/virtual/shop/cart.go", BEFORE);
+	const before = index.exportIndex();
+	await index.updateFile("/virtual/shop/cart.go", AFTER);
+	return { after: index.exportIndex(), before };
+}
+
+describe("diffIndex", () => {
+	it("should report added, removed and modified symbols by id", async () => {
+		const { after, before } = await snapshots();
+		const diff = diffIndex(before, after);
+
+		expect(diff.added.map((s) => s.name)).toEqual(["newHelper", "Refund"]);
+		expect(diff.removed.map((s) => s.name)).toEqual(["oldHelper"]);
+		expect(diff.renamed).toEqual([]);
+		expect(diff.modified.map((m) => [m.after.name, m.scope])).toEqual([
+			["Total", "body"],
+			["Discount", "signature"],
+		]);
+
+		const discount = diff.modified.find((m) => m.after.name === "Discount");
+		expect(discount?.oldSignature).toBe("func Discount(total int) int");
+		// Сигнатура каноническая: соседние параметры одного типа сгруппированы
+		expect(discount?.newSignature).toBe("func Discount(total, rate int) int");
+	});
+
+	it("should link renames within a file when detectRenames is set", async () => {
+		const { after, before } = await snapshots();
+		const diff = diffIndex(before, after, { detectRenames: true });
+
+		expect(diff.renamed.map((r) => [r.from.name, r.to.name])).toEqual([
+			["oldHelper", "newHelper"],
+		]);
+		expect(diff.added.map((s) => s.name)).toEqual(["Refund"]);
+		expect(diff.removed).toEqual([]);
+	});
+
+	it("should render a Markdown report", async () => {
+		const { after, before } = await snapshots();
+		const markdown = diffIndex(before, after, {
+			detectRenames: true,
+		}).toMarkdown();
+
+		expect(markdown).toContain("1 added, 0 removed, 2 modified, 1 renamed");
+		expect(markdown).toContain(
+			"- `Refund` (function) — /virtual/shop/cart.go:24"
+		);
+		expect(markdown).toContain("  - before: `func Discount(total int) int`");
+		expect(markdown).toContain("- `oldHelper` → `newHelper`");
+		expect(markdown).not.toContain("### Removed");
+	});
+
+	it("should return an empty diff for identical snapshots", async () => {
+		const index = new SymbolIndex();
+		await index.updateFile("/virtual/shop/cart.go", BEFORE);
+		const diff = diffIndex(index.exportIndex(), index.exportIndex());
+
+		expect(diff.added).toEqual([]);
+		expect(diff.removed).toEqual([]);
+		expect(diff.modified).toEqual([]);
+	});
+});
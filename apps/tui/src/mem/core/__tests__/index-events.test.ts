@@ -0,0 +1,139 @@
+// @ts-nocheck
+import { describe, expect, it } from "bun:test";
+import { diffSymbol } from "../index-events.ts";
+import { SymbolIndex } from "../symbol-index.ts";
+
+const SOURCE = `package sample
+
+func Greet() string {
+	return "hi"
+}
+
+func Count() int {
+	return 1
+}
+`;
+
+describe("index events", () => {
+	it("should publish one batch per updateFile", async () => {
+		const index = new SymbolIndex();
+		const batches = [];
+		index.events().onBatch((events) => batches.push(events));
+
+		await index.updateFile("/virtual/a.go", SOURCE);
+		await index.updateFile(
+			"/virtual/a.go",
+			SOURCE.replace('return "hi"', 'return "hello"').replace(
+				"func Count() int {\n\treturn 1\n}\n",
+				""
+			)
+		);
+
+		expect(batches.map((batch) => batch.map((e) => e.type))).toEqual([
+			["fileIndexed", "symbolAdded", "symbolAdded"],
+			["fileIndexed", "symbolChanged", "symbolRemoved"],
+		]);
+		const [indexed, changed, removed] = batches[1];
+		expect(indexed.path).toBe("/virtual/a.go");
+		expect(indexed.symbolIds).toEqual([changed.id]);
+		expect(changed.id).toBe(changed.symbol.id);
+		expect(changed.symbol.name).toBe("Greet");
+		expect(changed.diff.fields).toContain("body");
+		expect(changed.diff.signature).toBeUndefined();
+		expect(removed.symbol.name).toBe("Count");
+	});
+
+	it("should report removed files and parse errors", async () => {
+		const index = new SymbolIndex();
+		await index.updateFile("/virtual/a.go", SOURCE);
+		const errors = [];
+		const removals = [];
+		index.events().on("indexError", (event) => errors.push(event));
+		index.events().on("fileRemoved", (event) => removals.push(event));
+
+		const broken = SOURCE.replace(
+			"func Count() int {\n\treturn 1\n}",
+			"func Count() {\n\treturn (\n"
+		);
+		await index.updateFile("/virtual/a.go", broken);
+		expect(errors).toHaveLength(1);
+		expect(errors[0].path).toBe("/virtual/a.go");
+		expect(errors[0].diagnostics.length).toBeGreaterThan(0);
+
+		const ids = index.getFileSymbols("/virtual/a.go").map((s) => s.id);
+		index.removeFile("/virtual/a.go");
+		expect(removals).toEqual([
+			{ type: "fileRemoved", path: "/virtual/a.go", symbolIds: ids },
+		]);
+	});
+
+	it("should report a rename to an unsupported path as removal", async () => {
+		const index = new SymbolIndex();
+		await index.updateFile("/virtual/a.go", SOURCE);
+		const ids = index.getFileSymbols("/virtual/a.go").map((s) => s.id);
+		const batches = [];
+		index.events().onBatch((events) => batches.push(events));
+
+		const changes = await index.renameFile(
+			"/virtual/a.go",
+			"/virtual/a.go.bak"
+		);
+		expect(changes.map((c) => [c.type, c.symbol.name])).toEqual([
+			["removed", "Greet"],
+			["removed", "Count"],
+		]);
+		expect(batches).toHaveLength(1);
+		expect(batches[0][0]).toEqual({
+			type: "fileRemoved",
+			path: "/virtual/a.go",
+			symbolIds: ids,
+		});
+		expect(index.getFiles()).toEqual([]);
+	});
+
+	it("should keep notifying others when a listener throws", async () => {
+		const index = new SymbolIndex();
+		const added = [];
+		index.events().onBatch(() => {
+			throw new Error("boom");
+		});
+		const stop = index.events().on("symbolAdded", (event) => {
+			added.push(event.symbol.name);
+		});
+		index.events().on("symbolAdded", () => {
+			throw new Error("boom");
+		});
+
+		await index.updateFile("/virtual/a.go", SOURCE);
+		expect(added).toEqual(["Greet", "Count"]);
+
+		stop();
+		await index.updateFile("/virtual/b.go", SOURCE);
+		expect(added).toEqual(["Greet", "Count"]);
+	});
+
+	it("should diff symbol fields and declaration heads", () => {
+		const before = {
+			name: "Count",
+			path: "/virtual/a.go",
+			symbolType: "function",
+			startLine: 3,
+			endLine: 5,
+			body: "func Count() int {\n\treturn 1\n}",
+			metadata: { returnType: "int" },
+		};
+		const after = {
+			...before,
+			body: "func Count() int64 {\n\treturn 1\n}",
+			metadata: { returnType: "int64" },
+		};
+
+		expect(diffSymbol(before, after)).toEqual({
+			fields: ["body", "metadata.returnType"],
+			signature: {
+				after: "func Count() int64 {",
+				before: "func Count() int {",
+			},
+		});
+	});
+});
@@ -0,0 +1,126 @@
+// @ts-nocheck
+import { afterEach, describe, expect, it } from "bun:test";
+import { existsSync, mkdtempSync, rmSync, writeFileSync } from "node:fs";
+import { tmpdir } from "node:os";
+import { join } from "node:path";
+import { createIndex, sharedIndex } from "../index-lifecycle.ts";
+import { ParseCache } from "../parse-cache.ts";
+
+const alpha = (i: number) =>
+	`package a\n\nfunc Alpha${i}() {}\n\nfunc Beta() {}\n`;
+const gamma = (i: number) => `package a\n\nfunc Gamma${i}() {}\n`;
+
+const tick = () => new Promise((resolve) => setTimeout(resolve, 0));
+
+describe("createIndex lifecycle", () => {
+	const roots: string[] = [];
+
+	const makeRoot = () => {
+		const root = mkdtempSync(join(tmpdir(), "index-lifecycle-"));
+		roots.push(root);
+		return root;
+	};
+
+	afterEach(() => {
+		for (const root of roots.splice(0)) {
+			rmSync(root, { recursive: true, force: true });
+		}
+	});
+
+	it("should serialize concurrent updates so searches never see partial state", async () => {
+		const rejections: unknown[] = [];
+		const onRejection = (reason: unknown) => rejections.push(reason);
+		process.on("unhandledRejection", onRejection);
+
+		const index = await createIndex();
+		const updates = Array.from({ length: 20 }, (_, i) => [
+			index.updateFile("/virtual/a.go", alpha(i)),
+			index.updateFile("/virtual/b.go", gamma(i)),
+		]).flat();
+
+		let settled = false;
+		const all = Promise.all(updates).finally(() => {
+			settled = true;
+		});
+		while (!settled) {
+			const names = ["alpha", "beta", "gamma"].flatMap((query) =>
+				index.searchSymbols(query).map((r) => r.symbol.name)
+			);
+			// Файл a.go применяется целиком: Alpha* и Beta появляются вместе
+			const alphas = names.filter((n) => n.startsWith("Alpha"));
+			const betas = names.filter((n) => n === "Beta");
+			expect(alphas.length).toBe(betas.length);
+			expect(alphas.length).toBeLessThanOrEqual(1);
+			const gammas = names.filter((n) => n.startsWith("Gamma"));
+			expect(gammas.length).toBeLessThanOrEqual(1);
+			expect(new Set(names).size).toBe(names.length);
+			await tick();
+		}
+
+		const changes = (await all).flat();
+		// Каждое добавление парно удалению: дубликатов не остаётся
+		const net = (path: string) =>
+			changes
+				.filter((c) => c.path === path && c.type !== "changed")
+				.reduce((n, c) => n + (c.type === "added" ? 1 : -1), 0);
+		expect(net("/virtual/a.go")).toBe(2);
+		expect(net("/virtual/b.go")).toBe(1);
+		const final = index.getFileSymbols("/virtual/a.go");
+		expect(final.map((s) => s.name)).toEqual(["Alpha19", "Beta"]);
+		expect(index.symbolCount()).toBe(3);
+		index.assertInvariants();
+
+		await index.dispose();
+		await tick();
+		process.off("unhandledRejection", onRejection);
+		expect(rejections).toEqual([]);
+	});
+
+	it("should drain queued updates, flush the cache and reject new writes on dispose", async () => {
+		const root = makeRoot();
+		const cachePath = join(root, "cache", "parse-cache.json");
+		const index = await createIndex({
+			cache: new ParseCache({ filePath: cachePath }),
+		});
+		const received = [];
+		index.onChange((changes) => received.push(changes));
+
+		const pending = index.updateFile("/virtual/a.go", alpha(1));
+		const disposing = index.dispose();
+
+		expect((await pending).map((c) => c.symbol.name)).toEqual([
+			"Alpha1",
+			"Beta",
+		]);
+		await disposing;
+		expect(index.disposed).toBe(true);
+		expect(existsSync(cachePath)).toBe(true);
+		expect(index.dispose()).toBe(disposing);
+
+		await expect(index.updateFile("/virtual/a.go", alpha(2))).rejects.toThrow(
+			"disposed"
+		);
+		expect(() => index.removeFile("/virtual/a.go")).toThrow("disposed");
+		expect(received).toHaveLength(1);
+	});
+
+	it("should stop the watcher on dispose", async () => {
+		const root = makeRoot();
+		const index = await createIndex({ root, watch: { debounceMs: 20 } });
+		await index.dispose();
+
+		writeFileSync(join(root, "a.go"), alpha(1));
+		await new Promise((resolve) => setTimeout(resolve, 150));
+		expect(index.getFiles()).toEqual([]);
+	});
+
+	it("should share one index between concurrent callers until disposed", async () => {
+		const [first, second] = await Promise.all([sharedIndex(), sharedIndex()]);
+		expect(first).toBe(second);
+
+		await first.dispose();
+		const next = await sharedIndex();
+		expect(next).not.toBe(first);
+		await next.dispose();
+	});
+});
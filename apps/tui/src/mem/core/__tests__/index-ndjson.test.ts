@@ -0,0 +1,163 @@
+// @ts-nocheck
+import { describe, expect, it } from "bun:test";
+import { join } from "node:path";
+import { Writable } from "node:stream";
+import { readIndexRecords } from "../index-ndjson.ts";
+import { SymbolIndex } from "../symbol-index.ts";
+
+const fixturePath = join(
+	import.meta.dir,
+	"..",
+	"parsers",
+	"__tests__",
+	"fixtures",
+	"go",
+	"sample.go"
+);
+
+const buildIndex = async () => {
+	const index = new SymbolIndex();
+	await index.updateFile(fixturePath);
+	await index.updateFile(
+		"/virtual/b.go",
+		"package b\n\n// Größe считает размер\nfunc Größe() int { return helper() }\n\nfunc helper() int { return 1 }\n"
+	);
+	return index;
+};
+
+/**
+ * Writable с крошечным буфером и асинхронной записью, чтобы экспорт
+ * упирался в backpressure
+ */
+const collect = () => {
+	const chunks: string[] = [];
+	const writable = new Writable({
+		highWaterMark: 16,
+		write(chunk, _encoding, callback) {
+			chunks.push(chunk.toString());
+			setTimeout(callback, 0);
+		},
+	});
+	return { text: () => chunks.join(""), writable };
+};
+
+async function* bytesOf(text: string, size: number) {
+	const bytes = new TextEncoder().encode(text);
+	for (let i = 0; i < bytes.length; i += size) {
+		yield bytes.subarray(i, i + size);
+	}
+}
+
+describe("index NDJSON export", () => {
+	it("should stream the same content as exportIndex, one record per line", async () => {
+		const index = await buildIndex();
+		const { text, writable } = collect();
+		await index.exportIndexNDJSON(writable);
+
+		const records = text()
+			.trimEnd()
+			.split("\n")
+			.map((line) => JSON.parse(line));
+		expect(records[0]).toEqual({ kind: "header", schemaVersion: 1 });
+
+		const blob = index.exportIndex();
+		expect({
+			schemaVersion: records[0].schemaVersion,
+			files: records
+				.filter((r) => r.kind === "file")
+				.map(({ path, symbols }) => ({ path, symbols })),
+			relationships: {
+				calls: records
+					.filter((r) => r.kind === "call")
+					.map(({ kind: _kind, ...edge }) => edge),
+			},
+		}).toEqual(JSON.parse(JSON.stringify(blob)));
+		expect(records.filter((r) => r.kind === "file")).toHaveLength(2);
+		expect(blob.relationships.calls.length).toBeGreaterThan(0);
+	});
+
+	it("should rebuild an equivalent index from chunks split mid-line and mid-character", async () => {
+		const index = await buildIndex();
+		const { text, writable } = collect();
+		await index.exportIndexNDJSON(writable);
+
+		const restored = new SymbolIndex();
+		await restored.updateFile(
+			"/virtual/stale.go",
+			"package s\n\nfunc Stale() {}\n"
+		);
+		await restored.importIndexNDJSON(bytesOf(text(), 7));
+
+		expect(restored.getFiles()).toEqual(index.getFiles());
+		expect(restored.exportIndex()).toEqual(
+			JSON.parse(JSON.stringify(index.exportIndex()))
+		);
+		const helper = restored
+			.getFileSymbols("/virtual/b.go")
+			.find((s) => s.name === "helper");
+		expect(restored.callersOf(helper.id)).toHaveLength(1);
+	});
+
+	it("should hold concurrent updates until the export finishes", async () => {
+		const index = await buildIndex();
+		const blob = JSON.parse(JSON.stringify(index.exportIndex()));
+		const { text, writable } = collect();
+
+		const exported = index.exportIndexNDJSON(writable);
+		const updated = index.updateFile(
+			"/virtual/b.go",
+			"package b\n\nfunc Other() {}\n"
+		);
+		await Promise.all([exported, updated]);
+
+		const restored = new SymbolIndex();
+		await restored.importIndexNDJSON(bytesOf(text(), 64));
+		expect(restored.exportIndex()).toEqual(blob);
+		expect(
+			index.getFileSymbols("/virtual/b.go").map((s) => s.name)
+		).toContain("Other");
+	});
+
+	it("should yield records as they arrive and skip blank lines", async () => {
+		async function* lines() {
+			yield '{"kind":"header","schemaVersion":1}\n\n{"kind":"fi';
+			yield 'le","path":"/a.go","symbols":[]}\r\n';
+			yield '{"kind":"file","path":"/b.go","symbols":[]}';
+		}
+		const records = [];
+		for await (const record of readIndexRecords(lines())) {
+			records.push(record);
+		}
+
+		expect(records.map((r) => r.path ?? r.kind)).toEqual([
+			"header",
+			"/a.go",
+			"/b.go",
+		]);
+	});
+
+	it("should reject streams without a supported header", async () => {
+		const index = await buildIndex();
+		const files = index.getFiles();
+
+		await expect(
+			index.importIndexNDJSON(
+				bytesOf('{"kind":"file","path":"/a.go","symbols":[]}\n', 64)
+			)
+		).rejects.toThrow("Invalid index document");
+		await expect(
+			index.importIndexNDJSON(
+				bytesOf('{"kind":"header","schemaVersion":999}\n', 64)
+			)
+		).rejects.toThrow("newer than supported");
+		expect(index.getFiles()).toEqual(files);
+
+		// Состояние сбрасывается после заголовка: прочитанное до ошибки остаётся
+		await expect(
+			index.importIndexNDJSON(
+				bytesOf('{"kind":"header","schemaVersion":1}\nnot json\n', 64)
+			)
+		).rejects.toThrow("line 2");
+		expect(index.getFiles()).toEqual([]);
+	});
+});
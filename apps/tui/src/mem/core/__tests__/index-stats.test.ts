@@ -0,0 +1,67 @@
+// @ts-nocheck
+import { describe, expect, it } from "bun:test";
+import { SymbolIndex } from "../symbol-index.ts";
+
+const GO_SOURCE = `package store
+
+func Open() {}
+
+func parse(s string) int {
+	return 0
+}
+
+type Store struct{}
+`;
+
+const PY_SOURCE = "def run():\n    pass\n";
+
+describe("indexStats", () => {
+	it("should break symbols down by language, kind and export", async () => {
+		const index = new SymbolIndex();
+		await index.updateFile("/virtual/store.go", GO_SOURCE);
+		await index.updateFile("/virtual/run.py", PY_SOURCE);
+
+		expect(index.indexStats()).toEqual({
+			avgFunctionLines: 2,
+			byKind: { function: 3, struct: 1 },
+			byLanguage: {
+				go: { files: 1, symbols: 3 },
+				python: { files: 1, symbols: 1 },
+			},
+			exported: 3,
+			files: 2,
+			filesWithErrors: 0,
+			parseErrors: 0,
+			symbols: 4,
+			unexported: 1,
+		});
+	});
+
+	it("should update incrementally as files change and go away", async () => {
+		const index = new SymbolIndex();
+		await index.updateFile("/virtual/store.go", GO_SOURCE);
+		await index.updateFile("/virtual/run.py", PY_SOURCE);
+
+		await index.updateFile(
+			"/virtual/store.go",
+			"package store\n\nfunc Open() {\n\treturn (\n"
+		);
+		const broken = index.indexStats();
+		expect(broken.filesWithErrors).toBe(1);
+		expect(broken.parseErrors).toBeGreaterThan(0);
+
+		index.removeFile("/virtual/store.go");
+		const stats = index.indexStats();
+		expect(stats.byLanguage).toEqual({ python: { files: 1, symbols: 1 } });
+		expect(stats.byKind).toEqual({ function: 1 });
+		expect([stats.files, stats.symbols, stats.parseErrors]).toEqual([1, 1, 0]);
+
+		index.removeFile("/virtual/run.py");
+		expect(index.indexStats()).toMatchObject({
+			avgFunctionLines: 0,
+			byLanguage: {},
+			files: 0,
+			symbols: 0,
+		});
+	});
+});
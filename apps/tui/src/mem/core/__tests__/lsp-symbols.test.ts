@@ -0,0 +1,64 @@
+// @ts-nocheck
+import { describe, expect, it } from "bun:test";
+import { join } from "node:path";
+import { LspSymbolKind, toDocumentSymbols } from "../lsp-symbols.ts";
+import { GoParser } from "../parsers/go-parser.ts";
+
+const fixturePath = join(
+	import.meta.dir,
+	"..",
+	"parsers",
+	"__tests__",
+	"fixtures",
+	"go",
+	"sample.go"
+);
+
+describe("toDocumentSymbols", () => {
+	it("should nest fields and methods under their struct", async () => {
+		const symbols = await new GoParser().parse(fixturePath);
+		const docSymbols = toDocumentSymbols(symbols);
+		const user = docSymbols.find((s) => s.name === "User");
+
+		expect(user?.kind).toBe(LspSymbolKind.Struct);
+		expect(user?.children?.map((c) => [c.name, c.kind])).toEqual([
+			["ID", LspSymbolKind.Field],
+			["Name", LspSymbolKind.Field],
+			["Age", LspSymbolKind.Field],
+			["GetName", LspSymbolKind.Method],
+			["SetAge", LspSymbolKind.Method],
+			["privateMethod", LspSymbolKind.Method],
+		]);
+		expect(docSymbols.some((s) => s.name.startsWith("User."))).toBe(false);
+	});
+
+	it("should map symbol kinds to LSP values", async () => {
+		const symbols = await new GoParser().parse(fixturePath);
+		const kinds = Object.fromEntries(
+			toDocumentSymbols(symbols).map((s) => [s.name, s.kind])
+		);
+
+		expect(kinds.SimpleFunction).toBe(12);
+		expect(kinds.Service).toBe(11);
+		expect(kinds.MaxRetries).toBe(14);
+		expect(kinds.counter).toBe(13);
+	});
+
+	it("should convert ranges to 0-based LSP positions", async () => {
+		const symbols = await new GoParser().parse(fixturePath);
+		const getName = symbols.find((s) => s.name === "User.GetName");
+		const docSymbol = toDocumentSymbols(symbols)
+			.find((s) => s.name === "User")
+			?.children?.find((c) => c.name === "GetName");
+
+		expect(docSymbol?.range.start).toEqual({
+			line: getName.startLine - 1,
+			character: 0,
+		});
+		expect(docSymbol?.selectionRange).toEqual({
+			start: { line: getName.startLine - 1, character: 15 },
+			end: { line: getName.startLine - 1, character: 22 },
+		});
+		expect(docSymbol?.detail).toBe("() string");
+	});
+});
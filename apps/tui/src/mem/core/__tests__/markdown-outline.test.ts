@@ -0,0 +1,77 @@
+// @ts-nocheck
+import { describe, expect, it } from "bun:test";
+import { join } from "node:path";
+import { toMarkdownOutline } from "../markdown-outline.ts";
+import { GoParser } from "../parsers/go-parser.ts";
+
+const fixturePath = join(
+	import.meta.dir,
+	"..",
+	"parsers",
+	"__tests__",
+	"fixtures",
+	"go",
+	"sample.go"
+);
+
+describe("toMarkdownOutline", () => {
+	it("should render types with fields and methods", async () => {
+		const symbols = await new GoParser().parse(fixturePath);
+		const outline = toMarkdownOutline(symbols, { title: "sample" });
+
+		expect(outline.startsWith("# sample\n")).toBe(true);
+		expect(outline).toContain(
+			[
+				"## `User` (struct)",
+				"",
+				"User is a struct",
+				"",
+				"- `ID int`",
+				"- `Name string`",
+				"- `Age int`",
+				"- `GetName() string` — GetName is a method with receiver",
+				"- `SetAge(age int)` — SetAge is a method with value receiver",
+				"",
+			].join("\n")
+		);
+		expect(outline).toContain(
+			"- `SimpleFunction(name string) string` — SimpleFunction is a basic function with parameters"
+		);
+	});
+
+	it("should include private symbols only on request, after exported ones", async () => {
+		const symbols = await new GoParser().parse(fixturePath);
+
+		const publicOutline = toMarkdownOutline(symbols);
+		expect(publicOutline).not.toContain("privateMethod");
+		expect(publicOutline).not.toContain("counter");
+
+		const fullOutline = toMarkdownOutline(symbols, { includePrivate: true });
+		expect(fullOutline).toContain(
+			"- `privateMethod()` — privateMethod is not exported"
+		);
+		expect(fullOutline.indexOf("`SetAge")).toBeLessThan(
+			fullOutline.indexOf("`privateMethod")
+		);
+	});
+
+	it("should be deterministic regardless of input order", async () => {
+		const symbols = await new GoParser().parse(fixturePath);
+
+		expect(toMarkdownOutline([...symbols].reverse())).toBe(
+			toMarkdownOutline(symbols)
+		);
+	});
+
+	it("should order type members independently", async () => {
+		const symbols = await new GoParser().parse(fixturePath);
+		const outline = toMarkdownOutline(symbols, { memberOrder: "name" });
+
+		expect(outline).toContain(
+			["- `Age int`", "- `ID int`", "- `Name string`"].join("\n")
+		);
+		expect(outline.indexOf("## `Service`")).toBeGreaterThan(
+			outline.indexOf("## `User`")
+		);
+	});
+});
@@ -0,0 +1,123 @@
+// @ts-nocheck
+import { describe, expect, it } from "bun:test";
+import { findDuplicateNames } from "../name-collisions.ts";
+import { GoParser } from "../parsers/go-parser.ts";
+
+const files = {
+	"/virtual/store/file.go": [
+		"package store",
+		"",
+		"type File struct{}",
+		"",
+		"func (f *File) Close() error { return nil }",
+		"",
+		"func Open() *File { return nil }",
+		"",
+		"func init() {}",
+	],
+	"/virtual/store/conn.go": [
+		"package store",
+		"",
+		"type Conn struct{}",
+		"",
+		"func (c *Conn) Close() error { return nil }",
+		"",
+		"var config = 1",
+		"",
+		"func init() {}",
+	],
+	"/virtual/api/api.go": [
+		"package api",
+		"",
+		"type Config struct{}",
+		"",
+		"func Open() {}",
+		"",
+		"func Close() {}",
+		"",
+		"func load() {",
+		"\ttype config struct{}",
+		"}",
+	],
+	"/virtual/api/api_test.go": ["package api", "", "func Open() {}"],
+};
+
+const parseAll = async () => {
+	const parser = new GoParser({ captureLocals: true });
+	const results = await Promise.all(
+		Object.entries(files).map(([path, lines]) =>
+			parser.parseSource(path, lines.join("\n"))
+		)
+	);
+	return results.flat();
+};
+
+const summary = (groups) =>
+	groups.map((g) => [
+		g.name,
+		g.symbols.map((e) => `${e.packageName}:${e.symbol.name}:${e.line}`),
+	]);
+
+describe("findDuplicateNames", () => {
+	it("should group symbols sharing a name across packages", async () => {
+		const groups = findDuplicateNames(await parseAll());
+
+		expect(summary(groups)).toEqual([
+			["config", ["api:config:10", "store:config:7"]],
+			["Open", ["api:Open:5", "store:Open:7"]],
+		]);
+		const open = groups[1]?.symbols[0];
+		expect(open?.path).toBe("/virtual/api/api.go");
+		expect(open?.kind).toBe("function");
+	});
+
+	it("should group or mix methods by their name without receiver", async () => {
+		const symbols = await parseAll();
+
+		const grouped = findDuplicateNames(symbols, { methods: "group" });
+		expect(summary(grouped).find(([name]) => name === "Close")).toEqual([
+			"Close",
+			["store:Conn.Close:5", "store:File.Close:5"],
+		]);
+
+		const mixed = findDuplicateNames(symbols, { methods: "mixed" });
+		expect(summary(mixed).find(([name]) => name === "Close")).toEqual([
+			"Close",
+			["api:Close:7", "store:Conn.Close:5", "store:File.Close:5"],
+		]);
+	});
+
+	it("should scope groups by kind and package", async () => {
+		const symbols = await parseAll();
+
+		expect(summary(findDuplicateNames(symbols, { sameKind: true }))).toEqual([
+			["Open", ["api:Open:5", "store:Open:7"]],
+		]);
+		const local = findDuplicateNames(symbols, {
+			methods: "group",
+			samePackage: true,
+		});
+		expect(summary(local)).toEqual([
+			["Close", ["store:Conn.Close:5", "store:File.Close:5"]],
+		]);
+		expect(local[0]?.package).toBe("/virtual/store:store");
+	});
+
+	it("should honor ignore and includeTests", async () => {
+		const symbols = await parseAll();
+
+		const withTests = findDuplicateNames(symbols, {
+			ignore: [],
+			includeTests: true,
+		});
+		const counts = summary(withTests).map(([name, entries]) => [
+			name,
+			entries.length,
+		]);
+		expect(counts).toEqual([
+			["config", 2],
+			["init", 2],
+			["Open", 3],
+		]);
+	});
+});
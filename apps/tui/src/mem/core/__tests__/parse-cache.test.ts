@@ -0,0 +1,143 @@
+// @ts-nocheck
+import { afterAll, describe, expect, it } from "bun:test";
+import { mkdtempSync, rmSync } from "node:fs";
+import { tmpdir } from "node:os";
+import { join } from "node:path";
+import { hashSource, ParseCache } from "../parse-cache.ts";
+import { GoParser } from "../parsers/go-parser.ts";
+
+const fixturePath = join(
+	import.meta.dir,
+	"..",
+	"parsers",
+	"__tests__",
+	"fixtures",
+	"go",
+	"sample.go"
+);
+
+class CountingGoParser extends GoParser {
+	runs = 0;
+
+	protected doParseSourceWithDiagnostics(filePath: string, source: string) {
+		this.runs++;
+		return super.doParseSourceWithDiagnostics(filePath, source);
+	}
+}
+
+class UpgradedGoParser extends CountingGoParser {
+	readonly version = "2";
+}
+
+describe("ParseCache", () => {
+	const tempDir = mkdtempSync(join(tmpdir(), "parse-cache-test-"));
+
+	afterAll(() => {
+		rmSync(tempDir, { recursive: true, force: true });
+	});
+
+	it("should run the parser only once for unchanged content", async () => {
+		const cache = new ParseCache({ filePath: join(tempDir, "a.json") });
+		const parser = new CountingGoParser();
+
+		const first = await cache.parseFile(fixturePath, parser);
+		const second = await cache.parseFile(fixturePath, parser);
+
+		expect(parser.runs).toBe(1);
+		expect(second.map((s) => s.name)).toEqual(first.map((s) => s.name));
+	});
+
+	it("should persist entries to disk across instances", async () => {
+		const filePath = join(tempDir, "b.json");
+		const parser = new CountingGoParser();
+
+		const cache = new ParseCache({ filePath });
+		await cache.parseFile(fixturePath, parser);
+		await cache.flush();
+
+		const reloaded = new ParseCache({ filePath });
+		const symbols = await reloaded.parseFile(fixturePath, parser);
+
+		expect(parser.runs).toBe(1);
+		expect(symbols.some((s) => s.name === "NewUser")).toBe(true);
+	});
+
+	it("should invalidate entries on content or parser version change", async () => {
+		const cache = new ParseCache({ filePath: join(tempDir, "c.json") });
+		const parser = new CountingGoParser();
+
+		await cache.parseSource("/virtual/x.go", "package x\n", parser);
+		await cache.parseSource("/virtual/x.go", "package x\n\nfunc A() {}\n", parser);
+		expect(parser.runs).toBe(2);
+
+		const upgraded = new UpgradedGoParser();
+		await cache.parseSource("/virtual/x.go", "package x\n\nfunc A() {}\n", upgraded);
+		expect(upgraded.runs).toBe(1);
+	});
+
+	it("should reparse cached files after a parser version bump", async () => {
+		const filePath = join(tempDir, "d.json");
+		const cache = new ParseCache({ filePath });
+		await cache.parseFile(fixturePath, new CountingGoParser());
+		await cache.flush();
+
+		const reloaded = new ParseCache({ filePath });
+		const same = new CountingGoParser();
+		await reloaded.parseFile(fixturePath, same);
+		expect(same.runs).toBe(0);
+
+		const upgraded = new UpgradedGoParser();
+		const symbols = await reloaded.parseFile(fixturePath, upgraded);
+		expect(upgraded.runs).toBe(1);
+		expect(symbols.some((s) => s.name === "NewUser")).toBe(true);
+
+		await reloaded.parseFile(fixturePath, upgraded);
+		expect(upgraded.runs).toBe(1);
+	});
+
+	it("should bypass cached results when a preprocessor is set", async () => {
+		const cache = new ParseCache({ filePath: join(tempDir, "e.json") });
+		const source = "package x\n\n// @gen\nfunc A() {}\n";
+		const parser = new CountingGoParser();
+		await cache.parseSource("/virtual/x.go", source, parser);
+
+		// Препроцессор вырезает директиву, lineMap возвращает A на строку 4
+		parser.setPreprocessor((raw) => ({
+			source: raw.replace("// @gen\n", ""),
+			lineMap: [1, 2, 4],
+		}));
+		const first = await cache.parseSource("/virtual/x.go", source, parser);
+		const second = await cache.parseSource("/virtual/x.go", source, parser);
+		expect(parser.runs).toBe(3);
+		expect(first.find((s) => s.name === "A")?.startLine).toBe(4);
+		expect(second.find((s) => s.name === "A")?.startLine).toBe(4);
+		const hash = hashSource(source);
+		expect(await cache.lookup("/virtual/x.go", hash, parser)).toBeNull();
+
+		parser.setPreprocessor(null);
+		await cache.parseSource("/virtual/x.go", source, parser);
+		expect(parser.runs).toBe(3);
+	});
+
+	it("should not cache results with syntax errors", async () => {
+		const cache = new ParseCache({ filePath: join(tempDir, "f.json") });
+		const source = "package x\n\nfunc A( {\n";
+		const parser = new CountingGoParser();
+
+		const first = await cache.parseSourceWithDiagnostics(
+			"/virtual/broken.go",
+			source,
+			parser
+		);
+		await cache.parseSourceWithDiagnostics(
+			"/virtual/broken.go",
+			source,
+			parser
+		);
+		expect(first.diagnostics.some((d) => d.severity === "error")).toBe(true);
+		expect(parser.runs).toBe(2);
+		const hash = hashSource(source);
+		expect(await cache.lookup("/virtual/broken.go", hash, parser)).toBeNull();
+		expect(cache.peek("/virtual/broken.go", hash, parser)).toBeNull();
+	});
+});
@@ -0,0 +1,226 @@
+// @ts-nocheck
+import { describe, expect, it } from "bun:test";
+import { join } from "node:path";
+import { buildCallGraph } from "../call-graph.ts";
+import { buildReferenceIndex } from "../references.ts";
+import { SymbolIndex } from "../symbol-index.ts";
+
+const fixturePath = join(
+	import.meta.dir,
+	"..",
+	"parsers",
+	"__tests__",
+	"fixtures",
+	"go",
+	"sample.go"
+);
+
+async function indexFixture(): Promise<SymbolIndex> {
+	const index = new SymbolIndex();
+	await index.updateFile(fixturePath);
+	return index;
+}
+
+function idOf(index: SymbolIndex, name: string): string {
+	return index.getSymbols().find((s) => s.name === name)?.id;
+}
+
+describe("referencesTo", () => {
+	it("should find constructor and receivers referencing a type", async () => {
+		const index = await indexFixture();
+		const refs = index.referencesTo(idOf(index, "User"));
+		const names = new Set(refs.map((r) => r.symbol.name));
+
+		expect(names.has("NewUser")).toBe(true);
+		expect(names.has("User.GetName")).toBe(true);
+		expect(names.has("User.SetAge")).toBe(true);
+		expect(names.has("User.privateMethod")).toBe(true);
+		expect(names.has("User")).toBe(false);
+	});
+
+	it("should record the range of each mention", async () => {
+		const index = await indexFixture();
+		const refs = index
+			.referencesTo(idOf(index, "User"))
+			.filter((r) => r.symbol.name === "NewUser");
+
+		// func NewUser(name string, age int) *User {
+		expect(refs[0]).toMatchObject({
+			inBody: false,
+			kind: "type",
+			range: { startLine: 39, startCol: 36, endLine: 39, endCol: 40 },
+		});
+		// return &User{
+		expect(refs[1]).toMatchObject({
+			inBody: true,
+			range: { startLine: 40, startCol: 9 },
+		});
+	});
+
+	it("should include resolved calls and omit ambiguous types", async () => {
+		const index = new SymbolIndex();
+		await index.updateFile(
+			"/virtual/shop/a_linux.go",
+			"package shop\n\ntype Handle struct{ fd int }\n"
+		);
+		await index.updateFile(
+			"/virtual/shop/a_windows.go",
+			"package shop\n\ntype Handle struct{ h uintptr }\n"
+		);
+		await index.updateFile(
+			"/virtual/shop/open.go",
+			`package shop
+
+func helper() int { return 1 }
+
+func Open() *Handle {
+	helper()
+	return nil
+}
+`
+		);
+
+		const handles = index.getSymbols().filter((s) => s.name === "Handle");
+		for (const handle of handles) {
+			expect(index.referencesTo(handle.id)).toEqual([]);
+		}
+
+		const calls = index.referencesTo(idOf(index, "helper"));
+		expect(calls).toHaveLength(1);
+		expect(calls[0]).toMatchObject({
+			kind: "call",
+			range: { startLine: 6, startCol: 1, endLine: 6, endCol: 7 },
+		});
+		expect(calls[0].symbol.name).toBe("Open");
+	});
+});
+
+describe("external references", () => {
+	it("should unify aliases of the same import", async () => {
+		const index = new SymbolIndex();
+		await index.updateFile(
+			"/virtual/svc/a.go",
+			'package svc\n\nimport ctx "context"\n\nfunc A(c ctx.Context) {\n\tctx.Background()\n}\n'
+		);
+		await index.updateFile(
+			"/virtual/svc/b.go",
+			'package svc\n\nimport "context"\n\nfunc B(c context.Context) {\n\tcontext.Background()\n}\n'
+		);
+
+		const types = index.referencesTo("context.Context");
+		expect(types.map((r) => r.symbol.name)).toEqual(["A", "B"]);
+
+		const calls = index.callersOf("context.Background");
+		expect(calls.map((e) => e.reference)).toEqual([
+			"ctx.Background",
+			"context.Background",
+		]);
+		expect(
+			index.referencesTo("context.Background").map((r) => r.kind)
+		).toEqual(["call", "call"]);
+	});
+
+	it("should resolve unqualified names through dot imports", async () => {
+		const index = new SymbolIndex();
+		await index.updateFile(
+			"/virtual/models/user.go",
+			"package models\n\ntype User struct{ ID int }\n"
+		);
+		await index.updateFile(
+			"/virtual/app/load.go",
+			'package app\n\nimport (\n\t. "example.com/models"\n\t_ "embed"\n\t. "strings"\n)\n\nfunc Load() *User { return nil }\n\nfunc Split() Builder { return Builder{} }\n'
+		);
+		await index.updateFile(
+			"/virtual/web/page.go",
+			'package web\n\nimport . "strings"\n\nfunc Render() *Builder { return nil }\n'
+		);
+
+		const users = index.referencesTo(idOf(index, "User"));
+		expect(users.map((r) => r.symbol.name)).toEqual(["Load"]);
+
+		// Два dot-импорта: внешний Builder не приписывается ни одному из них
+		expect(
+			index.referencesTo("strings.Builder").map((r) => r.symbol.name)
+		).toEqual(["Render"]);
+		expect(index.referencesTo("embed.User")).toEqual([]);
+	});
+
+	it("should find types used as generic type arguments", async () => {
+		const index = new SymbolIndex();
+		await index.updateFile(
+			"/virtual/store/types.go",
+			"package store\n\ntype User struct{}\n\ntype List[T any] struct{ items []T }\n\ntype Cache struct {\n\tusers map[string]List[*User]\n}\n"
+		);
+
+		const refs = index.referencesTo(idOf(index, "User"));
+		expect(refs).toHaveLength(1);
+		expect(refs[0]).toMatchObject({ kind: "type", typeArgumentOf: "List" });
+		expect(refs[0].symbol.name).toBe("Cache");
+		expect(
+			index.referencesTo(idOf(index, "List")).map((r) => r.symbol.name)
+		).toEqual(["Cache"]);
+	});
+});
+
+describe("incremental graph and references", () => {
+	it("should match a full rebuild after editing one file", async () => {
+		const index = new SymbolIndex();
+		await index.updateFile(
+			"/virtual/shop/a.go",
+			`package shop
+
+func Checkout(c *Cart) int {
+	return helper(len(c.items))
+}
+`
+		);
+		await index.updateFile(
+			"/virtual/shop/b.go",
+			`package shop
+
+type Cart struct{ items []int }
+
+func helper(n int) int { return n * 2 }
+`
+		);
+		await index.updateFile(
+			"/virtual/other/c.go",
+			`package other
+
+func local() {}
+
+func Run() { local() }
+`
+		);
+		const helperId = idOf(index, "helper");
+		const cartId = idOf(index, "Cart");
+		expect(index.referencesTo(helperId)).toHaveLength(1);
+		expect(index.referencesTo(cartId)).toHaveLength(1);
+		const otherEdges = index
+			.callGraph()
+			.edges.filter((edge) => edge.caller.includes("other"));
+
+		await index.updateFile(
+			"/virtual/shop/b.go",
+			"package shop\n\ntype Basket struct{ items []int }\n"
+		);
+
+		const symbols = index.getSymbols();
+		const full = buildCallGraph(symbols);
+		expect(index.callGraph().edges).toEqual(full.edges);
+		expect(index.callersOf(helperId)).toEqual([]);
+		const calls = index.calleesOf(idOf(index, "Checkout"));
+		expect(calls.find((edge) => edge.reference === "helper")).toMatchObject({
+			callee: null,
+		});
+		expect(
+			index.callGraph().edges.filter((edge) => edge.caller.includes("other"))
+		).toEqual(otherEdges);
+
+		const references = buildReferenceIndex(symbols, full);
+		for (const id of [helperId, cartId, ...symbols.map((s) => s.id)]) {
+			expect(index.referencesTo(id)).toEqual(references.referencesTo(id));
+		}
+		expect(index.referencesTo(cartId)).toEqual([]);
+	});
+});
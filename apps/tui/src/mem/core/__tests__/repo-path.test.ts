@@ -0,0 +1,122 @@
+// @ts-nocheck
+import { afterAll, describe, expect, it } from "bun:test";
+import { mkdtempSync, rmSync, writeFileSync } from "node:fs";
+import { tmpdir } from "node:os";
+import { join } from "node:path";
+import {
+	absolutePathOf,
+	isAbsolutePath,
+	repoRelativePath,
+	resolveRepoPath,
+	toPosixPath,
+} from "../repo-path.ts";
+import { symbolId } from "../symbol-id.ts";
+import { SymbolIndex } from "../symbol-index.ts";
+import { parseFiles } from "../workspace.ts";
+
+const SOURCE = "package store\n\nfunc Open() {}\n";
+
+describe("repo paths", () => {
+	it("should normalize Windows-style separators and dot segments", () => {
+		expect(toPosixPath("store\\file.go")).toBe("store/file.go");
+		expect(toPosixPath(".\\store\\..\\store\\file.go")).toBe("store/file.go");
+		expect(toPosixPath("c:\\repo\\store\\")).toBe("C:/repo/store");
+		expect(isAbsolutePath("C:\\repo\\a.go")).toBe(true);
+		expect(isAbsolutePath("/repo/a.go")).toBe(true);
+		expect(isAbsolutePath("a\\b.go")).toBe(false);
+	});
+
+	it("should make paths relative to the repo root", () => {
+		expect(repoRelativePath("/repo/store/file.go", "/repo")).toBe(
+			"store/file.go"
+		);
+		expect(repoRelativePath("./store/file.go", "/repo")).toBe("store/file.go");
+		expect(repoRelativePath("store\\file.go", "/repo")).toBe("store/file.go");
+		expect(repoRelativePath("C:\\repo\\store\\file.go", "c:/repo/")).toBe(
+			"store/file.go"
+		);
+		// Вне корня и на другом диске путь остаётся абсолютным
+		expect(repoRelativePath("/other/file.go", "/repo")).toBe("/other/file.go");
+		expect(repoRelativePath("D:\\repo\\file.go", "C:\\repo")).toBe(
+			"D:/repo/file.go"
+		);
+	});
+
+	it("should resolve absolute paths on demand", () => {
+		expect(resolveRepoPath("store\\file.go", "/repo")).toBe(
+			"/repo/store/file.go"
+		);
+		expect(
+			absolutePathOf({ path: "x", relativePath: "store/file.go" }, "/repo")
+		).toBe("/repo/store/file.go");
+	});
+
+	it("should derive identical ids for every spelling of a path", () => {
+		const ids = [
+			"/repo/store/file.go",
+			"./store/file.go",
+			"store\\file.go",
+		].map((path) =>
+			symbolId(
+				{ name: "Open", path, symbolType: "function", metadata: {} },
+				"/repo"
+			)
+		);
+		expect(new Set(ids)).toEqual(new Set(["store/file.go#function:Open"]));
+	});
+});
+
+describe("repo paths in the index", () => {
+	const root = mkdtempSync(join(tmpdir(), "repo-path-"));
+	writeFileSync(join(root, "file.go"), SOURCE);
+
+	afterAll(() => {
+		rmSync(root, { recursive: true, force: true });
+	});
+
+	it("should not duplicate a file indexed under different spellings", async () => {
+		const index = new SymbolIndex({ root });
+		await index.updateFile("./file.go", SOURCE);
+		await index.updateFile(join(root, "file.go"), SOURCE);
+		await index.updateFile(".\\file.go", SOURCE);
+
+		expect(index.getFiles()).toEqual([join(root, "file.go")]);
+		const [open] = index.getSymbols();
+		expect(index.symbolCount()).toBe(1);
+		expect(open.id).toBe("file.go#function:store.Open");
+		expect(open.relativePath).toBe("file.go");
+		expect(open.path).toBe(join(root, "file.go"));
+		index.assertInvariants();
+	});
+
+	it("should parse a file once in parseFiles regardless of spelling", async () => {
+		const { results } = await parseFiles(
+			["file.go", join(root, "file.go"), "./file.go"],
+			{ root }
+		);
+
+		expect([...results.keys()]).toEqual(["file.go"]);
+		const [open] = results.get("file.go");
+		expect(open.id).toBe("file.go#function:store.Open");
+		expect(open.relativePath).toBe("file.go");
+	});
+});
+
+describe("index dumps", () => {
+	it("should re-key imported files under the importing root", async () => {
+		const source = new SymbolIndex({ root: "/machine-a/repo" });
+		await source.updateFile("/machine-a/repo/pkg/file.go", SOURCE);
+		const dump = source.exportIndex();
+
+		const index = new SymbolIndex({ root: "/machine-b/repo" });
+		await index.importIndex(dump);
+		const path = "/machine-b/repo/pkg/file.go";
+		expect(index.getFiles()).toEqual([path]);
+		expect(index.getSymbols().map((s) => s.path)).toEqual([path]);
+
+		expect(await index.updateFile(path, SOURCE)).toEqual([]);
+		expect(index.symbolCount()).toBe(1);
+		expect(index.removeFile(path)).toHaveLength(1);
+		expect(index.getFiles()).toEqual([]);
+	});
+});
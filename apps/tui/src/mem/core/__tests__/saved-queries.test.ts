@@ -0,0 +1,105 @@
+// @ts-nocheck
+import { describe, expect, it } from "bun:test";
+import { SavedQueries } from "../saved-queries.ts";
+import { SymbolIndex } from "../symbol-index.ts";
+
+const SOURCE = `package store
+
+func Open() {}
+
+func Parse(s string) int {
+	if s == "" {
+		return 0
+	}
+	return 1
+}
+
+func parseLine(s string) {}
+
+type Store struct{}
+
+func (s *Store) Close() {}
+`;
+
+const EXPORTED_FUNCS = {
+	kinds: ["function"],
+	exportedOnly: true,
+	scope: "store",
+	sortBy: "complexity",
+};
+
+describe("saved queries", () => {
+	it("should run a named listing with listSymbols filters", async () => {
+		const index = new SymbolIndex();
+		await index.updateFile("/virtual/store.go", SOURCE);
+		index.defineQuery("exported-funcs", EXPORTED_FUNCS);
+
+		const results = await index.runQuery("exported-funcs");
+
+		expect(results.map((r) => r.symbol.name)).toEqual(["Parse", "Open"]);
+		expect(results[0]).toMatchObject({ score: 1, matches: [] });
+	});
+
+	it("should combine filters with fuzzy search and limit", async () => {
+		const index = new SymbolIndex();
+		await index.updateFile("/virtual/store.go", SOURCE);
+		index.defineQuery("parse", { kinds: ["function"], query: "parse" });
+		index.defineQuery("first", { ...EXPORTED_FUNCS, limit: 1 });
+
+		const parse = await index.runQuery("parse");
+		expect(parse.map((r) => r.symbol.name)).toEqual(["Parse", "parseLine"]);
+		expect(parse[0]?.matches).toEqual([{ field: "name", start: 0, end: 5 }]);
+
+		const first = await index.runQuery("first");
+		expect(first.map((r) => r.symbol.name)).toEqual(["Parse"]);
+	});
+
+	it("should apply pending updates before running", async () => {
+		const index = new SymbolIndex();
+		index.defineQuery("exported-funcs", EXPORTED_FUNCS);
+
+		// Обновление в очереди и отложенное событие watcher'а
+		index.updateFile("/virtual/store.go", SOURCE);
+		index.onRefresh(() =>
+			index.updateFile(
+				"/virtual/extra.go",
+				"package store\n\nfunc Sync() {}\n"
+			)
+		);
+
+		const results = await index.runQuery("exported-funcs");
+		expect(results.map((r) => r.symbol.name)).toEqual([
+			"Parse",
+			"Open",
+			"Sync",
+		]);
+	});
+
+	it("should serialize queries and reject invalid ones", async () => {
+		const index = new SymbolIndex();
+		index.defineQuery("exported-funcs", EXPORTED_FUNCS);
+		index.defineQuery("by-name", { order: "name" });
+
+		const json = JSON.parse(JSON.stringify(index.savedQueries()));
+		expect(Object.keys(json)).toEqual(["by-name", "exported-funcs"]);
+		const restored = new SymbolIndex({ queries: json });
+		expect(restored.savedQueries().get("exported-funcs")).toEqual(
+			EXPORTED_FUNCS
+		);
+		expect(new SavedQueries(json).names()).toEqual([
+			"by-name",
+			"exported-funcs",
+		]);
+
+		expect(() => index.defineQuery("bad", { exported: true })).toThrow(
+			'Unknown option "exported" in saved query "bad"'
+		);
+		expect(() => index.defineQuery("bad", { sortBy: "size" })).toThrow(
+			'Invalid value for "sortBy"'
+		);
+		expect(() => index.defineQuery("bad", { limit: 0 })).toThrow();
+		await expect(index.runQuery("missing")).rejects.toThrow(
+			"Unknown saved query: missing"
+		);
+	});
+});
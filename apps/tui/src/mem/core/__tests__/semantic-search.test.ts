@@ -0,0 +1,100 @@
+// @ts-nocheck
+import { describe, expect, it } from "bun:test";
+import { SymbolIndex } from "../symbol-index.ts";
+
+const SOURCE = `package auth
+
+// Login checks user credentials
+func Login(user, password string) error {
+	return nil
+}
+
+// Render draws the page
+func Render() string {
+	return ""
+}
+`;
+
+const TOPICS = ["credentials", "authentication", "draws"];
+
+/** Вектор — есть ли в тексте слово из TOPICS; "authentication" ~ credentials */
+function fakeEmbedder(calls: string[][]) {
+	return async (texts: string[]) => {
+		calls.push(texts);
+		return texts.map((text) => {
+			const lower = text.toLowerCase();
+			const auth =
+				lower.includes(TOPICS[0]) || lower.includes(TOPICS[1]) ? 1 : 0;
+			return [auth, lower.includes(TOPICS[2]) ? 1 : 0];
+		});
+	};
+}
+
+describe("semanticSearch", () => {
+	it("should rank symbols by embedding similarity", async () => {
+		const index = new SymbolIndex();
+		await index.updateFile("/virtual/auth.go", SOURCE);
+		index.setEmbedder(fakeEmbedder([]));
+
+		const response = await index.semanticSearch("authentication");
+
+		expect(response.fallback).toBe(false);
+		expect(response.results[0]?.symbol.name).toBe("Login");
+		expect(response.results[0]?.score).toBeCloseTo(1);
+	});
+
+	it("should highlight query words in the doc of semantic hits", async () => {
+		const index = new SymbolIndex();
+		await index.updateFile("/virtual/auth.go", SOURCE);
+		index.setEmbedder(fakeEmbedder([]));
+
+		const response = await index.semanticSearch("check user credentials");
+
+		const [login] = response.results;
+		expect(login?.symbol.name).toBe("Login");
+		expect(login?.matches).toEqual([
+			{ field: "doc", start: 6, end: 11 },
+			{ field: "doc", start: 13, end: 17 },
+			{ field: "doc", start: 18, end: 29 },
+		]);
+	});
+
+	it("should cache symbol embeddings by bodyHash", async () => {
+		const index = new SymbolIndex();
+		await index.updateFile("/virtual/auth.go", SOURCE);
+		const calls: string[][] = [];
+		index.setEmbedder(fakeEmbedder(calls));
+
+		await index.semanticSearch("authentication", { batchSize: 1 });
+		// Запрос + два символа по одному в пакете
+		expect(calls.map((batch) => batch.length)).toEqual([1, 1, 1]);
+
+		// Правка только тела: bodyHash прежний, считается лишь запрос
+		calls.length = 0;
+		await index.updateFile(
+			"/virtual/auth.go",
+			SOURCE.replace('return ""', 'return "<html>"')
+		);
+		await index.semanticSearch("authentication");
+		expect(calls).toHaveLength(1);
+
+		calls.length = 0;
+		await index.updateFile(
+			"/virtual/auth.go",
+			SOURCE.replace("func Render()", "func Render(title string)")
+		);
+		await index.semanticSearch("authentication");
+		expect(calls).toHaveLength(2);
+		expect(calls[1]?.[0]).toStartWith("Render");
+	});
+
+	it("should fall back to fuzzy search without an embedder", async () => {
+		const index = new SymbolIndex();
+		await index.updateFile("/virtual/auth.go", SOURCE);
+
+		const response = await index.semanticSearch("login");
+
+		expect(response.fallback).toBe(true);
+		expect(response.results.map((r) => r.symbol.name)).toEqual(["Login"]);
+	});
+});
@@ -0,0 +1,128 @@
+// @ts-nocheck
+import { describe, expect, it } from "bun:test";
+import { mkdtempSync, writeFileSync } from "node:fs";
+import { tmpdir } from "node:os";
+import { join } from "node:path";
+import { GoParser } from "../parsers/go-parser.ts";
+import { renderSnippet } from "../snippet.ts";
+import { SymbolIndex } from "../symbol-index.ts";
+
+const SOURCE = `package shop
+
+// Cart holds items
+type Cart struct {
+	ID    string
+	Items []string
+	Total int
+	Owner string
+}
+
+// Add appends an item
+// and returns the new count.
+func (c *Cart) Add(item string) int {
+	c.Items = append(c.Items, item)
+	return len(c.Items)
+}
+
+func helper() {}
+`;
+
+describe("renderSnippet", () => {
+	it("should render a method signature with doc and receiver header", async () => {
+		const symbols = await new GoParser().parseSource("/virtual/cart.go", SOURCE);
+		const add = symbols.find((s) => s.name === "Cart.Add");
+
+		expect(renderSnippet(add, symbols)).toBe(
+			[
+				"// /virtual/cart.go:13",
+				"type Cart struct { … }",
+				"// Add appends an item",
+				"// and returns the new count.",
+				"func (c *Cart) Add(item string) int",
+			].join("\n")
+		);
+	});
+
+	it("should truncate struct members and long declarations", async () => {
+		const symbols = await new GoParser().parseSource("/virtual/cart.go", SOURCE);
+		const cart = symbols.find((s) => s.name === "Cart");
+
+		expect(renderSnippet(cart, symbols, undefined, { maxMembers: 2 })).toBe(
+			[
+				"// /virtual/cart.go:4",
+				"// Cart holds items",
+				"type Cart struct {",
+				"\tID    string",
+				"\tItems []string",
+				"\t// … 2 more",
+				"}",
+			].join("\n")
+		);
+		expect(
+			renderSnippet(cart, symbols, undefined, { maxLines: 2 }).split("\n")
+		).toEqual([
+			"// /virtual/cart.go:4",
+			"// Cart holds items",
+			"type Cart struct {",
+			"\tID    string",
+			"// … 4 more",
+		]);
+	});
+
+	it("should add surrounding context lines from the source", async () => {
+		const symbols = await new GoParser().parseSource("/virtual/cart.go", SOURCE);
+		const add = symbols.find((s) => s.name === "Cart.Add");
+
+		const snippet = renderSnippet(add, [], SOURCE, { contextLines: 2 });
+		expect(snippet.split("\n")).toEqual([
+			"// /virtual/cart.go:13",
+			"}",
+			"",
+			"// Add appends an item",
+			"// and returns the new count.",
+			"func (c *Cart) Add(item string) int",
+			"// …",
+			"",
+			"func helper() {}",
+		]);
+	});
+
+	it("should render the full body and omit docs on request", async () => {
+		const symbols = await new GoParser().parseSource("/virtual/cart.go", SOURCE);
+		const add = symbols.find((s) => s.name === "Cart.Add");
+
+		const snippet = renderSnippet(add, [], undefined, {
+			body: true,
+			docs: false,
+		});
+		expect(snippet.split("\n")).toEqual([
+			"// /virtual/cart.go:13",
+			"func (c *Cart) Add(item string) int {",
+			"\tc.Items = append(c.Items, item)",
+			"\treturn len(c.Items)",
+			"}",
+		]);
+	});
+});
+
+describe("SymbolIndex.snippetFor", () => {
+	it("should look up symbols by stable id", async () => {
+		const root = mkdtempSync(join(tmpdir(), "snippet-test-"));
+		const file = join(root, "cart.go");
+		writeFileSync(file, SOURCE);
+		const index = new SymbolIndex({ root });
+		await index.updateFile(file);
+
+		const snippet = await index.snippetFor("cart.go#function:shop.helper", {
+			contextLines: 1,
+		});
+
+		expect(snippet?.split("\n").slice(1)).toEqual([
+			"",
+			"func helper() {}",
+			"// …",
+			"",
+		]);
+		expect(await index.snippetFor("cart.go#function:shop.missing")).toBeNull();
+	});
+});
@@ -0,0 +1,56 @@
+// @ts-nocheck
+import { describe, expect, it } from "bun:test";
+import { GoParser } from "../parsers/go-parser.ts";
+import { normalizeTokens, symbolHashes } from "../symbol-hash.ts";
+
+const SOURCE = `package shop
+
+func Total(items []int) int {
+	sum := 0
+	for _, item := range items {
+		sum += item
+	}
+	return sum
+}
+`;
+
+async function parseTotal(source: string) {
+	const symbols = await new GoParser().parseSource("/virtual/shop.go", source);
+	return symbols.find((s) => s.name === "Total");
+}
+
+describe("symbolHashes", () => {
+	it("should ignore formatting-only edits", async () => {
+		const before = symbolHashes(await parseTotal(SOURCE));
+		const reformatted = symbolHashes(
+			await parseTotal(
+				SOURCE.replace("sum := 0", "sum  :=  0   ").replace(
+					"func Total(items []int) int {",
+					"func Total( items []int ) int {"
+				)
+			)
+		);
+
+		expect(reformatted).toEqual(before);
+	});
+
+	it("should separate signature and body changes", async () => {
+		const before = symbolHashes(await parseTotal(SOURCE));
+		const body = symbolHashes(
+			await parseTotal(SOURCE.replace("sum := 0", "sum := 1"))
+		);
+		const signature = symbolHashes(
+			await parseTotal(SOURCE.replace("(items []int)", "(values []int)"))
+		);
+
+		expect(body.bodyHash).toBe(before.bodyHash);
+		expect(body.fullHash).not.toBe(before.fullHash);
+		expect(signature.bodyHash).not.toBe(before.bodyHash);
+	});
+
+	it("should normalize whitespace around punctuation", () => {
+		expect(normalizeTokens("f( a ,b )  {\n\treturn a+ b\n}")).toBe(
+			"f(a,b){return a+b}"
+		);
+	});
+});
@@ -0,0 +1,79 @@
+// @ts-nocheck
+import { describe, expect, it } from "bun:test";
+import { GoParser } from "../parsers/go-parser.ts";
+import { SymbolIndex } from "../symbol-index.ts";
+import { assignSymbolIds, symbolId } from "../symbol-id.ts";
+
+const SOURCE = `package store
+
+type File struct{}
+
+type Conn struct{}
+
+func (f *File) Close() error { return nil }
+
+func (c *Conn) Close() error { return nil }
+
+func Open(name string) *File { return nil }
+
+func init() {}
+
+func init() {}
+`;
+
+describe("symbolId", () => {
+	it("should derive a repo-relative id from package, receiver, name and kind", async () => {
+		const symbols = await new GoParser().parseSource(
+			"/repo/store/file.go",
+			SOURCE
+		);
+		const byName = (name: string) => symbols.find((s) => s.name === name);
+
+		expect(symbolId(byName("File.Close"), "/repo")).toBe(
+			"store/file.go#method:store.File.Close"
+		);
+		expect(symbolId(byName("Conn.Close"), "/repo")).toBe(
+			"store/file.go#method:store.Conn.Close"
+		);
+		expect(symbolId(byName("File"), "/repo")).toBe(
+			"store/file.go#class:store.File"
+		);
+		expect(symbolId(byName("Open"))).toBe(
+			"/repo/store/file.go#function:store.Open"
+		);
+	});
+
+	it("should disambiguate duplicate ids in source order", async () => {
+		const symbols = assignSymbolIds(
+			await new GoParser().parseSource("/repo/store/file.go", SOURCE),
+			"/repo"
+		);
+
+		expect(
+			symbols.filter((s) => s.name === "init").map((s) => s.id)
+		).toEqual([
+			"store/file.go#function:store.init",
+			"store/file.go#function:store.init@2",
+		]);
+	});
+
+	it("should keep ids when a function moves within the file", async () => {
+		const index = new SymbolIndex({ root: "/repo" });
+		await index.updateFile("/repo/store/file.go", SOURCE);
+		const before = index.getSymbols().find((s) => s.name === "Open")?.id;
+
+		const moved = SOURCE.replace(
+			"func Open(name string) *File { return nil }\n\n",
+			""
+		).replace(
+			"type File struct{}",
+			"func Open(name string) *File { return nil }\n\ntype File struct{}"
+		);
+		await index.updateFile("/repo/store/file.go", moved);
+		const after = index.getSymbols().find((s) => s.name === "Open");
+
+		expect(before).toBe("store/file.go#function:store.Open");
+		expect(after?.id).toBe(before);
+		expect(after?.startLine).toBe(3);
+	});
+});
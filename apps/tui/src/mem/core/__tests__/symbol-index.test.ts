@@ -0,0 +1,368 @@
+// @ts-nocheck
+import { describe, expect, it } from "bun:test";
+import { mkdtempSync, rmSync, writeFileSync } from "node:fs";
+import { tmpdir } from "node:os";
+import { join } from "node:path";
+import { ParseCache } from "../parse-cache.ts";
+import { GoParser } from "../parsers/go-parser.ts";
+import { SymbolIndex } from "../symbol-index.ts";
+
+const fixturePath = join(
+	import.meta.dir,
+	"..",
+	"parsers",
+	"__tests__",
+	"fixtures",
+	"go",
+	"sample.go"
+);
+
+const ORIGINAL = `package sample
+
+// Greet says hello
+func Greet(name string) string {
+	return "hi " + name
+}
+
+func Count() int {
+	return 1
+}
+
+type User struct {
+	Name string
+}
+`;
+
+describe("SymbolIndex", () => {
+	it("should index a file from in-memory content", async () => {
+		const index = new SymbolIndex();
+		const changes = await index.updateFile("/virtual/a.go", ORIGINAL);
+
+		expect(changes.every((c) => c.type === "added")).toBe(true);
+		expect(index.getFileSymbols("/virtual/a.go").map((s) => s.name)).toEqual([
+			"Greet",
+			"Count",
+			"User",
+		]);
+	});
+
+	it("should keep identity of unchanged symbols and report changes", async () => {
+		const index = new SymbolIndex();
+		await index.updateFile("/virtual/a.go", ORIGINAL);
+		const before = index.getFileSymbols("/virtual/a.go");
+		const greet = before.find((s) => s.name === "Greet");
+		const user = before.find((s) => s.name === "User");
+
+		const edited = ORIGINAL.replace('"hi " + name', '"hello " + name').replace(
+			"func Count() int",
+			"func Count(step int) int"
+		);
+		const changes = await index.updateFile("/virtual/a.go", edited);
+		const after = index.getFileSymbols("/virtual/a.go");
+
+		expect(after.find((s) => s.name === "Greet")).toBe(greet);
+		expect(after.find((s) => s.name === "User")).toBe(user);
+		expect(changes.map((c) => [c.type, c.symbol.name]).sort()).toEqual([
+			["added", "Count"],
+			["changed", "Greet"],
+			["removed", "Count"],
+		]);
+	});
+
+	it("should emit change events to listeners", async () => {
+		const index = new SymbolIndex();
+		const received: string[] = [];
+		const unsubscribe = index.onChange((changes) => {
+			received.push(...changes.map((c) => `${c.type}:${c.symbol.name}`));
+		});
+
+		await index.updateFile("/virtual/a.go", ORIGINAL);
+		unsubscribe();
+		await index.updateFile("/virtual/a.go", "package sample\n");
+
+		expect(received).toEqual(["added:Greet", "added:Count", "added:User"]);
+	});
+
+	it("should purge only the removed file's symbols", async () => {
+		const index = new SymbolIndex();
+		await index.updateFile("/virtual/a.go", ORIGINAL);
+		await index.updateFile("/virtual/b.go", "package sample\n\nfunc Other() {}\n");
+
+		const removed = index.removeFile("/virtual/a.go");
+
+		expect(removed).toHaveLength(3);
+		expect(removed.every((c) => c.type === "removed")).toBe(true);
+		expect(index.hasFile("/virtual/a.go")).toBe(false);
+		expect(index.getSymbols().map((s) => s.name)).toEqual(["Other"]);
+		expect(index.removeFile("/virtual/a.go")).toEqual([]);
+	});
+
+	it("should round-trip the index through JSON losslessly", async () => {
+		const index = new SymbolIndex();
+		await index.updateFile(fixturePath);
+
+		const dump = JSON.stringify(index.exportIndex());
+		const restored = new SymbolIndex();
+		await restored.importIndex(dump);
+
+		expect(restored.getFiles()).toEqual(index.getFiles());
+		expect(restored.getSymbols()).toEqual(index.getSymbols());
+		expect(restored.exportIndex()).toEqual(JSON.parse(dump));
+	});
+
+	it("should reject dumps with unsupported schema versions", async () => {
+		const index = new SymbolIndex();
+
+		await expect(
+			index.importIndex({ schemaVersion: 999, files: [], relationships: {} })
+		).rejects.toThrow("newer than supported");
+		await expect(index.importIndex("{}")).rejects.toThrow(
+			"Invalid index document"
+		);
+	});
+
+	it("should import after queued updates and not after dispose", async () => {
+		const source = new SymbolIndex();
+		await source.updateFile("/virtual/a.go", ORIGINAL);
+		const dump = source.exportIndex();
+
+		const index = new SymbolIndex();
+		const pending = index.updateFile("/virtual/b.go", "package sample\n");
+		await index.importIndex(dump);
+		await pending;
+		expect(index.getFiles()).toEqual(["/virtual/a.go"]);
+
+		await index.dispose();
+		expect(() => index.importIndex(dump)).toThrow("disposed");
+	});
+
+	it("should keep symbols from the broken region until the file parses again", async () => {
+		const index = new SymbolIndex();
+		await index.updateFile("/virtual/a.go", ORIGINAL);
+
+		const broken = ORIGINAL.replace(
+			"func Count() int {\n\treturn 1\n}",
+			"func Count() int {\n\treturn (\n"
+		);
+		const changes = await index.updateFile("/virtual/a.go", broken);
+		const names = index.getFileSymbols("/virtual/a.go").map((s) => s.name);
+
+		expect(names).toContain("Greet");
+		expect(names).toContain("Count");
+		expect(names).toContain("User");
+		expect(changes.some((c) => c.type === "removed")).toBe(false);
+		expect(index.getDiagnostics("/virtual/a.go").length).toBeGreaterThan(0);
+
+		await index.updateFile("/virtual/a.go", "package sample\n");
+		expect(index.getFileSymbols("/virtual/a.go")).toEqual([]);
+		expect(index.getDiagnostics("/virtual/a.go")).toEqual([]);
+	});
+
+	it("should upsert re-parsed files instead of appending duplicates", async () => {
+		const index = new SymbolIndex();
+		await index.updateFile(fixturePath);
+		const once = index.symbolCount();
+		const user = index.getSymbols().find((s) => s.name === "User");
+
+		const crawled = await new GoParser().parse(fixturePath);
+		const changes = index.upsert(crawled);
+		await index.updateFile(fixturePath);
+
+		expect(once).toBeGreaterThan(0);
+		expect(index.symbolCount()).toBe(once);
+		expect(changes).toEqual([]);
+		expect(index.getSymbols().find((s) => s.name === "User")).toBe(user);
+		expect(() => index.assertInvariants()).not.toThrow();
+	});
+
+	it("should detect duplicate ids in invariant checks", async () => {
+		const index = new SymbolIndex();
+		await index.updateFile("/virtual/a.go", ORIGINAL);
+		const [greet] = index.getFileSymbols("/virtual/a.go");
+		index.getFileSymbols("/virtual/a.go").push({ ...greet });
+
+		expect(() => index.assertInvariants()).toThrow("Duplicate symbol id");
+	});
+
+	it("should report which part of a symbol changed", async () => {
+		const index = new SymbolIndex();
+		await index.updateFile("/virtual/a.go", ORIGINAL);
+
+		const whitespace = ORIGINAL.replace("return 1", "return 1   ");
+		expect(await index.updateFile("/virtual/a.go", whitespace)).toEqual([]);
+
+		const body = await index.updateFile(
+			"/virtual/a.go",
+			ORIGINAL.replace("return 1", "return 2")
+		);
+		expect(body.map((c) => [c.symbol.name, c.scope])).toEqual([
+			["Count", "body"],
+		]);
+
+		const doc = await index.updateFile(
+			"/virtual/a.go",
+			ORIGINAL.replace("return 1", "return 2").replace(
+				"// Greet says hello",
+				"// Greet greets"
+			)
+		);
+		expect(doc.map((c) => [c.symbol.name, c.scope])).toEqual([
+			["Greet", "doc"],
+		]);
+		expect(index.getSymbols().every((s) => s.bodyHash && s.fullHash)).toBe(
+			true
+		);
+	});
+});
+
+describe("SymbolIndex markers", () => {
+	it("should list markers across files", async () => {
+		const index = new SymbolIndex();
+		await index.updateFile(
+			"/virtual/b.go",
+			"package x\n\n// FIXME: leaks\nfunc B() {}\n"
+		);
+		await index.updateFile(
+			"/virtual/a.go",
+			"package x\n\nfunc A() {\n\t// TODO: cache\n\t// HACK: sleep\n}\n"
+		);
+
+		expect(
+			index.listMarkers().map((m) => [m.symbol.name, m.marker.kind])
+		).toEqual([
+			["A", "TODO"],
+			["A", "HACK"],
+			["B", "FIXME"],
+		]);
+		expect(
+			index.listMarkers({ kinds: ["FIXME"] }).map((m) => m.marker.line)
+		).toEqual([3]);
+	});
+});
+
+describe("SymbolIndex capacity", () => {
+	it("should evict least recently used files and reload them on demand", async () => {
+		const dir = mkdtempSync(join(tmpdir(), "symbol-index-lru-"));
+		const file = (name: string) => {
+			const path = join(dir, `${name}.go`);
+			writeFileSync(path, `package lru\n\nfunc ${name.toUpperCase()}() {}\n`);
+			return path;
+		};
+		const [a, b, c] = [file("a"), file("b"), file("c")];
+
+		try {
+			const index = new SymbolIndex({
+				cache: new ParseCache({ filePath: join(dir, "cache.json") }),
+				// по одной функции на файл
+				capacity: { maxSymbols: 2 },
+			});
+			index.pinFile(a);
+			await index.updateFile(a);
+			await index.updateFile(b);
+			await index.updateFile(c);
+
+			// a закреплён, c только что загружен: вытесняется b
+			expect(index.stats().symbols).toBe(2);
+			// Символы b по-прежнему видны из кеша парсинга
+			expect(index.symbolCount()).toBe(3);
+			expect(index.symbolCount()).toBe(index.getSymbols().length);
+			expect(index.hasFile(b)).toBe(true);
+			expect(index.getFiles()).toContain(b);
+			expect(index.getFileSymbols(b)).toEqual([]);
+			expect(index.getFileSymbols(a).map((s) => s.name)).toEqual(["A"]);
+
+			// Исходник не нужен: символы берутся из кеша парсинга по хешу
+			rmSync(b);
+			const reloaded = await index.ensureFile(b);
+			expect(reloaded.map((s) => s.name)).toEqual(["B"]);
+			expect(reloaded[0].id).toBeDefined();
+			expect(index.getFileSymbols(c)).toEqual([]);
+
+			expect(index.stats()).toMatchObject({
+				evictedFiles: 1,
+				evictions: 2,
+				hits: 1,
+				misses: 3,
+				symbols: 2,
+			});
+		} finally {
+			rmSync(dir, { recursive: true, force: true });
+		}
+	});
+
+	it("should report changes against evicted symbols on update", async () => {
+		const dir = mkdtempSync(join(tmpdir(), "symbol-index-lru-"));
+		const a = join(dir, "a.go");
+		const b = join(dir, "b.go");
+		writeFileSync(a, "package lru\n\nfunc A() {}\n");
+		writeFileSync(b, "package lru\n\nfunc B() {}\n");
+
+		try {
+			const index = new SymbolIndex({ capacity: { maxSymbols: 1 } });
+			await index.updateFile(a);
+			await index.updateFile(b);
+			expect(index.getFileSymbols(a)).toEqual([]);
+
+			const changes = await index.updateFile(
+				a,
+				"package lru\n\nfunc A() {}\n\nfunc A2() {}\n"
+			);
+			expect(changes.map((ch) => [ch.type, ch.symbol.name])).toEqual([
+				["added", "A2"],
+			]);
+		} finally {
+			rmSync(dir, { recursive: true, force: true });
+		}
+	});
+
+	it("should answer index-wide queries from evicted files", async () => {
+		const dir = mkdtempSync(join(tmpdir(), "symbol-index-lru-"));
+		const a = join(dir, "a.go");
+		const b = join(dir, "b.go");
+		writeFileSync(a, "package lru\n\nfunc Alpha() { Beta() }\n");
+		writeFileSync(b, "package lru\n\nfunc Beta() {}\n");
+
+		try {
+			const index = new SymbolIndex({
+				cache: new ParseCache({ filePath: join(dir, "cache.json") }),
+				capacity: { maxSymbols: 1 },
+			});
+			await index.updateFile(b);
+			await index.updateFile(a);
+			expect(index.getFileSymbols(b)).toEqual([]);
+
+			const [hit] = index.searchSymbols("Beta");
+			expect(hit.symbol.path).toBe(b);
+			const beta = hit.symbol.id;
+			expect(index.callersOf(beta).map((edge) => edge.reference)).toEqual([
+				"Beta",
+			]);
+			expect(
+				index.exportIndex().files.map((file) => file.path).sort()
+			).toEqual([a, b]);
+
+			const removals = [];
+			index.events().on("fileRemoved", (event) => removals.push(event));
+			const removed = index.removeFile(b);
+			expect(removed.map((ch) => [ch.type, ch.symbol.name])).toEqual([
+				["removed", "Beta"],
+			]);
+			expect(removals).toEqual([
+				{ type: "fileRemoved", path: b, symbolIds: [beta] },
+			]);
+
+			// b вытесняет a; upsert сверяется с символами a из кеша
+			await index.updateFile(b);
+			expect(index.getFileSymbols(a)).toEqual([]);
+			const [alpha] = index.searchSymbols("Alpha");
+			const upserted = index.upsert([
+				{ ...alpha.symbol, body: "func Alpha() {}" },
+			]);
+			expect(upserted.map((ch) => [ch.type, ch.symbol.id])).toEqual([
+				["changed", alpha.symbol.id],
+			]);
+		} finally {
+			rmSync(dir, { recursive: true, force: true });
+		}
+	});
+});
@@ -0,0 +1,76 @@
+// @ts-nocheck
+import { describe, expect, it } from "bun:test";
+import { join } from "node:path";
+import { GoParser } from "../parsers/go-parser.ts";
+import { RustParser } from "../parsers/rust-parser.ts";
+import { kindOf, normalizeKind } from "../symbol-kind.ts";
+import { listSymbols } from "../symbol-query.ts";
+import { searchSymbols } from "../symbol-search.ts";
+
+const fixtures = join(
+	import.meta.dir,
+	"..",
+	"parsers",
+	"__tests__",
+	"fixtures"
+);
+const goFixture = join(fixtures, "go", "sample.go");
+const rustFixture = join(fixtures, "rust", "sample.rs");
+
+describe("kindOf", () => {
+	it("should map Go and Rust declarations to canonical kinds", async () => {
+		const go = await new GoParser().parse(goFixture);
+		const rust = await new RustParser().parse(rustFixture);
+		const kind = (symbols, name: string) =>
+			kindOf(symbols.find((s) => s.name === name));
+
+		expect(kind(go, "User")).toBe("struct");
+		expect(kind(go, "Service")).toBe("interface");
+		expect(kind(go, "Result")).toBe("type-alias");
+		expect(kind(go, "Handler")).toBe("type-alias");
+		expect(kind(go, "SimpleFunction")).toBe("function");
+		expect(kind(go, "User.GetName")).toBe("method");
+		expect(kind(go, "MaxRetries")).toBe("constant");
+
+		expect(kind(rust, "User")).toBe("struct");
+		expect(kind(rust, "Processor")).toBe("trait");
+		expect(kind(rust, "Result")).toBe("type-alias");
+	});
+
+	it("should normalize kind aliases", () => {
+		expect(normalizeKind("func")).toBe("function");
+		expect(normalizeKind("fn")).toBe("function");
+		expect(normalizeKind("Struct")).toBe("struct");
+		expect(normalizeKind("type")).toBe("type-alias");
+		expect(normalizeKind("type_alias")).toBe("type-alias");
+		expect(normalizeKind("widget")).toBeUndefined();
+	});
+});
+
+describe("canonical kind filters", () => {
+	it("should match structs across languages in listSymbols", async () => {
+		const symbols = [
+			...(await new GoParser().parse(goFixture)),
+			...(await new RustParser().parse(rustFixture)),
+		];
+
+		const structs = listSymbols(symbols, { kinds: ["struct"] });
+		expect(structs.map((s) => s.name)).toContain("Calculator");
+		expect(structs.map((s) => s.name)).toContain("Container");
+		expect(structs.every((s) => kindOf(s) === "struct")).toBe(true);
+
+		const traits = listSymbols(symbols, { kinds: ["trait"] });
+		expect(traits.map((s) => s.name).sort()).toEqual([
+			"Parser",
+			"Processor",
+			"Storage",
+		]);
+	});
+
+	it("should accept aliases in searchSymbols", async () => {
+		const symbols = await new GoParser().parse(goFixture);
+
+		const results = searchSymbols(symbols, "user", { kinds: ["func"] });
+		expect(results.map((r) => r.symbol.name)).toEqual(["NewUser"]);
+	});
+});
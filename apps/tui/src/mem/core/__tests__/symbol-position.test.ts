@@ -0,0 +1,68 @@
+// @ts-nocheck
+import { describe, expect, it } from "bun:test";
+import { join } from "node:path";
+import { GoParser } from "../parsers/go-parser.ts";
+import { SymbolIndex } from "../symbol-index.ts";
+import { symbolsAtPosition } from "../symbol-position.ts";
+
+const fixturePath = join(
+	import.meta.dir,
+	"..",
+	"parsers",
+	"__tests__",
+	"fixtures",
+	"go",
+	"sample.go"
+);
+
+describe("symbolsAtPosition", () => {
+	it("returns method, receiver type and package inside a method", async () => {
+		const symbols = await new GoParser({ packageSymbol: true }).parse(
+			fixturePath
+		);
+		const chain = symbolsAtPosition(symbols, fixturePath, 48);
+
+		expect(chain.map((s) => s.name)).toEqual([
+			"User.GetName",
+			"User",
+			"sample",
+		]);
+		expect(chain[2]?.symbolType).toBe("package");
+		expect(chain[2]?.metadata?.docComment).toBe(
+			"Package sample for testing Go parser"
+		);
+	});
+
+	it("returns only the package outside any symbol", async () => {
+		const symbols = await new GoParser({ packageSymbol: true }).parse(
+			fixturePath
+		);
+		const chain = symbolsAtPosition(symbols, fixturePath, 3);
+
+		expect(chain.map((s) => s.name)).toEqual(["sample"]);
+	});
+
+	it("picks the innermost of nested symbols", async () => {
+		const symbols = await new GoParser({ captureLocals: true }).parseSource(
+			"/tmp/run.go",
+			"package app\n\nfunc Run() {\n\thelper := func() {\n\t\tprintln()\n\t}\n\thelper()\n}\n"
+		);
+		const chain = symbolsAtPosition(symbols, "/tmp/run.go", 5);
+
+		expect(chain.map((s) => s.name)).toEqual(["helper", "Run", "app"]);
+	});
+
+	it("derives the package from symbols in SymbolIndex", async () => {
+		const index = new SymbolIndex();
+		await index.updateFile(fixturePath);
+
+		const chain = index.symbolsAtPosition(fixturePath, 53);
+		expect(chain.map((s) => s.name)).toEqual([
+			"User.SetAge",
+			"User",
+			"sample",
+		]);
+		const outside = index.symbolsAtPosition(fixturePath, 8);
+		expect(outside.map((s) => s.name)).toEqual(["sample"]);
+	});
+});
@@ -0,0 +1,169 @@
+// @ts-nocheck
+import { describe, expect, it } from "bun:test";
+import { join } from "node:path";
+import { GoParser } from "../parsers/go-parser.ts";
+import { listSymbols, symbolsForType } from "../symbol-query.ts";
+
+const fixturePath = join(
+	import.meta.dir,
+	"..",
+	"parsers",
+	"__tests__",
+	"fixtures",
+	"go",
+	"sample.go"
+);
+
+describe("symbolsForType", () => {
+	it("should group a type with all its methods", async () => {
+		const symbols = await new GoParser().parse(fixturePath);
+		const names = symbolsForType(symbols, "User").map((s) => s.name);
+
+		expect(names).toEqual([
+			"User",
+			"User.GetName",
+			"User.SetAge",
+			"User.privateMethod",
+		]);
+	});
+
+	it("should link receivers by base type name", async () => {
+		const symbols = await new GoParser().parse(fixturePath);
+		const group = symbolsForType(symbols, "Calculator");
+
+		expect(group.map((s) => s.name)).toEqual(["Calculator", "Calculator.Add"]);
+		expect(group[1]?.metadata?.receiver).toBe("Calculator");
+		expect(group[1]?.metadata?.receiverIsPointer).toBe(true);
+	});
+
+	it("should include typed enum members between type and methods", async () => {
+		const symbols = await new GoParser().parseSource(
+			"/virtual/color.go",
+			`package paint
+
+type Color int
+
+func (c Color) String() string { return "" }
+
+const (
+	Red Color = iota
+	Green
+)
+
+const Black = 0
+`
+		);
+
+		expect(symbolsForType(symbols, "Color").map((s) => s.name)).toEqual([
+			"Color",
+			"Red",
+			"Green",
+			"Color.String",
+		]);
+	});
+
+	it("should return empty list for unknown types", async () => {
+		const symbols = await new GoParser().parse(fixturePath);
+		expect(symbolsForType(symbols, "Missing")).toEqual([]);
+	});
+});
+
+describe("listSymbols", () => {
+	it("should list interfaces and optionally exclude constraints", async () => {
+		const symbols = await new GoParser().parse(fixturePath);
+
+		const interfaces = listSymbols(symbols, { kinds: ["interface"] });
+		expect(interfaces.map((s) => s.name)).toEqual(["Service", "Number"]);
+
+		const withoutConstraints = listSymbols(symbols, {
+			kinds: ["interface"],
+			excludeConstraints: true,
+		});
+		expect(withoutConstraints.map((s) => s.name)).toEqual(["Service"]);
+	});
+
+	it("should filter exported functions by file or package scope", async () => {
+		const symbols = await new GoParser().parse(fixturePath);
+		const expected = ["SimpleFunction", "ComplexFunction", "NewUser", "Sum"];
+
+		const byFile = listSymbols(symbols, {
+			kinds: ["function"],
+			exportedOnly: true,
+			scope: fixturePath,
+		});
+		expect(byFile.map((s) => s.name)).toEqual(expected);
+
+		const byPackage = listSymbols(symbols, {
+			kinds: ["function"],
+			exportedOnly: true,
+			scope: "sample",
+		});
+		expect(byPackage.map((s) => s.name)).toEqual(expected);
+
+		expect(listSymbols(symbols, { scope: "other" })).toEqual([]);
+	});
+
+	it("should sort functions by complexity", async () => {
+		const symbols = await new GoParser().parseSource(
+			"/virtual/sort.go",
+			"package sort\n\nfunc A() {}\n\nfunc B(x int) {\n\tif x > 0 {\n\t}\n}\n\ntype T struct{}\n"
+		);
+
+		const sorted = listSymbols(symbols, { sortBy: "complexity" });
+		expect(sorted.map((s) => s.name)).toEqual(["B", "A", "T"]);
+	});
+
+	it("should exclude test symbols unless includeTests is set", async () => {
+		const parser = new GoParser();
+		const symbols = [
+			...(await parser.parseSource(
+				"/virtual/cart/cart.go",
+				"package cart\n\nfunc Total() int { return 0 }\n"
+			)),
+			...(await parser.parseSource(
+				"/virtual/cart/cart_test.go",
+				'package cart\n\nimport "testing"\n\nfunc TestTotal(t *testing.T) {}\n'
+			)),
+		];
+
+		expect(listSymbols(symbols).map((s) => s.name)).toEqual(["Total"]);
+		expect(
+			listSymbols(symbols, { includeTests: true }).map((s) => s.name)
+		).toEqual(["Total", "TestTotal"]);
+	});
+
+	it("should order results by source, name or kind then name", async () => {
+		const symbols = await new GoParser().parseSource(
+			"/virtual/order.go",
+			"package order\n\nfunc b() {}\n\ntype A struct{}\n\nfunc a() {}\n"
+		);
+		const names = (order) => listSymbols(symbols, { order }).map((s) => s.name);
+
+		expect(names("source")).toEqual(["b", "A", "a"]);
+		expect(names("name")).toEqual(["a", "A", "b"]);
+		expect(names("kind-then-name")).toEqual(["a", "b", "A"]);
+	});
+
+	it("should list only deprecated symbols with deprecatedOnly", async () => {
+		const symbols = await new GoParser().parseSource(
+			"/virtual/api.go",
+			[
+				"package api",
+				"",
+				"// Fetch loads a page.",
+				"//",
+				"// Deprecated: use FetchContext instead.",
+				"func Fetch() {}",
+				"",
+				"func FetchContext() {}",
+			].join("\n")
+		);
+
+		const deprecated = listSymbols(symbols, { deprecatedOnly: true });
+		expect(deprecated.map((s) => s.name)).toEqual(["Fetch"]);
+		expect(deprecated[0]?.metadata?.deprecationMessage).toBe(
+			"use FetchContext instead."
+		);
+		expect(listSymbols(symbols)).toHaveLength(2);
+	});
+});
@@ -0,0 +1,192 @@
+// @ts-nocheck
+import { describe, expect, it } from "bun:test";
+import { dirname, join } from "node:path";
+import { GoParser } from "../parsers/go-parser.ts";
+import { searchSymbols } from "../symbol-search.ts";
+
+const fixturePath = join(
+	import.meta.dir,
+	"..",
+	"parsers",
+	"__tests__",
+	"fixtures",
+	"go",
+	"sample.go"
+);
+const unicodeFixturePath = join(dirname(fixturePath), "unicode.go");
+
+describe("searchSymbols", () => {
+	it("should rank exact, boundary and qualifier matches for 'user'", async () => {
+		const symbols = await new GoParser().parse(fixturePath);
+		const names = searchSymbols(symbols, "user").map((r) => r.symbol.name);
+
+		expect(names.slice(0, 2)).toEqual(["User", "NewUser"]);
+		expect(names).toContain("User.GetName");
+		expect(names).toContain("User.SetAge");
+		expect(names.indexOf("User.GetName")).toBeGreaterThan(1);
+	});
+
+	it("should report scores and highlighted ranges", async () => {
+		const symbols = await new GoParser().parse(fixturePath);
+		const results = searchSymbols(symbols, "user");
+		const newUser = results.find((r) => r.symbol.name === "NewUser");
+
+		expect(results[0]?.score).toBe(1);
+		expect(newUser?.matches).toEqual([{ field: "name", start: 3, end: 7 }]);
+		expect(newUser?.score).toBeLessThan(1);
+	});
+
+	it("should filter by kinds and cap by limit", async () => {
+		const symbols = await new GoParser().parse(fixturePath);
+
+		const methods = searchSymbols(symbols, "user", { kinds: ["method"] });
+		expect(methods.every((r) => r.symbol.symbolType === "method")).toBe(true);
+		expect(methods.length).toBeGreaterThan(1);
+
+		expect(searchSymbols(symbols, "user", { limit: 1 })).toHaveLength(1);
+	});
+
+	it("should match fuzzy subsequences and doc comments", async () => {
+		const symbols = await new GoParser().parse(fixturePath);
+
+		const fuzzy = searchSymbols(symbols, "smplfn");
+		expect(fuzzy[0]?.symbol.name).toBe("SimpleFunction");
+
+		expect(
+			searchSymbols(symbols, "constructor").map((r) => r.symbol.name)
+		).toEqual([]);
+		const docs = searchSymbols(symbols, "constructor", { includeDocs: true });
+		expect(docs[0]?.symbol.name).toBe("NewUser");
+		expect(docs[0]?.matches[0]?.field).toBe("doc");
+	});
+
+	it("should match Unicode names by prefix and subsequence", async () => {
+		const symbols = await new GoParser().parse(unicodeFixturePath);
+
+		expect(searchSymbols(symbols, "ПРИВЕТ")[0]?.symbol.name).toBe(
+			"Приветствие"
+		);
+
+		const fuzzy = searchSymbols(symbols, "flch");
+		expect(fuzzy.map((r) => r.symbol.name)).toEqual(["Größe.Fläche"]);
+		expect(fuzzy[0]?.matches).toEqual([
+			{ field: "name", start: 6, end: 8 },
+			{ field: "name", start: 9, end: 11 },
+		]);
+
+		// 𝛂 — две UTF-16 единицы
+		expect(searchSymbols(symbols, "𝛂t")[0]?.matches).toEqual([
+			{ field: "name", start: 0, end: 2 },
+			{ field: "name", start: 6, end: 7 },
+		]);
+	});
+
+	it("should report name and doc ranges together with includeDocs", async () => {
+		const symbols = await new GoParser().parse(fixturePath);
+		const results = searchSymbols(symbols, "user", { includeDocs: true });
+		const newUser = results.find((r) => r.symbol.name === "NewUser");
+
+		expect(newUser?.matches).toEqual([
+			{ field: "name", start: 3, end: 7 },
+			{ field: "doc", start: 3, end: 7 },
+		]);
+		expect(newUser?.score).toBe(0.8);
+	});
+
+	it("should map offsets back when lowercasing changes length", () => {
+		const symbol = {
+			name: "İstanbulUser",
+			path: "/virtual/city.go",
+			symbolType: "function",
+			metadata: {},
+		};
+
+		// "İ".toLowerCase() — две UTF-16 единицы
+		const [result] = searchSymbols([symbol], "user");
+		expect(result?.matches).toEqual([{ field: "name", start: 8, end: 12 }]);
+		expect(symbol.name.slice(8, 12)).toBe("User");
+	});
+
+	it("should match abbreviations at camelCase and snake_case boundaries", async () => {
+		const symbols = await new GoParser().parse(fixturePath);
+		const first = (query: string) => searchSymbols(symbols, query)[0];
+
+		expect(first("gn")?.symbol.name).toBe("User.GetName");
+		expect(first("gn")?.matches).toEqual([
+			{ field: "name", start: 5, end: 6 },
+			{ field: "name", start: 8, end: 9 },
+		]);
+		expect(first("new_user")?.symbol.name).toBe("NewUser");
+		expect(first("new_user")?.matches).toEqual([
+			{ field: "name", start: 0, end: 7 },
+		]);
+		expect(first("mr")?.symbol.name).toBe("MaxRetries");
+		expect(first("max_retries")?.symbol.name).toBe("MaxRetries");
+		expect(first("mxrt")?.symbol.name).toBe("MaxRetries");
+	});
+
+	it("should rank boundary subsequences below substrings", () => {
+		const symbols = ["Gain", "GetName", "Signal", "parse_name_list"].map(
+			(name) => ({ name, path: "/virtual/a.go", symbolType: "function" })
+		);
+		const results = searchSymbols(symbols, "gn");
+
+		// Подстрока выше подпоследовательности, начала слов выше середины
+		expect(results.map((r) => r.symbol.name)).toEqual([
+			"Signal",
+			"GetName",
+			"Gain",
+		]);
+		expect(results[0]?.score).toBeGreaterThan(results[1]?.score);
+
+		const snake = searchSymbols(symbols, "pnl")[0];
+		expect(snake?.symbol.name).toBe("parse_name_list");
+		expect(snake?.matches).toEqual([
+			{ field: "name", start: 0, end: 1 },
+			{ field: "name", start: 6, end: 7 },
+			{ field: "name", start: 11, end: 12 },
+		]);
+	});
+
+	it("should float exported symbols up with the exported weight", () => {
+		const symbols = [
+			{ path: "/virtual/a.ts", metadata: { isExported: false } },
+			{ path: "/virtual/b.ts", metadata: { isExported: true } },
+		].map((s) => ({ ...s, name: "load", symbolType: "function" }));
+		const paths = (options) =>
+			searchSymbols(symbols, "load", options).map((r) => r.symbol.path);
+
+		expect(paths()).toEqual(["/virtual/a.ts", "/virtual/b.ts"]);
+		expect(paths({ scoring: { exported: 0.5 } })).toEqual([
+			"/virtual/b.ts",
+			"/virtual/a.ts",
+		]);
+		const scoring = { exported: 0.5 };
+		expect(searchSymbols(symbols, "load", { scoring })[0]?.score).toBe(1.5);
+	});
+
+	it("should weight doc matches, kinds and recent edits", () => {
+		const symbols = [
+			{ name: "Retry", symbolType: "function", path: "/virtual/old.go" },
+			{
+				name: "Backoff",
+				symbolType: "type",
+				path: "/virtual/new.go",
+				jsDoc: "Backoff controls retry delays",
+			},
+		];
+		const first = (options) =>
+			searchSymbols(symbols, "retry", { includeDocs: true, ...options })[0]
+				?.symbol.name;
+
+		expect(first()).toBe("Retry");
+		expect(first({ scoring: { doc: 20 } })).toBe("Backoff");
+		expect(first({ scoring: { kind: { "type-alias": 1 } } })).toBe("Backoff");
+
+		const now = Date.now();
+		const editedAt = { "/virtual/old.go": now - 7 * 86_400_000 };
+		editedAt["/virtual/new.go"] = now;
+		expect(first({ editedAt, scoring: { recency: 1 } })).toBe("Backoff");
+		expect(first({ editedAt })).toBe("Retry");
+	});
+});
@@ -0,0 +1,178 @@
+// @ts-nocheck
+import { afterEach, describe, expect, it } from "bun:test";
+import {
+	mkdirSync,
+	mkdtempSync,
+	renameSync,
+	rmSync,
+	unlinkSync,
+	writeFileSync,
+} from "node:fs";
+import { tmpdir } from "node:os";
+import { join } from "node:path";
+import { IgnoreMatcher } from "../gitignore.ts";
+import { watch } from "../watch.ts";
+
+const SOURCE = "package a\n\nfunc A() {}\n\nfunc B() {}\n";
+
+function nextChanges(received: unknown[][], timeoutMs = 3000) {
+	const start = received.length;
+	return new Promise((resolve, reject) => {
+		const deadline = Date.now() + timeoutMs;
+		const poll = () => {
+			if (received.length > start) {
+				resolve(received[start]);
+			} else if (Date.now() > deadline) {
+				reject(new Error("No changes received"));
+			} else {
+				setTimeout(poll, 10);
+			}
+		};
+		poll();
+	});
+}
+
+describe("watch", () => {
+	const roots: string[] = [];
+	const watchers: { close(): void }[] = [];
+
+	const makeRoot = () => {
+		const root = mkdtempSync(join(tmpdir(), "watch-test-"));
+		roots.push(root);
+		return root;
+	};
+
+	afterEach(() => {
+		for (const watcher of watchers.splice(0)) {
+			watcher.close();
+		}
+		for (const root of roots.splice(0)) {
+			rmSync(root, { recursive: true, force: true });
+		}
+	});
+
+	it("should coalesce rapid saves into one symbol diff", async () => {
+		const root = makeRoot();
+		const received = [];
+		const watcher = await watch(root, (changes) => received.push(changes), {
+			debounceMs: 50,
+		});
+		watchers.push(watcher);
+
+		const file = join(root, "a.go");
+		writeFileSync(file, "package a\n");
+		writeFileSync(file, "package a\n\nfunc A() {}\n");
+		writeFileSync(file, SOURCE);
+
+		const changes = await nextChanges(received);
+		expect(changes.map((c) => [c.type, c.symbol.name])).toEqual([
+			["added", "A"],
+			["added", "B"],
+		]);
+		expect(received).toHaveLength(1);
+
+		unlinkSync(file);
+		const removed = await nextChanges(received);
+		expect(removed.map((c) => c.type)).toEqual(["removed", "removed"]);
+		expect(watcher.index.getFiles()).toEqual([]);
+	});
+
+	it("should map renames onto the same symbol objects", async () => {
+		const root = makeRoot();
+		const received = [];
+		const watcher = await watch(root, (changes) => received.push(changes), {
+			debounceMs: 50,
+		});
+		watchers.push(watcher);
+
+		const from = join(root, "old.go");
+		const to = join(root, "new.go");
+		writeFileSync(from, SOURCE);
+		await watcher.index.updateFile(from);
+		const before = watcher.index.getFileSymbols(from);
+
+		renameSync(from, to);
+		const changes = await nextChanges(received);
+
+		expect(changes.map((c) => [c.type, c.path])).toEqual([
+			["changed", to],
+			["changed", to],
+		]);
+		expect(watcher.index.getFileSymbols(to)).toEqual(before);
+		expect(watcher.index.getFileSymbols(to)[0]).toBe(before[0]);
+		expect(watcher.index.hasFile(from)).toBe(false);
+	});
+
+	it("should skip gitignored and explicitly ignored paths", async () => {
+		const root = makeRoot();
+		writeFileSync(join(root, ".gitignore"), "gen/\n*.pb.go\n");
+		mkdirSync(join(root, "gen"));
+		mkdirSync(join(root, "vendor"));
+		const received = [];
+		const watcher = await watch(root, (changes) => received.push(changes), {
+			debounceMs: 50,
+			ignore: ["vendor/"],
+		});
+		watchers.push(watcher);
+
+		writeFileSync(join(root, "gen", "x.go"), SOURCE);
+		writeFileSync(join(root, "vendor", "y.go"), SOURCE);
+		writeFileSync(join(root, "api.pb.go"), SOURCE);
+		writeFileSync(join(root, "kept.go"), SOURCE);
+
+		const changes = await nextChanges(received);
+		expect([...new Set(changes.map((c) => c.path))]).toEqual([
+			join(root, "kept.go"),
+		]);
+	});
+
+	it("should index a moved-in directory and keep it on later events", async () => {
+		const root = makeRoot();
+		const outside = makeRoot();
+		mkdirSync(join(outside, "pkg"));
+		writeFileSync(join(outside, "pkg", "a.go"), SOURCE);
+		const received = [];
+		const watcher = await watch(root, (changes) => received.push(changes), {
+			debounceMs: 50,
+		});
+		watchers.push(watcher);
+
+		renameSync(join(outside, "pkg"), join(root, "pkg"));
+		const changes = await nextChanges(received);
+		expect(changes.map((c) => [c.type, c.symbol.name])).toEqual([
+			["added", "A"],
+			["added", "B"],
+		]);
+
+		writeFileSync(join(root, "pkg", "b.go"), "package a\n\nfunc C() {}\n");
+		await nextChanges(received);
+		expect(watcher.index.getFiles().sort()).toEqual([
+			join(root, "pkg", "a.go"),
+			join(root, "pkg", "b.go"),
+		]);
+	});
+});
+
+describe("IgnoreMatcher", () => {
+	it("should follow gitignore anchoring, negation and directory rules", () => {
+		const matcher = new IgnoreMatcher("/repo", [
+			"*.log",
+			"!keep.log",
+			"/build-out",
+			"docs/**/*.tmp",
+			"cache/",
+		]);
+
+		expect(matcher.isIgnored("/repo/a/b/debug.log")).toBe(true);
+		expect(matcher.isIgnored("/repo/keep.log")).toBe(false);
+		expect(matcher.isIgnored("/repo/build-out/x.go")).toBe(true);
+		expect(matcher.isIgnored("/repo/src/build-out/x.go")).toBe(false);
+		expect(matcher.isIgnored("/repo/docs/a/b/x.tmp")).toBe(true);
+		expect(matcher.isIgnored("/repo/docs/x.tmp")).toBe(true);
+		expect(matcher.isIgnored("/repo/cache", true)).toBe(true);
+		expect(matcher.isIgnored("/repo/cache")).toBe(false);
+		expect(matcher.isIgnored("/repo/pkg/cache/x.go")).toBe(true);
+		expect(matcher.isIgnored("/repo/node_modules/x/y.go")).toBe(true);
+		expect(matcher.isIgnored("/repo/.hidden/x.go")).toBe(true);
+	});
+});
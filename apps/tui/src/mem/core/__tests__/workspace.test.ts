@@ -0,0 +1,158 @@
+// @ts-nocheck
+import { afterAll, describe, expect, it } from "bun:test";
+import { mkdirSync, mkdtempSync, rmSync, writeFileSync } from "node:fs";
+import { tmpdir } from "node:os";
+import { join } from "node:path";
+import { BaseParser } from "../parsers/base-parser.ts";
+import { createDefaultRegistry } from "../parsers/parser-registry.ts";
+import { listFiles, parseFiles, parseWorkspace } from "../workspace.ts";
+
+class FailingParser extends BaseParser {
+	protected doParse(): Promise<never> {
+		return Promise.reject(new Error("boom"));
+	}
+
+	protected doParseSource(): Promise<never> {
+		return Promise.reject(new Error("boom"));
+	}
+}
+
+describe("parseWorkspace", () => {
+	const root = mkdtempSync(join(tmpdir(), "workspace-test-"));
+	mkdirSync(join(root, "pkg"));
+	mkdirSync(join(root, "node_modules"));
+	writeFileSync(join(root, "a.go"), "package a\n\nfunc A1() {}\n\nfunc A2() {}\n");
+	writeFileSync(join(root, "pkg", "b.go"), "package pkg\n\nfunc B() {}\n");
+	writeFileSync(join(root, "c.py"), "def c():\n    pass\n");
+	writeFileSync(join(root, "broken.bad"), "???");
+	writeFileSync(join(root, "node_modules", "skip.go"), "package skip\n");
+	writeFileSync(join(root, "README.md"), "# readme\n");
+
+	afterAll(() => {
+		rmSync(root, { recursive: true, force: true });
+	});
+
+	it("should parse all supported files in deterministic order", async () => {
+		const serial = await parseWorkspace(root, { concurrency: 1 });
+		const parallel = await parseWorkspace(root, { concurrency: 8 });
+
+		const names = serial.symbols.map((s) => s.name);
+		expect(names).toEqual(["A1", "A2", "c", "B"]);
+		expect(parallel.symbols.map((s) => s.name)).toEqual(names);
+		expect(serial.files).toHaveLength(3);
+		expect(serial.errors).toEqual([]);
+	});
+
+	it("should collect per-file errors without aborting the run", async () => {
+		const registry = createDefaultRegistry();
+		registry.register(".bad", () => new FailingParser());
+
+		const result = await parseWorkspace(root, { registry, concurrency: 2 });
+
+		expect(result.errors).toEqual([
+			{ path: join(root, "broken.bad"), error: "boom" },
+		]);
+		expect(result.symbols.map((s) => s.name)).toEqual(["A1", "A2", "c", "B"]);
+	});
+});
+
+describe("listFiles", () => {
+	const root = mkdtempSync(join(tmpdir(), "list-files-test-"));
+	const write = (rel: string, content = "package x\n") => {
+		mkdirSync(join(root, rel, ".."), { recursive: true });
+		writeFileSync(join(root, rel), content);
+	};
+	write(".gitignore", "*.gen.go\ngenerated/\n");
+	write("main.go");
+	write("api.gen.go");
+	write("generated/x.go");
+	write("vendor/dep/dep.go");
+	write(".hidden/h.go");
+	write("pkg/.gitignore", "*.go\n!keep.go\n");
+	write("pkg/drop.go");
+	write("pkg/keep.go");
+	write("other/__tests__/fixtures/sample.go");
+	write("other/util.go");
+
+	afterAll(() => {
+		rmSync(root, { recursive: true, force: true });
+	});
+
+	const rel = (files: string[]) =>
+		files.map((f) => f.slice(root.length + 1).split("\\").join("/"));
+
+	it("should honor root and nested .gitignore with negation", async () => {
+		expect(rel(await listFiles(root))).toEqual([
+			"main.go",
+			"other/__tests__/fixtures/sample.go",
+			"other/util.go",
+			"pkg/keep.go",
+		]);
+	});
+
+	it("should apply explicit ignore globs and the include allowlist", async () => {
+		const ignored = await listFiles(root, {
+			ignore: ["**/__tests__/fixtures/"],
+		});
+		expect(rel(ignored)).toEqual(["main.go", "other/util.go", "pkg/keep.go"]);
+
+		const included = await listFiles(root, { include: ["other/**", "*.md"] });
+		expect(rel(included)).toEqual([
+			"other/__tests__/fixtures/sample.go",
+			"other/util.go",
+		]);
+	});
+
+	it("should list gitignored files when gitignore is disabled", async () => {
+		const files = rel(await listFiles(root, { gitignore: false }));
+
+		expect(files).toContain("api.gen.go");
+		expect(files).toContain("pkg/drop.go");
+		expect(files).not.toContain("vendor/dep/dep.go");
+		expect(files).not.toContain(".hidden/h.go");
+	});
+
+	it("should parse exactly the listed files", async () => {
+		const options = { ignore: ["**/__tests__/fixtures/"] };
+		const result = await parseWorkspace(root, options);
+
+		expect(result.files).toEqual(await listFiles(root, options));
+	});
+});
+
+describe("parseFiles", () => {
+	const root = mkdtempSync(join(tmpdir(), "parse-files-test-"));
+	writeFileSync(join(root, "a.go"), "package a\n\nfunc A() {}\n");
+	writeFileSync(join(root, "broken.bad"), "???");
+	writeFileSync(join(root, "notes.txt"), "hello\n");
+
+	afterAll(() => {
+		rmSync(root, { recursive: true, force: true });
+	});
+
+	it("should split results, errors and skipped files without throwing", async () => {
+		const registry = createDefaultRegistry();
+		registry.register(".bad", () => new FailingParser());
+		const paths = [
+			join(root, "a.go"),
+			join(root, "missing.go"),
+			join(root, "broken.bad"),
+			join(root, "notes.txt"),
+		];
+
+		const { results, errors, skipped } = await parseFiles(paths, {
+			registry,
+			root,
+		});
+
+		expect([...results.keys()]).toEqual([join(root, "a.go")]);
+		const [symbol] = results.get(join(root, "a.go"));
+		expect(symbol?.id).toBe("a.go#function:a.A");
+		expect([...errors.keys()]).toEqual([
+			join(root, "missing.go"),
+			join(root, "broken.bad"),
+		]);
+		expect(errors.get(join(root, "broken.bad"))?.message).toBe("boom");
+		expect(skipped).toEqual([join(root, "notes.txt")]);
+	});
+});
@@ -0,0 +1,123 @@
+/**
+ * Заметки на символах ("deprecated", "owner: team-x", "hot path"),
+ * хранящиеся отдельно от результатов парсинга
+ *
+ * Ключ — стабильный `id` символа, поэтому заметки переживают
+ * перепарсинг файла, пока идентичность символа сохраняется. Заметки
+ * исчезнувшего символа уходят в orphaned и возвращаются, если символ
+ * с тем же id появится снова до истечения grace period.
+ */
+
+/** Сколько хранятся заметки удалённого символа: 7 дней */
+export const DEFAULT_ORPHAN_GRACE_MS = 7 * 24 * 60 * 60 * 1000;
+
+export type Annotations = Record<string, string>;
+
+export interface OrphanedAnnotations {
+	annotations: Annotations;
+	/** Когда символ исчез из индекса (ms) */
+	orphanedAt: number;
+}
+
+export interface AnnotationStoreOptions {
+	gracePeriodMs?: number;
+	/** Источник времени (для тестов) */
+	now?: () => number;
+}
+
+export class AnnotationStore {
+	private readonly active = new Map<string, Annotations>();
+	private readonly orphans = new Map<string, OrphanedAnnotations>();
+	private readonly gracePeriodMs: number;
+	private readonly now: () => number;
+
+	constructor(options: AnnotationStoreOptions = {}) {
+		this.gracePeriodMs = options.gracePeriodMs ?? DEFAULT_ORPHAN_GRACE_MS;
+		this.now = options.now ?? Date.now;
+	}
+
+	/**
+	 * Записать заметку; повторный вызов с тем же key перезаписывает значение
+	 */
+	annotate(symbolId: string, key: string, value: string): void {
+		const current = this.active.get(symbolId) ?? this.restore(symbolId) ?? {};
+		current[key] = value;
+		this.active.set(symbolId, current);
+	}
+
+	/**
+	 * Удалить заметку (или все заметки символа, если key не передан)
+	 */
+	unannotate(symbolId: string, key?: string): void {
+		if (key === undefined) {
+			this.active.delete(symbolId);
+			return;
+		}
+		const current = this.active.get(symbolId);
+		if (!current) {
+			return;
+		}
+		delete current[key];
+		if (Object.keys(current).length === 0) {
+			this.active.delete(symbolId);
+		}
+	}
+
+	/**
+	 * Заметки символа (пустой объект, если их нет или символ orphaned)
+	 */
+	annotationsFor(symbolId: string): Annotations {
+		return { ...this.active.get(symbolId) };
+	}
+
+	/**
+	 * Заметки исчезнувших символов, ещё не истёкшие
+	 */
+	orphaned(): Map<string, OrphanedAnnotations> {
+		this.prune();
+		return new Map(this.orphans);
+	}
+
+	/**
+	 * Символ исчез из индекса: перенести его заметки в orphaned
+	 */
+	orphan(symbolId: string): void {
+		const annotations = this.active.get(symbolId);
+		if (!annotations) {
+			return;
+		}
+		this.active.delete(symbolId);
+		this.orphans.set(symbolId, { annotations, orphanedAt: this.now() });
+	}
+
+	/**
+	 * Символ снова в индексе: вернуть заметки из orphaned, если не истекли
+	 */
+	restore(symbolId: string): Annotations | undefined {
+		const orphan = this.orphans.get(symbolId);
+		if (!orphan) {
+			return undefined;
+		}
+		this.orphans.delete(symbolId);
+		if (this.isExpired(orphan)) {
+			return undefined;
+		}
+		this.active.set(symbolId, orphan.annotations);
+		return orphan.annotations;
+	}
+
+	/**
+	 * Выбросить orphaned-заметки старше grace period
+	 */
+	prune(): void {
+		for (const [id, orphan] of this.orphans) {
+			if (this.isExpired(orphan)) {
+				this.orphans.delete(id);
+			}
+		}
+	}
+
+	private isExpired(orphan: OrphanedAnnotations): boolean {
+		return this.now() - orphan.orphanedAt > this.gracePeriodMs;
+	}
+}
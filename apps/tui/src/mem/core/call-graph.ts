@@ -0,0 +1,296 @@
+import { dirname } from "node:path";
+import type { CallReference, EnhancedCodeSymbol } from "./parsers/types.ts";
+import { symbolId } from "./symbol-id.ts";
+
+const CALLABLE_TYPES = new Set(["function", "method", "hook", "component"]);
+
+/**
+ * Ребро call graph; callee === null для неразрешённых вызовов
+ * (stdlib, внешние пакеты), которые сохраняются текстом в reference
+ */
+export interface CallEdge {
+	callee: string | null;
+	caller: string;
+	/**
+	 * Вызов функции внешнего пакета: `<import path>.<name>` независимо от
+	 * алиаса импорта (`ctx.Background` и `context.Background` совпадают)
+	 */
+	external?: string;
+	line: number;
+	reference: string;
+}
+
+/**
+ * Граф вызовов в пределах пакета
+ */
+export class CallGraph {
+	readonly edges: CallEdge[];
+	private readonly byCaller = new Map<string, CallEdge[]>();
+	private readonly byCallee = new Map<string, CallEdge[]>();
+
+	constructor(edges: CallEdge[]) {
+		this.edges = edges;
+		for (const edge of edges) {
+			pushTo(this.byCaller, edge.caller, edge);
+			const target = edge.callee ?? edge.external;
+			if (target) {
+				pushTo(this.byCallee, target, edge);
+			}
+		}
+	}
+
+	/**
+	 * Входящие рёбра: кто вызывает символ (id или `<import path>.<name>`)
+	 */
+	callersOf(id: string): CallEdge[] {
+		return this.byCallee.get(id) ?? [];
+	}
+
+	/**
+	 * Исходящие рёбра символа, включая неразрешённые вызовы
+	 */
+	calleesOf(id: string): CallEdge[] {
+		return this.byCaller.get(id) ?? [];
+	}
+}
+
+function pushTo<K, V>(map: Map<K, V[]>, key: K, value: V): void {
+	const list = map.get(key);
+	if (list) {
+		list.push(value);
+	} else {
+		map.set(key, [value]);
+	}
+}
+
+/**
+ * Пакет символа: директория + имя пакета (Go), иначе только директория
+ */
+export function packageKey(symbol: EnhancedCodeSymbol): string {
+	const pkg = symbol.metadata?.packageName;
+	return pkg ? `${dirname(symbol.path)}:${pkg}` : dirname(symbol.path);
+}
+
+/**
+ * Вызовы символа: структурированные callRefs, если парсер их заполнил,
+ * иначе плоский список calls без квалификаторов
+ */
+function callRefsOf(symbol: EnhancedCodeSymbol): CallReference[] {
+	return (
+		symbol.metadata?.callRefs ??
+		symbol.calls.map((name) => ({ name, line: symbol.startLine }))
+	);
+}
+
+/**
+ * Построить call graph, разрешая вызовы против символов того же пакета
+ *
+ * - `helper()` -> функция `helper` пакета
+ * - `u.Save()`, где `u` — receiver вызывающего метода -> `User.Save`
+ * - `User.Save(u)` (method expression) -> `User.Save`
+ * Остальное (`fmt.Sprintf`, методы полей и локальных переменных)
+ * остаётся неразрешённым.
+ */
+export function buildCallGraph(symbols: EnhancedCodeSymbol[]): CallGraph {
+	const byPackage = new Map<string, Map<string, EnhancedCodeSymbol>>();
+	addCallables(byPackage, symbols);
+	return new CallGraph(callEdges(symbols, byPackage));
+}
+
+/**
+ * Call graph, который поддерживается по файлам
+ *
+ * Вызов разрешается только против символов своего пакета, поэтому после
+ * правки файла заново разрешаются вызовы из него и из файлов его пакетов
+ * (старых и новых): имя в пакете могло появиться, исчезнуть или сменить
+ * владельца, и рёбра на удалённые символы становятся неразрешёнными.
+ * Рёбра остальных файлов переиспользуются. Результат совпадает
+ * с buildCallGraph по всем символам, включая порядок рёбер.
+ */
+export class CallGraphBuilder {
+	private readonly edgesByFile = new Map<string, CallEdge[]>();
+	private readonly packagesByFile = new Map<string, Set<string>>();
+	private readonly filesByPackage = new Map<string, Set<string>>();
+
+	/**
+	 * Пересчитать рёбра после изменения файлов
+	 *
+	 * @param files - символы всех файлов в порядке индекса
+	 * @param changed - добавленные, изменённые и удалённые пути; без него
+	 * граф строится заново
+	 * @returns файлы, рёбра которых пересчитаны
+	 */
+	update(
+		files: ReadonlyMap<string, EnhancedCodeSymbol[]>,
+		changed?: Iterable<string>
+	): Set<string> {
+		if (!changed) {
+			this.edgesByFile.clear();
+			this.packagesByFile.clear();
+			this.filesByPackage.clear();
+		}
+		const paths = new Set(changed ?? files.keys());
+		const affected = new Set<string>();
+		for (const path of paths) {
+			for (const pkg of this.packagesByFile.get(path) ?? []) {
+				affected.add(pkg);
+			}
+			this.unlink(path);
+			const symbols = files.get(path);
+			if (symbols) {
+				for (const pkg of this.link(path, symbols)) {
+					affected.add(pkg);
+				}
+			}
+		}
+
+		const updated = new Set([...paths].filter((path) => files.has(path)));
+		for (const pkg of affected) {
+			for (const path of this.filesByPackage.get(pkg) ?? []) {
+				updated.add(path);
+			}
+		}
+		const packages = new Set<string>();
+		for (const path of updated) {
+			for (const pkg of this.packagesByFile.get(path) ?? []) {
+				packages.add(pkg);
+			}
+		}
+
+		// Первое объявление имени в пакете — в порядке индекса, как в полной сборке
+		const byPackage = new Map<string, Map<string, EnhancedCodeSymbol>>();
+		for (const [path, symbols] of files) {
+			const own = this.packagesByFile.get(path);
+			if (own && [...own].some((pkg) => packages.has(pkg))) {
+				addCallables(byPackage, symbols, packages);
+			}
+		}
+		for (const path of updated) {
+			this.edgesByFile.set(path, callEdges(files.get(path) ?? [], byPackage));
+		}
+		return updated;
+	}
+
+	/**
+	 * Граф из рёбер всех файлов в порядке индекса
+	 */
+	graph(files: ReadonlyMap<string, EnhancedCodeSymbol[]>): CallGraph {
+		return new CallGraph(
+			[...files.keys()].flatMap((path) => this.edgesByFile.get(path) ?? [])
+		);
+	}
+
+	/**
+	 * Исходящие рёбра символов файла
+	 */
+	edgesOf(path: string): CallEdge[] {
+		return this.edgesByFile.get(path) ?? [];
+	}
+
+	private link(path: string, symbols: EnhancedCodeSymbol[]): Set<string> {
+		const packages = new Set(
+			symbols
+				.filter((symbol) => CALLABLE_TYPES.has(symbol.symbolType))
+				.map(packageKey)
+		);
+		this.packagesByFile.set(path, packages);
+		for (const pkg of packages) {
+			const files = this.filesByPackage.get(pkg) ?? new Set();
+			files.add(path);
+			this.filesByPackage.set(pkg, files);
+		}
+		return packages;
+	}
+
+	private unlink(path: string): void {
+		for (const pkg of this.packagesByFile.get(path) ?? []) {
+			const files = this.filesByPackage.get(pkg);
+			files?.delete(path);
+			if (files?.size === 0) {
+				this.filesByPackage.delete(pkg);
+			}
+		}
+		this.packagesByFile.delete(path);
+		this.edgesByFile.delete(path);
+	}
+}
+
+/**
+ * Вызываемые символы по пакетам: первое объявление имени выигрывает
+ *
+ * @param only - учитывать только эти пакеты
+ */
+function addCallables(
+	byPackage: Map<string, Map<string, EnhancedCodeSymbol>>,
+	symbols: EnhancedCodeSymbol[],
+	only?: ReadonlySet<string>
+): void {
+	for (const symbol of symbols) {
+		if (!CALLABLE_TYPES.has(symbol.symbolType)) {
+			continue;
+		}
+		const key = packageKey(symbol);
+		if (only && !only.has(key)) {
+			continue;
+		}
+		let names = byPackage.get(key);
+		if (!names) {
+			names = new Map();
+			byPackage.set(key, names);
+		}
+		if (!names.has(symbol.name)) {
+			names.set(symbol.name, symbol);
+		}
+	}
+}
+
+function callEdges(
+	symbols: EnhancedCodeSymbol[],
+	byPackage: Map<string, Map<string, EnhancedCodeSymbol>>
+): CallEdge[] {
+	const edges: CallEdge[] = [];
+	for (const symbol of symbols) {
+		if (!CALLABLE_TYPES.has(symbol.symbolType)) {
+			continue;
+		}
+		const caller = symbol.id ?? symbolId(symbol);
+		const names = byPackage.get(packageKey(symbol));
+
+		for (const ref of callRefsOf(symbol)) {
+			const target = resolveCall(symbol, ref, names);
+			const external =
+				!target && ref.importPath
+					? `${ref.importPath}.${ref.name}`
+					: undefined;
+			edges.push({
+				caller,
+				callee: target ? (target.id ?? symbolId(target)) : null,
+				...(external && { external }),
+				line: ref.line,
+				reference: ref.qualifier ? `${ref.qualifier}.${ref.name}` : ref.name,
+			});
+		}
+	}
+	return edges;
+}
+
+function resolveCall(
+	caller: EnhancedCodeSymbol,
+	ref: CallReference,
+	names: Map<string, EnhancedCodeSymbol> | undefined
+): EnhancedCodeSymbol | null {
+	if (!names) {
+		return null;
+	}
+	if (!ref.qualifier) {
+		return names.get(ref.name) ?? null;
+	}
+
+	const meta = caller.metadata;
+	const owner =
+		meta?.receiverName && ref.qualifier === meta.receiverName
+			? meta.receiver
+			: ref.qualifier;
+	const method = names.get(`${owner}.${ref.name}`);
+	return method?.symbolType === "method" ? method : null;
+}
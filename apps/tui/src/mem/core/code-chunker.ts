@@ -8,13 +8,21 @@ import { parseFileWithTsCompiler } from "./ts-parser.ts";
 
 export interface CodeSymbol {
 	body: string;
+	/** Хеш заголовка объявления (сигнатуры), см. symbolHashes() */
+	bodyHash?: string;
 	calls: string[];
 	endLine: number;
+	/** Хеш объявления вместе с телом */
+	fullHash?: string;
+	/** Стабильный идентификатор, см. symbolId() */
+	id?: string;
 	imports: string[];
 	jsDoc: string;
 	metadata?: SymbolMetadata;
 	name: string;
 	path: string;
+	/** Путь относительно корня репозитория через `/`, см. repoRelativePath() */
+	relativePath?: string;
 	startLine: number;
 	symbolType:
 		| "function"
@@ -26,7 +34,8 @@ export interface CodeSymbol {
 		| "component"
 		| "constant"
 		| "variable"
-		| "hook";
+		| "hook"
+		| "package";
 }
 
 export interface CodeChunk {
@@ -61,21 +70,21 @@ const LANG_BY_EXT: Record<string, string> = {
 const MAX_BODY_LENGTH = 3000;
 const MAX_EMBEDDING_LENGTH = 4000;
 
-const FUNCTION_RE = /^(export\s+)?(async\s+)?function\s+(\w+)/;
+const FUNCTION_RE = /^(export\s+)?(async\s+)?function\s+([\p{ID_Start}_$][\p{ID_Continue}$]*)/u;
 const EXPORT_DEFAULT_FUNC_RE =
-	/^export\s+default\s+(async\s+)?function\s+(\w+)/;
+	/^export\s+default\s+(async\s+)?function\s+([\p{ID_Start}_$][\p{ID_Continue}$]*)/u;
 const ARROW_RE =
-	/^(export\s+)?(const|let)\s+(\w+)\s*=\s*(async\s+)?(\([^)]*\)|[a-zA-Z_]\w*)\s*(?::\s*[^=]+)?\s*=>/;
+	/^(export\s+)?(const|let)\s+([\p{ID_Start}_$][\p{ID_Continue}$]*)\s*=\s*(async\s+)?(\([^)]*\)|[\p{ID_Start}_$][\p{ID_Continue}$]*)\s*(?::\s*[^=]+)?\s*=>/u;
 const FUNC_EXPRESSION_RE =
-	/^(export\s+)?(const|let)\s+(\w+)\s*=\s*(async\s+)?function/;
+	/^(export\s+)?(const|let)\s+([\p{ID_Start}_$][\p{ID_Continue}$]*)\s*=\s*(async\s+)?function/u;
 const REACT_WRAPPER_RE =
-	/^(export\s+)?(const|let)\s+(\w+)\s*=\s*(?:React\.)?(?:memo|forwardRef|lazy)\s*\(/;
-const MULTILINE_ARROW_START_RE = /^(export\s+)?(const|let)\s+(\w+)\s*[=:]/;
-const CLASS_RE = /^(export\s+)?(abstract\s+)?class\s+(\w+)/;
-const INTERFACE_RE = /^(export\s+)?interface\s+(\w+)/;
-const TYPE_RE = /^(export\s+)?type\s+(\w+)/;
-const ENUM_RE = /^(export\s+)?(const\s+)?enum\s+(\w+)/;
-const METHOD_NAME_RE = /^(\w+)\s*[(<]/;
+	/^(export\s+)?(const|let)\s+([\p{ID_Start}_$][\p{ID_Continue}$]*)\s*=\s*(?:React\.)?(?:memo|forwardRef|lazy)\s*\(/u;
+const MULTILINE_ARROW_START_RE = /^(export\s+)?(const|let)\s+([\p{ID_Start}_$][\p{ID_Continue}$]*)\s*[=:]/u;
+const CLASS_RE = /^(export\s+)?(abstract\s+)?class\s+([\p{ID_Start}_$][\p{ID_Continue}$]*)/u;
+const INTERFACE_RE = /^(export\s+)?interface\s+([\p{ID_Start}_$][\p{ID_Continue}$]*)/u;
+const TYPE_RE = /^(export\s+)?type\s+([\p{ID_Start}_$][\p{ID_Continue}$]*)/u;
+const ENUM_RE = /^(export\s+)?(const\s+)?enum\s+([\p{ID_Start}_$][\p{ID_Continue}$]*)/u;
+const METHOD_NAME_RE = /^([\p{ID_Start}_$][\p{ID_Continue}$]*)\s*[(<]/u;
 const CLASS_MODIFIER_RE =
 	/^(public|private|protected|static|abstract|override|readonly|async|get|set)\s+/g;
 const JSDOC_START_RE = /^\s*\/\*\*/;
@@ -87,8 +96,8 @@ const COMMENT_STRIP_RE = /^\/\/\s?/;
 const TSX_EXT_RE = /\.[jt]sx$/;
 
 const IMPORT_NAMED_RE = /import\s+\{([^}]+)\}\s+from\s+["']([^"']+)["']/g;
-const IMPORT_DEFAULT_RE = /import\s+(\w+)\s+from\s+["']([^"']+)["']/g;
-const CALL_RE = /\b([a-zA-Z_]\w{2,})\s*\(/g;
+const IMPORT_DEFAULT_RE = /import\s+([\p{ID_Start}_$][\p{ID_Continue}$]*)\s+from\s+["']([^"']+)["']/gu;
+const CALL_RE = /(?<!\p{ID_Continue})([\p{ID_Start}_]\p{ID_Continue}{2,})\s*\(/gu;
 const AS_SPLIT_RE = /\s+as\s+/;
 
 const JS_KEYWORDS = new Set([
@@ -365,7 +374,7 @@ function hasTopLevelArrow(line: string): boolean {
 }
 
 const VALID_ARROW_RHS_RE =
-	/=\s*(?:async\s+)?(?:\(|<|[a-zA-Z_]\w*\s*(?:=>|,|\)))/;
+	/=\s*(?:async\s+)?(?:\(|<|[\p{ID_Start}_$][\p{ID_Continue}$]*\s*(?:=>|,|\)))/u;
 
 function tryMatchMultilineArrow(
 	lines: string[],
@@ -478,7 +487,8 @@ export async function parseFileSymbols(
 	const ext = extname(filePath);
 
 	// Попробовать использовать Tree-sitter парсер из ParserFactory
-	if (isSupported(ext)) {
+	// NOTE: TypeScript/JavaScript используют свой parseFileWithTsCompiler()
+	if (!TS_JS_EXTS.has(ext) && isSupported(ext)) {
 		const parser = getParser(ext);
 		if (parser) {
 			try {
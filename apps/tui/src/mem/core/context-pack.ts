@@ -0,0 +1,175 @@
+import type { EnhancedCodeSymbol } from "./parsers/types.ts";
+import type { ScoringConfig } from "./scoring.ts";
+import { resolveScoring, symbolBonus } from "./scoring.ts";
+import type { SnippetOptions } from "./snippet.ts";
+import { renderSnippet } from "./snippet.ts";
+
+export interface PackContextOptions {
+	/** Время последней правки по пути файла (мс с эпохи) для веса recency */
+	editedAt?: Record<string, number>;
+	/** Оценка числа токенов текста (по умолчанию длина / 4) */
+	estimateTokens?: (text: string) => number;
+	/** Надбавки к релевантности за вид, экспорт и свежесть (см. ScoringConfig) */
+	scoring?: Partial<ScoringConfig>;
+	/** Релевантность по id символа; без оценки порядок — как в symbolIds */
+	scores?: Record<string, number>;
+}
+
+export interface PackedContext {
+	/** Не поместились в бюджет или не найдены в индексе */
+	dropped: string[];
+	/** Вошли целиком */
+	included: string[];
+	text: string;
+	/** Оценка токенов text */
+	tokens: number;
+	/** Вошли в сокращённом виде: без тела, doc comment или части членов */
+	truncated: string[];
+}
+
+const SEPARATOR = "\n\n";
+
+/**
+ * Детализация фрагмента от полной к минимальной: всё тело, тело
+ * с обычным ограничением строк, сигнатура с doc comment, одна сигнатура
+ */
+const LEVELS: SnippetOptions[] = [
+	{
+		body: true,
+		maxLines: Number.POSITIVE_INFINITY,
+		maxMembers: Number.POSITIVE_INFINITY,
+	},
+	{ body: true },
+	{},
+	{ docs: false, maxMembers: 0 },
+];
+// Первый уровень без тела функции
+const COMPACT_LEVEL = 2;
+
+/**
+ * Грубая оценка токенов: около четырёх символов на токен
+ */
+export function estimateTokens(text: string): number {
+	return Math.ceil(text.length / 4);
+}
+
+interface Variant {
+	text: string;
+	tokens: number;
+}
+
+interface PackEntry {
+	/** Выбранный уровень LEVELS */
+	chosen: number;
+	id: string;
+	variants: Variant[];
+}
+
+/**
+ * Собрать фрагменты символов в контекст для модели в пределах бюджета
+ *
+ * Сначала каждый символ по убыванию релевантности получает компактный
+ * фрагмент (сигнатура и doc, как renderSnippet), а если он не влезает —
+ * одну сигнатуру. Оставшийся бюджет тратится на тела самых релевантных
+ * символов. Так при тесном бюджете в контекст попадают сигнатуры многих
+ * символов, а не тело одного. Бюджет приблизительный: он сравнивается
+ * с оценкой estimateTokens. Релевантность символа — scores плюс
+ * надбавки scoring.
+ *
+ * @param symbols - символы индекса (для поиска по id и receiver-типов)
+ */
+export function packContext(
+	symbols: EnhancedCodeSymbol[],
+	symbolIds: string[],
+	tokenBudget: number,
+	options: PackContextOptions = {}
+): PackedContext {
+	const estimate = options.estimateTokens ?? estimateTokens;
+	const scores = options.scores ?? {};
+	const byId = new Map<string, EnhancedCodeSymbol>();
+	for (const symbol of symbols) {
+		if (symbol.id && !byId.has(symbol.id)) {
+			byId.set(symbol.id, symbol);
+		}
+	}
+
+	const ids = [...new Set(symbolIds)];
+	const rank = new Map(ids.map((id, i) => [id, i]));
+	const config = resolveScoring(options.scoring);
+	const now = Date.now();
+	const priority = new Map(
+		ids.map((id) => {
+			const symbol = byId.get(id);
+			const bonus = symbol
+				? symbolBonus(symbol, config, options.editedAt, now)
+				: 0;
+			return [id, (scores[id] ?? 0) + bonus];
+		})
+	);
+	ids.sort(
+		(a, b) =>
+			(priority.get(b) ?? 0) - (priority.get(a) ?? 0) ||
+			(rank.get(a) ?? 0) - (rank.get(b) ?? 0)
+	);
+
+	const dropped: string[] = [];
+	const picks: PackEntry[] = [];
+	let used = 0;
+	for (const id of ids) {
+		const symbol = byId.get(id);
+		if (!symbol) {
+			dropped.push(id);
+			continue;
+		}
+		const variants = variantsOf(symbol, symbols, estimate);
+		// Самый подробный из вариантов без тела, который влезает
+		const compact = variants.findIndex(
+			(v, i) => i >= COMPACT_LEVEL && used + v.tokens <= tokenBudget
+		);
+		if (compact === -1) {
+			dropped.push(id);
+			continue;
+		}
+		used += (variants[compact] as Variant).tokens;
+		picks.push({ chosen: compact, id, variants });
+	}
+
+	// Остаток бюджета — на тела, начиная с самых релевантных
+	for (const pick of picks) {
+		const current = (pick.variants[pick.chosen] as Variant).tokens;
+		const better = pick.variants.findIndex(
+			(v, i) => i < pick.chosen && used - current + v.tokens <= tokenBudget
+		);
+		if (better !== -1) {
+			used += (pick.variants[better] as Variant).tokens - current;
+			pick.chosen = better;
+		}
+	}
+
+	const textOf = (p: PackEntry) => (p.variants[p.chosen] as Variant).text;
+	// Символ без тела и лишних членов целиком виден и в компактном виде
+	const complete = (p: PackEntry) => textOf(p) === p.variants[0]?.text;
+	const text = picks.map(textOf).join(SEPARATOR);
+	return {
+		dropped,
+		included: picks.filter(complete).map((p) => p.id),
+		text,
+		tokens: text ? estimate(text) : 0,
+		truncated: picks.filter((p) => !complete(p)).map((p) => p.id),
+	};
+}
+
+/**
+ * Фрагмент символа на каждом уровне LEVELS. Стоимость включает
+ * разделитель, чтобы сумма не была меньше оценки всего текста
+ */
+function variantsOf(
+	symbol: EnhancedCodeSymbol,
+	symbols: EnhancedCodeSymbol[],
+	estimate: (text: string) => number
+): Variant[] {
+	return LEVELS.map((level) => {
+		const text = renderSnippet(symbol, symbols, undefined, level);
+		return { text, tokens: estimate(text + SEPARATOR) };
+	});
+}
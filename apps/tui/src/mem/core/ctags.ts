@@ -0,0 +1,127 @@
+import { extname, relative } from "node:path";
+import type { EnhancedCodeSymbol } from "./parsers/types.ts";
+import type { SymbolOrder } from "./symbol-order.ts";
+import { symbolComparator } from "./symbol-order.ts";
+
+export interface CtagsOptions {
+	/** Базовая директория для путей в tags файле (по умолчанию пути как есть) */
+	baseDir?: string;
+	/**
+	 * Порядок строк, по умолчанию kind-then-name. Только "name" даёт
+	 * байтовую сортировку по тегу и `!_TAG_FILE_SORTED 1`
+	 */
+	order?: SymbolOrder;
+}
+
+function header(sorted: boolean): string[] {
+	return [
+		"!_TAG_FILE_FORMAT\t2\t/extended format; --format=1 will not append ;\" to lines/",
+		`!_TAG_FILE_SORTED\t${sorted ? 1 : 0}\t/0=unsorted, 1=sorted, 2=foldcase/`,
+		"!_TAG_FILE_ENCODING\tutf-8\t//",
+		"!_TAG_PROGRAM_NAME\tyep\t//",
+	];
+}
+
+const KIND_LETTERS: Record<string, string> = {
+	class: "c",
+	component: "f",
+	constant: "C",
+	enum: "g",
+	function: "f",
+	hook: "f",
+	interface: "i",
+	method: "m",
+	type: "t",
+	variable: "v",
+};
+
+// Буквы Universal Ctags для Go: const — c, package — p, struct — s
+const GO_KIND_LETTERS: Record<string, string> = {
+	...KIND_LETTERS,
+	constant: "c",
+	package: "p",
+};
+
+function kindLetter(symbol: EnhancedCodeSymbol): string {
+	if (extname(symbol.path) === ".go") {
+		if (symbol.symbolType === "class") {
+			return symbol.metadata?.typeKind === "struct" ? "s" : "t";
+		}
+		return GO_KIND_LETTERS[symbol.symbolType] ?? "v";
+	}
+	return KIND_LETTERS[symbol.symbolType] ?? "v";
+}
+
+function accessOf(symbol: EnhancedCodeSymbol): string {
+	const meta = symbol.metadata;
+	if (meta?.visibility) {
+		return meta.visibility;
+	}
+	return meta?.isExported === false ? "private" : "public";
+}
+
+/**
+ * Поисковый шаблон: первая строка объявления, `\` и `/` экранируются.
+ * Якорь `^` ставится только когда объявление начинается с начала строки
+ */
+function searchPattern(symbol: EnhancedCodeSymbol): string {
+	const firstLine = symbol.body.split("\n")[0] ?? "";
+	const escaped = firstLine.replace(/[\\/]/g, "\\$&");
+	const anchored = (symbol.metadata?.range?.startCol ?? 0) === 0;
+	return `/${anchored ? "^" : ""}${escaped}$/`;
+}
+
+function localName(symbol: EnhancedCodeSymbol): string {
+	const receiver = symbol.metadata?.receiver;
+	return receiver && symbol.name.startsWith(`${receiver}.`)
+		? symbol.name.slice(receiver.length + 1)
+		: symbol.name;
+}
+
+/**
+ * Сериализовать символы в extended ctags формат (Universal Ctags)
+ *
+ * По умолчанию строки идут в порядке kind-then-name (стабильно для diff'ов)
+ * с `!_TAG_FILE_SORTED 0`; с `order: "name"` отсортированы по имени тега
+ * (байтовый порядок), как того требует `!_TAG_FILE_SORTED 1`. Методы
+ * получают scope-поле `struct:` (Go struct) или `class:` по receiver'у.
+ */
+export function toCtags(
+	symbols: EnhancedCodeSymbol[],
+	options: CtagsOptions = {}
+): string {
+	const structs = new Set(
+		symbols
+			.filter((s) => s.metadata?.typeKind === "struct")
+			.map((s) => s.name)
+	);
+
+	const order = options.order ?? "kind-then-name";
+	const ordered =
+		order === "name" ? symbols : [...symbols].sort(symbolComparator(order));
+	const lines = ordered.map((symbol) => {
+		const file = options.baseDir
+			? relative(options.baseDir, symbol.path)
+			: symbol.path;
+		const fields = [
+			kindLetter(symbol),
+			`line:${symbol.startLine}`,
+			`access:${accessOf(symbol)}`,
+		];
+		const receiver = symbol.metadata?.receiver;
+		if (receiver) {
+			fields.push(`${structs.has(receiver) ? "struct" : "class"}:${receiver}`);
+		}
+		return [
+			localName(symbol),
+			file,
+			`${searchPattern(symbol)};"`,
+			...fields,
+		].join("\t");
+	});
+
+	if (order === "name") {
+		lines.sort((a, b) => (a < b ? -1 : a > b ? 1 : 0));
+	}
+	return `${[...header(order === "name"), ...lines].join("\n")}\n`;
+}
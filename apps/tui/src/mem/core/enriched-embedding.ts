@@ -54,7 +54,7 @@ function extractSignature(sym: CodeSymbol): string | null {
 	if (sym.metadata.parameters && sym.metadata.parameters.length > 0) {
 		const params = sym.metadata.parameters
 			.map((p) => {
-				let param = p.name;
+				let param = p.variadic ? `...${p.name}` : p.name;
 				if (p.type) {
 					param += `: ${p.type}`;
 				}
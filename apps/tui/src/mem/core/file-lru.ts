@@ -0,0 +1,151 @@
+import type { EnhancedCodeSymbol } from "./parsers/types.ts";
+
+/**
+ * Ограничение памяти индекса: по числу символов и/или оценке в байтах
+ */
+export interface SymbolIndexCapacity {
+	maxBytes?: number;
+	maxSymbols?: number;
+}
+
+export interface FileLruStats {
+	/** Оценка памяти загруженных символов */
+	bytes: number;
+	evictions: number;
+	/** Запросы к загруженному файлу */
+	hits: number;
+	/** Запросы к вытесненному файлу */
+	misses: number;
+	symbols: number;
+}
+
+interface FileEntry {
+	bytes: number;
+	symbols: number;
+}
+
+/**
+ * Грубая оценка памяти символов: UTF-16 длина их JSON-представления
+ */
+export function estimateSymbolBytes(symbols: EnhancedCodeSymbol[]): number {
+	return JSON.stringify(symbols).length * 2;
+}
+
+/**
+ * LRU-учёт загруженных файлов индекса
+ *
+ * Хранит только размеры и порядок обращений; сами символы остаются
+ * в SymbolIndex. Порядок — порядок вставки Map: touch переносит файл
+ * в конец, кандидаты на вытеснение берутся с начала. Закреплённые
+ * файлы не вытесняются, даже если лимит из-за них превышен.
+ */
+export class FileLru {
+	private readonly entries = new Map<string, FileEntry>();
+	private readonly pinned = new Set<string>();
+	private bytes = 0;
+	private symbols = 0;
+	private hits = 0;
+	private misses = 0;
+	private evictions = 0;
+	private readonly capacity: SymbolIndexCapacity;
+
+	constructor(capacity: SymbolIndexCapacity) {
+		this.capacity = capacity;
+	}
+
+	/**
+	 * Файл загружен или перепарсен: обновить размер и сделать самым свежим
+	 */
+	set(path: string, symbols: EnhancedCodeSymbol[]): void {
+		this.delete(path);
+		const entry = {
+			bytes: this.capacity.maxBytes ? estimateSymbolBytes(symbols) : 0,
+			symbols: symbols.length,
+		};
+		this.entries.set(path, entry);
+		this.bytes += entry.bytes;
+		this.symbols += entry.symbols;
+	}
+
+	delete(path: string): void {
+		const entry = this.entries.get(path);
+		if (!entry) {
+			return;
+		}
+		this.entries.delete(path);
+		this.bytes -= entry.bytes;
+		this.symbols -= entry.symbols;
+	}
+
+	/**
+	 * Учесть обращение к файлу: загруженный становится самым свежим
+	 */
+	touch(path: string, evicted: boolean): void {
+		if (evicted) {
+			this.misses++;
+			return;
+		}
+		const entry = this.entries.get(path);
+		if (entry) {
+			this.hits++;
+			this.entries.delete(path);
+			this.entries.set(path, entry);
+		}
+	}
+
+	pin(path: string): void {
+		this.pinned.add(path);
+	}
+
+	unpin(path: string): void {
+		this.pinned.delete(path);
+	}
+
+	isPinned(path: string): boolean {
+		return this.pinned.has(path);
+	}
+
+	/**
+	 * Снять давно не использованные файлы, пока индекс не уложится в лимит,
+	 * и вернуть их пути. except — файл, который только что загружен
+	 */
+	evict(except?: string): string[] {
+		const victims: string[] = [];
+		for (const path of [...this.entries.keys()]) {
+			if (!this.overCapacity()) {
+				break;
+			}
+			if (path === except || this.pinned.has(path)) {
+				continue;
+			}
+			this.delete(path);
+			this.evictions++;
+			victims.push(path);
+		}
+		return victims;
+	}
+
+	clear(): void {
+		this.entries.clear();
+		this.bytes = 0;
+		this.symbols = 0;
+	}
+
+	stats(): FileLruStats {
+		return {
+			bytes: this.bytes,
+			evictions: this.evictions,
+			hits: this.hits,
+			misses: this.misses,
+			symbols: this.symbols,
+		};
+	}
+
+	private overCapacity(): boolean {
+		const { maxBytes, maxSymbols } = this.capacity;
+		return (
+			(maxSymbols !== undefined && this.symbols > maxSymbols) ||
+			(maxBytes !== undefined && this.bytes > maxBytes)
+		);
+	}
+}
@@ -0,0 +1,206 @@
+import { readFile } from "node:fs/promises";
+import { join, relative, sep } from "node:path";
+
+/**
+ * Директории, которые не обходятся никогда (зависимости, артефакты сборки)
+ */
+export const DEFAULT_IGNORE_DIRS = new Set([
+	"node_modules",
+	".git",
+	".next",
+	"dist",
+	"build",
+	".yep-mem",
+	".entire",
+	"coverage",
+	".turbo",
+	".cache",
+	"vendor",
+	"target",
+	"__pycache__",
+	"venv",
+]);
+
+interface IgnoreRule {
+	/** Директория .gitignore относительно root ("" — сам root) */
+	base: string;
+	dirOnly: boolean;
+	negate: boolean;
+	regex: RegExp;
+}
+
+/**
+ * Перевести glob в RegExp по правилам .gitignore
+ *
+ * `*` и `?` не пересекают `/`, `**` — любое число сегментов. Шаблон без
+ * `/` в начале или середине совпадает на любой глубине.
+ */
+export function globToRegExp(pattern: string): RegExp {
+	const anchored = pattern.startsWith("/") || pattern.includes("/");
+	const body = pattern.replace(/^\//, "");
+	let out = "";
+
+	for (let i = 0; i < body.length; i++) {
+		const char = body[i] as string;
+		if (char === "*" && body[i + 1] === "*") {
+			const atStart = i === 0 || body[i - 1] === "/";
+			const atEnd = i + 2 === body.length || body[i + 2] === "/";
+			if (atStart && atEnd) {
+				// "**/" — ноль и более сегментов, "/**" в конце — всё внутри
+				out += i + 2 === body.length ? ".*" : "(?:.*/)?";
+				i += i + 2 === body.length ? 1 : 2;
+				continue;
+			}
+		}
+		if (char === "*") {
+			out += "[^/]*";
+		} else if (char === "?") {
+			out += "[^/]";
+		} else if (char === "[") {
+			const close = body.indexOf("]", i + 1);
+			if (close === -1) {
+				out += "\\[";
+			} else {
+				const set = body.slice(i + 1, close).replace(/^!/, "^");
+				out += `[${set.replace(/\\/g, "\\\\")}]`;
+				i = close;
+			}
+		} else if (char === "\\" && i + 1 < body.length) {
+			out += `\\${body[++i]}`;
+		} else {
+			out += char.replace(/[.+^${}()|\\]/g, "\\$&");
+		}
+	}
+
+	return new RegExp(anchored ? `^${out}$` : `^(?:.*/)?${out}$`);
+}
+
+/**
+ * Разобрать содержимое .gitignore (или список шаблонов) в правила
+ *
+ * @param base - директория файла относительно root, через `/`
+ */
+export function parseIgnorePatterns(
+	lines: string | string[],
+	base = ""
+): IgnoreRule[] {
+	const rules: IgnoreRule[] = [];
+	const list = typeof lines === "string" ? lines.split(/\r?\n/) : lines;
+
+	for (const raw of list) {
+		let line = raw.replace(/(?<!\\)\s+$/, "");
+		if (line === "" || line.startsWith("#")) {
+			continue;
+		}
+		const negate = line.startsWith("!");
+		if (negate) {
+			line = line.slice(1);
+		}
+		line = line.replace(/^\\([#!])/, "$1");
+		const dirOnly = line.endsWith("/");
+		if (dirOnly) {
+			line = line.slice(0, -1);
+		}
+		if (line === "") {
+			continue;
+		}
+		rules.push({ base, dirOnly, negate, regex: globToRegExp(line) });
+	}
+
+	return rules;
+}
+
+function toPosix(path: string): string {
+	return sep === "/" ? path : path.split(sep).join("/");
+}
+
+/**
+ * Проверка путей по правилам .gitignore и явным ignore-шаблонам
+ *
+ * Как в git, побеждает последнее совпавшее правило, а файл внутри
+ * игнорируемой директории не возвращается отрицанием. Явные шаблоны
+ * проверяются после всех .gitignore, так что их нельзя отменить `!`
+ * в репозитории. Скрытые файлы и директории пропускаются всегда.
+ */
+export class IgnoreMatcher {
+	private readonly gitRules: IgnoreRule[] = [];
+	private readonly extraRules: IgnoreRule[];
+	readonly root: string;
+
+	constructor(root: string, patterns: string[] = []) {
+		this.root = root;
+		this.extraRules = parseIgnorePatterns(patterns);
+	}
+
+	/**
+	 * Добавить правила .gitignore из директории (путь абсолютный)
+	 */
+	async addGitignore(dir: string): Promise<void> {
+		let content: string;
+		try {
+			content = await readFile(join(dir, ".gitignore"), "utf-8");
+		} catch {
+			return;
+		}
+		const base = toPosix(relative(this.root, dir));
+		// Правила вложенных .gitignore идут после корневых и перекрывают их
+		this.gitRules.push(...parseIgnorePatterns(content, base));
+	}
+
+	/**
+	 * Игнорируется ли путь (абсолютный) с учётом родительских директорий
+	 */
+	isIgnored(path: string, isDir = false): boolean {
+		const rel = toPosix(relative(this.root, path));
+		if (rel === "" || rel.startsWith("..")) {
+			return false;
+		}
+		const segments = rel.split("/");
+		for (let i = 1; i < segments.length; i++) {
+			if (this.matches(segments.slice(0, i).join("/"), true)) {
+				return true;
+			}
+		}
+		return this.matches(rel, isDir);
+	}
+
+	/**
+	 * Совпадение одного пути без учёта родителей (для обхода сверху вниз)
+	 */
+	matches(rel: string, isDir: boolean): boolean {
+		const name = rel.slice(rel.lastIndexOf("/") + 1);
+		if (name.startsWith(".") || (isDir && DEFAULT_IGNORE_DIRS.has(name))) {
+			return true;
+		}
+
+		let ignored = false;
+		for (const rule of [...this.gitRules, ...this.extraRules]) {
+			if (rule.dirOnly && !isDir) {
+				continue;
+			}
+			let candidate = rel;
+			if (rule.base) {
+				if (!rel.startsWith(`${rule.base}/`)) {
+					continue;
+				}
+				candidate = rel.slice(rule.base.length + 1);
+			}
+			if (rule.regex.test(candidate)) {
+				ignored = !rule.negate;
+			}
+		}
+		return ignored;
+	}
+}
+
+/**
+ * Matcher с корневым .gitignore и явными шаблонами
+ */
+export async function loadIgnoreMatcher(
+	root: string,
+	patterns: string[] = []
+): Promise<IgnoreMatcher> {
+	const matcher = new IgnoreMatcher(root, patterns);
+	await matcher.addGitignore(root);
+	return matcher;
+}
@@ -0,0 +1,408 @@
+import { packageKey } from "./call-graph.ts";
+import type {
+	EnhancedCodeSymbol,
+	FunctionParameter,
+	FunctionResult,
+	StructField,
+} from "./parsers/types.ts";
+
+export interface InterfaceImplementation {
+	/** Интерфейс реализует только *T (часть методов с pointer receiver) */
+	pointerOnly: boolean;
+	type: EnhancedCodeSymbol;
+}
+
+/**
+ * Method sets конкретного типа T по правилам Go
+ *
+ * Методы встроенных структур входят в наборы копиями с
+ * metadata.promotedFrom — типом, где метод объявлен.
+ */
+export interface MethodSets {
+	/** Методы *T: с value и с pointer receiver, включая продвинутые */
+	pointerMethodSet: EnhancedCodeSymbol[];
+	type: EnhancedCodeSymbol;
+	/**
+	 * Методы T: с value receiver; продвинутые с pointer receiver — только
+	 * если встраивание на пути к ним через указатель (`*User`)
+	 */
+	valueMethodSet: EnhancedCodeSymbol[];
+}
+
+const CONCRETE_TYPES = new Set(["class", "type"]);
+// Квалификатор пакета в типе: io.Writer -> Writer
+const QUALIFIER_RE = /\b[\p{L}_][\p{L}\p{N}_]*\./gu;
+
+function normalizeType(type: string | null | undefined): string {
+	return (type ?? "").replace(QUALIFIER_RE, "").replace(/\s+/g, "");
+}
+
+function typeList(
+	params: Array<Pick<FunctionParameter, "type" | "variadic">> | undefined
+): string {
+	return (params ?? [])
+		.map((p) => `${p.variadic ? "..." : ""}${normalizeType(p.type)}`)
+		.join(",");
+}
+
+function methodSignature(
+	parameters: FunctionParameter[] | undefined,
+	returns: FunctionResult[] | undefined
+): string {
+	return `(${typeList(parameters)})(${typeList(returns)})`;
+}
+
+function baseName(name: string): string {
+	return name.slice(name.lastIndexOf(".") + 1);
+}
+
+function isConcreteType(symbol: EnhancedCodeSymbol): boolean {
+	return (
+		CONCRETE_TYPES.has(symbol.symbolType) &&
+		symbol.metadata?.typeKind !== "alias"
+	);
+}
+
+/**
+ * Найти символ по имени (`User`) или `pkg.User`
+ */
+function findByName(
+	symbols: EnhancedCodeSymbol[],
+	qualifiedName: string,
+	accept: (symbol: EnhancedCodeSymbol) => boolean
+): EnhancedCodeSymbol | undefined {
+	const dot = qualifiedName.lastIndexOf(".");
+	const pkg = dot === -1 ? undefined : qualifiedName.slice(0, dot);
+	const name = baseName(qualifiedName);
+	return symbols.find(
+		(s) =>
+			accept(s) &&
+			s.name === name &&
+			(!pkg || s.metadata?.packageName === pkg)
+	);
+}
+
+/**
+ * Методы и конкретные типы символов, сгруппированные для разрешения
+ * встраивания
+ */
+interface TypeIndex {
+	/** Методы по типу-владельцу в пределах пакета: ключ `пакет#Тип` */
+	owners: Map<string, EnhancedCodeSymbol[]>;
+	/** Типы по `пакет#Тип` (packageKey) */
+	types: Map<string, EnhancedCodeSymbol>;
+	/** Типы по `имя пакета#Тип` для встраивания `pkg.User` */
+	typesByPackageName: Map<string, EnhancedCodeSymbol>;
+}
+
+function typeIndex(symbols: EnhancedCodeSymbol[]): TypeIndex {
+	const index: TypeIndex = {
+		owners: new Map(),
+		types: new Map(),
+		typesByPackageName: new Map(),
+	};
+	for (const symbol of symbols) {
+		const receiver = symbol.metadata?.receiver;
+		if (symbol.symbolType === "method" && receiver) {
+			const key = `${packageKey(symbol)}#${receiver}`;
+			const list = index.owners.get(key);
+			if (list) {
+				list.push(symbol);
+			} else {
+				index.owners.set(key, [symbol]);
+			}
+		} else if (isConcreteType(symbol)) {
+			const key = `${packageKey(symbol)}#${symbol.name}`;
+			if (!index.types.has(key)) {
+				index.types.set(key, symbol);
+			}
+			const pkg = symbol.metadata?.packageName;
+			if (pkg && !index.typesByPackageName.has(`${pkg}#${symbol.name}`)) {
+				index.typesByPackageName.set(`${pkg}#${symbol.name}`, symbol);
+			}
+		}
+	}
+	return index;
+}
+
+/**
+ * Тип встроенного поля без указателя и аргументов типа: `*pkg.Base[T]`
+ * -> `pkg.Base`
+ */
+function embeddedTypeName(type: string): string {
+	return type.replace(/^\*/, "").replace(/\[.*$/s, "");
+}
+
+/**
+ * Найти тип встроенного поля: без квалификатора — в пакете владельца
+ * (в любом его файле), `pkg.User` — по имени пакета
+ */
+function resolveEmbedded(
+	field: StructField,
+	owner: EnhancedCodeSymbol,
+	index: TypeIndex
+): EnhancedCodeSymbol | undefined {
+	const name = embeddedTypeName(field.type);
+	const dot = name.lastIndexOf(".");
+	if (dot === -1) {
+		return index.types.get(`${packageKey(owner)}#${name}`);
+	}
+	return index.typesByPackageName.get(
+		`${name.slice(0, dot)}#${name.slice(dot + 1)}`
+	);
+}
+
+/**
+ * Поле или метод, доступный через селектор `x.name`
+ */
+interface Member {
+	depth: number;
+	field?: StructField;
+	method?: EnhancedCodeSymbol;
+	/** Тип, где член объявлен */
+	owner: EnhancedCodeSymbol;
+	/** На пути встраивания есть указатель (`*User`) */
+	viaPointer: boolean;
+}
+
+/**
+ * Члены типа с учётом встраивания по правилам селекторов Go
+ *
+ * Имя разрешается на наименьшей глубине встраивания: член внешнего типа
+ * затеняет одноимённый член встроенного. Если на этой глубине имя
+ * встречается больше одного раза, оно неоднозначно и не продвигается
+ * совсем (более глубокие члены тоже не видны). Встроенные интерфейсы
+ * и типы вне индекса не раскрываются.
+ */
+function selectMembers(type: EnhancedCodeSymbol, index: TypeIndex): Member[] {
+	const selected: Member[] = [];
+	const decided = new Set<string>();
+	const visited = new Set<EnhancedCodeSymbol>();
+	let level = [{ type, viaPointer: false }];
+
+	for (let depth = 0; level.length > 0; depth++) {
+		const byName = new Map<string, Member[]>();
+		const add = (name: string, member: Member) => {
+			const list = byName.get(name);
+			if (list) {
+				list.push(member);
+			} else {
+				byName.set(name, [member]);
+			}
+		};
+		const next: typeof level = [];
+
+		for (const { type: owner, viaPointer } of level) {
+			if (visited.has(owner)) {
+				continue;
+			}
+			for (const field of owner.metadata?.fields ?? []) {
+				const name = field.embedded
+					? baseName(embeddedTypeName(field.type))
+					: field.name;
+				if (name) {
+					add(name, { depth, field, owner, viaPointer });
+				}
+				const embedded = field.embedded
+					? resolveEmbedded(field, owner, index)
+					: undefined;
+				if (embedded) {
+					next.push({
+						type: embedded,
+						viaPointer: viaPointer || field.type.startsWith("*"),
+					});
+				}
+			}
+			const methods =
+				index.owners.get(`${packageKey(owner)}#${owner.name}`) ?? [];
+			for (const method of methods) {
+				add(baseName(method.name), { depth, method, owner, viaPointer });
+			}
+		}
+		for (const entry of level) {
+			visited.add(entry.type);
+		}
+
+		for (const [name, members] of byName) {
+			if (decided.has(name)) {
+				continue;
+			}
+			decided.add(name);
+			if (members.length === 1) {
+				selected.push(members[0] as Member);
+			}
+		}
+		level = next;
+	}
+	return selected;
+}
+
+function promoted(
+	method: EnhancedCodeSymbol,
+	from: EnhancedCodeSymbol
+): EnhancedCodeSymbol {
+	return {
+		...method,
+		metadata: { ...method.metadata, promotedFrom: from.name },
+	};
+}
+
+function methodSetsOf(type: EnhancedCodeSymbol, index: TypeIndex): MethodSets {
+	const own = index.owners.get(`${packageKey(type)}#${type.name}`) ?? [];
+	const pointerMethodSet = [...own];
+	const valueMethodSet = own.filter((m) => !m.metadata?.receiverIsPointer);
+	for (const member of selectMembers(type, index)) {
+		if (!member.method || member.depth === 0) {
+			continue;
+		}
+		const method = promoted(member.method, member.owner);
+		pointerMethodSet.push(method);
+		if (member.viaPointer || !member.method.metadata?.receiverIsPointer) {
+			valueMethodSet.push(method);
+		}
+	}
+	return { pointerMethodSet, type, valueMethodSet };
+}
+
+/**
+ * Method sets типа: T содержит методы с value receiver, *T — все методы
+ *
+ * Нужны для проверок реализации интерфейсов: тип, которому не хватает
+ * методов в valueMethodSet, реализует интерфейс только как *T. null,
+ * если конкретный тип не найден.
+ *
+ * @param typeName - имя типа (`User`) или `pkg.User`
+ */
+export function methodSetsFor(
+	symbols: EnhancedCodeSymbol[],
+	typeName: string
+): MethodSets | null {
+	const type = findByName(symbols, typeName, isConcreteType);
+	return type ? methodSetsOf(type, typeIndex(symbols)) : null;
+}
+
+/**
+ * Поля структуры вместе с продвинутыми из встроенных структур
+ *
+ * Собственные поля идут первыми (встроенное поле — как поле с именем
+ * типа), затем продвинутые по глубине встраивания с promotedFrom —
+ * типом, где поле объявлено. Затенённые и неоднозначные поля не
+ * продвигаются. null, если конкретный тип не найден.
+ *
+ * @param typeName - имя типа (`Admin`) или `pkg.Admin`
+ */
+export function fieldsFor(
+	symbols: EnhancedCodeSymbol[],
+	typeName: string
+): StructField[] | null {
+	const type = findByName(symbols, typeName, isConcreteType);
+	if (!type) {
+		return null;
+	}
+	const fields = [...(type.metadata?.fields ?? [])];
+	for (const member of selectMembers(type, typeIndex(symbols))) {
+		if (member.field && member.depth > 0) {
+			fields.push({ ...member.field, promotedFrom: member.owner.name });
+		}
+	}
+	return fields;
+}
+
+/**
+ * Полный method set интерфейса: объявленные методы плюс методы
+ * встроенных интерфейсов, найденных среди символов
+ *
+ * Встроенные интерфейсы вне индекса (io.Reader без stdlib) пропускаются.
+ */
+function requiredMethods(
+	iface: EnhancedCodeSymbol,
+	interfaces: Map<string, EnhancedCodeSymbol>,
+	visited = new Set<EnhancedCodeSymbol>()
+): Map<string, string> {
+	const required = new Map<string, string>();
+	if (visited.has(iface)) {
+		return required;
+	}
+	visited.add(iface);
+
+	for (const method of iface.metadata?.methods ?? []) {
+		if (!method.embedded) {
+			required.set(
+				method.name,
+				methodSignature(method.parameters, method.returns)
+			);
+			continue;
+		}
+		const embedded = interfaces.get(baseName(method.name));
+		if (embedded) {
+			for (const [name, signature] of requiredMethods(
+				embedded,
+				interfaces,
+				visited
+			)) {
+				required.set(name, signature);
+			}
+		}
+	}
+	return required;
+}
+
+/**
+ * Найти конкретные типы, чьи method set покрывают интерфейс
+ *
+ * Методы сравниваются по имени и типам параметров/результатов (без имён
+ * параметров и квалификаторов пакетов). Method set значения T включает
+ * только value receiver методы, *T — все; если интерфейс реализует лишь
+ * *T, результат помечается pointerOnly. Поиск приблизительный: видны
+ * только проиндексированные пакеты.
+ *
+ * @param interfaceName - имя интерфейса (`Service`) или `pkg.Service`
+ */
+export function implementationsOf(
+	symbols: EnhancedCodeSymbol[],
+	interfaceName: string
+): InterfaceImplementation[] {
+	const interfaces = new Map<string, EnhancedCodeSymbol>();
+	for (const symbol of symbols) {
+		if (symbol.symbolType === "interface" && !interfaces.has(symbol.name)) {
+			interfaces.set(symbol.name, symbol);
+		}
+	}
+
+	const iface = findByName(
+		symbols,
+		interfaceName,
+		(s) => s.symbolType === "interface"
+	);
+	if (!iface) {
+		return [];
+	}
+	const required = requiredMethods(iface, interfaces);
+	if (required.size === 0) {
+		return [];
+	}
+
+	const covers = (methods: EnhancedCodeSymbol[]) =>
+		[...required].every(([name, signature]) =>
+			methods.some(
+				(m) =>
+					baseName(m.name) === name &&
+					methodSignature(m.metadata?.parameters, m.metadata?.returns) ===
+						signature
+			)
+		);
+
+	const index = typeIndex(symbols);
+	const results: InterfaceImplementation[] = [];
+	for (const type of symbols) {
+		if (!isConcreteType(type)) {
+			continue;
+		}
+		const sets = methodSetsOf(type, index);
+		if (covers(sets.pointerMethodSet)) {
+			results.push({ type, pointerOnly: !covers(sets.valueMethodSet) });
+		}
+	}
+
+	return results;
+}
@@ -0,0 +1,205 @@
+import type { EnhancedCodeSymbol } from "./parsers/types.ts";
+import { declarationHead, normalizeTokens } from "./symbol-hash.ts";
+import { symbolId } from "./symbol-id.ts";
+import type { IndexDocument, SymbolChangeScope } from "./symbol-index.ts";
+import { changeScope } from "./symbol-index.ts";
+
+export interface ModifiedSymbol {
+	after: EnhancedCodeSymbol;
+	before: EnhancedCodeSymbol;
+	id: string;
+	newSignature: string;
+	oldSignature: string;
+	/** signature — изменился заголовок, body — только тело, doc — только doc */
+	scope: SymbolChangeScope;
+}
+
+export interface RenamedSymbol {
+	from: EnhancedCodeSymbol;
+	to: EnhancedCodeSymbol;
+}
+
+export interface IndexDiff {
+	added: EnhancedCodeSymbol[];
+	modified: ModifiedSymbol[];
+	removed: EnhancedCodeSymbol[];
+	/** Пусто, если detectRenames не включён */
+	renamed: RenamedSymbol[];
+	/** Отчёт для комментария к PR */
+	toMarkdown(): string;
+}
+
+export interface DiffIndexOptions {
+	/**
+	 * Связывать removed + added в rename: тот же файл, вид и тело с точностью
+	 * до имени. Без опции переименование — это пара removed + added
+	 */
+	detectRenames?: boolean;
+}
+
+function symbolsOf(snapshot: IndexDocument): Map<string, EnhancedCodeSymbol> {
+	const byId = new Map<string, EnhancedCodeSymbol>();
+	for (const file of snapshot.files) {
+		for (const symbol of file.symbols) {
+			byId.set(symbol.id ?? symbolId(symbol), symbol);
+		}
+	}
+	return byId;
+}
+
+/**
+ * Сигнатура в одну строку: каноническая из парсера (Go), иначе заголовок
+ * объявления без открывающей `{`
+ */
+function signatureLine(symbol: EnhancedCodeSymbol): string {
+	if (symbol.metadata?.signature) {
+		return symbol.metadata.signature;
+	}
+	return declarationHead(symbol)
+		.replace(/\s+/g, " ")
+		.replace(/\s*\{$/, "")
+		.trim();
+}
+
+/**
+ * Ключ для поиска переименований: путь, вид и тело, где имя символа
+ * заменено плейсхолдером. bodyHash для этого не подходит — имя входит
+ * в заголовок объявления
+ */
+function renameKey(symbol: EnhancedCodeSymbol): string {
+	const name = symbol.name.slice(symbol.name.lastIndexOf(".") + 1);
+	const escaped = name.replace(/[$.*+?^()[\]{}|\\]/g, "\\$&");
+	const anonymous = symbol.body.replace(
+		new RegExp(`(?<![\\p{L}\\p{N}_$])${escaped}(?![\\p{L}\\p{N}_$])`, "gu"),
+		"\0"
+	);
+	return `${symbol.path}\0${symbol.symbolType}\0${normalizeTokens(anonymous)}`;
+}
+
+function linkRenames(
+	added: EnhancedCodeSymbol[],
+	removed: EnhancedCodeSymbol[]
+): RenamedSymbol[] {
+	const pool = new Map<string, EnhancedCodeSymbol[]>();
+	for (const symbol of removed) {
+		const key = renameKey(symbol);
+		const bucket = pool.get(key);
+		if (bucket) {
+			bucket.push(symbol);
+		} else {
+			pool.set(key, [symbol]);
+		}
+	}
+	const renamed: RenamedSymbol[] = [];
+	for (const symbol of added) {
+		const from = pool.get(renameKey(symbol))?.shift();
+		if (from) {
+			renamed.push({ from, to: symbol });
+		}
+	}
+	return renamed;
+}
+
+/**
+ * Разница между двумя снимками индекса (как exportIndex)
+ *
+ * Символы сопоставляются по стабильному `id`. Совпавший символ попадает
+ * в modified, если изменились его хеши или doc comment: scope signature
+ * означает изменение bodyHash, body — только fullHash.
+ */
+export function diffIndex(
+	oldSnapshot: IndexDocument,
+	newSnapshot: IndexDocument,
+	options: DiffIndexOptions = {}
+): IndexDiff {
+	const before = symbolsOf(oldSnapshot);
+	const after = symbolsOf(newSnapshot);
+
+	let added: EnhancedCodeSymbol[] = [];
+	const modified: ModifiedSymbol[] = [];
+	for (const [id, symbol] of after) {
+		const previous = before.get(id);
+		if (!previous) {
+			added.push(symbol);
+			continue;
+		}
+		const scope = changeScope(previous, symbol);
+		if (scope) {
+			modified.push({
+				after: symbol,
+				before: previous,
+				id,
+				newSignature: signatureLine(symbol),
+				oldSignature: signatureLine(previous),
+				scope,
+			});
+		}
+	}
+	let removed = [...before]
+		.filter(([id]) => !after.has(id))
+		.map(([, symbol]) => symbol);
+
+	let renamed: RenamedSymbol[] = [];
+	if (options.detectRenames) {
+		renamed = linkRenames(added, removed);
+		const linked = new Set(renamed.flatMap((r) => [r.from, r.to]));
+		added = added.filter((s) => !linked.has(s));
+		removed = removed.filter((s) => !linked.has(s));
+	}
+
+	const diff = { added, modified, removed, renamed };
+	return { ...diff, toMarkdown: () => indexDiffToMarkdown(diff) };
+}
+
+function location(symbol: EnhancedCodeSymbol): string {
+	return `${symbol.path}:${symbol.startLine}`;
+}
+
+/**
+ * Отрендерить разницу снимков в Markdown
+ */
+export function indexDiffToMarkdown(
+	diff: Omit<IndexDiff, "toMarkdown">
+): string {
+	const { added, modified, removed, renamed } = diff;
+	const counts = [
+		`${added.length} added`,
+		`${removed.length} removed`,
+		`${modified.length} modified`,
+		...(renamed.length > 0 ? [`${renamed.length} renamed`] : []),
+	];
+	const lines = ["## Symbol changes", "", counts.join(", ")];
+
+	const section = (title: string, items: string[]) => {
+		if (items.length > 0) {
+			lines.push("", `### ${title}`, "", ...items);
+		}
+	};
+	section(
+		"Added",
+		added.map((s) => `- \`${s.name}\` (${s.symbolType}) — ${location(s)}`)
+	);
+	section(
+		"Removed",
+		removed.map((s) => `- \`${s.name}\` (${s.symbolType}) — ${location(s)}`)
+	);
+	section(
+		"Modified",
+		modified.flatMap((m) => [
+			`- \`${m.after.name}\` (${m.scope}) — ${location(m.after)}`,
+			...(m.scope === "signature"
+				? [
+						`  - before: \`${m.oldSignature}\``,
+						`  - after: \`${m.newSignature}\``,
+					]
+				: []),
+		])
+	);
+	section(
+		"Renamed",
+		renamed.map(
+			(r) => `- \`${r.from.name}\` → \`${r.to.name}\` — ${location(r.to)}`
+		)
+	);
+	return `${lines.join("\n")}\n`;
+}
@@ -0,0 +1,174 @@
+import { createLogger } from "../lib/logger.ts";
+import type { EnhancedCodeSymbol, ParseDiagnostic } from "./parsers/types.ts";
+import { declarationHead } from "./symbol-hash.ts";
+
+const log = createLogger("index-events");
+
+/**
+ * События изменения индекса для TUI, статус-бара и внешних подписчиков
+ *
+ * Одна операция индекса (updateFile, renameFile, removeFile, upsert)
+ * публикует все свои события одной пачкой: onBatch получает их целиком
+ * уже после применения, поэтому подписчик видит согласованное состояние.
+ * Подписчики отдельных типов (on) вызываются по порядку событий пачки.
+ */
+
+/**
+ * Что изменилось в символе с событием symbolChanged
+ */
+export interface SymbolDiff {
+	/** Изменившиеся поля: `body`, `startLine`, `metadata.returnType` и т.д. */
+	fields: string[];
+	/** Заголовок объявления, если он изменился */
+	signature?: { after: string; before: string };
+}
+
+export interface IndexEventMap {
+	fileIndexed: {
+		diagnostics: ParseDiagnostic[];
+		path: string;
+		/** id всех символов файла после индексации */
+		symbolIds: string[];
+	};
+	fileRemoved: {
+		path: string;
+		/** id символов, которые были в файле */
+		symbolIds: string[];
+	};
+	/** Файл не распарсился или распарсился с ошибками (diagnostics) */
+	indexError: {
+		diagnostics: ParseDiagnostic[];
+		error?: string;
+		path: string;
+	};
+	symbolAdded: { id: string; path: string; symbol: EnhancedCodeSymbol };
+	symbolChanged: {
+		diff: SymbolDiff;
+		id: string;
+		path: string;
+		symbol: EnhancedCodeSymbol;
+	};
+	symbolRemoved: { id: string; path: string; symbol: EnhancedCodeSymbol };
+}
+
+export type IndexEventType = keyof IndexEventMap;
+
+export type IndexEvent = {
+	[K in IndexEventType]: { type: K } & IndexEventMap[K];
+}[IndexEventType];
+
+export type IndexEventListener<K extends IndexEventType> = (
+	event: Extract<IndexEvent, { type: K }>
+) => void;
+
+/**
+ * Ключ символа в событиях: id, а без него — путь и имя
+ */
+export function eventSymbolId(symbol: EnhancedCodeSymbol): string {
+	return symbol.id ?? `${symbol.path}#${symbol.name}`;
+}
+
+/**
+ * Поля, которыми различаются две версии символа (metadata — по ключам)
+ */
+export function diffSymbol(
+	before: EnhancedCodeSymbol,
+	after: EnhancedCodeSymbol
+): SymbolDiff {
+	const fields: string[] = [];
+	const differs = (a: unknown, b: unknown) =>
+		JSON.stringify(a) !== JSON.stringify(b);
+	const keys = new Set([...Object.keys(before), ...Object.keys(after)]);
+	for (const key of [...keys].sort()) {
+		const a = before[key as keyof EnhancedCodeSymbol];
+		const b = after[key as keyof EnhancedCodeSymbol];
+		if (key !== "metadata") {
+			if (differs(a, b)) {
+				fields.push(key);
+			}
+			continue;
+		}
+		const metaBefore = (a ?? {}) as Record<string, unknown>;
+		const metaAfter = (b ?? {}) as Record<string, unknown>;
+		const metaKeys = new Set([
+			...Object.keys(metaBefore),
+			...Object.keys(metaAfter),
+		]);
+		for (const metaKey of [...metaKeys].sort()) {
+			if (differs(metaBefore[metaKey], metaAfter[metaKey])) {
+				fields.push(`metadata.${metaKey}`);
+			}
+		}
+	}
+
+	const headBefore = declarationHead(before);
+	const headAfter = declarationHead(after);
+	return headBefore === headAfter
+		? { fields }
+		: { fields, signature: { after: headAfter, before: headBefore } };
+}
+
+export class IndexEventEmitter {
+	private readonly batchListeners = new Set<(events: IndexEvent[]) => void>();
+	private readonly listeners = new Map<
+		IndexEventType,
+		Set<(event: IndexEvent) => void>
+	>();
+
+	/**
+	 * Подписаться на события одного типа. Возвращает функцию отписки
+	 */
+	on<K extends IndexEventType>(
+		type: K,
+		listener: IndexEventListener<K>
+	): () => void {
+		const listeners = this.listeners.get(type) ?? new Set();
+		const wrapped = listener as (event: IndexEvent) => void;
+		listeners.add(wrapped);
+		this.listeners.set(type, listeners);
+		return () => {
+			listeners.delete(wrapped);
+		};
+	}
+
+	/**
+	 * Подписаться на пачки: все события одной операции индекса разом
+	 */
+	onBatch(listener: (events: IndexEvent[]) => void): () => void {
+		this.batchListeners.add(listener);
+		return () => {
+			this.batchListeners.delete(listener);
+		};
+	}
+
+	/**
+	 * Опубликовать пачку; исключение подписчика логируется и не мешает
+	 * остальным
+	 */
+	emit(events: IndexEvent[]): void {
+		if (events.length === 0) {
+			return;
+		}
+		for (const listener of this.batchListeners) {
+			this.call(() => listener(events));
+		}
+		for (const event of events) {
+			for (const listener of this.listeners.get(event.type) ?? []) {
+				this.call(() => listener(event));
+			}
+		}
+	}
+
+	clear(): void {
+		this.batchListeners.clear();
+		this.listeners.clear();
+	}
+
+	private call(run: () => void): void {
+		try {
+			run();
+		} catch (err) {
+			log.warn("Index event listener failed", { error: String(err) });
+		}
+	}
+}
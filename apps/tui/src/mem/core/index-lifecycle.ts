@@ -0,0 +1,67 @@
+import { resolve } from "node:path";
+import type { SymbolIndexOptions } from "./symbol-index.ts";
+import { SymbolIndex } from "./symbol-index.ts";
+import type { WatchOptions } from "./watch.ts";
+import { watch } from "./watch.ts";
+
+export interface CreateIndexOptions extends SymbolIndexOptions {
+	/**
+	 * Следить за root (по умолчанию cwd) и обновлять индекс; watcher
+	 * останавливается в dispose()
+	 */
+	watch?: boolean | Pick<WatchOptions, "debounceMs" | "ignore">;
+}
+
+/**
+ * Создать индекс с явным жизненным циклом
+ *
+ * Всё, что запущено для индекса (watcher), привязано к index.dispose().
+ */
+export async function createIndex(
+	options: CreateIndexOptions = {}
+): Promise<SymbolIndex> {
+	const { watch: watchOptions, ...indexOptions } = options;
+	const index = new SymbolIndex(indexOptions);
+	if (watchOptions) {
+		const root = resolve(options.root ?? process.cwd());
+		const watcher = await watch(root, () => undefined, {
+			...(watchOptions === true ? {} : watchOptions),
+			index,
+			registry: options.registry,
+		});
+		index.onDispose(() => watcher.close());
+	}
+	return index;
+}
+
+let shared: Promise<SymbolIndex> | null = null;
+
+/**
+ * Общий индекс процесса (например, для долгоживущего TUI)
+ *
+ * Параллельные вызовы получают один и тот же экземпляр; options
+ * учитываются только при создании. После dispose() следующий вызов
+ * создаёт новый индекс.
+ */
+export function sharedIndex(
+	options: CreateIndexOptions = {}
+): Promise<SymbolIndex> {
+	if (!shared) {
+		const creating = createIndex(options).then((index) => {
+			index.onDispose(() => {
+				if (shared === creating) {
+					shared = null;
+				}
+			});
+			return index;
+		});
+		// Неудачное создание не должно закрепиться за процессом
+		creating.catch(() => {
+			if (shared === creating) {
+				shared = null;
+			}
+		});
+		shared = creating;
+	}
+	return shared;
+}
@@ -0,0 +1,77 @@
+/**
+ * Потоковый дамп индекса в JSON Lines
+ *
+ * Одна запись на строку: заголовок со schemaVersion, затем по строке на
+ * файл с его символами, затем рёбра call graph. Такой дамп можно
+ * обрабатывать построчно (`grep`, `jq -c`) и загружать, не держа весь
+ * документ в памяти.
+ */
+
+import { once } from "node:events";
+import type { Writable } from "node:stream";
+import type { CallEdge } from "./call-graph.ts";
+import type { EnhancedCodeSymbol } from "./parsers/types.ts";
+
+export type IndexRecord =
+	| { kind: "header"; schemaVersion: number }
+	| { kind: "file"; path: string; symbols: EnhancedCodeSymbol[] }
+	| ({ kind: "call" } & CallEdge);
+
+/** Источник строк: Node Readable, web ReadableStream или генератор */
+export type IndexRecordSource = AsyncIterable<string | Uint8Array>;
+
+/**
+ * Записать запись строкой, дождавшись drain при переполнении буфера
+ */
+export async function writeIndexRecord(
+	writable: Writable,
+	record: IndexRecord
+): Promise<void> {
+	if (!writable.write(`${JSON.stringify(record)}\n`)) {
+		await once(writable, "drain");
+	}
+}
+
+function parseRecord(line: string, lineNumber: number): IndexRecord {
+	try {
+		return JSON.parse(line) as IndexRecord;
+	} catch (err) {
+		throw new Error(
+			`Invalid index record at line ${lineNumber}: ${String(err)}`
+		);
+	}
+}
+
+/**
+ * Прочитать записи по мере поступления данных
+ *
+ * Чанки могут резать строки и многобайтовые символы в любом месте;
+ * пустые строки пропускаются.
+ */
+export async function* readIndexRecords(
+	source: IndexRecordSource
+): AsyncGenerator<IndexRecord> {
+	const decoder = new TextDecoder();
+	let buffer = "";
+	let lineNumber = 0;
+	for await (const chunk of source) {
+		buffer +=
+			typeof chunk === "string"
+				? chunk
+				: decoder.decode(chunk, { stream: true });
+		let newline = buffer.indexOf("\n");
+		while (newline !== -1) {
+			const line = buffer.slice(0, newline).trim();
+			buffer = buffer.slice(newline + 1);
+			lineNumber++;
+			if (line) {
+				yield parseRecord(line, lineNumber);
+			}
+			newline = buffer.indexOf("\n");
+		}
+	}
+	const rest = (buffer + decoder.decode()).trim();
+	if (rest) {
+		yield parseRecord(rest, lineNumber + 1);
+	}
+}
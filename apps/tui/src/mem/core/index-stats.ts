@@ -0,0 +1,168 @@
+import { languageForFile } from "./parsers/base-parser.ts";
+import type { EnhancedCodeSymbol, ParseDiagnostic } from "./parsers/types.ts";
+import { kindOf } from "./symbol-kind.ts";
+
+/**
+ * Сводка по индексу для обзорной панели
+ *
+ * Вклад каждого файла считается один раз при его замене в индексе,
+ * а сводка складывается из накопленных сумм: вызов indexStats() не
+ * обходит символы. Вытесненные LRU файлы продолжают учитываться.
+ */
+
+export interface LanguageStats {
+	files: number;
+	symbols: number;
+}
+
+export interface IndexStats {
+	/** Средняя длина функций и методов в строках (0, если их нет) */
+	avgFunctionLines: number;
+	/** По каноническому виду (kindOf) */
+	byKind: Record<string, number>;
+	/** По языку файла (по расширению); `unknown` — нераспознанные */
+	byLanguage: Record<string, LanguageStats>;
+	exported: number;
+	files: number;
+	/** Файлы, последний парсинг которых дал ошибки */
+	filesWithErrors: number;
+	/** Число диагностик с severity "error" */
+	parseErrors: number;
+	symbols: number;
+	unexported: number;
+}
+
+interface FileContribution {
+	byKind: Map<string, number>;
+	exported: number;
+	functionLines: number;
+	functions: number;
+	language: string;
+	symbols: number;
+}
+
+const FUNCTION_KINDS = new Set(["function", "method"]);
+
+function contributionOf(
+	path: string,
+	symbols: EnhancedCodeSymbol[]
+): FileContribution {
+	const contribution: FileContribution = {
+		byKind: new Map(),
+		exported: 0,
+		functionLines: 0,
+		functions: 0,
+		language: languageForFile(path),
+		symbols: symbols.length,
+	};
+	for (const symbol of symbols) {
+		const kind = kindOf(symbol);
+		contribution.byKind.set(kind, (contribution.byKind.get(kind) ?? 0) + 1);
+		if (symbol.metadata?.isExported) {
+			contribution.exported++;
+		}
+		if (FUNCTION_KINDS.has(kind)) {
+			contribution.functions++;
+			contribution.functionLines += symbol.endLine - symbol.startLine + 1;
+		}
+	}
+	return contribution;
+}
+
+/**
+ * Накопитель сводки: файл добавляется и убирается целиком
+ */
+export class IndexStatsAccumulator {
+	private readonly contributions = new Map<string, FileContribution>();
+	private readonly byKind = new Map<string, number>();
+	private readonly byLanguage = new Map<string, LanguageStats>();
+	private exported = 0;
+	private functionLines = 0;
+	private functions = 0;
+	private symbols = 0;
+
+	/**
+	 * Учесть символы файла вместо прежних
+	 */
+	set(path: string, symbols: EnhancedCodeSymbol[]): void {
+		this.delete(path);
+		const contribution = contributionOf(path, symbols);
+		this.contributions.set(path, contribution);
+		this.apply(contribution, 1);
+	}
+
+	delete(path: string): void {
+		const contribution = this.contributions.get(path);
+		if (contribution) {
+			this.contributions.delete(path);
+			this.apply(contribution, -1);
+		}
+	}
+
+	clear(): void {
+		this.contributions.clear();
+		this.byKind.clear();
+		this.byLanguage.clear();
+		this.exported = 0;
+		this.functionLines = 0;
+		this.functions = 0;
+		this.symbols = 0;
+	}
+
+	/**
+	 * Сводка с диагностиками последнего парсинга по файлам
+	 */
+	snapshot(diagnostics: ReadonlyMap<string, ParseDiagnostic[]>): IndexStats {
+		let parseErrors = 0;
+		let filesWithErrors = 0;
+		for (const list of diagnostics.values()) {
+			const errors = list.filter((d) => d.severity === "error").length;
+			parseErrors += errors;
+			filesWithErrors += errors > 0 ? 1 : 0;
+		}
+		return {
+			avgFunctionLines:
+				this.functions > 0 ? this.functionLines / this.functions : 0,
+			byKind: Object.fromEntries(
+				[...this.byKind].sort(([a], [b]) => a.localeCompare(b))
+			),
+			byLanguage: Object.fromEntries(
+				[...this.byLanguage]
+					.sort(([a], [b]) => a.localeCompare(b))
+					.map(([language, stats]) => [language, { ...stats }])
+			),
+			exported: this.exported,
+			files: this.contributions.size,
+			filesWithErrors,
+			parseErrors,
+			symbols: this.symbols,
+			unexported: this.symbols - this.exported,
+		};
+	}
+
+	private apply(contribution: FileContribution, sign: 1 | -1): void {
+		for (const [kind, count] of contribution.byKind) {
+			const next = (this.byKind.get(kind) ?? 0) + sign * count;
+			if (next > 0) {
+				this.byKind.set(kind, next);
+			} else {
+				this.byKind.delete(kind);
+			}
+		}
+		const language = this.byLanguage.get(contribution.language) ?? {
+			files: 0,
+			symbols: 0,
+		};
+		language.files += sign;
+		language.symbols += sign * contribution.symbols;
+		if (language.files > 0) {
+			this.byLanguage.set(contribution.language, language);
+		} else {
+			this.byLanguage.delete(contribution.language);
+		}
+		this.exported += sign * contribution.exported;
+		this.functionLines += sign * contribution.functionLines;
+		this.functions += sign * contribution.functions;
+		this.symbols += sign * contribution.symbols;
+	}
+}
@@ -0,0 +1,190 @@
+import type {
+	EnhancedCodeSymbol,
+	SourceRange,
+	StructField,
+} from "./parsers/types.ts";
+
+/**
+ * Значения LSP SymbolKind (подмножество, которое встречается в индексе)
+ */
+export const LspSymbolKind = {
+	Class: 5,
+	Method: 6,
+	Field: 8,
+	Enum: 10,
+	Interface: 11,
+	Function: 12,
+	Variable: 13,
+	Constant: 14,
+	Struct: 23,
+} as const;
+
+export type LspSymbolKindValue =
+	(typeof LspSymbolKind)[keyof typeof LspSymbolKind];
+
+/**
+ * LSP Position: строка и колонка 0-based
+ */
+export interface LspPosition {
+	character: number;
+	line: number;
+}
+
+export interface LspRange {
+	end: LspPosition;
+	start: LspPosition;
+}
+
+/**
+ * LSP DocumentSymbol без зависимости от vscode-languageserver
+ */
+export interface DocumentSymbol {
+	children?: DocumentSymbol[];
+	detail?: string;
+	kind: LspSymbolKindValue;
+	name: string;
+	range: LspRange;
+	selectionRange: LspRange;
+}
+
+const CONTAINER_TYPES = new Set(["class", "interface", "type", "enum"]);
+
+function toLspRange(range: SourceRange): LspRange {
+	return {
+		start: { line: range.startLine - 1, character: range.startCol },
+		end: { line: range.endLine - 1, character: range.endCol },
+	};
+}
+
+/**
+ * Диапазон символа; для парсеров без metadata.range — по строкам
+ */
+function symbolRange(symbol: EnhancedCodeSymbol): SourceRange {
+	return (
+		symbol.metadata?.range ?? {
+			startLine: symbol.startLine,
+			startCol: 0,
+			endLine: symbol.endLine,
+			endCol: 0,
+		}
+	);
+}
+
+function kindOf(symbol: EnhancedCodeSymbol): LspSymbolKindValue {
+	switch (symbol.symbolType) {
+		case "class":
+			return symbol.metadata?.typeKind === "struct"
+				? LspSymbolKind.Struct
+				: LspSymbolKind.Class;
+		case "interface":
+			return LspSymbolKind.Interface;
+		case "enum":
+			return LspSymbolKind.Enum;
+		case "method":
+			return LspSymbolKind.Method;
+		case "constant":
+			return LspSymbolKind.Constant;
+		case "variable":
+			return LspSymbolKind.Variable;
+		case "type":
+			return LspSymbolKind.Class;
+		default:
+			return LspSymbolKind.Function;
+	}
+}
+
+function detailOf(symbol: EnhancedCodeSymbol): string | undefined {
+	const meta = symbol.metadata;
+	if (!meta?.parameters) {
+		return meta?.underlying;
+	}
+	const params = meta.parameters
+		.map((p) =>
+			[p.name, p.variadic && p.type ? `...${p.type}` : p.type].filter(Boolean)
+		)
+		.map((parts) => parts.join(" "))
+		.join(", ");
+	return `(${params})${meta.returnType ? ` ${meta.returnType}` : ""}`;
+}
+
+function toDocumentSymbol(symbol: EnhancedCodeSymbol): DocumentSymbol {
+	const range = symbolRange(symbol);
+	const receiver = symbol.metadata?.receiver;
+	// Метод внутри контейнера показывается без префикса типа
+	const name =
+		receiver && symbol.name.startsWith(`${receiver}.`)
+			? symbol.name.slice(receiver.length + 1)
+			: symbol.name;
+
+	return {
+		name,
+		detail: detailOf(symbol),
+		kind: kindOf(symbol),
+		range: toLspRange(range),
+		selectionRange: toLspRange(symbol.metadata?.nameRange ?? range),
+	};
+}
+
+function fieldSymbol(field: StructField, parent: SourceRange): DocumentSymbol {
+	const range = field.range ?? parent;
+	return {
+		name: field.name ?? field.type,
+		detail: field.type,
+		kind: LspSymbolKind.Field,
+		range: toLspRange(range),
+		selectionRange: toLspRange(field.nameRange ?? range),
+	};
+}
+
+/**
+ * Построить иерархию LSP DocumentSymbol для символов одного файла
+ *
+ * Типы становятся контейнерами: поля структуры и методы с тем же
+ * receiver (объявленные в этом же файле) вкладываются как children.
+ * Методы, чей тип объявлен в другом файле, остаются на верхнем уровне.
+ */
+export function toDocumentSymbols(
+	symbols: EnhancedCodeSymbol[]
+): DocumentSymbol[] {
+	const containers = new Map<string, DocumentSymbol>();
+	const result: DocumentSymbol[] = [];
+
+	for (const symbol of symbols) {
+		if (symbol.symbolType === "method") {
+			continue;
+		}
+		const docSymbol = toDocumentSymbol(symbol);
+		if (CONTAINER_TYPES.has(symbol.symbolType)) {
+			const fields = symbol.metadata?.fields ?? [];
+			docSymbol.children = fields.map((f) =>
+				fieldSymbol(f, symbolRange(symbol))
+			);
+			if (!containers.has(symbol.name)) {
+				containers.set(symbol.name, docSymbol);
+			}
+		}
+		result.push(docSymbol);
+	}
+
+	for (const symbol of symbols) {
+		if (symbol.symbolType !== "method") {
+			continue;
+		}
+		const receiver = symbol.metadata?.receiver;
+		const container = receiver ? containers.get(receiver) : undefined;
+		if (container) {
+			container.children?.push(toDocumentSymbol(symbol));
+		} else {
+			result.push(toDocumentSymbol(symbol));
+		}
+	}
+
+	const byPosition = (a: DocumentSymbol, b: DocumentSymbol) =>
+		a.range.start.line - b.range.start.line ||
+		a.range.start.character - b.range.start.character;
+
+	for (const container of containers.values()) {
+		container.children?.sort(byPosition);
+	}
+	return result.sort(byPosition);
+}
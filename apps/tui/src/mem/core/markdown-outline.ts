@@ -0,0 +1,147 @@
+import type { EnhancedCodeSymbol, StructField } from "./parsers/types.ts";
+import type { SymbolOrder } from "./symbol-order.ts";
+import { orderFields, symbolComparator } from "./symbol-order.ts";
+
+export interface MarkdownOutlineOptions {
+	/** Включать неэкспортируемые символы (`User.privateMethod`, `counter`) */
+	includePrivate?: boolean;
+	/** Порядок полей и методов внутри типа, по умолчанию source */
+	memberOrder?: SymbolOrder;
+	/** Порядок типов и символов в секциях, по умолчанию kind-then-name */
+	order?: SymbolOrder;
+	/** Заголовок первого уровня */
+	title?: string;
+}
+
+const TYPE_SYMBOL_TYPES = new Set(["class", "interface", "type", "enum"]);
+
+const SECTIONS: [title: string, types: string[]][] = [
+	["Functions", ["function", "hook", "component"]],
+	["Methods", ["method"]],
+	["Constants", ["constant"]],
+	["Variables", ["variable"]],
+];
+
+function isExportedSymbol(symbol: EnhancedCodeSymbol): boolean {
+	const meta = symbol.metadata;
+	return meta?.isExported ?? meta?.visibility !== "private";
+}
+
+/**
+ * Экспортируемые раньше неэкспортируемых, дальше — заданный порядок
+ */
+function exportedFirst(
+	order: SymbolOrder
+): (a: EnhancedCodeSymbol, b: EnhancedCodeSymbol) => number {
+	const compare = symbolComparator(order);
+	return (a, b) =>
+		Number(isExportedSymbol(b)) - Number(isExportedSymbol(a)) ||
+		compare(a, b) ||
+		a.name.localeCompare(b.name);
+}
+
+function firstDocLine(symbol: EnhancedCodeSymbol): string {
+	const doc = symbol.metadata?.docComment ?? symbol.jsDoc ?? "";
+	return doc.trim().split("\n")[0]?.trim() ?? "";
+}
+
+function localName(symbol: EnhancedCodeSymbol): string {
+	const receiver = symbol.metadata?.receiver;
+	return receiver && symbol.name.startsWith(`${receiver}.`)
+		? symbol.name.slice(receiver.length + 1)
+		: symbol.name;
+}
+
+function signatureOf(symbol: EnhancedCodeSymbol, name: string): string {
+	const meta = symbol.metadata;
+	if (!meta?.parameters) {
+		return name;
+	}
+	const params = meta.parameters
+		.map((p) =>
+			[p.name, p.variadic && p.type ? `...${p.type}` : p.type].filter(Boolean)
+		)
+		.map((parts) => parts.join(" "))
+		.join(", ");
+	return `${name}(${params})${meta.returnType ? ` ${meta.returnType}` : ""}`;
+}
+
+function bullet(code: string, description: string): string {
+	return description ? `- \`${code}\` — ${description}` : `- \`${code}\``;
+}
+
+function fieldLine(field: StructField): string {
+	return bullet(field.name ? `${field.name} ${field.type}` : field.type, "");
+}
+
+/**
+ * Отрендерить символы в детерминированный Markdown outline
+ *
+ * Типы — заголовки второго уровня с полями и методами списком под ними;
+ * функции, методы чужих типов, константы и переменные — отдельными секциями.
+ * Экспортируемые символы всегда идут раньше неэкспортируемых.
+ */
+export function toMarkdownOutline(
+	symbols: EnhancedCodeSymbol[],
+	options: MarkdownOutlineOptions = {}
+): string {
+	const visible = symbols
+		.filter((s) => options.includePrivate || isExportedSymbol(s))
+		.sort(exportedFirst(options.order ?? "kind-then-name"));
+	const memberOrder = options.memberOrder ?? "source";
+
+	const types = visible.filter((s) => TYPE_SYMBOL_TYPES.has(s.symbolType));
+	const typeNames = new Set(types.map((t) => t.name));
+	const lines: string[] = [];
+
+	if (options.title) {
+		lines.push(`# ${options.title}`, "");
+	}
+
+	for (const type of types) {
+		const kind = type.metadata?.typeKind ?? type.symbolType;
+		lines.push(`## \`${type.name}\` (${kind})`, "");
+
+		const doc = firstDocLine(type);
+		if (doc) {
+			lines.push(doc, "");
+		}
+
+		const members = [
+			...orderFields(type.metadata?.fields ?? [], memberOrder)
+				.filter((f) => options.includePrivate || f.exported)
+				.map(fieldLine),
+			...visible
+				.filter(
+					(s) =>
+						s.symbolType === "method" && s.metadata?.receiver === type.name
+				)
+				.sort(exportedFirst(memberOrder))
+				.map((m) => bullet(signatureOf(m, localName(m)), firstDocLine(m))),
+		];
+		if (members.length > 0) {
+			lines.push(...members, "");
+		}
+	}
+
+	for (const [title, sectionTypes] of SECTIONS) {
+		const items = visible.filter(
+			(s) =>
+				sectionTypes.includes(s.symbolType) &&
+				!(
+					s.symbolType === "method" &&
+					typeNames.has(s.metadata?.receiver ?? "")
+				)
+		);
+		if (items.length === 0) {
+			continue;
+		}
+		lines.push(`## ${title}`, "");
+		for (const item of items) {
+			lines.push(bullet(signatureOf(item, item.name), firstDocLine(item)));
+		}
+		lines.push("");
+	}
+
+	return `${lines.join("\n").trimEnd()}\n`;
+}
@@ -0,0 +1,130 @@
+import { packageKey } from "./call-graph.ts";
+import type { EnhancedCodeSymbol } from "./parsers/types.ts";
+import type { SymbolKind } from "./symbol-kind.ts";
+import { kindOf } from "./symbol-kind.ts";
+
+export interface FindDuplicateNamesOptions {
+	/** Имена, которые повторяются намеренно (по умолчанию `init`, `main`, `_`) */
+	ignore?: string[];
+	/** Включить символы тестовых файлов (metadata.isTest), по умолчанию нет */
+	includeTests?: boolean;
+	/**
+	 * Методы разных receiver'ов с одним именем (`File.Close`, `Conn.Close`):
+	 * exclude — не учитывать (по умолчанию), group — отдельными группами
+	 * только среди методов, mixed — вместе с функциями и типами
+	 */
+	methods?: "exclude" | "group" | "mixed";
+	/** Сравнивать только символы одного вида (см. kindOf) */
+	sameKind?: boolean;
+	/** Сравнивать только символы одного пакета (см. packageKey) */
+	samePackage?: boolean;
+}
+
+export interface DuplicateNameEntry {
+	kind: SymbolKind;
+	line: number;
+	packageName?: string;
+	path: string;
+	symbol: EnhancedCodeSymbol;
+}
+
+export interface DuplicateNameGroup {
+	/** Вид группы при sameKind */
+	kind?: SymbolKind;
+	/** Имя без receiver'а: `Close` для `File.Close` */
+	name: string;
+	/** packageKey группы при samePackage */
+	package?: string;
+	symbols: DuplicateNameEntry[];
+}
+
+const DEFAULT_IGNORE = ["init", "main", "_"];
+
+/**
+ * Имя для сравнения: у метода без квалификатора receiver'а
+ */
+function shortName(symbol: EnhancedCodeSymbol): string {
+	const receiver = symbol.metadata?.receiver;
+	if (
+		symbol.symbolType === "method" &&
+		receiver &&
+		symbol.name.startsWith(`${receiver}.`)
+	) {
+		return symbol.name.slice(receiver.length + 1);
+	}
+	return symbol.name;
+}
+
+/**
+ * Найти символы с одинаковыми именами во всём workspace
+ *
+ * Помогает заметить случайное затенение (локальный `config` рядом
+ * с пакетным) и разнобой в именовании. Группы отсортированы по имени,
+ * символы в группе — по файлу и строке.
+ */
+export function findDuplicateNames(
+	symbols: EnhancedCodeSymbol[],
+	options: FindDuplicateNamesOptions = {}
+): DuplicateNameGroup[] {
+	const ignore = new Set(options.ignore ?? DEFAULT_IGNORE);
+	const methods = options.methods ?? "exclude";
+	const groups = new Map<string, DuplicateNameGroup>();
+
+	for (const symbol of symbols) {
+		if (symbol.symbolType === "package") {
+			continue;
+		}
+		if (!options.includeTests && symbol.metadata?.isTest) {
+			continue;
+		}
+		const isMethod = symbol.symbolType === "method";
+		if (isMethod && methods === "exclude") {
+			continue;
+		}
+		const name = shortName(symbol);
+		if (ignore.has(name)) {
+			continue;
+		}
+
+		const kind = kindOf(symbol);
+		const pkg = options.samePackage ? packageKey(symbol) : undefined;
+		const key = [
+			name,
+			options.sameKind ? kind : "",
+			pkg ?? "",
+			isMethod && methods === "group" ? "method" : "",
+		].join("\0");
+		let group = groups.get(key);
+		if (!group) {
+			group = {
+				kind: options.sameKind ? kind : undefined,
+				name,
+				package: pkg,
+				symbols: [],
+			};
+			groups.set(key, group);
+		}
+		group.symbols.push({
+			kind,
+			line: symbol.startLine,
+			packageName: symbol.metadata?.packageName,
+			path: symbol.path,
+			symbol,
+		});
+	}
+
+	return [...groups.values()]
+		.filter((group) => group.symbols.length > 1)
+		.map((group) => ({
+			...group,
+			symbols: group.symbols.sort(
+				(a, b) => a.path.localeCompare(b.path) || a.line - b.line
+			),
+		}))
+		.sort(
+			(a, b) =>
+				a.name.localeCompare(b.name) ||
+				(a.package ?? "").localeCompare(b.package ?? "") ||
+				(a.kind ?? "").localeCompare(b.kind ?? "")
+		);
+}
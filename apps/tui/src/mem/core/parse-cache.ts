@@ -0,0 +1,288 @@
+import { createHash } from "node:crypto";
+import { mkdir, readFile, writeFile } from "node:fs/promises";
+import { dirname, join, resolve } from "node:path";
+import { getStorePath } from "../lib/config.ts";
+import { createLogger } from "../lib/logger.ts";
+import type {
+	BaseParser,
+	ParseSourceOptions,
+} from "./parsers/base-parser.ts";
+import type { ParserRegistry } from "./parsers/parser-registry.ts";
+import { parserRegistry } from "./parsers/parser-registry.ts";
+import { readSource } from "./parsers/source-text.ts";
+import type {
+	EnhancedCodeSymbol,
+	ParseDiagnostic,
+	ParseResult,
+} from "./parsers/types.ts";
+
+const log = createLogger("parse-cache");
+
+interface ParseCacheEntry {
+	contentHash: string;
+	diagnostics?: ParseDiagnostic[];
+	parserVersion: string;
+	symbols: EnhancedCodeSymbol[];
+}
+
+interface ParseCacheData {
+	entries: Record<string, ParseCacheEntry>;
+}
+
+export interface ParseCacheOptions {
+	/** Путь к JSON-файлу кеша (по умолчанию рядом с остальными кешами mem) */
+	filePath?: string;
+	registry?: ParserRegistry;
+}
+
+export function hashSource(source: string): string {
+	return createHash("sha256").update(source).digest("hex").slice(0, 24);
+}
+
+/**
+ * Ключ версии парсера: класс + версия формата вывода (+ опции парсера)
+ */
+export function parserVersionOf(parser: BaseParser): string {
+	const version = `${parser.constructor.name}@${parser.version}`;
+	return parser.optionsKey ? `${version}#${parser.optionsKey}` : version;
+}
+
+/**
+ * Запись с синтаксическими ошибками не переиспользуется: сбой мог быть
+ * временным (таймаут, лимит глубины), а не свойством содержимого
+ */
+function isUsable(
+	entry: ParseCacheEntry | undefined,
+	contentHash: string,
+	parserVersion: string
+): entry is ParseCacheEntry {
+	return (
+		entry !== undefined &&
+		entry.contentHash === contentHash &&
+		entry.parserVersion === parserVersion &&
+		!entry.diagnostics?.some((d) => d.severity === "error")
+	);
+}
+
+function defaultCachePath(): string {
+	return join(getStorePath(), "..", "cache", "parse-cache.json");
+}
+
+/**
+ * Дисковый кеш результатов парсинга
+ *
+ * Запись хранится по абсолютному пути и валидна, только если совпадают
+ * хеш содержимого и версия парсера. Результаты с ошибками парсинга
+ * не кешируются. Изменения копятся в памяти и записываются на диск
+ * через flush(). Безопасен для параллельных вызовов:
+ * файл кеша читается один раз, а записи обновляются синхронно.
+ *
+ * Парсер с препроцессором кеш обходит: результат зависит от функции,
+ * которой нет в ключе, а записи до его регистрации устарели.
+ */
+export class ParseCache {
+	private data: ParseCacheData | null = null;
+	private loading: Promise<ParseCacheData> | null = null;
+	private dirty = false;
+	private readonly filePath: string;
+	private readonly registry: ParserRegistry;
+
+	constructor(options: ParseCacheOptions = {}) {
+		this.filePath = options.filePath ?? defaultCachePath();
+		this.registry = options.registry ?? parserRegistry;
+	}
+
+	/**
+	 * Распарсить файл с диска, используя кеш если содержимое не менялось
+	 */
+	async parseFile(
+		filePath: string,
+		parser?: BaseParser | null
+	): Promise<EnhancedCodeSymbol[]> {
+		const path = resolve(filePath);
+		const source = await readSource(path);
+		return this.parseSource(path, source, parser);
+	}
+
+	/**
+	 * Распарсить уже загруженное содержимое, используя кеш
+	 */
+	async parseSource(
+		filePath: string,
+		source: string,
+		parser?: BaseParser | null,
+		options: ParseSourceOptions = {}
+	): Promise<EnhancedCodeSymbol[]> {
+		const result = await this.parseSourceWithDiagnostics(
+			filePath,
+			source,
+			parser,
+			options
+		);
+		return result.symbols;
+	}
+
+	/**
+	 * То же, что parseSource, но вместе с диагностиками парсера
+	 */
+	async parseSourceWithDiagnostics(
+		filePath: string,
+		source: string,
+		parser?: BaseParser | null,
+		options: ParseSourceOptions = {}
+	): Promise<ParseResult> {
+		const path = resolve(filePath);
+		const resolved = parser ?? this.registry.parserForFile(path);
+		if (!resolved) {
+			return { symbols: [], diagnostics: [] };
+		}
+		if (resolved.preprocessed) {
+			return resolved.parseSourceWithDiagnostics(path, source, options);
+		}
+
+		const contentHash = hashSource(source);
+		const parserVersion = parserVersionOf(resolved);
+		const data = await this.load();
+		const cached = data.entries[path];
+		if (isUsable(cached, contentHash, parserVersion)) {
+			return structuredClone({
+				symbols: cached.symbols,
+				diagnostics: cached.diagnostics ?? [],
+			});
+		}
+
+		const result = await resolved.parseSourceWithDiagnostics(
+			path,
+			source,
+			options
+		);
+		if (result.diagnostics.some((d) => d.severity === "error")) {
+			// Устаревшая запись тоже не нужна
+			if (path in data.entries) {
+				delete data.entries[path];
+				this.dirty = true;
+			}
+			return result;
+		}
+		data.entries[path] = {
+			contentHash,
+			parserVersion,
+			symbols: structuredClone(result.symbols),
+			diagnostics:
+				result.diagnostics.length > 0
+					? structuredClone(result.diagnostics)
+					: undefined,
+		};
+		this.dirty = true;
+		return result;
+	}
+
+	/**
+	 * Результат из кеша по хешу содержимого, без чтения исходника
+	 *
+	 * null, если записи нет или она от другого содержимого или версии
+	 * парсера.
+	 */
+	async lookup(
+		filePath: string,
+		contentHash: string,
+		parser?: BaseParser | null
+	): Promise<ParseResult | null> {
+		const path = resolve(filePath);
+		const resolved = parser ?? this.registry.parserForFile(path);
+		if (!resolved || resolved.preprocessed) {
+			return null;
+		}
+		const data = await this.load();
+		const cached = data.entries[path];
+		if (!isUsable(cached, contentHash, parserVersionOf(resolved))) {
+			return null;
+		}
+		return structuredClone({
+			symbols: cached.symbols,
+			diagnostics: cached.diagnostics ?? [],
+		});
+	}
+
+	/**
+	 * Синхронный lookup: null и тогда, когда кеш ещё не прочитан с диска
+	 * (прочитать его можно через ready)
+	 */
+	peek(
+		filePath: string,
+		contentHash: string,
+		parser?: BaseParser | null
+	): ParseResult | null {
+		const path = resolve(filePath);
+		const resolved = parser ?? this.registry.parserForFile(path);
+		const cached = this.data?.entries[path];
+		if (
+			!resolved ||
+			resolved.preprocessed ||
+			!isUsable(cached, contentHash, parserVersionOf(resolved))
+		) {
+			return null;
+		}
+		return structuredClone({
+			symbols: cached.symbols,
+			diagnostics: cached.diagnostics ?? [],
+		});
+	}
+
+	/**
+	 * Прочитать кеш с диска, если он ещё не загружен
+	 */
+	async ready(): Promise<void> {
+		await this.load();
+	}
+
+	/**
+	 * Удалить запись файла из кеша
+	 */
+	async invalidate(filePath: string): Promise<void> {
+		const data = await this.load();
+		const path = resolve(filePath);
+		if (path in data.entries) {
+			delete data.entries[path];
+			this.dirty = true;
+		}
+	}
+
+	/**
+	 * Записать накопленные изменения на диск
+	 */
+	async flush(): Promise<void> {
+		if (!(this.dirty && this.data)) {
+			return;
+		}
+		this.dirty = false;
+		await mkdir(dirname(this.filePath), { recursive: true });
+		await writeFile(this.filePath, JSON.stringify(this.data));
+	}
+
+	private load(): Promise<ParseCacheData> {
+		if (this.data) {
+			return Promise.resolve(this.data);
+		}
+		// Один общий промис, чтобы параллельные вызовы не читали файл повторно
+		this.loading ??= this.readFromDisk().then((data) => {
+			this.data = data;
+			return data;
+		});
+		return this.loading;
+	}
+
+	private async readFromDisk(): Promise<ParseCacheData> {
+		try {
+			const content = await readFile(this.filePath, "utf-8");
+			const parsed = JSON.parse(content) as ParseCacheData;
+			return parsed?.entries ? parsed : { entries: {} };
+		} catch (err) {
+			log.debug("Parse cache not loaded", {
+				path: this.filePath,
+				error: String(err),
+			});
+			return { entries: {} };
+		}
+	}
+}
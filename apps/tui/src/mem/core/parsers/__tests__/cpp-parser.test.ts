@@ -0,0 +1,216 @@
+// @ts-nocheck
+import { describe, expect, it } from "bun:test";
+import { join } from "node:path";
+import { CppParser } from "../cpp-parser.ts";
+
+describe("CppParser", () => {
+	const parser = new CppParser();
+	const fixtures = join(import.meta.dir, "fixtures", "cpp");
+	const headerPath = join(fixtures, "buffer.hpp");
+	const modulePath = join(fixtures, "module.c");
+
+	const find = async (path: string, name: string) =>
+		(await parser.parse(path)).find((s) => s.name === name);
+
+	it("should extract C declarations, typedefs and enums", async () => {
+		const result = await parser.parse(modulePath);
+
+		expect(result.map((s) => [s.name, s.symbolType])).toEqual([
+			["Point", "class"],
+			["node", "class"],
+			["node_t", "type"],
+			["value", "class"],
+			["color", "enum"],
+			["RED", "constant"],
+			["GREEN", "constant"],
+			["BLUE", "constant"],
+			["compare_fn", "type"],
+			["add", "function"],
+			["helper", "function"],
+			["dup_name", "function"],
+		]);
+		expect((await find(modulePath, "value"))?.metadata?.modifiers).toEqual([
+			"union",
+		]);
+		expect(
+			result
+				.filter((s) => s.metadata?.enumGroup === "color")
+				.map((s) => s.metadata?.value)
+		).toEqual([0, 5, 6]);
+	});
+
+	it("should name anonymous typedef structs and describe aliases", async () => {
+		const point = await find(modulePath, "Point");
+		expect(point?.metadata?.typeKind).toBe("struct");
+		expect(point?.metadata?.docComment).toBe("Point in 2D space.");
+		expect(point?.startLine).toBe(6);
+		expect(point?.metadata?.fields?.map((f) => [f.name, f.type])).toEqual([
+			["x", "int"],
+			["y", "int"],
+		]);
+
+		const node = await find(modulePath, "node");
+		expect(node?.metadata?.fields?.map((f) => [f.name, f.type])).toEqual([
+			["next", "struct node *"],
+			["name", "char[16]"],
+		]);
+		expect((await find(modulePath, "node_t"))?.metadata?.underlying).toBe(
+			"struct node"
+		);
+		expect((await find(modulePath, "compare_fn"))?.metadata?.underlying).toBe(
+			"int (*)(const void *, const void *)"
+		);
+	});
+
+	it("should extract function signatures, prototypes and calls", async () => {
+		const add = await find(modulePath, "add");
+		expect(add?.metadata?.parameters).toEqual([
+			{ name: "a", type: "int" },
+			{ name: "b", type: "int" },
+		]);
+		expect(add?.metadata?.returnType).toBe("int");
+		expect(add?.metadata?.visibility).toBe("public");
+		expect(add?.calls).toEqual(["helper"]);
+		expect(add?.metadata?.docComment).toBe(
+			"Adds two numbers.\n@deprecated use add_checked"
+		);
+		expect(add?.metadata?.deprecated).toBe(true);
+		expect(add?.metadata?.deprecationMessage).toBe("use add_checked");
+
+		const dup = await find(modulePath, "dup_name");
+		expect(dup?.metadata?.returnType).toBe("char *");
+		expect(dup?.metadata?.modifiers).toEqual(["prototype"]);
+		expect(dup?.metadata?.parameters).toEqual([
+			{ name: "name", type: "const char *" },
+			{ name: "", type: "...", variadic: true },
+		]);
+	});
+
+	it("should merge a prototype into its definition in the same file", async () => {
+		const result = await parser.parse(modulePath);
+		const helpers = result.filter((s) => s.name === "helper");
+
+		expect(helpers).toHaveLength(1);
+		expect(helpers[0].startLine).toBe(33);
+		expect(helpers[0].metadata?.modifiers).toEqual(["static"]);
+		// static — внутреннее связывание
+		expect(helpers[0].metadata?.visibility).toBe("internal");
+		expect(helpers[0].metadata?.isExported).toBe(false);
+	});
+
+	it("should extract object-like and function-like macros", async () => {
+		const result = await parser.parse(headerPath);
+		const capacity = await find(headerPath, "BUFFER_CAPACITY");
+		expect(capacity?.symbolType).toBe("constant");
+		expect(capacity?.metadata?.modifiers).toEqual(["macro"]);
+		expect(capacity?.metadata?.value).toBe(64);
+
+		const min = await find(headerPath, "BUFFER_MIN");
+		expect(min?.symbolType).toBe("function");
+		expect(min?.metadata?.parameters).toEqual([{ name: "a" }, { name: "b" }]);
+		expect([min?.startLine, min?.endLine]).toEqual([7, 7]);
+
+		// Include guard — не символ и не условие
+		expect(result.find((s) => s.name === "BUFFER_HPP")).toBeUndefined();
+		expect(capacity?.metadata?.preprocessorCondition).toBeUndefined();
+	});
+
+	it("should link class methods and honor access sections", async () => {
+		const buffer = await find(headerPath, "Buffer");
+		expect(buffer?.symbolType).toBe("class");
+		expect(buffer?.metadata?.packageName).toBe("io");
+		expect(buffer?.metadata?.docComment).toBe(
+			"Growable byte buffer.\nNot thread-safe."
+		);
+		expect(buffer?.metadata?.genericParams).toEqual([
+			{ name: "T" },
+			{ name: "N", constraint: "int" },
+		]);
+		expect(buffer?.metadata?.underlying).toBe("Base");
+		expect(buffer?.startLine).toBe(13);
+		expect(
+			buffer?.metadata?.fields?.map((f) => [f.name, f.type, f.exported])
+		).toEqual([
+			["data", "T *", false],
+			["length", "std::size_t", false],
+		]);
+
+		const visibility = async (name: string) =>
+			(await find(headerPath, name))?.metadata?.visibility;
+		expect(await visibility("Buffer.Buffer")).toBe("public");
+		expect(await visibility("Buffer.size")).toBe("public");
+		expect(await visibility("Buffer.grow")).toBe("protected");
+
+		const ctor = await find(headerPath, "Buffer.Buffer");
+		expect(ctor?.symbolType).toBe("method");
+		expect(ctor?.metadata?.receiver).toBe("Buffer");
+		expect(ctor?.metadata?.modifiers).toEqual(["constructor", "prototype"]);
+		expect(ctor?.metadata?.docComment).toBe("Creates an empty buffer.");
+		expect(
+			(await find(headerPath, "Buffer.~Buffer"))?.metadata?.modifiers
+		).toEqual(["destructor", "prototype"]);
+
+		const size = await find(headerPath, "Buffer.size");
+		expect(size?.metadata?.returnType).toBe("std::size_t");
+		expect(size?.metadata?.modifiers).toEqual(["const", "prototype"]);
+		expect(
+			(await find(headerPath, "Buffer.flush"))?.metadata?.modifiers
+		).toEqual(["virtual", "abstract", "prototype"]);
+	});
+
+	it("should qualify scoped enum constants and aliases", async () => {
+		const mode = await find(headerPath, "Mode");
+		expect(mode?.metadata?.modifiers).toEqual(["scoped"]);
+		expect(mode?.metadata?.underlying).toBe("int");
+		expect((await find(headerPath, "Mode.Append"))?.metadata?.value).toBe(5);
+		expect((await find(headerPath, "Mode.Write"))?.metadata?.parent).toBe(
+			"Mode"
+		);
+
+		const callback = await find(headerPath, "Callback");
+		expect(callback?.symbolType).toBe("type");
+		expect(callback?.metadata?.underlying).toBe("void (*)(int)");
+	});
+
+	it("should record the preprocessor branch of a declaration", async () => {
+		const result = await parser.parse(headerPath);
+		const inits = result.filter((s) => s.name === "platform_init");
+
+		expect(
+			inits.map((s) => [s.startLine, s.metadata?.preprocessorCondition])
+		).toEqual([
+			[40, "defined(_WIN32)"],
+			[42, "!defined(_WIN32)"],
+		]);
+		expect(inits[1].metadata?.returnType).toBe("int");
+		expect(inits[0].metadata?.parameters).toEqual([]);
+	});
+
+	it("should parse out-of-class method definitions", async () => {
+		const result = await parser.parseSource(
+			"buffer.cpp",
+			`namespace io {
+/// Drops all bytes.
+void Buffer::clear() { reset(); }
+}
+
+void io::Buffer::grow(int by) {}
+
+static void local() {}
+`
+		);
+
+		const clear = result.find((s) => s.name === "Buffer.clear");
+		expect(clear?.symbolType).toBe("method");
+		expect(clear?.metadata?.receiver).toBe("Buffer");
+		expect(clear?.metadata?.packageName).toBe("io");
+		expect(clear?.metadata?.docComment).toBe("Drops all bytes.");
+		expect(clear?.calls).toEqual(["reset"]);
+
+		const grow = result.find((s) => s.name === "Buffer.grow");
+		expect(grow?.metadata?.packageName).toBe("io");
+		expect(result.find((s) => s.name === "local")?.metadata?.visibility).toBe(
+			"internal"
+		);
+	});
+});
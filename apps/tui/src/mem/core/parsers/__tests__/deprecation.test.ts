@@ -0,0 +1,156 @@
+// @ts-nocheck
+import { describe, expect, it } from "bun:test";
+import {
+	docTagDeprecation,
+	goDeprecation,
+	pythonDeprecation,
+} from "../deprecation.ts";
+import { GoParser } from "../go-parser.ts";
+import { JavaParser } from "../java-parser.ts";
+import { JavaScriptParser } from "../javascript-parser.ts";
+import { PythonParser } from "../python-parser.ts";
+import { TypeScriptAstParser } from "../ts-ast-parser.ts";
+
+const deprecationOf = (symbols, name: string) => {
+	const metadata = symbols.find((s) => s.name === name)?.metadata;
+	return [metadata?.deprecated, metadata?.deprecationMessage];
+};
+
+describe("deprecation markers", () => {
+	it("should require Go Deprecated: to start a paragraph", () => {
+		expect(
+			goDeprecation("Old API.\n\nDeprecated: use New\ninstead.\n\nMore.")
+		).toEqual({ message: "use New instead." });
+		expect(goDeprecation("Deprecated:")).toEqual({});
+		expect(goDeprecation("Old API.\nDeprecated: not a paragraph")).toBeNull();
+		expect(goDeprecation(undefined)).toBeNull();
+	});
+
+	it("should stop the doc tag message at the next tag", () => {
+		expect(
+			docTagDeprecation("Old.\n@deprecated since 2.0,\nuse bar\n@param x")
+		).toEqual({ message: "since 2.0, use bar" });
+		expect(docTagDeprecation("@deprecated")).toEqual({});
+		expect(docTagDeprecation("Not @deprecated inline")).toBeNull();
+	});
+
+	it("should read the first string argument of a Python decorator", () => {
+		expect(
+			pythonDeprecation([{ name: 'deprecated("Use run() instead")' }], "")
+		).toEqual({ message: "Use run() instead" });
+		expect(
+			pythonDeprecation([{ name: "typing_extensions.deprecated" }], "")
+		).toEqual({});
+		expect(pythonDeprecation([{ name: "not_deprecated" }], "")).toBeNull();
+	});
+});
+
+describe("deprecated symbols across parsers", () => {
+	it("should detect Go Deprecated: paragraphs, including group docs", async () => {
+		const symbols = await new GoParser().parseSource(
+			"/virtual/old.go",
+			[
+				"package old",
+				"",
+				"// Get fetches.",
+				"//",
+				"// Deprecated: use Fetch.",
+				"func Get() {}",
+				"",
+				"// Deprecated: legacy limits.",
+				"const (",
+				"\tMaxA = 1",
+				"\tMaxB = 2",
+				")",
+				"",
+				"func Fetch() {}",
+			].join("\n")
+		);
+
+		expect(deprecationOf(symbols, "Get")).toEqual([true, "use Fetch."]);
+		expect(deprecationOf(symbols, "MaxB")).toEqual([true, "legacy limits."]);
+		expect(deprecationOf(symbols, "Fetch")).toEqual([undefined, undefined]);
+	});
+
+	it("should detect @deprecated JSDoc tags in TypeScript and JavaScript", async () => {
+		const source = [
+			"/**",
+			" * Old helper.",
+			" * @deprecated Use newHelper.",
+			" */",
+			"export function oldHelper() {}",
+			"",
+			"export function newHelper() {}",
+		].join("\n");
+
+		for (const [parser, path] of [
+			[new TypeScriptAstParser(), "/virtual/a.ts"],
+			[new JavaScriptParser(), "/virtual/a.js"],
+		]) {
+			const symbols = await parser.parseSource(path, source);
+			expect(deprecationOf(symbols, "oldHelper")).toEqual([
+				true,
+				"Use newHelper.",
+			]);
+			expect(deprecationOf(symbols, "newHelper")[0]).toBeUndefined();
+		}
+	});
+
+	it("should detect Java @Deprecated with the Javadoc message", async () => {
+		const symbols = await new JavaParser().parseSource(
+			"/virtual/Api.java",
+			[
+				"public class Api {",
+				"    /**",
+				"     * @deprecated use {@link #fetch()}",
+				"     */",
+				"    @Deprecated",
+				"    public void get() {}",
+				"",
+				"    @Deprecated(since = \"9\")",
+				"    public void load() {}",
+				"",
+				"    public void fetch() {}",
+				"}",
+			].join("\n")
+		);
+
+		expect(deprecationOf(symbols, "Api.get")).toEqual([
+			true,
+			"use {@link #fetch()}",
+		]);
+		expect(deprecationOf(symbols, "Api.load")).toEqual([true, undefined]);
+		expect(deprecationOf(symbols, "Api.fetch")[0]).toBeUndefined();
+	});
+
+	it("should detect Python decorators and docstring directives", async () => {
+		const symbols = await new PythonParser().parseSource(
+			"/virtual/old.py",
+			[
+				"@deprecated(\"Use run() instead\")",
+				"def start():",
+				"    pass",
+				"",
+				"def stop():",
+				'    """Stop the worker.',
+				"",
+				"    .. deprecated:: 1.2",
+				"       Use shutdown().",
+				'    """',
+				"",
+				"def shutdown():",
+				"    pass",
+			].join("\n")
+		);
+
+		expect(deprecationOf(symbols, "start")).toEqual([
+			true,
+			"Use run() instead",
+		]);
+		expect(deprecationOf(symbols, "stop")).toEqual([
+			true,
+			"1.2 Use shutdown().",
+		]);
+		expect(deprecationOf(symbols, "shutdown")[0]).toBeUndefined();
+	});
+});
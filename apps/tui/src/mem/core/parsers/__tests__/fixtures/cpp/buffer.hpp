@@ -0,0 +1,47 @@
+#ifndef BUFFER_HPP
+#define BUFFER_HPP
+
+#include <cstddef>
+
+#define BUFFER_CAPACITY 64
+#define BUFFER_MIN(a, b) ((a) < (b) ? (a) : (b))
+
+namespace io {
+
+/// Growable byte buffer.
+/// Not thread-safe.
+template <typename T, int N = 4>
+class Buffer : public Base {
+public:
+    /** Creates an empty buffer. */
+    Buffer();
+    ~Buffer();
+
+    std::size_t size() const;
+    virtual void flush() = 0;
+
+protected:
+    void grow(std::size_t by);
+
+private:
+    T *data;
+    std::size_t length;
+};
+
+enum class Mode : int {
+    Read,
+    Write = 4,
+    Append,
+};
+
+using Callback = void (*)(int);
+
+#ifdef _WIN32
+void platform_init(void);
+#else
+int platform_init(int flags);
+#endif
+
+}  // namespace io
+
+#endif
@@ -0,0 +1,38 @@
+#include <stdio.h>
+
+/**
+ * Point in 2D space.
+ */
+typedef struct {
+    int x;
+    int y;
+} Point;
+
+typedef struct node {
+    struct node *next;
+    char name[16];
+} node_t;
+
+union value {
+    int i;
+    double d;
+};
+
+enum color { RED, GREEN = 5, BLUE };
+
+typedef int (*compare_fn)(const void *, const void *);
+
+static int helper(int a);
+
+/// Adds two numbers.
+/// @deprecated use add_checked
+int add(int a, int b) {
+    return helper(a) + b;
+}
+
+static int helper(int a) {
+    printf("%d", a);
+    return a;
+}
+
+char *dup_name(const char *name, ...);
@@ -0,0 +1,34 @@
+package endings
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Greeter says hello.
+//
+// It is used by the CLI.
+type Greeter struct {
+	Name string // who to greet
+}
+
+/*
+Greet returns a greeting
+for the configured name.
+*/
+func (g *Greeter) Greet() string {
+	return fmt.Sprintf("Hello, %s", g.Name)
+}
+
+const (
+	// First level
+	Low = iota
+	High
+)
+
+var banner = `line one
+line two`
+
+func Shout(s string) string {
+	return strings.ToUpper(s)
+}
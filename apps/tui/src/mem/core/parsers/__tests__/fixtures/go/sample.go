@@ -32,7 +32,7 @@ func ComplexFunction(ctx context.Context, id int, options ...string) (string, er
 type User struct {
 	ID   int
 	Name string
-	Age  int
+	Age  int // years
 }
 
 // NewUser is a constructor function
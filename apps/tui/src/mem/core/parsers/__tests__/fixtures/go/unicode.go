@@ -0,0 +1,31 @@
+package i18n
+
+// Приветствие возвращает приветствие на русском
+func Приветствие(имя string) string {
+	return "Привет, " + имя
+}
+
+// Größe описывает размеры
+type Größe struct {
+	Breite int
+	Höhe   int
+}
+
+// Fläche считает площадь
+func (g Größe) Fläche() int {
+	return g.Breite * g.Höhe
+}
+
+func vérifier(ΔT float64) bool {
+	return ΔT > 0
+}
+
+// 名前 не экспортируется: у иероглифов нет заглавных букв
+func 名前() string {
+	return "名前"
+}
+
+// 𝛂Count — буква вне BMP
+func 𝛂Count() int {
+	return 0
+}
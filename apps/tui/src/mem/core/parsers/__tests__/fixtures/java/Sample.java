@@ -0,0 +1,83 @@
+package com.example.users;
+
+import java.util.ArrayList;
+import java.util.List;
+
+/**
+ * Repository of users.
+ *
+ * @param <T> user type
+ */
+public class UserRepository<T extends User> implements Repository<T> {
+    /** Default page size. */
+    public static final int PAGE_SIZE = 20;
+
+    private final List<T> users = new ArrayList<>();
+    protected String name;
+    int count, sizes[];
+
+    public UserRepository(String name) {
+        this.name = name;
+    }
+
+    /**
+     * Find a user by id.
+     */
+    @Override
+    public T findById(long id) {
+        return users.stream().filter(u -> u.getId() == id).findFirst().orElse(null);
+    }
+
+    @Deprecated
+    @SuppressWarnings("unchecked")
+    protected static <R extends Comparable<R>> R max(R a, R b) {
+        return a.compareTo(b) > 0 ? a : b;
+    }
+
+    private void log(String format, Object... args) {
+        System.out.println(String.format(format, args));
+    }
+
+    void reset() {
+        users.clear();
+    }
+
+    public static class Page {
+        private int number;
+
+        public int getNumber() {
+            return number;
+        }
+    }
+
+    private class Cursor {
+        int position;
+    }
+}
+
+interface Repository<T> {
+    int MAX_RESULTS = 100;
+
+    T findById(long id);
+}
+
+public enum Status {
+    ACTIVE("active"),
+    INACTIVE("inactive");
+
+    private final String label;
+
+    Status(String label) {
+        this.label = label;
+    }
+
+    public String getLabel() {
+        return label;
+    }
+}
+
+public record Point(int x, int y) {
+    public double length() {
+        return Math.sqrt(x * x + y * y);
+    }
+}
@@ -0,0 +1,48 @@
+import { readFile } from "node:fs/promises";
+const path = require("node:path");
+
+/**
+ * Greets a user
+ * @param {string} name - user name
+ * @param {number} [times=1] - repeat count
+ * @returns {string}
+ */
+export function greet(name, times = 1) {
+	return `Hello, ${name}`.repeat(times);
+}
+
+function untyped(a, b, ...rest) {
+	return [a, b, ...rest];
+}
+
+export const double = (x) => x * 2;
+
+const load = async (file) => readFile(path.join(".", file), "utf-8");
+
+export const MAX_ITEMS = 10;
+
+/** A shopping cart */
+export default class Cart {
+	constructor(owner) {
+		this.owner = owner;
+	}
+
+	/**
+	 * @param {Item} item
+	 */
+	add(item) {
+		return item;
+	}
+
+	#recalculate() {
+		return 0;
+	}
+}
+
+class Helper {}
+
+export { Helper };
+
+module.exports = { untyped, format: function format(value) { return String(value); } };
+exports.parse = (text) => JSON.parse(text);
+exports.VERSION = "1.0.0";
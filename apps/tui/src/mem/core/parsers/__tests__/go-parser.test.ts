@@ -1,11 +1,24 @@
 // @ts-nocheck
-import { describe, expect, it } from "bun:test";
+import { afterAll, describe, expect, it } from "bun:test";
+import { mkdirSync, mkdtempSync, rmSync, writeFileSync } from "node:fs";
+import { tmpdir } from "node:os";
 import { join } from "node:path";
 import { GoParser } from "../go-parser.ts";
 
 describe("GoParser", () => {
 	const parser = new GoParser();
 	const fixturePath = join(import.meta.dir, "fixtures", "go", "sample.go");
+	const tempDir = mkdtempSync(join(tmpdir(), "go-parser-test-"));
+
+	afterAll(() => {
+		rmSync(tempDir, { recursive: true, force: true });
+	});
+
+	function createTestFile(filename: string, content: string): string {
+		const filePath = join(tempDir, filename);
+		writeFileSync(filePath, content, "utf-8");
+		return filePath;
+	}
 
 	it("should parse Go file", async () => {
 		const result = await parser.parse(fixturePath);
@@ -101,6 +114,51 @@ describe("GoParser", () => {
 		expect(counter?.metadata?.isExported).toBe(false); // lowercase
 	});
 
+	it("should extract package vars holding function literals as functions", async () => {
+		const filePath = createTestFile(
+			"handlers.go",
+			`package handlers
+
+type Handler func(string) error
+
+// Default handles unknown commands
+var Default Handler = func(s string) error {
+	return validate(s)
+}
+
+var (
+	lookup = func(key string, fallback int) (int, bool) { return fallback, false }
+	limit  = 10
+)
+`
+		);
+		const result = await parser.parse(filePath);
+
+		const def = result.find((s) => s.name === "Default");
+		expect(def?.symbolType).toBe("function");
+		expect(def?.metadata?.backingKind).toBe("variable");
+		expect(def?.metadata?.parameters).toEqual([
+			{ name: "s", type: "string" },
+		]);
+		expect(def?.metadata?.returnType).toBe("error");
+		expect(def?.metadata?.language?.goVarType).toBe("Handler");
+		expect(def?.metadata?.docComment).toBe("Default handles unknown commands");
+		expect(def?.metadata?.isExported).toBe(true);
+		expect(def?.calls).toContain("validate");
+		expect([def?.startLine, def?.endLine]).toEqual([6, 8]);
+
+		const lookup = result.find((s) => s.name === "lookup");
+		expect(lookup?.symbolType).toBe("function");
+		expect(lookup?.metadata?.returns?.map((r) => r.type)).toEqual([
+			"int",
+			"bool",
+		]);
+
+		const limit = result.find((s) => s.name === "limit");
+		expect(limit?.symbolType).toBe("variable");
+		expect(limit?.metadata?.backingKind).toBeUndefined();
+	});
+
 	it("should extract type aliases", async () => {
 		const result = await parser.parse(fixturePath);
 		const resultType = result.find((s) => s.name === "Result");
@@ -116,4 +174,1567 @@ describe("GoParser", () => {
 
 		expect(simpleFunc?.jsDoc).toContain("basic function");
 	});
+
+	it("should populate docComment from preceding comments", async () => {
+		const result = await parser.parse(fixturePath);
+		const newUser = result.find((s) => s.name === "NewUser");
+
+		expect(newUser?.metadata?.docComment).toBe(
+			"NewUser is a constructor function"
+		);
+		expect(newUser?.jsDoc).toBe(newUser?.metadata?.docComment);
+	});
+
+	it("should ignore comments separated by a blank line", async () => {
+		const filePath = createTestFile(
+			"blank.go",
+			`package blank
+
+// Detached comment
+
+func Detached() {}
+
+// First line
+// Second line
+func Attached() {}
+`
+		);
+		const result = await parser.parse(filePath);
+
+		const detached = result.find((s) => s.name === "Detached");
+		expect(detached?.metadata?.docComment).toBeUndefined();
+
+		const attached = result.find((s) => s.name === "Attached");
+		expect(attached?.metadata?.docComment).toBe("First line\nSecond line");
+	});
+
+	it("should attach group and per-entry comments in const blocks", async () => {
+		const filePath = createTestFile(
+			"group.go",
+			`package group
+
+// Limits for the client
+const (
+	// MaxConn is the connection limit
+	MaxConn = 10
+	MinConn = 1 // trailing, not a doc
+	Timeout = 30
+)
+`
+		);
+		const result = await parser.parse(filePath);
+
+		const maxConn = result.find((s) => s.name === "MaxConn");
+		expect(maxConn?.metadata?.docComment).toBe(
+			"MaxConn is the connection limit"
+		);
+		expect(maxConn?.metadata?.groupDocComment).toBe("Limits for the client");
+
+		const timeout = result.find((s) => s.name === "Timeout");
+		expect(timeout?.metadata?.docComment).toBeUndefined();
+		expect(timeout?.metadata?.groupDocComment).toBe("Limits for the client");
+	});
+
+	it("should capture variadic parameters and structured returns", async () => {
+		const result = await parser.parse(fixturePath);
+		const complexFunc = result.find((s) => s.name === "ComplexFunction");
+
+		expect(complexFunc?.metadata?.parameters?.[2]).toEqual({
+			name: "options",
+			type: "string",
+			variadic: true,
+		});
+		expect(complexFunc?.metadata?.returns).toEqual([
+			{ name: null, type: "string" },
+			{ name: null, type: "error" },
+		]);
+	});
+
+	it("should expand grouped parameters", async () => {
+		const result = await parser.parse(fixturePath);
+		const sum = result.find((s) => s.name === "Sum");
+
+		expect(sum?.metadata?.parameters).toEqual([
+			{ name: "a", type: "T" },
+			{ name: "b", type: "T" },
+		]);
+		expect(sum?.metadata?.returns).toEqual([{ name: null, type: "T" }]);
+	});
+
+	it("should extract generic type parameters", async () => {
+		const result = await parser.parse(fixturePath);
+		const sum = result.find((s) => s.name === "Sum");
+
+		expect(sum?.metadata?.genericParams).toEqual([
+			{ name: "T", constraint: "Number" },
+		]);
+	});
+
+	it("should flag constraint interfaces with their union members", async () => {
+		const result = await parser.parse(fixturePath);
+
+		const number = result.find((s) => s.name === "Number");
+		expect(number?.symbolType).toBe("interface");
+		expect(number?.metadata?.isConstraint).toBe(true);
+		expect(number?.metadata?.unionTypes).toEqual(["int", "int64", "float64"]);
+
+		const service = result.find((s) => s.name === "Service");
+		expect(service?.metadata?.isConstraint).toBeUndefined();
+	});
+
+	it("should not flag interfaces embedding error or any as constraints", async () => {
+		const symbols = await parser.parseSource(
+			"/virtual/errs.go",
+			`package errs
+
+type Temporary interface {
+	error
+	Temporary() bool
+}
+
+type Anything interface{ any }
+
+type Comparable interface{ comparable }
+`
+		);
+		const find = (name) => symbols.find((s) => s.name === name);
+
+		for (const name of ["Temporary", "Anything"]) {
+			expect(find(name)?.metadata?.isConstraint).toBeUndefined();
+			expect(find(name)?.metadata?.unionTypes).toBeUndefined();
+		}
+		expect(find("Comparable")?.metadata?.isConstraint).toBe(true);
+	});
+
+	it("should distinguish type aliases from type definitions", async () => {
+		const result = await parser.parse(fixturePath);
+
+		const resultType = result.find((s) => s.name === "Result");
+		expect(resultType?.metadata?.typeKind).toBe("alias");
+		expect(resultType?.metadata?.underlying).toBe("map[string]interface{}");
+
+		const handler = result.find((s) => s.name === "Handler");
+		expect(handler?.metadata?.typeKind).toBe("func");
+		expect(handler?.metadata?.underlying).toBe("func(string) error");
+
+		const user = result.find((s) => s.name === "User");
+		expect(user?.metadata?.typeKind).toBe("struct");
+
+		const service = result.find((s) => s.name === "Service");
+		expect(service?.metadata?.typeKind).toBe("interface");
+	});
+
+	it("should extract struct fields with tags", async () => {
+		const filePath = createTestFile(
+			"fields.go",
+			`package fields
+
+import "io"
+
+type Record struct {
+	io.Reader
+	*Base
+	ID    int    \`json:"id"\`
+	X, Y  int
+	label string
+}
+`
+		);
+		const result = await parser.parse(filePath);
+		const record = result.find((s) => s.name === "Record");
+
+		const at = (line: number, startCol: number, endCol: number) => ({
+			startLine: line,
+			startCol,
+			endLine: line,
+			endCol,
+		});
+		expect(record?.metadata?.fields).toEqual([
+			{
+				type: "io.Reader",
+				exported: true,
+				embedded: true,
+				range: at(6, 1, 10),
+				nameRange: at(6, 1, 10),
+			},
+			{
+				type: "*Base",
+				exported: true,
+				embedded: true,
+				range: at(7, 1, 6),
+				nameRange: at(7, 2, 6),
+			},
+			{
+				name: "ID",
+				type: "int",
+				tag: '`json:"id"`',
+				exported: true,
+				range: at(8, 1, 25),
+				nameRange: at(8, 1, 3),
+			},
+			{
+				name: "X",
+				type: "int",
+				exported: true,
+				range: at(9, 1, 10),
+				nameRange: at(9, 1, 2),
+			},
+			{
+				name: "Y",
+				type: "int",
+				exported: true,
+				range: at(9, 1, 10),
+				nameRange: at(9, 4, 5),
+			},
+			{
+				name: "label",
+				type: "string",
+				exported: false,
+				range: at(10, 1, 13),
+				nameRange: at(10, 1, 6),
+			},
+		]);
+	});
+
+	it("should expose fixture struct fields", async () => {
+		const result = await parser.parse(fixturePath);
+		const user = result.find((s) => s.name === "User");
+
+		expect(user?.metadata?.fields?.map((f) => f.name)).toEqual([
+			"ID",
+			"Name",
+			"Age",
+		]);
+	});
+
+	it("should capture trailing comments only with the trailingComments option", async () => {
+		const fieldComments = (symbols) =>
+			symbols
+				.find((s) => s.name === "User")
+				?.metadata?.fields?.map((f) => [f.name, f.trailingComment]);
+
+		expect(fieldComments(await parser.parse(fixturePath))).toEqual([
+			["ID", undefined],
+			["Name", undefined],
+			["Age", undefined],
+		]);
+		const trailing = new GoParser({ trailingComments: true });
+		expect(fieldComments(await trailing.parse(fixturePath))).toEqual([
+			["ID", undefined],
+			["Name", undefined],
+			["Age", "years"],
+		]);
+	});
+
+	it("should not turn a trailing comment into the next spec's doc", async () => {
+		const source = [
+			"package p",
+			"",
+			"const (",
+			"\tRed = iota // warm",
+			"\tGreen",
+			"\t// Blue is cold.",
+			"\tBlue",
+			")",
+			"",
+			"var Limit = 10 // requests per second",
+			"// Name is documented.",
+			"var Name = \"x\"",
+		].join("\n");
+		const symbols = await new GoParser({ trailingComments: true }).parseSource(
+			"/virtual/colors.go",
+			source
+		);
+		const byName = (name: string) => symbols.find((s) => s.name === name);
+
+		expect(byName("Red")?.metadata?.trailingComment).toBe("warm");
+		expect(byName("Green")?.metadata?.docComment).toBeUndefined();
+		expect(byName("Green")?.metadata?.trailingComment).toBeUndefined();
+		expect(byName("Blue")?.metadata?.docComment).toBe("Blue is cold.");
+		expect(byName("Limit")?.metadata?.trailingComment).toBe(
+			"requests per second"
+		);
+		expect(byName("Name")?.metadata?.docComment).toBe("Name is documented.");
+		expect(byName("Name")?.metadata?.trailingComment).toBeUndefined();
+	});
+
+	it("should record declaration and name ranges", async () => {
+		const result = await parser.parse(fixturePath);
+
+		const getName = result.find((s) => s.name === "User.GetName");
+		expect(getName?.metadata?.range).toEqual({
+			startLine: getName?.startLine,
+			startCol: 0,
+			endLine: getName?.endLine,
+			endCol: 1,
+		});
+		expect(getName?.metadata?.nameRange).toEqual({
+			startLine: getName?.startLine,
+			startCol: 15,
+			endLine: getName?.startLine,
+			endCol: 22,
+		});
+
+		const apiUrl = result.find((s) => s.name === "APIUrl");
+		expect(apiUrl?.metadata?.range?.startLine).toBe(apiUrl?.startLine);
+		expect(apiUrl?.metadata?.range?.startCol).toBe(1);
+	});
+
+	it("should include doc comment in range when opted in", async () => {
+		const withDocs = new GoParser({ rangeIncludesDocComment: true });
+		const result = await withDocs.parse(fixturePath);
+		const simpleFunc = result.find((s) => s.name === "SimpleFunction");
+
+		expect(simpleFunc?.metadata?.range?.startLine).toBe(
+			(simpleFunc?.startLine ?? 0) - 1
+		);
+		expect(simpleFunc?.metadata?.nameRange?.startLine).toBe(
+			simpleFunc?.startLine
+		);
+	});
+
+	it("should merge package files and keep other packages separate", async () => {
+		const pkgDir = join(tempDir, "pkg");
+		mkdirSync(pkgDir);
+		writeFileSync(
+			join(pkgDir, "types.go"),
+			"package shop\n\ntype Cart struct {\n\tItems []string\n}\n"
+		);
+		writeFileSync(
+			join(pkgDir, "cart.go"),
+			"package shop\n\nfunc (c *Cart) Add(item string) {}\n"
+		);
+		writeFileSync(
+			join(pkgDir, "extra.go"),
+			"package shop_extra\n\nfunc Helper() {}\n"
+		);
+		writeFileSync(
+			join(pkgDir, "cart_test.go"),
+			"package shop\n\nfunc TestAdd() {}\n"
+		);
+
+		const packages = await parser.parsePackage(pkgDir);
+
+		expect([...packages.keys()].sort()).toEqual(["shop", "shop_extra"]);
+
+		const shop = packages.get("shop");
+		expect(shop?.files).toHaveLength(2);
+		const names = shop?.symbols.map((s) => s.name);
+		expect(names).toContain("Cart");
+		expect(names).toContain("Cart.Add");
+		expect(names).not.toContain("TestAdd");
+
+		const add = shop?.symbols.find((s) => s.name === "Cart.Add");
+		expect(add?.metadata?.receiver).toBe("Cart");
+		expect(add?.metadata?.packageName).toBe("shop");
+	});
+
+	it("should include _test.go files in packages with includeTests", async () => {
+		const pkgDir = join(tempDir, "pkg-with-tests");
+		mkdirSync(pkgDir);
+		writeFileSync(
+			join(pkgDir, "cart.go"),
+			"package shop\n\nfunc Add(item string) {}\n"
+		);
+		writeFileSync(
+			join(pkgDir, "cart_test.go"),
+			"package shop\n\nfunc TestAdd() {}\n"
+		);
+		writeFileSync(
+			join(pkgDir, "export_test.go"),
+			"package shop_test\n\nfunc TestExternal() {}\n"
+		);
+
+		const packages = await new GoParser({ includeTests: true }).parsePackage(
+			pkgDir
+		);
+
+		expect([...packages.keys()].sort()).toEqual(["shop", "shop_test"]);
+		expect(packages.get("shop")?.files).toHaveLength(2);
+		expect(packages.get("shop")?.symbols.map((s) => s.name)).toContain(
+			"TestAdd"
+		);
+	});
+
+	it("should resolve signature type references to imports", async () => {
+		const result = await parser.parse(fixturePath);
+		const complex = result.find((s) => s.name === "ComplexFunction");
+		const refs = complex?.metadata?.typeRefs;
+
+		expect(refs).toContainEqual({
+			name: "Context",
+			qualifier: "context",
+			package: "context",
+			importPath: "context",
+		});
+		expect(refs).toContainEqual({ name: "int", builtin: true });
+		expect(refs).toContainEqual({ name: "error", builtin: true });
+
+		const newUser = result.find((s) => s.name === "NewUser");
+		expect(newUser?.metadata?.typeRefs).toContainEqual({
+			name: "User",
+			package: "sample",
+		});
+
+		const sum = result.find((s) => s.name === "Sum");
+		expect(sum?.metadata?.typeRefs).toEqual([
+			{ name: "Number", package: "sample" },
+		]);
+	});
+
+	it("should resolve aliased imports in signatures and fields", async () => {
+		const filePath = createTestFile(
+			"aliased.go",
+			`package api
+
+import (
+	stdctx "context"
+	"gopkg.in/yaml.v3"
+	. "strings"
+	_ "embed"
+)
+
+type Config struct {
+	Node yaml.Node
+	Ctx  stdctx.Context
+}
+
+func Load(ctx stdctx.Context, r *Reader) error {
+	return nil
+}
+`
+		);
+		const result = await parser.parse(filePath);
+
+		const config = result.find((s) => s.name === "Config");
+		expect(config?.metadata?.typeRefs).toEqual([
+			{
+				name: "Node",
+				qualifier: "yaml",
+				package: "yaml",
+				importPath: "gopkg.in/yaml.v3",
+			},
+			{
+				name: "Context",
+				qualifier: "stdctx",
+				package: "context",
+				importPath: "context",
+			},
+		]);
+
+		const load = result.find((s) => s.name === "Load");
+		expect(load?.metadata?.typeRefs).toContainEqual({
+			name: "Reader",
+			package: "api",
+		});
+	});
+
+	it("should attach build constraints from comments and file names", async () => {
+		const tagged = await parser.parse(
+			createTestFile(
+				"tagged.go",
+				"//go:build linux && !arm\n\npackage tagged\n\nfunc Tagged() {}\n"
+			)
+		);
+		expect(tagged[0]?.metadata?.buildConstraints).toEqual({
+			expression: "linux && !arm",
+		});
+
+		const legacy = await parser.parse(
+			createTestFile(
+				"legacy.go",
+				"// +build linux darwin\n// +build amd64\n\npackage tagged\n\nfunc Legacy() {}\n"
+			)
+		);
+		expect(legacy[0]?.metadata?.buildConstraints?.expression).toBe(
+			"(linux || darwin) && amd64"
+		);
+
+		const suffixed = await parser.parse(
+			createTestFile("net_windows_amd64.go", "package tagged\n\nfunc Dial() {}\n")
+		);
+		expect(suffixed[0]?.metadata?.buildConstraints).toEqual({
+			goos: "windows",
+			goarch: "amd64",
+		});
+
+		const plain = await parser.parse(fixturePath);
+		expect(plain[0]?.metadata?.buildConstraints).toBeUndefined();
+	});
+
+	it("should filter package files by target platform", async () => {
+		const pkgDir = join(tempDir, "platform");
+		mkdirSync(pkgDir);
+		writeFileSync(join(pkgDir, "common.go"), "package platform\n\nfunc Common() {}\n");
+		writeFileSync(
+			join(pkgDir, "open_linux.go"),
+			"package platform\n\nfunc Open() {}\n"
+		);
+		writeFileSync(
+			join(pkgDir, "open_windows.go"),
+			"package platform\n\nfunc Open() {}\n"
+		);
+		writeFileSync(
+			join(pkgDir, "unixonly.go"),
+			"//go:build unix\n\npackage platform\n\nfunc UnixOnly() {}\n"
+		);
+
+		const linux = (await parser.parsePackage(pkgDir, { goos: "linux" })).get(
+			"platform"
+		);
+		expect(linux?.symbols.map((s) => s.name).sort()).toEqual([
+			"Common",
+			"Open",
+			"UnixOnly",
+		]);
+		expect(
+			linux?.symbols.find((s) => s.name === "Open")?.metadata?.buildConstraints
+		).toEqual({ goos: "linux" });
+
+		const windows = (
+			await parser.parsePackage(pkgDir, { goos: "windows" })
+		).get("platform");
+		expect(windows?.symbols.map((s) => s.name).sort()).toEqual([
+			"Common",
+			"Open",
+		]);
+
+		const all = (await parser.parsePackage(pkgDir)).get("platform");
+		const opens = all?.symbols.filter((s) => s.name === "Open");
+		expect(opens?.map((s) => s.metadata?.buildConstraints?.goos)).toEqual([
+			"linux",
+			"windows",
+		]);
+	});
+
+	it("should resolve iota enum values within const groups", async () => {
+		const filePath = createTestFile(
+			"enums.go",
+			`package enums
+
+const (
+	Sunday = iota
+	Monday
+	Tuesday
+)
+
+const (
+	_  = iota
+	KB = 1 << (10 * iota)
+	MB
+)
+
+const (
+	FlagA uint8 = 1 << iota
+	FlagB
+	Mask = FlagA | FlagB
+	Dynamic = len("abc") + iota
+)
+`
+		);
+		const result = await parser.parse(filePath);
+		const value = (name: string) =>
+			result.find((s) => s.name === name)?.metadata?.value;
+
+		expect([value("Sunday"), value("Monday"), value("Tuesday")]).toEqual([
+			0, 1, 2,
+		]);
+		expect(result.find((s) => s.name === "Tuesday")?.metadata?.enumGroup).toBe(
+			"Sunday"
+		);
+
+		expect(result.some((s) => s.name === "_")).toBe(false);
+		expect([value("KB"), value("MB")]).toEqual([1024, 1_048_576]);
+		expect(result.find((s) => s.name === "MB")?.metadata?.enumGroup).toBe(
+			"KB"
+		);
+
+		expect([value("FlagA"), value("FlagB"), value("Mask")]).toEqual([1, 2, 3]);
+		expect(value("Dynamic")).toBeNull();
+	});
+
+	it("should link typed iota constants to their named type", async () => {
+		const result = await parser.parseSource(
+			"/virtual/color.go",
+			`package paint
+
+type Color int
+
+const (
+	Red Color = iota
+	Green
+	Blue
+)
+
+const (
+	FlagA uint8 = 1 << iota
+	FlagB
+)
+`
+		);
+		const meta = (name: string) =>
+			result.find((s) => s.name === name)?.metadata;
+
+		expect(
+			["Red", "Green", "Blue"].map((name) => [
+				meta(name)?.enumType,
+				meta(name)?.returnType,
+				meta(name)?.value,
+			])
+		).toEqual([
+			["Color", "Color", 0],
+			["Color", "Color", 1],
+			["Color", "Color", 2],
+		]);
+		expect(meta("Blue")?.enumGroup).toBe("Red");
+		// Встроенный тип не делает группу типизированным перечислением
+		expect(meta("FlagB")?.returnType).toBe("uint8");
+		expect(meta("FlagB")?.enumType).toBeUndefined();
+	});
+
+	it("should not mark plain const groups as enums", async () => {
+		const result = await parser.parse(fixturePath);
+		const maxRetries = result.find((s) => s.name === "MaxRetries");
+
+		expect(maxRetries?.metadata?.enumGroup).toBeUndefined();
+		expect(maxRetries?.metadata?.value).toBeUndefined();
+	});
+
+	it("should normalize visibility from capitalization", async () => {
+		const result = await parser.parse(fixturePath);
+
+		const privateMethod = result.find((s) => s.name === "User.privateMethod");
+		expect(privateMethod?.metadata?.visibility).toBe("private");
+
+		const others = result.filter(
+			(s) => s.name !== "User.privateMethod" && s.metadata?.isExported
+		);
+		expect(others.every((s) => s.metadata?.visibility === "public")).toBe(
+			true
+		);
+		expect(result.find((s) => s.name === "counter")?.metadata?.visibility).toBe(
+			"private"
+		);
+	});
+
+	it("should capture local types and named closures when enabled", async () => {
+		const filePath = await createTestFile(
+			"locals.go",
+			`package main
+
+func Outer() int {
+	type point struct {
+		X int
+	}
+	const limit = 3
+	helper := func(n int) int {
+		inner := func() int { return n }
+		return inner()
+	}
+	go func() {
+		helper(1)
+	}()
+	return helper(limit)
+}
+`
+		);
+
+		const plain = await parser.parse(filePath);
+		expect(plain.map((s) => s.name)).toEqual(["Outer"]);
+
+		const withLocals = await new GoParser({ captureLocals: true }).parse(
+			filePath
+		);
+		const byName = (name: string) => withLocals.find((s) => s.name === name);
+
+		expect(withLocals.map((s) => s.name).sort()).toEqual([
+			"Outer",
+			"helper",
+			"inner",
+			"point",
+		]);
+		expect(byName("point")?.metadata?.parent).toBe("Outer");
+		expect(byName("point")?.metadata?.isExported).toBe(false);
+		expect(byName("helper")?.symbolType).toBe("function");
+		expect(byName("helper")?.metadata?.parent).toBe("Outer");
+		expect(byName("helper")?.metadata?.parameters).toEqual([
+			{ name: "n", type: "int" },
+		]);
+		expect(byName("inner")?.metadata?.parent).toBe("helper");
+		expect(byName("Outer")?.metadata?.parent).toBeUndefined();
+	});
+
+	it("should recover sibling declarations around a syntax error", async () => {
+		const source = `package main
+
+// Before is fine
+func Before() int {
+	return 1
+}
+
+func Broken() {
+	x := 
+	if x {
+}
+
+type After struct {
+	Name string
+}
+
+func Last() {}
+`;
+		const result = await parser.parseSourceWithDiagnostics(
+			"/virtual/broken.go",
+			source
+		);
+		const names = result.symbols.map((s) => s.name);
+
+		expect(names).toContain("Before");
+		expect(names).toContain("After");
+		expect(names).toContain("Last");
+		expect(result.symbols.find((s) => s.name === "Before")?.jsDoc).toBe(
+			"Before is fine"
+		);
+		expect(result.symbols.find((s) => s.name === "Last")?.startLine).toBe(17);
+		expect(result.diagnostics.length).toBeGreaterThan(0);
+		expect(result.diagnostics.every((d) => d.range.startLine >= 8)).toBe(true);
+	});
+
+	it("should report no diagnostics for valid files", async () => {
+		const source = await Bun.file(fixturePath).text();
+		const result = await parser.parseSourceWithDiagnostics(fixturePath, source);
+
+		expect(result.diagnostics).toEqual([]);
+		expect(result.symbols).toEqual(await parser.parse(fixturePath));
+	});
+
+	it("should extract interface method signatures", async () => {
+		const result = await parser.parse(fixturePath);
+		const service = result.find((s) => s.name === "Service");
+
+		expect(
+			service?.metadata?.methods?.map(({ range, ...method }) => method)
+		).toEqual([
+			{
+				name: "Process",
+				parameters: [{ name: "data", type: "string" }],
+				returnType: "error",
+				returns: [{ name: null, type: "error" }],
+			},
+			{
+				name: "Close",
+				parameters: [],
+				returnType: "error",
+				returns: [{ name: null, type: "error" }],
+			},
+		]);
+		expect(service?.metadata?.methods?.[0]?.range?.startLine).toBe(63);
+	});
+
+	it("should mark embedded interfaces in method sets", async () => {
+		const filePath = createTestFile(
+			"embedded_iface.go",
+			`package streams
+
+import "io"
+
+type ReadCloser interface {
+	io.Reader
+	Closer
+	Close() error
+}
+
+type Number interface {
+	~int | ~float64
+}
+`
+		);
+		const result = await parser.parse(filePath);
+		const methods = result.find((s) => s.name === "ReadCloser")?.metadata
+			?.methods;
+
+		expect(methods?.map((m) => [m.name, !!m.embedded])).toEqual([
+			["io.Reader", true],
+			["Closer", true],
+			["Close", false],
+		]);
+		expect(
+			result.find((s) => s.name === "Number")?.metadata?.methods
+		).toBeUndefined();
+	});
+
+	it("should read block comments as doc comments", async () => {
+		const filePath = createTestFile(
+			"block_docs.go",
+			`package gen
+
+/* Single does X */
+func Single() {}
+
+/*
+ * Starred spans
+ * several lines.
+ */
+func Starred() {}
+
+/*
+	Indented block
+		keeps nesting
+*/
+func Indented() {}
+
+// Mixed starts with a line
+/* and ends with a block */
+func Mixed() {}
+
+/* Detached */
+
+func Detached() {}
+`
+		);
+		const result = await parser.parse(filePath);
+		const doc = (name: string) => result.find((s) => s.name === name)?.jsDoc;
+
+		expect(doc("Single")).toBe("Single does X");
+		expect(doc("Starred")).toBe("Starred spans\nseveral lines.");
+		expect(doc("Indented")).toBe("Indented block\n\tkeeps nesting");
+		expect(doc("Mixed")).toBe(
+			"Mixed starts with a line\nand ends with a block"
+		);
+		expect(doc("Detached")).toBe("");
+	});
+
+	it("should emit package symbol with package doc comment", async () => {
+		const result = await new GoParser({ packageSymbol: true }).parse(
+			fixturePath
+		);
+		const pkg = result.find((s) => s.symbolType === "package");
+
+		expect(pkg?.name).toBe("sample");
+		expect(pkg?.startLine).toBe(2);
+		expect(pkg?.metadata?.docComment).toBe(
+			"Package sample for testing Go parser"
+		);
+		expect(pkg?.metadata?.packageName).toBe("sample");
+		expect(result[0]).toBe(pkg);
+
+		const plain = await parser.parse(fixturePath);
+		expect(plain.some((s) => s.symbolType === "package")).toBe(false);
+	});
+
+	it("should merge package docs in parsePackage, preferring doc.go", async () => {
+		const merged = join(tempDir, "merged");
+		mkdirSync(merged, { recursive: true });
+		writeFileSync(
+			join(merged, "a.go"),
+			"// Package merged handles A.\npackage merged\n\nfunc A() {}\n"
+		);
+		writeFileSync(
+			join(merged, "b.go"),
+			"// Package merged handles B.\npackage merged\n\nfunc B() {}\n"
+		);
+		writeFileSync(join(merged, "c.go"), "package merged\n\nfunc C() {}\n");
+
+		const pkg = (await parser.parsePackage(merged)).get("merged");
+		expect(pkg?.doc).toBe(
+			"Package merged handles A.\n\nPackage merged handles B."
+		);
+		expect(pkg?.packageSymbol?.name).toBe("merged");
+		expect(pkg?.symbols.map((s) => s.name).sort()).toEqual(["A", "B", "C"]);
+
+		writeFileSync(
+			join(merged, "doc.go"),
+			"// Package merged is the canonical overview.\npackage merged\n"
+		);
+		const withDoc = (await parser.parsePackage(merged)).get("merged");
+		expect(withDoc?.doc).toBe("Package merged is the canonical overview.");
+		expect(withDoc?.packageSymbol?.path).toBe(join(merged, "doc.go"));
+	});
+
+	it("should take the package name from the clause, not a comment", async () => {
+		const dir = join(tempDir, "commented");
+		mkdirSync(dir, { recursive: true });
+		writeFileSync(
+			join(dir, "a.go"),
+			"/*\npackage fake\n*/\n\npackage real\n\nfunc A() {}\n"
+		);
+
+		const packages = await parser.parsePackage(dir);
+		expect([...packages.keys()]).toEqual(["real"]);
+		expect(packages.get("real")?.symbols.map((s) => s.name)).toEqual(["A"]);
+	});
+
+	it("should take package imports and directives from one parse", async () => {
+		const dir = join(tempDir, "preprocessed");
+		mkdirSync(dir, { recursive: true });
+		const file = join(dir, "a.go");
+		writeFileSync(
+			file,
+			'package gen\n\nimport "fmt"\n\n{{ generate }}\ntype Color int\n\nfunc Print() { fmt.Println() }\n'
+		);
+
+		const gen = new GoParser();
+		const directive = "//go:generate stringer -type=Color";
+		gen.setPreprocessor((raw) => ({
+			source: raw.replace("{{ generate }}", directive),
+		}));
+		const pkg = (await gen.parsePackage(dir)).get("gen");
+
+		expect(pkg?.directives.get(file)).toEqual([
+			{ name: "go:generate", args: ["stringer", "-type=Color"], line: 5 },
+		]);
+		expect(pkg?.imports.get(file)?.map((i) => i.path)).toEqual(["fmt"]);
+		const language = pkg?.packageSymbol?.metadata?.language;
+		expect(language?.goImports).toBeUndefined();
+	});
+
+	it("should stream symbols of a large generated file", async () => {
+		const count = 10_000;
+		const lines = ["package big", ""];
+		for (let i = 0; i < count; i++) {
+			lines.push(
+				`func Handler${i}(n int) int {`,
+				"\treturn helper(n)",
+				"}",
+				""
+			);
+		}
+		const filePath = createTestFile("big.go", lines.join("\n"));
+
+		const started = performance.now();
+		let firstAt = 0;
+		let seen = 0;
+		let withCalls = 0;
+		for await (const symbol of parser.parseFileStreaming(filePath)) {
+			if (seen === 0) {
+				firstAt = performance.now() - started;
+				expect(symbol.name).toBe("Handler0");
+			}
+			seen++;
+			if (symbol.calls.length > 0 || symbol.metadata?.callRefs?.length) {
+				withCalls++;
+			}
+		}
+		const total = performance.now() - started;
+
+		expect(seen).toBe(count);
+		// Файл больше bodyAnalysisLimit: тела не анализируются
+		expect(withCalls).toBe(0);
+		expect(firstAt).toBeLessThanOrEqual(total);
+		expect(total).toBeLessThan(30_000);
+	});
+
+	it("should analyze bodies below bodyAnalysisLimit", async () => {
+		const filePath = createTestFile(
+			"small.go",
+			"package small\n\nfunc Run() {\n\thelper()\n}\n"
+		);
+
+		const streamed = [];
+		for await (const symbol of parser.parseFileStreaming(filePath)) {
+			streamed.push(symbol);
+		}
+		expect(streamed.map((s) => s.name)).toEqual(["Run"]);
+		expect(streamed[0]?.calls).toEqual(["helper"]);
+
+		const limited = new GoParser({ bodyAnalysisLimit: 10 });
+		const result = await limited.parseSourceWithDiagnostics(
+			filePath,
+			"package small\n\nfunc Run() {\n\thelper()\n}\n"
+		);
+		expect(result.symbols[0]?.calls).toEqual([]);
+		expect(result.diagnostics[0]?.severity).toBe("warning");
+	});
+
+	it("should compute size and complexity metrics for functions", async () => {
+		const filePath = createTestFile(
+			"metrics.go",
+			`package metrics
+
+func Classify(n int, strict bool) string {
+	if n < 0 && strict {
+		return "negative"
+	}
+	for i := 0; i < n; i++ {
+		switch {
+		case i%2 == 0:
+			continue
+		case i%3 == 0 || i%5 == 0:
+			break
+		default:
+		}
+	}
+	return "ok"
+}
+
+func Noop() {}
+`
+		);
+		const result = await parser.parse(filePath);
+		const classify = result.find((s) => s.name === "Classify");
+
+		// 1 + if + && + for + 2 case + ||
+		expect(classify?.metadata?.metrics).toEqual({
+			complexity: 7,
+			loc: 15,
+			parameterCount: 2,
+		});
+		expect(result.find((s) => s.name === "Noop")?.metadata?.metrics).toEqual({
+			complexity: 1,
+			loc: 1,
+			parameterCount: 0,
+		});
+
+		const limited = await new GoParser({ bodyAnalysisLimit: 10 }).parse(
+			filePath
+		);
+		const skipped = limited.find((s) => s.name === "Classify");
+		expect(skipped?.metadata?.metrics?.complexity).toBeUndefined();
+		expect(skipped?.metadata?.metrics?.loc).toBe(15);
+	});
+
+	it("should capture named, unnamed and grouped results", async () => {
+		const filePath = createTestFile(
+			"results.go",
+			`package sample
+
+func f() (n int, err error) { return }
+
+func g() error { return nil }
+
+func h() (a, b int) { return }
+
+func k() (string, error) { return "", nil }
+`
+		);
+		const result = await parser.parse(filePath);
+		const returns = (name: string) =>
+			result.find((s) => s.name === name)?.metadata?.returns;
+
+		expect(returns("f")).toEqual([
+			{ name: "n", type: "int" },
+			{ name: "err", type: "error" },
+		]);
+		expect(returns("g")).toEqual([{ name: null, type: "error" }]);
+		expect(returns("h")).toEqual([
+			{ name: "a", type: "int" },
+			{ name: "b", type: "int" },
+		]);
+		expect(returns("k")).toEqual([
+			{ name: null, type: "string" },
+			{ name: null, type: "error" },
+		]);
+	});
+
+	it("should skip declarations nested deeper than maxDepth", async () => {
+		const depth = 20_000;
+		const source = [
+			"package deep",
+			"",
+			"func Before() int { return 1 }",
+			"",
+			`func Deep() int { return ${"(".repeat(depth)}1${")".repeat(depth)} }`,
+			"",
+			`type Nested ${"[]".repeat(depth)}int`,
+			"",
+			"func After() int { return 2 }",
+			"",
+		].join("\n");
+
+		const started = performance.now();
+		const result = await new GoParser().parseSourceWithDiagnostics(
+			"/virtual/deep.go",
+			source
+		);
+		const elapsed = performance.now() - started;
+
+		expect(result.symbols.map((s) => s.name)).toEqual(["Before", "After"]);
+		expect(
+			result.diagnostics.filter((d) => d.message.includes("maxDepth"))
+		).toHaveLength(2);
+		expect(result.diagnostics[0]?.range.startLine).toBe(5);
+		expect(elapsed).toBeLessThan(10_000);
+	});
+
+	it("should honor a custom maxDepth", async () => {
+		const source = "package p\n\nfunc F() int { return ((((1)))) }\n";
+
+		const strict = await new GoParser({ maxDepth: 5 }).parseSource(
+			"/virtual/p.go",
+			source
+		);
+		const relaxed = await new GoParser().parseSource("/virtual/p.go", source);
+
+		expect(strict).toEqual([]);
+		expect(relaxed.map((s) => s.name)).toEqual(["F"]);
+	});
+
+	it("should tag test files and classify test functions", async () => {
+		const filePath = createTestFile(
+			"cart_kinds_test.go",
+			`package cart
+
+import "testing"
+
+func TestTotal(t *testing.T) {}
+
+func BenchmarkTotal(b *testing.B) {}
+
+func FuzzParse(f *testing.F) {}
+
+// ExampleTotal shows how to sum a cart
+func ExampleTotal() {
+	fmt.Println(Total())
+	// Output: 3
+}
+
+func Testify() {}
+
+func TestWrongSignature(n int) {}
+
+type fixture struct{}
+`
+		);
+		const result = await parser.parse(filePath);
+		const meta = (name: string) =>
+			result.find((s) => s.name === name)?.metadata;
+
+		expect(result.every((s) => s.metadata?.isTest)).toBe(true);
+		expect(meta("TestTotal")?.testKind).toBe("test");
+		expect(meta("BenchmarkTotal")?.testKind).toBe("benchmark");
+		expect(meta("FuzzParse")?.testKind).toBe("fuzz");
+		expect(meta("ExampleTotal")?.testKind).toBe("example");
+		expect(meta("Testify")?.testKind).toBeUndefined();
+		expect(meta("TestWrongSignature")?.testKind).toBeUndefined();
+		expect(meta("fixture")?.testKind).toBeUndefined();
+		expect(result.find((s) => s.name === "ExampleTotal")?.jsDoc).toContain(
+			"shows how to sum a cart"
+		);
+
+		const regular = await parser.parse(fixturePath);
+		expect(regular.some((s) => s.metadata?.isTest)).toBe(false);
+	});
+
+	it("should resolve import aliases across a package and flag conflicts", async () => {
+		const pkgDir = join(tempDir, "aliases");
+		mkdirSync(pkgDir);
+		writeFileSync(
+			join(pkgDir, "a.go"),
+			'package svc\n\nimport (\n\tctx "context"\n\tlog "example.com/a/log"\n)\n\nfunc A(c ctx.Context) { log.Print() }\n'
+		);
+		writeFileSync(
+			join(pkgDir, "b.go"),
+			'package svc\n\nimport (\n\t"context"\n\tlog "example.com/b/log"\n)\n\nfunc B(c context.Context) { log.Print() }\n'
+		);
+
+		const svc = (await parser.parsePackage(pkgDir)).get("svc");
+		const resolver = svc?.importResolver;
+
+		expect(svc?.imports.get(join(pkgDir, "a.go"))).toContainEqual({
+			alias: "ctx",
+			kind: "aliased",
+			name: "ctx",
+			path: "context",
+		});
+		expect(resolver?.resolve("ctx")).toBe("context");
+		expect(resolver?.resolve("context")).toBe("context");
+		expect(resolver?.aliasesOf("context")).toEqual(["context", "ctx"]);
+
+		// log указывает на разные пути: без файла не разрешается
+		expect(resolver?.resolve("log")).toBeUndefined();
+		expect(resolver?.resolve("log", join(pkgDir, "b.go"))).toBe(
+			"example.com/b/log"
+		);
+		expect(resolver?.conflicts.map((c) => c.name)).toEqual(["log"]);
+		expect([...(resolver?.conflicts[0]?.paths.values() ?? [])]).toEqual([
+			"example.com/a/log",
+			"example.com/b/log",
+		]);
+	});
+
+	it("should classify dot and blank imports", async () => {
+		const pkgDir = join(tempDir, "dot-imports");
+		mkdirSync(pkgDir);
+		const file = join(pkgDir, "a.go");
+		writeFileSync(
+			file,
+			'package app\n\nimport (\n\t"context"\n\tctx "context"\n\t. "example.com/models"\n\t_ "embed"\n)\n\nfunc Load(c ctx.Context) *User { return nil }\n'
+		);
+
+		const app = (await parser.parsePackage(pkgDir)).get("app");
+		expect(app?.imports.get(file)?.map((i) => [i.name, i.kind])).toEqual([
+			["context", "normal"],
+			["ctx", "aliased"],
+			[".", "dot"],
+			["_", "blank"],
+		]);
+
+		const resolver = app?.importResolver;
+		expect(resolver?.dotImports(file)).toEqual(["example.com/models"]);
+		expect(resolver?.sideEffectImports()).toEqual(["embed"]);
+		expect(resolver?.resolve("_")).toBeUndefined();
+		expect(resolver?.resolve(".")).toBeUndefined();
+		expect(resolver?.aliasesOf("embed")).toEqual([]);
+
+		const load = app?.symbols.find((s) => s.name === "Load");
+		expect(load?.metadata?.typeMentions).toContainEqual(
+			expect.objectContaining({
+				name: "User",
+				dotImports: ["example.com/models"],
+			})
+		);
+	});
+
+	it("should split generic instantiations into base and type arguments", async () => {
+		const filePath = createTestFile(
+			"generics_inst.go",
+			`package inst
+
+import "example.com/models"
+
+type List[T any] struct{ items []T }
+
+type Index struct {
+	Users  List[*User]
+	ByName map[string]List[models.Account]
+	Pairs  Map[Key, List[Value]]
+}
+
+func (l *List[T]) Push(v T) {}
+
+func Build() List[User] {
+	var pairs Map[Key, Value]
+	_ = pairs
+	return List[User]{}
+}
+`
+		);
+		const result = await parser.parse(filePath);
+		const refs = (name: string) =>
+			result.find((s) => s.name === name)?.metadata?.typeRefs;
+
+		expect(refs("Index")).toEqual([
+			{ name: "List", package: "inst" },
+			{ name: "User", package: "inst", typeArgumentOf: "List" },
+			{ name: "string", builtin: true },
+			{
+				name: "Account",
+				qualifier: "models",
+				package: "models",
+				importPath: "example.com/models",
+				typeArgumentOf: "List",
+			},
+			{ name: "Map", package: "inst" },
+			{ name: "Key", package: "inst", typeArgumentOf: "Map" },
+			{ name: "Value", package: "inst", typeArgumentOf: "List" },
+		]);
+		// T — параметр типа receiver'а, а не тип пакета
+		expect(refs("List.Push")).toEqual([{ name: "List", package: "inst" }]);
+
+		const mentions = result.find((s) => s.name === "Build")?.metadata
+			?.typeMentions;
+		expect(
+			mentions?.map((m) => [m.name, m.typeArgumentOf, m.inBody ?? false])
+		).toEqual([
+			["List", undefined, false],
+			["User", "List", false],
+			["Map", undefined, true],
+			["Key", "Map", true],
+			["Value", "Map", true],
+			["List", undefined, true],
+			["User", "List", true],
+		]);
+	});
+
+	it("should collect TODO/FIXME markers from doc comments and bodies", async () => {
+		const source = `package debt
+
+// Retry calls fn until it succeeds.
+// TODO(alice): add backoff
+func Retry(fn func() error) {
+	// FIXME: unbounded loop
+	for fn() != nil {
+	}
+	/* HACK works around
+	   XXX flaky CI */
+}
+
+const (
+	// TODO: rename
+	A = 1
+	B = 2 // todo lower case is not a marker
+)
+`;
+		const markersOf = (symbols, name: string) =>
+			symbols.find((s) => s.name === name)?.metadata?.markers;
+		const result = await parser.parseSource("/virtual/debt.go", source);
+
+		expect(markersOf(result, "Retry")).toEqual([
+			{ kind: "TODO", line: 4, text: "add backoff" },
+			{ kind: "FIXME", line: 6, text: "unbounded loop" },
+			{ kind: "HACK", line: 9, text: "works around" },
+			{ kind: "XXX", line: 10, text: "flaky CI" },
+		]);
+		expect(markersOf(result, "A")).toEqual([
+			{ kind: "TODO", line: 14, text: "rename" },
+		]);
+		expect(markersOf(result, "B")).toBeUndefined();
+
+		const custom = new GoParser({ markers: ["FIXME"] });
+		expect(
+			markersOf(await custom.parseSource("/virtual/debt.go", source), "Retry")
+		).toEqual([{ kind: "FIXME", line: 6, text: "unbounded loop" }]);
+
+		// Тела не анализируются: остаются только маркеры doc comment
+		const limited = new GoParser({ bodyAnalysisLimit: 10 });
+		expect(
+			markersOf(await limited.parseSource("/virtual/debt.go", source), "Retry")
+		).toEqual([{ kind: "TODO", line: 4, text: "add backoff" }]);
+
+		const sample = await parser.parse(fixturePath);
+		expect(markersOf(sample, "User.privateMethod")).toBeUndefined();
+	});
+
+	it("should index Unicode identifiers with full names and ranges", async () => {
+		const result = await parser.parse(
+			join(import.meta.dir, "fixtures", "go", "unicode.go")
+		);
+		const byName = (name: string) => result.find((s) => s.name === name);
+
+		expect(result.map((s) => s.name)).toEqual([
+			"Приветствие",
+			"Größe",
+			"Größe.Fläche",
+			"vérifier",
+			"名前",
+			"𝛂Count",
+		]);
+		expect(byName("Приветствие")?.metadata?.isExported).toBe(true);
+		expect(byName("Größe")?.metadata?.isExported).toBe(true);
+		expect(byName("vérifier")?.metadata?.isExported).toBe(false);
+		expect(byName("名前")?.metadata?.isExported).toBe(false);
+		expect(byName("Größe")?.metadata?.fields?.map((f) => f.name)).toEqual([
+			"Breite",
+			"Höhe",
+		]);
+		expect(byName("Приветствие")?.metadata?.parameters).toEqual([
+			{ name: "имя", type: "string" },
+		]);
+
+		// Колонки — UTF-16 code units: "func (g Größe) " занимает 15
+		expect(byName("Größe.Fläche")?.metadata?.nameRange).toEqual({
+			startLine: 15,
+			startCol: 15,
+			endLine: 15,
+			endCol: 21,
+		});
+		expect(byName("𝛂Count")?.metadata?.nameRange).toEqual({
+			startLine: 29,
+			startCol: 5,
+			endLine: 29,
+			endCol: 12,
+		});
+	});
+
+	it("should collect tool directives and link them to declarations", async () => {
+		const source = [
+			"//go:build linux",
+			"",
+			"package assets",
+			"",
+			'import "embed"',
+			"",
+			"// Templates holds the HTML templates.",
+			"//",
+			"//go:embed templates/*.html static",
+			"var Templates embed.FS",
+			"",
+			"//go:generate stringer -type=Color",
+			"type Color int",
+			"",
+			"var legacy = load() //nolint:errcheck,gosec // startup only",
+			"",
+			"// go:generate is mentioned here, but not as a directive",
+			"//TODO: not a directive either",
+			"func load() int {",
+			'	//go:generate sh -c "echo hi"',
+			"	return 0",
+			"}",
+			"",
+			"//export Exported",
+			"func Exported() {}",
+			"",
+		].join("\n");
+		const filePath = createTestFile("directives.go", source);
+		const symbols = await parser.parse(filePath);
+		const byName = (name: string) => symbols.find((s) => s.name === name);
+
+		expect(parser.parseDirectives(source)).toEqual([
+			{ name: "go:build", args: ["linux"], line: 1 },
+			{ name: "go:embed", args: ["templates/*.html", "static"], line: 9 },
+			{ name: "go:generate", args: ["stringer", "-type=Color"], line: 12 },
+			{ name: "nolint", args: ["errcheck", "gosec"], line: 15 },
+			{ name: "go:generate", args: ["sh", "-c", "echo hi"], line: 20 },
+			{ name: "export", args: ["Exported"], line: 24 },
+		]);
+		expect(byName("Templates")?.metadata?.directives).toEqual([
+			{ name: "go:embed", args: ["templates/*.html", "static"], line: 9 },
+		]);
+		expect(byName("Color")?.metadata?.directives?.[0]?.name).toBe(
+			"go:generate"
+		);
+		expect(byName("legacy")?.metadata?.directives?.[0]?.args).toEqual([
+			"errcheck",
+			"gosec",
+		]);
+		expect(byName("Exported")?.metadata?.directives?.[0]?.name).toBe("export");
+		expect(byName("load")?.metadata?.directives).toBeUndefined();
+	});
+
+	it("should expose directives per file in parsePackage", async () => {
+		const pkgDir = join(tempDir, "pkg-directives");
+		mkdirSync(pkgDir);
+		writeFileSync(
+			join(pkgDir, "gen.go"),
+			"package gen\n\n//go:generate mockgen -source=gen.go\nfunc Run() {}\n"
+		);
+
+		const pkg = (await parser.parsePackage(pkgDir)).get("gen");
+
+		expect(pkg?.directives.get(join(pkgDir, "gen.go"))).toEqual([
+			{ name: "go:generate", args: ["mockgen", "-source=gen.go"], line: 3 },
+		]);
+	});
+
+	it("should render canonical one-line signatures", async () => {
+		const result = await parser.parse(fixturePath);
+		const signature = (name: string) =>
+			result.find((s) => s.name === name)?.metadata?.signature;
+
+		expect(signature("ComplexFunction")).toBe(
+			"func ComplexFunction(ctx context.Context, id int, options ...string) (string, error)"
+		);
+		expect(signature("Sum")).toBe("func Sum[T Number](a, b T) T");
+		expect(signature("User.GetName")).toBe("func (u *User) GetName() string");
+		expect(signature("User.SetAge")).toBe("func (u User) SetAge(age int)");
+	});
+
+	it("should keep signatures stable across reformatting", async () => {
+		const compact = createTestFile(
+			"signature_compact.go",
+			[
+				"package sig",
+				"",
+				"func Load(ctx context.Context, a int, b int) (n int, err error) {",
+				"\treturn 0, nil",
+				"}",
+				"",
+				"func (s *Store[K, V]) Get(key K) (V, bool) { var v V; return v, false }",
+				"",
+				"func Map[K comparable, V any](m map[K]V, f func(K) V) []V { return nil }",
+				"",
+				"var Handle = func(w io.Writer, args ...string) error { return nil }",
+				"",
+			].join("\n")
+		);
+		const wrapped = createTestFile(
+			"signature_wrapped.go",
+			[
+				"package sig",
+				"",
+				"func Load(",
+				"\tctx context.Context,",
+				"\ta, b int,",
+				") (n int, err error) {",
+				"\treturn 0, nil",
+				"}",
+				"",
+				"func (s *Store[K, V]) Get(",
+				"\tkey K,",
+				") (V, bool) {",
+				"\tvar v V",
+				"\treturn v, false",
+				"}",
+				"",
+				"func Map[",
+				"\tK comparable,",
+				"\tV any,",
+				"](m map[K]V, f func(",
+				"\tK,",
+				") V) []V {",
+				"\treturn nil",
+				"}",
+				"",
+				"var Handle = func(",
+				"\tw io.Writer,",
+				"\targs ...string,",
+				") error {",
+				"\treturn nil",
+				"}",
+				"",
+			].join("\n")
+		);
+
+		const signatures = async (filePath: string) =>
+			(await parser.parse(filePath)).map((s) => s.metadata?.signature);
+
+		expect(await signatures(compact)).toEqual([
+			"func Load(ctx context.Context, a, b int) (n int, err error)",
+			"func (s *Store[K, V]) Get(key K) (V, bool)",
+			"func Map[K comparable, V any](m map[K]V, f func(K) V) []V",
+			"var Handle func(w io.Writer, args ...string) error",
+		]);
+		expect(await signatures(wrapped)).toEqual(await signatures(compact));
+	});
+
+	it("should parse BOM + CRLF files like their LF equivalent", async () => {
+		const fixture = (name: string) =>
+			join(import.meta.dir, "fixtures", "go", name);
+		const shape = (symbols) =>
+			symbols.map((s) => ({
+				name: s.name,
+				startLine: s.startLine,
+				endLine: s.endLine,
+				body: s.body,
+				jsDoc: s.jsDoc,
+				packageName: s.metadata?.packageName,
+				range: s.metadata?.range,
+				nameRange: s.metadata?.nameRange,
+			}));
+
+		const lf = await parser.parse(fixture("line_endings.go"));
+		const crlf = await parser.parse(fixture("line_endings_crlf_bom.go"));
+
+		expect(lf.map((s) => s.name)).toEqual([
+			"Greeter",
+			"Greeter.Greet",
+			"Low",
+			"High",
+			"banner",
+			"Shout",
+		]);
+		expect(shape(crlf)).toEqual(shape(lf));
+		expect(crlf[0]?.metadata?.packageName).toBe("endings");
+		expect(crlf[1]?.metadata?.range).toEqual({
+			startLine: 19,
+			startCol: 0,
+			endLine: 21,
+			endCol: 1,
+		});
+		expect(crlf[4]?.body).toBe("banner = `line one\nline two`");
+	});
+
+	it("should group BOM + CRLF files by package clause", async () => {
+		const pkgDir = join(tempDir, "pkg-crlf");
+		mkdirSync(pkgDir);
+		writeFileSync(
+			join(pkgDir, "win.go"),
+			"\uFEFFpackage win\r\n\r\nfunc Run() {}\r\n"
+		);
+
+		const packages = await parser.parsePackage(pkgDir);
+
+		expect([...packages.keys()]).toEqual(["win"]);
+		expect(packages.get("win")?.symbols.map((s) => s.endLine)).toEqual([3]);
+	});
 });
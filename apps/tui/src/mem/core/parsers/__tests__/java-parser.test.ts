@@ -0,0 +1,163 @@
+// @ts-nocheck
+import { describe, expect, it } from "bun:test";
+import { join } from "node:path";
+import { JavaParser } from "../java-parser.ts";
+
+describe("JavaParser", () => {
+	const parser = new JavaParser();
+	const fixturePath = join(import.meta.dir, "fixtures", "java", "Sample.java");
+
+	const find = async (name: string) =>
+		(await parser.parse(fixturePath)).find((s) => s.name === name);
+
+	it("should extract classes, interfaces, enums and records", async () => {
+		const result = await parser.parse(fixturePath);
+		const types = result
+			.filter((s) => s.symbolType !== "method" && s.symbolType !== "constant")
+			.map((s) => [s.name, s.symbolType]);
+
+		expect(types).toEqual([
+			["UserRepository", "class"],
+			["UserRepository.Page", "class"],
+			["UserRepository.Cursor", "class"],
+			["Repository", "interface"],
+			["Status", "enum"],
+			["Point", "class"],
+		]);
+		expect((await find("Point"))?.metadata?.modifiers).toEqual(["record"]);
+		expect((await find("UserRepository"))?.metadata?.packageName).toBe(
+			"com.example.users"
+		);
+	});
+
+	it("should map modifiers to visibility", async () => {
+		const visibility = async (name: string) =>
+			(await find(name))?.metadata?.visibility;
+
+		expect(await visibility("UserRepository")).toBe("public");
+		expect(await visibility("Repository")).toBe("package");
+		expect(await visibility("UserRepository.max")).toBe("protected");
+		expect(await visibility("UserRepository.log")).toBe("private");
+		expect(await visibility("UserRepository.reset")).toBe("package");
+		// Члены интерфейса неявно public
+		expect(await visibility("Repository.findById")).toBe("public");
+		expect((await find("Repository"))?.metadata?.isExported).toBe(false);
+		expect((await find("UserRepository.max"))?.metadata?.isExported).toBe(true);
+	});
+
+	it("should link methods and constructors to their class", async () => {
+		const findById = await find("UserRepository.findById");
+		expect(findById?.symbolType).toBe("method");
+		expect(findById?.metadata?.receiver).toBe("UserRepository");
+		expect(findById?.metadata?.returnType).toBe("T");
+		expect(findById?.metadata?.parameters).toEqual([
+			{ name: "id", type: "long", variadic: undefined },
+		]);
+		expect(findById?.calls).toContain("findFirst");
+
+		const ctor = await find("UserRepository.UserRepository");
+		expect(ctor?.symbolType).toBe("method");
+		expect(ctor?.metadata?.modifiers).toContain("constructor");
+
+		const log = await find("UserRepository.log");
+		expect(log?.metadata?.parameters?.[1]).toEqual({
+			name: "args",
+			type: "Object",
+			variadic: true,
+		});
+
+		const getNumber = await find("UserRepository.Page.getNumber");
+		expect(getNumber?.metadata?.receiver).toBe("UserRepository.Page");
+		expect((await find("Point.length"))?.metadata?.returnType).toBe("double");
+		expect((await find("Status.Status"))?.metadata?.receiver).toBe("Status");
+	});
+
+	it("should extract generics with bounds", async () => {
+		expect((await find("UserRepository"))?.metadata?.genericParams).toEqual([
+			{ name: "T", constraint: "User" },
+		]);
+		expect((await find("UserRepository.max"))?.metadata?.genericParams).toEqual(
+			[{ name: "R", constraint: "Comparable<R>" }]
+		);
+		expect((await find("Repository"))?.metadata?.genericParams).toEqual([
+			{ name: "T", constraint: undefined },
+		]);
+	});
+
+	it("should extract Javadoc into docComment", async () => {
+		const repo = await find("UserRepository");
+		expect(repo?.metadata?.docComment).toBe(
+			"Repository of users.\n\n@param <T> user type"
+		);
+		expect((await find("UserRepository.findById"))?.metadata?.docComment).toBe(
+			"Find a user by id."
+		);
+		expect((await find("UserRepository.PAGE_SIZE"))?.jsDoc).toBe(
+			"Default page size."
+		);
+		expect((await find("UserRepository.log"))?.metadata?.docComment).toBe(
+			undefined
+		);
+	});
+
+	it("should capture annotations as decorators", async () => {
+		const findById = await find("UserRepository.findById");
+		expect(findById?.metadata?.decorators).toEqual([
+			{ name: "Override", arguments: undefined },
+		]);
+		expect((await find("UserRepository.max"))?.metadata?.decorators).toEqual([
+			{ name: "Deprecated", arguments: undefined },
+			{ name: "SuppressWarnings", arguments: ['"unchecked"'] },
+		]);
+		expect((await find("UserRepository.max"))?.metadata?.modifiers).toEqual([
+			"static",
+		]);
+	});
+
+	it("should extract fields of classes and records", async () => {
+		const fields = (await find("UserRepository"))?.metadata?.fields?.map(
+			(f) => [f.name, f.type, f.exported]
+		);
+		expect(fields).toEqual([
+			["PAGE_SIZE", "int", true],
+			["users", "List<T>", false],
+			["name", "String", true],
+			["count", "int", false],
+			["sizes", "int[]", false],
+		]);
+		expect(
+			(await find("Point"))?.metadata?.fields?.map((f) => [f.name, f.type])
+		).toEqual([
+			["x", "int"],
+			["y", "int"],
+		]);
+	});
+
+	it("should extract constants from static final fields, interfaces and enums", async () => {
+		const result = await parser.parse(fixturePath);
+		const constants = result
+			.filter((s) => s.symbolType === "constant")
+			.map((s) => [s.name, s.metadata?.returnType, s.metadata?.parent]);
+
+		expect(constants).toEqual([
+			["UserRepository.PAGE_SIZE", "int", "UserRepository"],
+			["Repository.MAX_RESULTS", "int", "Repository"],
+			["Status.ACTIVE", "Status", "Status"],
+			["Status.INACTIVE", "Status", "Status"],
+		]);
+	});
+
+	it("should nest inner classes under their outer class", async () => {
+		const page = await find("UserRepository.Page");
+		expect(page?.metadata?.parent).toBe("UserRepository");
+		expect(page?.metadata?.modifiers).toEqual(["static"]);
+		expect(page?.metadata?.nameRange).toMatchObject({
+			startLine: 45,
+			startCol: 24,
+		});
+		expect((await find("UserRepository.Cursor"))?.metadata?.visibility).toBe(
+			"private"
+		);
+		expect((await find("UserRepository"))?.metadata?.parent).toBeUndefined();
+	});
+});
@@ -0,0 +1,110 @@
+// @ts-nocheck
+import { describe, expect, it } from "bun:test";
+import { join } from "node:path";
+import { JavaScriptParser } from "../javascript-parser.ts";
+import { parserRegistry } from "../parser-registry.ts";
+
+describe("JavaScriptParser", () => {
+	const parser = new JavaScriptParser();
+	const fixturePath = join(
+		import.meta.dir,
+		"fixtures",
+		"javascript",
+		"sample.js"
+	);
+
+	it("should extract functions, classes, methods and constants", async () => {
+		const result = await parser.parse(fixturePath);
+		const byName = new Map(result.map((s) => [s.name, s.symbolType]));
+
+		expect(byName.get("greet")).toBe("function");
+		expect(byName.get("untyped")).toBe("function");
+		expect(byName.get("double")).toBe("function");
+		expect(byName.get("load")).toBe("function");
+		expect(byName.get("MAX_ITEMS")).toBe("constant");
+		expect(byName.get("Cart")).toBe("class");
+		expect(byName.get("Cart.add")).toBe("method");
+		expect(byName.get("Helper")).toBe("class");
+		// require() — импорт, а не символ
+		expect(byName.has("path")).toBe(false);
+	});
+
+	it("should leave param types null without JSDoc", async () => {
+		const result = await parser.parse(fixturePath);
+		const untyped = result.find((s) => s.name === "untyped");
+
+		expect(untyped?.metadata?.parameters).toEqual([
+			{ name: "a", type: null, isOptional: false },
+			{ name: "b", type: null, isOptional: false },
+			{ name: "rest", type: null, isOptional: false, variadic: true },
+		]);
+	});
+
+	it("should take param and return types from JSDoc", async () => {
+		const result = await parser.parse(fixturePath);
+		const greet = result.find((s) => s.name === "greet");
+
+		expect(greet?.metadata?.parameters?.[0]).toEqual({
+			name: "name",
+			type: "string",
+			isOptional: false,
+		});
+		expect(greet?.metadata?.parameters?.[1]).toMatchObject({
+			name: "times",
+			type: "number",
+			isOptional: true,
+			defaultValue: "1",
+		});
+		expect(greet?.metadata?.returnType).toBe("string");
+		expect(greet?.jsDoc).toContain("Greets a user");
+
+		const add = result.find((s) => s.name === "Cart.add");
+		expect(add?.metadata?.parameters?.[0]?.type).toBe("Item");
+		expect(add?.metadata?.receiver).toBe("Cart");
+	});
+
+	it("should detect ES and CommonJS exports", async () => {
+		const result = await parser.parse(fixturePath);
+		const exported = (name: string) =>
+			result.find((s) => s.name === name)?.metadata?.isExported;
+
+		expect(exported("greet")).toBe(true);
+		expect(exported("double")).toBe(true);
+		expect(exported("Cart")).toBe(true);
+		expect(exported("Helper")).toBe(true);
+		expect(exported("untyped")).toBe(true);
+		expect(exported("format")).toBe(true);
+		expect(exported("parse")).toBe(true);
+		expect(exported("VERSION")).toBe(true);
+		expect(exported("load")).toBe(false);
+		expect(exported("Cart.#recalculate")).toBe(false);
+	});
+
+	it("should detect async functions and resolve imports", async () => {
+		const result = await parser.parse(fixturePath);
+		const load = result.find((s) => s.name === "load");
+
+		expect(load?.metadata?.isAsync).toBe(true);
+		expect(load?.imports).toContain("readFile:node:fs/promises");
+		expect(load?.imports).toContain("path:node:path");
+	});
+
+	it("should treat capitalized functions in .jsx as components", async () => {
+		const result = await parser.parseSource(
+			"/tmp/Button.jsx",
+			"export function Button({ label }) {\n\treturn <button>{label}</button>;\n}\n"
+		);
+
+		expect(result[0]?.name).toBe("Button");
+		expect(result[0]?.symbolType).toBe("component");
+		expect(result[0]?.metadata?.parameters?.[0]?.type).toBeNull();
+	});
+
+	it("should be registered for JavaScript extensions", () => {
+		for (const ext of [".js", ".jsx", ".mjs", ".cjs"]) {
+			expect(parserRegistry.parserForExtension(ext)).toBeInstanceOf(
+				JavaScriptParser
+			);
+		}
+	});
+});
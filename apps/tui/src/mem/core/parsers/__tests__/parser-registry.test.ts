@@ -0,0 +1,191 @@
+// @ts-nocheck
+import { describe, expect, it } from "bun:test";
+import { BaseParser } from "../base-parser.ts";
+import { CppParser } from "../cpp-parser.ts";
+import { GoParser } from "../go-parser.ts";
+import { JavaParser } from "../java-parser.ts";
+import { createDefaultRegistry, ParserRegistry } from "../parser-registry.ts";
+import { PythonParser } from "../python-parser.ts";
+import { TypeScriptAstParser } from "../ts-ast-parser.ts";
+import type { EnhancedCodeSymbol } from "../types.ts";
+
+class CustomParser extends BaseParser {
+	protected async doParse(_filePath: string): Promise<EnhancedCodeSymbol[]> {
+		return [];
+	}
+}
+
+describe("ParserRegistry", () => {
+	it("should dispatch built-in parsers by file extension", () => {
+		const registry = createDefaultRegistry();
+
+		expect(registry.parserForFile("pkg/sample.go")).toBeInstanceOf(GoParser);
+		expect(registry.parserForFile("src/app.tsx")).toBeInstanceOf(
+			TypeScriptAstParser
+		);
+		expect(registry.parserForFile("main.py")).toBeInstanceOf(PythonParser);
+		expect(registry.parserForFile("src/App.java")).toBeInstanceOf(JavaParser);
+		expect(registry.parserForFile("ext/module.c")).toBeInstanceOf(CppParser);
+		expect(registry.parserForFile("lib/buffer.hpp")).toBeInstanceOf(CppParser);
+	});
+
+	it("should return null for unknown extensions", () => {
+		const registry = createDefaultRegistry();
+
+		expect(registry.parserForFile("README.md")).toBeNull();
+		expect(registry.parserForFile("Makefile")).toBeNull();
+	});
+
+	it("should let one parser claim multiple extensions", () => {
+		const registry = new ParserRegistry();
+		registry.register([".foo", "bar"], () => new CustomParser());
+
+		expect(registry.parserForFile("a.foo")).toBeInstanceOf(CustomParser);
+		expect(registry.parserForFile("a.BAR")).toBeInstanceOf(CustomParser);
+		expect(registry.getSupportedExtensions()).toEqual([".foo", ".bar"]);
+	});
+
+	it("should override registrations with last-wins semantics", () => {
+		const registry = createDefaultRegistry();
+		registry.register(".go", () => new CustomParser());
+
+		expect(registry.parserForFile("sample.go")).toBeInstanceOf(CustomParser);
+	});
+
+	it("should expose parser version and capabilities", () => {
+		const registry = createDefaultRegistry();
+		const go = registry.parserForFile("main.go");
+		const py = registry.parserForFile("main.py");
+
+		expect(go.version).toBe("1");
+		expect(go.capabilities.has("callGraph")).toBe(true);
+		expect(go.capabilities.has("fields")).toBe(true);
+		expect(py.capabilities.has("docComments")).toBe(true);
+		expect(py.capabilities.has("callGraph")).toBe(false);
+		expect(new CustomParser().capabilities.size).toBe(0);
+	});
+
+	it("should run registered preprocessors and map lines back", async () => {
+		const registry = new ParserRegistry();
+		registry.register([".go", ".gotmpl"], () => new GoParser());
+		// {{ ... }} директивы шаблона вырезаются целыми строками
+		registry.registerPreprocessor("gotmpl", (raw) => {
+			const lines: string[] = [];
+			const lineMap: number[] = [];
+			raw.split("\n").forEach((line, i) => {
+				if (!line.startsWith("{{")) {
+					lines.push(line);
+					lineMap.push(i + 1);
+				}
+			});
+			return { source: lines.join("\n"), lineMap };
+		});
+
+		const source =
+			"package gen\n\n{{ range .Types }}\n{{ end }}\n\nfunc Generated() int {\n\treturn helper()\n}\n";
+		const parser = registry.parserForFile("types.gotmpl");
+		const result = await parser.parseSourceWithDiagnostics(
+			"types.gotmpl",
+			source
+		);
+		const generated = result.symbols.find((s) => s.name === "Generated");
+
+		expect(result.diagnostics).toEqual([]);
+		expect(generated?.startLine).toBe(6);
+		expect(generated?.endLine).toBe(8);
+		expect(generated?.metadata?.range?.startLine).toBe(6);
+		expect(generated?.metadata?.callRefs?.[0]?.line).toBe(7);
+
+		// Без препроцессора для расширения ничего не меняется
+		const plain = await registry
+			.parserForFile("types.go")
+			.parseSourceWithDiagnostics("types.go", source);
+		expect(plain.diagnostics.length).toBeGreaterThan(0);
+	});
+
+	it("should map directive lines back through the lineMap", async () => {
+		const parser = new GoParser();
+		// Строки-директивы шаблона `{{ ... }}` вырезаются
+		parser.setPreprocessor((raw) => {
+			const lines: string[] = [];
+			const lineMap: number[] = [];
+			raw.split("\n").forEach((line, i) => {
+				if (!line.startsWith("{{")) {
+					lines.push(line);
+					lineMap.push(i + 1);
+				}
+			});
+			return { source: lines.join("\n"), lineMap };
+		});
+
+		const source =
+			"package gen\n\n{{ if .Stringer }}\n{{ end }}\n//go:generate stringer -type=Color\ntype Color int\n\nfunc Run() {} //nolint:errcheck\n";
+		const symbols = await parser.parseSource("colors.gotmpl", source);
+		const color = symbols.find((s) => s.name === "Color");
+		const run = symbols.find((s) => s.name === "Run");
+
+		expect(color?.metadata?.directives).toEqual([
+			{ name: "go:generate", args: ["stringer", "-type=Color"], line: 5 },
+		]);
+		const runDirectives = run?.metadata?.directives ?? [];
+		expect(runDirectives.map((d) => [d.name, d.line])).toEqual([
+			["nolint", 8],
+		]);
+	});
+
+	it("should pass per-language options to parsers", async () => {
+		const registry = createDefaultRegistry({
+			go: { includeTests: true },
+			python: { underscoreIsPrivate: false },
+		});
+		const source = "def _helper():\n    pass\n\nclass Box:\n    def _peek(self):\n        pass\n";
+
+		const py = registry.parserForFile("box.py");
+		const symbols = (await py.parseSourceWithDiagnostics("box.py", source))
+			.symbols;
+		const visibility = (name: string) =>
+			symbols.find((s) => s.name === name)?.metadata?.visibility;
+		expect(visibility("_helper")).toBe("public");
+		expect(visibility("Box._peek")).toBe("public");
+
+		const defaults = await createDefaultRegistry()
+			.parserForFile("box.py")
+			.parseSourceWithDiagnostics("box.py", source);
+		expect(
+			defaults.symbols.find((s) => s.name === "_helper")?.metadata?.visibility
+		).toBe("private");
+
+		// Опции входят в ключ версии кеша парсинга
+		expect(py.optionsKey).not.toBe("");
+		expect(registry.parserForFile("main.go").optionsKey).toContain(
+			"includeTests"
+		);
+		expect(registry.parserForFile("main.rs").optionsKey).toBe("");
+	});
+
+	it("should reject unknown option keys and languages with a clear error", () => {
+		expect(() =>
+			createDefaultRegistry({ python: { underscoreIsPrivat: false } })
+		).toThrow(
+			"Unknown python parser options: underscoreIsPrivat (supported: underscoreIsPrivate)"
+		);
+		expect(() => createDefaultRegistry({ rust: { strict: true } })).toThrow(
+			"Unknown rust parser options: strict (supported: none)"
+		);
+		expect(() => createDefaultRegistry({ cobol: {} })).toThrow(
+			"Unknown parser language: cobol"
+		);
+		expect(() => new GoParser({ includeTest: true })).toThrow(
+			"Unknown go parser options: includeTest"
+		);
+
+		// Неверные опции не заменяют уже действующие
+		const registry = createDefaultRegistry({ go: { includeTests: true } });
+		expect(() =>
+			registry.setParserOptions({ go: { maxDepth: 2, bogus: 1 } })
+		).toThrow("bogus");
+		expect(registry.getParserOptions()).toEqual({
+			go: { includeTests: true },
+		});
+	});
+});
@@ -1,5 +1,7 @@
 // @ts-nocheck
 import { describe, expect, it } from "bun:test";
+import { rmSync, writeFileSync } from "node:fs";
+import { tmpdir } from "node:os";
 import { join } from "node:path";
 import { PythonParser } from "../python-parser.ts";
 
@@ -106,4 +108,95 @@ describe("PythonParser", () => {
 		expect(dataProcessor).toBeDefined();
 		expect(dataProcessor?.imports).toContain("BaseProcessor");
 	});
+
+	it("should link methods to their class as receiver", async () => {
+		const result = await parser.parse(fixturePath);
+
+		const addMethod = result.find((s) => s.name === "Calculator.add");
+		expect(addMethod?.metadata?.receiver).toBe("Calculator");
+		expect(addMethod?.metadata?.docComment).toBe("Public method");
+
+		// Методы не дублируются как top-level функции
+		expect(result.find((s) => s.name === "add")).toBeUndefined();
+	});
+
+	it("should extract decorated methods", async () => {
+		const result = await parser.parse(fixturePath);
+		const property = result.find((s) => s.name === "Calculator.current_value");
+
+		expect(property).toBeDefined();
+		expect(property?.metadata?.language?.pythonDecorators).toEqual([
+			"property",
+		]);
+	});
+
+	it("should handle dedent with blank and comment lines", async () => {
+		const filePath = join(tmpdir(), `py-dedent-${Date.now()}.py`);
+		writeFileSync(
+			filePath,
+			`class Outer:
+    def first(self):
+        x = 1
+
+        # comment inside body
+        return x
+
+    # comment between methods
+
+    def second(self):
+        pass
+
+
+def after_class():
+    pass
+`
+		);
+		try {
+			const result = await parser.parse(filePath);
+
+			expect(result.find((s) => s.name === "Outer.first")).toBeDefined();
+			expect(
+				result.find((s) => s.name === "Outer.second")?.metadata?.receiver
+			).toBe("Outer");
+			const afterClass = result.find((s) => s.name === "after_class");
+			expect(afterClass?.symbolType).toBe("function");
+			expect(afterClass?.metadata?.receiver).toBeUndefined();
+		} finally {
+			rmSync(filePath, { force: true });
+		}
+	});
+
+	it("should derive visibility from the underscore convention", async () => {
+		const filePath = join(tmpdir(), `python-vis-${Date.now()}.py`);
+		writeFileSync(
+			filePath,
+			`_CACHE_SIZE = 10
+
+def public_api():
+    pass
+
+def _helper():
+    pass
+
+class _Internal:
+    def __init__(self):
+        pass
+`
+		);
+		try {
+			const result = await parser.parse(filePath);
+			const meta = (name: string) =>
+				result.find((s) => s.name === name)?.metadata;
+
+			expect(meta("public_api")?.visibility).toBe("public");
+			expect(meta("public_api")?.isExported).toBe(true);
+			expect(meta("_helper")?.visibility).toBe("private");
+			expect(meta("_helper")?.isExported).toBe(false);
+			expect(meta("_CACHE_SIZE")?.isExported).toBe(false);
+			expect(meta("_Internal")?.visibility).toBe("private");
+			expect(meta("_Internal.__init__")?.visibility).toBe("public");
+		} finally {
+			rmSync(filePath, { force: true });
+		}
+	});
 });
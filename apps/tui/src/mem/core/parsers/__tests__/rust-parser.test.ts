@@ -1,5 +1,7 @@
 // @ts-nocheck
 import { describe, expect, it } from "bun:test";
+import { rmSync, writeFileSync } from "node:fs";
+import { tmpdir } from "node:os";
 import { join } from "node:path";
 import { RustParser } from "../rust-parser.ts";
 
@@ -175,4 +177,50 @@ describe("RustParser", () => {
 
 		expect(simpleFunc?.jsDoc).toContain("Simple function");
 	});
+
+	it("should link impl methods to their receiver type", async () => {
+		const result = await parser.parse(fixturePath);
+
+		const newMethod = result.find((s) => s.name === "User.new");
+		expect(newMethod?.metadata?.receiver).toBe("User");
+
+		const processMethod = result.find((s) => s.name === "User.process");
+		expect(processMethod?.metadata?.receiver).toBe("User");
+		expect(processMethod?.metadata?.language?.rustTraitImpl).toBe("Processor");
+
+		const containerMethods = result.filter(
+			(s) => s.metadata?.receiver === "Container"
+		);
+		expect(containerMethods.length).toBeGreaterThan(0);
+	});
+
+	it("should treat pub(crate) as exported and capture doc comments", async () => {
+		const filePath = join(tmpdir(), `rust-vis-${Date.now()}.rs`);
+		writeFileSync(
+			filePath,
+			`//! Crate level docs
+pub(crate) fn crate_visible() {}
+
+/// Documented item
+fn private_fn<T: Clone>(value: T) {}
+`
+		);
+		try {
+			const result = await parser.parse(filePath);
+
+			const crateVisible = result.find((s) => s.name === "crate_visible");
+			expect(crateVisible?.metadata?.visibility).toBe("internal");
+			expect(crateVisible?.metadata?.isExported).toBe(true);
+			expect(crateVisible?.metadata?.docComment).toBe("Crate level docs");
+
+			const privateFn = result.find((s) => s.name === "private_fn");
+			expect(privateFn?.metadata?.isExported).toBe(false);
+			expect(privateFn?.metadata?.docComment).toBe("Documented item");
+			expect(privateFn?.metadata?.genericParams).toEqual([
+				{ name: "T", constraint: "Clone" },
+			]);
+		} finally {
+			rmSync(filePath, { force: true });
+		}
+	});
 });
@@ -0,0 +1,28 @@
+// @ts-nocheck
+import { describe, expect, it } from "bun:test";
+import { decodeSource, normalizeSource } from "../source-text.ts";
+
+describe("source text", () => {
+	it("should strip a leading BOM and convert CRLF to LF", () => {
+		expect(normalizeSource("\uFEFFpackage a\r\n\r\nfunc F() {}\r\n")).toBe(
+			"package a\n\nfunc F() {}\n"
+		);
+		// Одиночный \r и BOM не в начале файла не трогаются
+		expect(normalizeSource("a\rb\uFEFF\n")).toBe("a\rb\uFEFF\n");
+	});
+
+	it("should decode UTF-8 and UTF-16 by BOM", () => {
+		const text = "package ä\r\n";
+		const units = [...text].map((c) => c.charCodeAt(0));
+		const utf16le = new Uint8Array([
+			0xff,
+			0xfe,
+			...units.flatMap((unit) => [unit & 0xff, unit >> 8]),
+		]);
+
+		expect(decodeSource(new TextEncoder().encode(`\uFEFF${text}`))).toBe(
+			"package ä\n"
+		);
+		expect(decodeSource(utf16le)).toBe("package ä\n");
+	});
+});
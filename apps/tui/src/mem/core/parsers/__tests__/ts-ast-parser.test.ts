@@ -58,10 +58,53 @@ const INTERNAL = 42; // not exported
 		const result = await parser.parse(filePath);
 
 		const constants = result.filter((s) => s.symbolType === "constant");
-		expect(constants).toHaveLength(2);
+		expect(constants).toHaveLength(3);
 		expect(constants[0]?.name).toBe("MAX_SIZE");
+		expect(constants[0]?.metadata?.isExported).toBe(true);
 		expect(constants[1]?.name).toBe("API_URL");
 		expect(constants[1]?.metadata?.returnType).toBe("string");
+		expect(constants[2]?.name).toBe("INTERNAL");
+		expect(constants[2]?.metadata?.isExported).toBe(false);
+	});
+
+	it("should extract top-level let as variables", async () => {
+		const code = `
+let counter = 0;
+export let current: string | null = null;
+		`;
+		const filePath = createTestFile("test-let.ts", code);
+		const result = await parser.parse(filePath);
+
+		const variables = result.filter((s) => s.symbolType === "variable");
+		expect(variables.map((v) => v.name)).toEqual(["counter", "current"]);
+		expect(variables[0]?.metadata?.isExported).toBe(false);
+		expect(variables[1]?.metadata?.isExported).toBe(true);
+	});
+
+	it("should link class methods to their class and keep JSDoc", async () => {
+		const code = `
+/** Shopping cart */
+class Cart {
+	/** Adds an item */
+	add(item: string): void {}
+}
+
+const total = (prices: number[]) => prices.length;
+		`;
+		const filePath = createTestFile("test-receiver.ts", code);
+		const result = await parser.parse(filePath);
+
+		const cart = result.find((s) => s.name === "Cart");
+		expect(cart?.metadata?.isExported).toBe(false);
+		expect(cart?.metadata?.docComment).toBe("Shopping cart");
+
+		const add = result.find((s) => s.name === "Cart.add");
+		expect(add?.metadata?.receiver).toBe("Cart");
+		expect(add?.metadata?.docComment).toBe("Adds an item");
+
+		const total = result.find((s) => s.name === "total");
+		expect(total?.symbolType).toBe("function");
+		expect(total?.metadata?.isExported).toBe(false);
 	});
 
 	it("should detect custom hooks", async () => {
@@ -201,4 +244,34 @@ export function fetchUser() {
 		expect(func?.imports).toContain("useState:react");
 		expect(func?.imports).toContain("axios:axios");
 	});
+
+	it("should normalize visibility for top-level symbols and members", async () => {
+		const code = `
+export function exported() {}
+
+function local() {}
+
+export class Store {
+	private cache = 0;
+
+	get(): number {
+		return 1;
+	}
+
+	private reset(): void {}
+}
+		`;
+		const filePath = createTestFile("test-visibility.ts", code);
+		const result = await parser.parse(filePath);
+		const meta = (name: string) =>
+			result.find((s) => s.name === name)?.metadata;
+
+		expect(meta("exported")?.visibility).toBe("public");
+		expect(meta("local")?.visibility).toBe("private");
+		expect(meta("Store")?.visibility).toBe("public");
+		expect(meta("Store.get")?.visibility).toBe("public");
+		expect(meta("Store.get")?.isExported).toBe(true);
+		expect(meta("Store.reset")?.visibility).toBe("private");
+		expect(meta("Store.reset")?.isExported).toBe(false);
+	});
 });
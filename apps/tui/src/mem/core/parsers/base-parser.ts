@@ -1,7 +1,10 @@
 import { extname } from "node:path";
 import { createLogger } from "../../lib/logger.ts";
 import type { CodeSymbol } from "../code-chunker.ts";
-import type { EnhancedCodeSymbol } from "./types.ts";
+import type { Preprocessor } from "./preprocessor.ts";
+import { remapParseResult } from "./preprocessor.ts";
+import { normalizeSource, readSource } from "./source-text.ts";
+import type { EnhancedCodeSymbol, ParseResult } from "./types.ts";
 
 const log = createLogger("parser");
 
@@ -14,24 +17,128 @@ const LANG_BY_EXT: Record<string, string> = {
 	".tsx": "typescript",
 	".js": "javascript",
 	".jsx": "javascript",
+	".mjs": "javascript",
+	".cjs": "javascript",
 	".py": "python",
 	".go": "go",
 	".rs": "rust",
+	".java": "java",
+	".c": "c",
+	".h": "c",
+	".cpp": "cpp",
+	".cc": "cpp",
+	".cxx": "cpp",
+	".hpp": "cpp",
+	".hh": "cpp",
+	".hxx": "cpp",
 };
 
+/**
+ * Что парсер умеет извлекать для своего языка
+ *
+ * - docComments — doc comment символа (docComment/jsDoc)
+ * - ranges — точные позиции объявлений (metadata.range)
+ * - callGraph — вызовы с позициями (callRefs) для call graph
+ * - generics — generic параметры (genericParams)
+ * - fields — поля структур (fields)
+ */
+export type ParserCapability =
+	| "docComments"
+	| "ranges"
+	| "callGraph"
+	| "generics"
+	| "fields";
+
+const NO_CAPABILITIES: ReadonlySet<ParserCapability> = new Set();
+
+/**
+ * Язык файла по расширению (`unknown`, если расширение не известно)
+ */
+export function languageForFile(filePath: string): string {
+	return LANG_BY_EXT[extname(filePath)] ?? "unknown";
+}
+
+/**
+ * Проверить, что в опциях парсера нет неизвестных ключей
+ *
+ * Опечатка в ключе (`underscoreIsPrivat`) иначе молча игнорируется,
+ * и парсер работает с поведением по умолчанию.
+ *
+ * @param known - все поддерживаемые ключи опций
+ */
+export function assertKnownOptions(
+	language: string,
+	options: object,
+	known: readonly string[]
+): void {
+	const unknown = Object.keys(options).filter((key) => !known.includes(key));
+	if (unknown.length > 0) {
+		const supported = known.length > 0 ? known.join(", ") : "none";
+		throw new Error(
+			`Unknown ${language} parser options: ${unknown.join(", ")} (supported: ${supported})`
+		);
+	}
+}
+
+export interface ParseSourceOptions {
+	/** Пробросить ошибку вместо возврата пустого списка символов */
+	rethrow?: boolean;
+}
+
 /**
  * Абстрактный базовый класс для всех парсеров
  */
 export abstract class BaseParser {
+	/**
+	 * Версия формата вывода парсера. Увеличивается при изменении извлекаемых
+	 * данных, чтобы инвалидировать закешированные результаты
+	 */
+	readonly version: string = "1";
+	/**
+	 * Возможности парсера: вызывающий код проверяет, доступна ли функция
+	 * для языка, вместо того чтобы гадать по отсутствию данных
+	 */
+	readonly capabilities: ReadonlySet<ParserCapability> = NO_CAPABILITIES;
 	protected maxBodyLength = MAX_BODY_LENGTH;
 	protected maxCalls = MAX_CALLS;
 	protected maxImports = MAX_IMPORTS;
 	protected fallbackParser?: BaseParser;
+	protected preprocessor?: Preprocessor;
+
+	/**
+	 * Отпечаток опций, влияющих на вывод; входит в ключ кеша парсинга,
+	 * чтобы результаты с другими опциями не переиспользовались
+	 */
+	get optionsKey(): string {
+		return "";
+	}
+
+	/**
+	 * Преобразовывать исходник перед парсингом (null — отключить)
+	 *
+	 * Позиции результата переводятся в строки оригинала по lineMap
+	 * препроцессора.
+	 */
+	setPreprocessor(preprocessor: Preprocessor | null): void {
+		this.preprocessor = preprocessor ?? undefined;
+	}
+
+	/**
+	 * Задан ли препроцессор. Функцию нельзя включить в ключ кеша
+	 * парсинга, поэтому такие результаты не кешируются
+	 */
+	get preprocessed(): boolean {
+		return this.preprocessor !== undefined;
+	}
 
 	/**
 	 * Основной метод парсинга файла
 	 */
 	async parse(filePath: string): Promise<EnhancedCodeSymbol[]> {
+		if (this.preprocessor) {
+			const source = await readSource(filePath);
+			return this.parseSource(filePath, source);
+		}
 		try {
 			return await this.doParse(filePath);
 		} catch (error) {
@@ -56,11 +163,108 @@ export abstract class BaseParser {
 		}
 	}
 
+	/**
+	 * Парсинг уже загруженного содержимого файла (без чтения с диска)
+	 */
+	async parseSource(
+		filePath: string,
+		source: string,
+		options: ParseSourceOptions = {}
+	): Promise<EnhancedCodeSymbol[]> {
+		const result = await this.parseSourceWithDiagnostics(
+			filePath,
+			source,
+			options
+		);
+		return result.symbols;
+	}
+
+	/**
+	 * Парсинг содержимого с диагностиками синтаксических ошибок
+	 *
+	 * При ошибках в исходнике возвращаются символы, которые удалось
+	 * восстановить, и места ошибок в `diagnostics`. BOM и CRLF убираются
+	 * до парсинга (см. normalizeSource).
+	 */
+	async parseSourceWithDiagnostics(
+		filePath: string,
+		rawSource: string,
+		options: ParseSourceOptions = {}
+	): Promise<ParseResult> {
+		const source = normalizeSource(rawSource);
+		try {
+			if (this.preprocessor) {
+				const processed = this.preprocessor(source);
+				const result = await this.doParseSourceWithDiagnostics(
+					filePath,
+					processed.source
+				);
+				return remapParseResult(result, processed.lineMap);
+			}
+			return await this.doParseSourceWithDiagnostics(filePath, source);
+		} catch (error) {
+			log.warn("Primary parser failed", {
+				file: filePath,
+				error: String(error),
+			});
+
+			if (this.fallbackParser) {
+				log.info("Trying fallback parser", { file: filePath });
+				try {
+					return await this.fallbackParser.parseSourceWithDiagnostics(
+						filePath,
+						source,
+						options
+					);
+				} catch (fallbackError) {
+					log.error("Fallback parser also failed", {
+						file: filePath,
+						error: String(fallbackError),
+					});
+				}
+			}
+
+			if (options.rethrow) {
+				throw error;
+			}
+			return {
+				symbols: this.handleError(error as Error, filePath),
+				diagnostics: [],
+			};
+		}
+	}
+
 	/**
 	 * Реальная имплементация парсинга (должна быть переопределена в подклассах)
 	 */
 	protected abstract doParse(filePath: string): Promise<EnhancedCodeSymbol[]>;
 
+	/**
+	 * Парсинг из строки. Парсеры, которые умеют работать с содержимым
+	 * напрямую, переопределяют этот метод
+	 */
+	protected doParseSource(
+		filePath: string,
+		_source: string
+	): Promise<EnhancedCodeSymbol[]> {
+		return Promise.reject(
+			new Error(`Parsing from source is not supported for ${filePath}`)
+		);
+	}
+
+	/**
+	 * Парсинг из строки с диагностиками. По умолчанию диагностик нет
+	 */
+	protected async doParseSourceWithDiagnostics(
+		filePath: string,
+		source: string
+	): Promise<ParseResult> {
+		return {
+			symbols: await this.doParseSource(filePath, source),
+			diagnostics: [],
+		};
+	}
+
 	/**
 	 * Установить fallback парсер
 	 */
@@ -83,8 +287,7 @@ export abstract class BaseParser {
 	 * Определить язык программирования по расширению файла
 	 */
 	protected detectLanguage(filePath: string): string {
-		const ext = extname(filePath);
-		return LANG_BY_EXT[ext] ?? "unknown";
+		return languageForFile(filePath);
 	}
 
 	/**
@@ -102,7 +305,7 @@ export abstract class BaseParser {
 	 */
 	protected extractCalls(body: string, ownName: string): string[] {
 		const calls = new Set<string>();
-		const callPattern = /\b([a-zA-Z_]\w{2,})\s*\(/g;
+		const callPattern = /(?<!\p{ID_Continue})([\p{ID_Start}_]\p{ID_Continue}{2,})\s*\(/gu;
 
 		let match: RegExpExecArray | null;
 		while ((match = callPattern.exec(body)) !== null) {
@@ -180,7 +383,7 @@ export abstract class BaseParser {
 		}
 
 		// Default imports: import foo from "module"
-		const defaultPattern = /import\s+(\w+)\s+from\s+["']([^"']+)["']/g;
+		const defaultPattern = /import\s+([\p{ID_Start}_$][\p{ID_Continue}$]*)\s+from\s+["']([^"']+)["']/gu;
 		while ((match = defaultPattern.exec(body)) !== null) {
 			const name = match[1];
 			const source = match[2];
@@ -0,0 +1,1279 @@
+// @ts-nocheck
+import type Parser from "tree-sitter";
+import Cpp from "tree-sitter-cpp";
+import type { ParserCapability } from "./base-parser.ts";
+import { applyDeprecation, cppDeprecation } from "./deprecation.ts";
+import {
+	BaseNodeExtractor,
+	type NodeExtractor,
+	TreeSitterParser,
+} from "./tree-sitter-parser.ts";
+import type {
+	Decorator,
+	EnhancedCodeSymbol,
+	FunctionParameter,
+	GenericParameter,
+	SourceRange,
+	StructField,
+	SymbolMetadata,
+} from "./types.ts";
+
+type Visibility = NonNullable<SymbolMetadata["visibility"]>;
+
+// struct/class/union и доступ к членам по умолчанию
+const AGGREGATES: Record<string, { access: Visibility; keyword: string }> = {
+	class_specifier: { access: "private", keyword: "class" },
+	struct_specifier: { access: "public", keyword: "struct" },
+	union_specifier: { access: "public", keyword: "union" },
+};
+const CONDITIONALS = new Set([
+	"preproc_if",
+	"preproc_ifdef",
+	"preproc_elif",
+	"preproc_elifdef",
+	"preproc_else",
+]);
+// Обёртки вокруг имени в деклараторе: `*name`, `&name`, `name[4]`, `(name)`
+const WRAPPER_DECLARATORS = new Set([
+	"array_declarator",
+	"attributed_declarator",
+	"init_declarator",
+	"parenthesized_declarator",
+	"pointer_declarator",
+	"reference_declarator",
+]);
+// Узлы имени в деклараторе
+const NAME_TYPES = new Set([
+	"destructor_name",
+	"field_identifier",
+	"identifier",
+	"operator_name",
+	"qualified_identifier",
+	"template_function",
+	"type_identifier",
+]);
+const SPECIFIER_TYPES = new Set([
+	"explicit_function_specifier",
+	"storage_class_specifier",
+	"virtual",
+	"virtual_function_specifier",
+]);
+const DOC_LINE_RE = /^\s*\*\s?/;
+const INTEGER_RE = /^\(?\s*(-?(?:0[xX][\da-fA-F]+|\d+))[uUlL]*\s*\)?$/;
+const MAX_CALLS = 30;
+
+/**
+ * Область, в которой встречено объявление
+ */
+interface Scope {
+	/** Доступ по секции `public:`/`private:` внутри класса */
+	access?: Visibility;
+	/** Условие #if/#ifdef ветки: `defined(_WIN32) && !defined(NDEBUG)` */
+	condition?: string;
+	/** Анонимный namespace: внутреннее связывание */
+	internal?: boolean;
+	namespace?: string;
+	/** Узел-обёртка объявления (template): его doc comment и диапазон */
+	outer?: Parser.SyntaxNode;
+	/** Класс-владелец: `Outer.Inner` */
+	owner?: string;
+	template?: GenericParameter[];
+}
+
+/**
+ * C/C++ парсер на уровне объявлений (Tree-sitter, грамматика C++)
+ *
+ * Грамматика C++ — надмножество C, поэтому один парсер обслуживает
+ * и `.c`/`.h`. Тела функций анализируются только ради вызовов.
+ */
+export class CppParser extends TreeSitterParser {
+	readonly capabilities: ReadonlySet<ParserCapability> = new Set([
+		"docComments",
+		"ranges",
+		"generics",
+		"fields",
+	]);
+
+	protected getLanguage(): unknown {
+		return Cpp;
+	}
+
+	protected getNodeExtractor(): NodeExtractor {
+		return new CppNodeExtractor();
+	}
+}
+
+/**
+ * Extractor для C/C++ AST
+ *
+ * Члены класса получают имя с квалификатором владельца (`Buffer.size`,
+ * вложенный тип — `Outer.Inner` с parent `Outer`), namespace попадает
+ * в packageName (`net::http`). Прототип, для которого в том же файле
+ * есть определение, в определение и сливается. Макросы `#define` —
+ * constant или function с модификатором "macro".
+ */
+class CppNodeExtractor extends BaseNodeExtractor {
+	private filePath = "";
+	private namespaces = new Set<string>();
+
+	extractSymbols(
+		tree: Parser.Tree,
+		filePath: string,
+		_source: string
+	): EnhancedCodeSymbol[] {
+		this.filePath = filePath;
+		const root = tree.rootNode;
+		// Квалификатор `a::B::f` у определения вне класса: namespace или класс
+		this.namespaces = new Set(
+			this.findNodesOfType(root, "namespace_definition").flatMap((ns) => {
+				const name = this.getChild(ns, "name");
+				return name ? this.getText(name).split("::") : [];
+			})
+		);
+
+		const symbols: EnhancedCodeSymbol[] = [];
+		this.visitItems(root.children, {}, symbols);
+		const linked = this.mergePrototypes(symbols);
+		for (const symbol of linked) {
+			applyDeprecation(
+				symbol,
+				cppDeprecation(symbol.metadata?.decorators, symbol.jsDoc)
+			);
+		}
+		return linked;
+	}
+
+	private visitItems(
+		nodes: Parser.SyntaxNode[],
+		scope: Scope,
+		symbols: EnhancedCodeSymbol[]
+	): void {
+		for (const node of nodes) {
+			this.tryExtract(node, undefined, () =>
+				this.visitItem(node, scope, symbols)
+			);
+		}
+	}
+
+	/**
+	 * Объявление верхнего уровня или тела namespace
+	 */
+	private visitItem(
+		node: Parser.SyntaxNode,
+		scope: Scope,
+		symbols: EnhancedCodeSymbol[]
+	): void {
+		if (AGGREGATES[node.type]) {
+			this.extractAggregate(node, scope, symbols);
+			return;
+		}
+		if (CONDITIONALS.has(node.type)) {
+			this.visitConditional(node, scope, (items, branch) =>
+				this.visitItems(items, branch, symbols)
+			);
+			return;
+		}
+		switch (node.type) {
+			case "function_definition":
+			case "declaration":
+				for (const declarator of this.declaratorsOf(node)) {
+					const fn = this.extractFunction(node, declarator, scope);
+					if (fn) {
+						symbols.push(fn);
+					}
+				}
+				this.extractTypeSpecifier(node, scope, symbols);
+				return;
+			case "enum_specifier":
+				this.extractEnum(node, scope, symbols);
+				return;
+			case "type_definition":
+			case "alias_declaration":
+				this.extractTypedef(node, scope, symbols);
+				return;
+			case "preproc_def":
+			case "preproc_function_def":
+				this.extractMacro(node, scope, symbols);
+				return;
+			case "template_declaration":
+				this.visitTemplate(node, scope, (inner, templated) =>
+					this.visitItem(inner, templated, symbols)
+				);
+				return;
+			case "namespace_definition": {
+				const nameNode = this.getChild(node, "name");
+				const name = nameNode ? this.getText(nameNode) : undefined;
+				const body = this.getChild(node, "body");
+				const inner: Scope = {
+					condition: scope.condition,
+					internal: scope.internal || !name,
+					namespace: [scope.namespace, name].filter(Boolean).join("::"),
+				};
+				this.visitItems(body?.children ?? [], inner, symbols);
+				return;
+			}
+			case "linkage_specification": {
+				// extern "C" { ... } или extern "C" void f();
+				const body = this.getChild(node, "body");
+				if (body?.type === "declaration_list") {
+					this.visitItems(body.children, scope, symbols);
+				} else if (body) {
+					this.visitItem(body, scope, symbols);
+				}
+				return;
+			}
+		}
+	}
+
+	/**
+	 * Ветки #if/#ifdef/#elif/#else: символы веток получают условие ветки,
+	 * а else — отрицание условий предыдущих веток
+	 *
+	 * Include guard (`#ifndef X_H` + пустой `#define X_H`) условием
+	 * не считается, а его `#define` не извлекается.
+	 */
+	private visitConditional(
+		node: Parser.SyntaxNode,
+		scope: Scope,
+		visit: (items: Parser.SyntaxNode[], branch: Scope) => void,
+		previous: string[] = []
+	): void {
+		const nameNode = this.getChild(node, "name");
+		const conditionNode = this.getChild(node, "condition");
+		const alternative = this.getChild(node, "alternative");
+		const isNegated = /^#(?:el)?ifndef$/.test(node.children[0]?.type ?? "");
+		let condition: string | undefined;
+		if (nameNode) {
+			const name = this.getText(nameNode);
+			condition = isNegated ? `!defined(${name})` : `defined(${name})`;
+		} else if (conditionNode) {
+			condition = this.getText(conditionNode).trim();
+		}
+
+		const skip = new Set(
+			[nameNode, conditionNode, alternative]
+				.filter(Boolean)
+				.map((n) => n.startIndex)
+		);
+		let items = node.children.filter(
+			(c) => c.isNamed && !skip.has(c.startIndex)
+		);
+		const guard =
+			isNegated && nameNode && this.isIncludeGuard(items[0], nameNode);
+		if (guard) {
+			items = items.slice(1);
+		}
+
+		const own =
+			node.type === "preproc_else" || guard ? [] : [condition ?? ""];
+		const branch = [...previous.map(negateCondition), ...own].filter(Boolean);
+		visit(items, {
+			...scope,
+			condition:
+				[scope.condition, ...branch].filter(Boolean).join(" && ") ||
+				undefined,
+		});
+
+		if (alternative) {
+			this.visitConditional(alternative, scope, visit, [
+				...previous,
+				...own,
+			]);
+		}
+	}
+
+	/**
+	 * Пустой `#define` с именем из `#ifndef`
+	 */
+	private isIncludeGuard(
+		item: Parser.SyntaxNode | undefined,
+		nameNode: Parser.SyntaxNode
+	): boolean {
+		const defined = item?.type === "preproc_def" && this.getChild(item, "name");
+		return (
+			!!defined &&
+			!this.getChild(item, "value") &&
+			this.getText(defined) === this.getText(nameNode)
+		);
+	}
+
+	/**
+	 * `template <typename T, int N = 4>` перед объявлением
+	 */
+	private visitTemplate(
+		node: Parser.SyntaxNode,
+		scope: Scope,
+		visit: (inner: Parser.SyntaxNode, templated: Scope) => void
+	): void {
+		const paramsNode = this.getChild(node, "parameters");
+		const inner = node.namedChildren.find(
+			(c) => c.type !== "template_parameter_list" && c.type !== "comment"
+		);
+		if (!inner) {
+			return;
+		}
+		const template: GenericParameter[] = [];
+		for (const param of paramsNode?.namedChildren ?? []) {
+			const nameNode =
+				this.getChild(param, "name") ??
+				this.declaratorName(this.getChild(param, "declarator")) ??
+				param.namedChildren.find((c) => c.type === "type_identifier");
+			if (!nameNode) {
+				continue;
+			}
+			// Параметр-значение: `int N` — тип в constraint
+			const typeNode = param.type.includes("parameter_declaration")
+				? this.getChild(param, "type")
+				: null;
+			template.push({
+				name: this.getText(nameNode),
+				constraint:
+					typeNode && !param.type.includes("type_parameter")
+						? this.getText(typeNode)
+						: undefined,
+			});
+		}
+		visit(inner, { ...scope, outer: scope.outer ?? node, template });
+	}
+
+	/**
+	 * struct/class/enum с телом в типе объявления: `struct P { ... } p;`
+	 */
+	private extractTypeSpecifier(
+		node: Parser.SyntaxNode,
+		scope: Scope,
+		symbols: EnhancedCodeSymbol[]
+	): void {
+		const typeNode = this.getChild(node, "type");
+		if (!typeNode || !this.getChild(typeNode, "body")) {
+			return;
+		}
+		const declNode = scope.outer ?? node;
+		if (AGGREGATES[typeNode.type]) {
+			this.extractAggregate(typeNode, scope, symbols, undefined, declNode);
+		} else if (typeNode.type === "enum_specifier") {
+			this.extractEnum(typeNode, scope, symbols, undefined, declNode);
+		}
+	}
+
+	/**
+	 * struct/class/union с телом, его поля, методы и вложенные типы
+	 *
+	 * @param typedefName - имя из `typedef struct { ... } Name;`
+	 * @param declNode - узел, от которого берутся doc comment и диапазон
+	 */
+	private extractAggregate(
+		node: Parser.SyntaxNode,
+		scope: Scope,
+		symbols: EnhancedCodeSymbol[],
+		typedefName?: string,
+		declNode: Parser.SyntaxNode = scope.outer ?? node
+	): void {
+		const body = this.getChild(node, "body");
+		const nameNode = this.getChild(node, "name");
+		const shortName = nameNode ? this.getText(nameNode) : typedefName;
+		if (!(body && shortName)) {
+			return;
+		}
+		const { access, keyword } = AGGREGATES[node.type];
+		const name = scope.owner ? `${scope.owner}.${shortName}` : shortName;
+		const visibility = this.scopeVisibility(scope, node);
+		const docComment = this.extractDocComment(declNode);
+		const bases = this.getChildrenOfType(node, "base_class_clause").flatMap(
+			(clause) =>
+				clause.namedChildren
+					.filter((c) => c.type !== "access_specifier")
+					.map((c) => this.getText(c))
+		);
+
+		const fields: StructField[] = [];
+		const symbol: EnhancedCodeSymbol = {
+			name,
+			symbolType: "class",
+			path: this.filePath,
+			startLine: this.getLineNumber(declNode.startPosition),
+			endLine: this.getLineNumber(declNode.endPosition),
+			body: this.truncateBody(this.getText(declNode)),
+			jsDoc: docComment,
+			calls: [],
+			imports: [],
+			metadata: {
+				visibility,
+				isExported: isExportedVisibility(visibility),
+				typeKind: keyword === "class" ? undefined : "struct",
+				genericParams: scope.template,
+				docComment: docComment || undefined,
+				decorators: this.extractAttributes(node, declNode),
+				modifiers: keyword === "union" ? ["union"] : undefined,
+				underlying: bases.length > 0 ? bases.join(", ") : undefined,
+				packageName: scope.namespace || undefined,
+				parent: scope.owner,
+				preprocessorCondition: scope.condition,
+				range: this.getRange(declNode),
+				nameRange: nameNode ? this.getRange(nameNode) : undefined,
+			},
+		};
+		symbols.push(symbol);
+
+		const memberScope: Scope = {
+			access,
+			condition: scope.condition,
+			internal: scope.internal,
+			namespace: scope.namespace,
+			owner: name,
+		};
+		this.visitMembers(body.children, memberScope, shortName, symbols, fields);
+		if (fields.length > 0) {
+			symbol.metadata.fields = fields;
+		}
+	}
+
+	/**
+	 * Члены тела класса; секции `public:` меняют доступ последующих членов
+	 */
+	private visitMembers(
+		nodes: Parser.SyntaxNode[],
+		scope: Scope,
+		className: string,
+		symbols: EnhancedCodeSymbol[],
+		fields: StructField[]
+	): void {
+		for (const member of nodes) {
+			if (member.type === "access_specifier") {
+				scope.access = this.getText(member).replace(":", "").trim();
+				continue;
+			}
+			if (CONDITIONALS.has(member.type)) {
+				this.visitConditional(member, scope, (items, branch) => {
+					this.visitMembers(items, branch, className, symbols, fields);
+					scope.access = branch.access;
+				});
+				continue;
+			}
+			this.tryExtract(member, undefined, () =>
+				this.extractMember(member, scope, className, symbols, fields)
+			);
+		}
+	}
+
+	private extractMember(
+		member: Parser.SyntaxNode,
+		scope: Scope,
+		className: string,
+		symbols: EnhancedCodeSymbol[],
+		fields: StructField[]
+	): void {
+		switch (member.type) {
+			case "field_declaration":
+			case "declaration":
+			case "function_definition": {
+				const typeNode = this.getChild(member, "type");
+				for (const declarator of this.declaratorsOf(member)) {
+					const method = this.extractFunction(
+						member,
+						declarator,
+						scope,
+						className
+					);
+					if (method) {
+						symbols.push(method);
+						continue;
+					}
+					const field = this.extractField(
+						member,
+						typeNode,
+						declarator,
+						scope
+					);
+					if (field) {
+						fields.push(field);
+					}
+				}
+				this.extractTypeSpecifier(member, { ...scope }, symbols);
+				return;
+			}
+			case "template_declaration":
+				this.visitTemplate(member, scope, (inner, templated) =>
+					this.extractMember(inner, templated, className, symbols, fields)
+				);
+				return;
+			case "enum_specifier":
+				this.extractEnum(member, { ...scope }, symbols);
+				return;
+			case "type_definition":
+			case "alias_declaration":
+				this.extractTypedef(member, { ...scope }, symbols);
+				return;
+			case "preproc_def":
+			case "preproc_function_def":
+				this.extractMacro(member, scope, symbols);
+				return;
+		}
+		if (AGGREGATES[member.type]) {
+			this.extractAggregate(member, { ...scope }, symbols);
+		}
+	}
+
+	/**
+	 * Поле класса: `char *name;`, `int data[4];`
+	 */
+	private extractField(
+		member: Parser.SyntaxNode,
+		typeNode: Parser.SyntaxNode | null,
+		declarator: Parser.SyntaxNode,
+		scope: Scope
+	): StructField | null {
+		const nameNode = this.declaratorName(declarator);
+		if (!(nameNode && typeNode)) {
+			return null;
+		}
+		return {
+			name: this.getText(nameNode),
+			type: this.declaredType(member, declarator, nameNode),
+			exported: isExportedVisibility(scope.access ?? "public"),
+			range: this.getRange(member),
+			nameRange: this.getRange(nameNode),
+		};
+	}
+
+	/**
+	 * Функция или метод: определение либо прототип (модификатор "prototype")
+	 *
+	 * Определение вне класса `void Buffer::clear()` становится методом
+	 * `Buffer.clear`; видимость для него берётся из прототипа в классе.
+	 *
+	 * @param className - короткое имя класса, если объявление внутри него
+	 */
+	private extractFunction(
+		node: Parser.SyntaxNode,
+		declarator: Parser.SyntaxNode,
+		scope: Scope,
+		className?: string
+	): EnhancedCodeSymbol | null {
+		const { fn, suffix } = this.functionDeclarator(declarator);
+		const nameNode = fn && this.getChild(fn, "declarator");
+		// `int (*handler)(int)` — указатель на функцию, а не функция
+		if (!(nameNode && NAME_TYPES.has(nameNode.type))) {
+			return null;
+		}
+		const declNode = scope.outer ?? node;
+		const segments = this.nameSegments(nameNode);
+		const shortName = segments.pop() as string;
+
+		// `ns::Buffer::clear`: ведущие namespace — в packageName, остальное — класс
+		const namespaceSegments: string[] = [];
+		while (segments.length > 0 && this.namespaces.has(segments[0])) {
+			namespaceSegments.push(segments.shift() as string);
+		}
+		const owner =
+			scope.owner ??
+			(segments.length > 0 ? segments.join(".") : undefined);
+		const ownerShortName = className ?? segments.at(-1);
+		const packageName =
+			[scope.namespace, ...namespaceSegments].filter(Boolean).join("::") ||
+			undefined;
+
+		const body = this.getChild(node, "body");
+		const isDefinition = node.type === "function_definition";
+		const modifiers = this.extractSpecifiers(node, fn);
+		if (ownerShortName && shortName === ownerShortName) {
+			modifiers.push("constructor");
+		} else if (owner && shortName.startsWith("~")) {
+			modifiers.push("destructor");
+		}
+		// virtual void draw() = 0;
+		const defaultValue = this.getChild(node, "default_value");
+		if (defaultValue && this.getText(defaultValue) === "0") {
+			modifiers.push("abstract");
+		}
+		if (!isDefinition) {
+			modifiers.push("prototype");
+		}
+
+		const visibility =
+			scope.owner !== undefined
+				? scope.access
+				: owner
+					? undefined
+					: this.scopeVisibility(scope, node);
+		const typeNode = this.getChild(node, "type");
+		const docComment = this.extractDocComment(declNode);
+
+		return {
+			name: owner ? `${owner}.${shortName}` : shortName,
+			symbolType: owner ? "method" : "function",
+			path: this.filePath,
+			startLine: this.getLineNumber(declNode.startPosition),
+			endLine: this.getLineNumber(declNode.endPosition),
+			body: this.truncateBody(this.getText(declNode)),
+			jsDoc: docComment,
+			calls: body ? this.extractCalls(body) : [],
+			imports: [],
+			metadata: {
+				parameters: this.extractParameters(this.getChild(fn, "parameters")),
+				returnType: typeNode
+					? joinType(this.baseType(node), suffix)
+					: undefined,
+				visibility,
+				isExported: visibility && isExportedVisibility(visibility),
+				genericParams: scope.template,
+				receiver: owner,
+				docComment: docComment || undefined,
+				decorators: this.extractAttributes(node, declNode),
+				modifiers: modifiers.length > 0 ? modifiers : undefined,
+				packageName,
+				preprocessorCondition: scope.condition,
+				range: this.getRange(declNode),
+				nameRange: this.getRange(nameNode),
+			},
+		};
+	}
+
+	/**
+	 * enum и его элементы; элементы `enum class` квалифицируются именем enum
+	 */
+	private extractEnum(
+		node: Parser.SyntaxNode,
+		scope: Scope,
+		symbols: EnhancedCodeSymbol[],
+		typedefName?: string,
+		declNode: Parser.SyntaxNode = scope.outer ?? node
+	): void {
+		const body = this.getChild(node, "body");
+		if (!body) {
+			return;
+		}
+		const nameNode = this.getChild(node, "name");
+		const shortName = nameNode ? this.getText(nameNode) : typedefName;
+		const name =
+			shortName && scope.owner ? `${scope.owner}.${shortName}` : shortName;
+		const isScoped = node.children.some(
+			(c) => c.type === "class" || c.type === "struct"
+		);
+		const visibility = this.scopeVisibility(scope, node);
+		const packageName = scope.namespace || undefined;
+		const baseNode = this.getChild(node, "base");
+
+		if (name) {
+			const docComment = this.extractDocComment(declNode);
+			symbols.push({
+				name,
+				symbolType: "enum",
+				path: this.filePath,
+				startLine: this.getLineNumber(declNode.startPosition),
+				endLine: this.getLineNumber(declNode.endPosition),
+				body: this.truncateBody(this.getText(declNode)),
+				jsDoc: docComment,
+				calls: [],
+				imports: [],
+				metadata: {
+					visibility,
+					isExported: isExportedVisibility(visibility),
+					docComment: docComment || undefined,
+					modifiers: isScoped ? ["scoped"] : undefined,
+					underlying: baseNode ? this.getText(baseNode) : undefined,
+					packageName,
+					parent: scope.owner,
+					preprocessorCondition: scope.condition,
+					range: this.getRange(declNode),
+					nameRange: nameNode ? this.getRange(nameNode) : undefined,
+				},
+			});
+		}
+
+		// Без явного значения элемент на единицу больше предыдущего
+		let next: number | null = 0;
+		for (const enumerator of this.getChildrenOfType(body, "enumerator")) {
+			const enumeratorName = this.getChild(enumerator, "name");
+			if (!enumeratorName) {
+				continue;
+			}
+			const valueNode = this.getChild(enumerator, "value");
+			const value = valueNode
+				? parseInteger(this.getText(valueNode))
+				: next;
+			next = value === null ? null : value + 1;
+			const owner = isScoped ? name : scope.owner;
+			const docComment = this.extractDocComment(enumerator);
+			symbols.push({
+				name: owner
+					? `${owner}.${this.getText(enumeratorName)}`
+					: this.getText(enumeratorName),
+				symbolType: "constant",
+				path: this.filePath,
+				startLine: this.getLineNumber(enumerator.startPosition),
+				endLine: this.getLineNumber(enumerator.endPosition),
+				body: this.getText(enumerator),
+				jsDoc: docComment,
+				calls: [],
+				imports: [],
+				metadata: {
+					visibility,
+					isExported: isExportedVisibility(visibility),
+					returnType: name,
+					value,
+					enumGroup: name,
+					docComment: docComment || undefined,
+					packageName,
+					parent: owner,
+					preprocessorCondition: scope.condition,
+					range: this.getRange(enumerator),
+					nameRange: this.getRange(enumeratorName),
+				},
+			});
+		}
+	}
+
+	/**
+	 * `typedef <type> Name;` и `using Name = <type>;`
+	 *
+	 * `typedef struct { ... } Point;` — анонимная структура получает имя
+	 * typedef'а, отдельный символ-алиас для неё не создаётся.
+	 */
+	private extractTypedef(
+		node: Parser.SyntaxNode,
+		scope: Scope,
+		symbols: EnhancedCodeSymbol[]
+	): void {
+		const typeNode = this.getChild(node, "type");
+		const declNode = scope.outer ?? node;
+		const declarators =
+			node.type === "alias_declaration"
+				? [this.getChild(node, "name")].filter(Boolean)
+				: this.declaratorsOf(node);
+		let consumed: Parser.SyntaxNode | undefined;
+		let typeText =
+			node.type === "alias_declaration"
+				? this.getText(typeNode)
+				: this.baseType(node);
+
+		if (typeNode && this.getChild(typeNode, "body")) {
+			const tagNode = this.getChild(typeNode, "name");
+			const first = declarators[0];
+			const anonymousName =
+				!tagNode && first?.type === "type_identifier"
+					? this.getText(first)
+					: undefined;
+			const keyword =
+				AGGREGATES[typeNode.type]?.keyword ??
+				(typeNode.type === "enum_specifier" ? "enum" : "");
+			if (AGGREGATES[typeNode.type]) {
+				this.extractAggregate(
+					typeNode,
+					scope,
+					symbols,
+					anonymousName,
+					declNode
+				);
+			} else if (typeNode.type === "enum_specifier") {
+				this.extractEnum(typeNode, scope, symbols, anonymousName, declNode);
+			}
+			if (anonymousName) {
+				consumed = first;
+			}
+			// Тело уже описано символом типа: в underlying только его имя
+			const tag = tagNode ? this.getText(tagNode) : (anonymousName ?? "");
+			typeText = `${keyword} ${tag}`.trim();
+		}
+
+		const docComment = this.extractDocComment(declNode);
+		const visibility = this.scopeVisibility(scope, node);
+		for (const declarator of declarators) {
+			if (declarator === consumed) {
+				continue;
+			}
+			const nameNode = this.declaratorName(declarator);
+			if (!nameNode) {
+				continue;
+			}
+			const shortName = this.getText(nameNode);
+			symbols.push({
+				name: scope.owner ? `${scope.owner}.${shortName}` : shortName,
+				symbolType: "type",
+				path: this.filePath,
+				startLine: this.getLineNumber(declNode.startPosition),
+				endLine: this.getLineNumber(declNode.endPosition),
+				body: this.truncateBody(this.getText(declNode)),
+				jsDoc: docComment,
+				calls: [],
+				imports: [],
+				metadata: {
+					visibility,
+					isExported: isExportedVisibility(visibility),
+					typeKind: "alias",
+					underlying:
+						node.type === "alias_declaration"
+							? typeText
+							: this.declaredTypeText(typeText, declarator, nameNode),
+					genericParams: scope.template,
+					docComment: docComment || undefined,
+					packageName: scope.namespace || undefined,
+					parent: scope.owner,
+					preprocessorCondition: scope.condition,
+					range: this.getRange(declNode),
+					nameRange: this.getRange(nameNode),
+				},
+			});
+		}
+	}
+
+	/**
+	 * `#define NAME value` (constant) и `#define NAME(a, b) ...` (function)
+	 *
+	 * Include guard отбрасывается ещё в visitConditional.
+	 */
+	private extractMacro(
+		node: Parser.SyntaxNode,
+		scope: Scope,
+		symbols: EnhancedCodeSymbol[]
+	): void {
+		const nameNode = this.getChild(node, "name");
+		if (!nameNode) {
+			return;
+		}
+		const name = this.getText(nameNode);
+		const valueNode = this.getChild(node, "value");
+		const isFunction = node.type === "preproc_function_def";
+		const parameters: FunctionParameter[] = [];
+		for (const param of this.getChild(node, "parameters")?.children ?? []) {
+			if (param.type === "identifier") {
+				parameters.push({ name: this.getText(param) });
+			} else if (param.type === "...") {
+				parameters.push({ name: "__VA_ARGS__", variadic: true });
+			}
+		}
+		const docComment = this.extractDocComment(node);
+		// Узел директивы включает завершающий перевод строки
+		const text = this.getText(node).trimEnd();
+		const range = this.textRange(node, text);
+
+		symbols.push({
+			name,
+			symbolType: isFunction ? "function" : "constant",
+			path: this.filePath,
+			startLine: range.startLine,
+			endLine: range.endLine,
+			body: this.truncateBody(text),
+			jsDoc: docComment,
+			calls: [],
+			imports: [],
+			metadata: {
+				parameters: isFunction ? parameters : undefined,
+				visibility: "public",
+				isExported: true,
+				value:
+					isFunction || !valueNode
+						? undefined
+						: parseInteger(this.getText(valueNode).trim()),
+				docComment: docComment || undefined,
+				modifiers: ["macro"],
+				preprocessorCondition: scope.condition,
+				range,
+				nameRange: this.getRange(nameNode),
+			},
+		});
+	}
+
+	/**
+	 * Прототип, для которого в файле есть определение, сливается с ним:
+	 * doc comment, модификаторы и видимость определения дополняются
+	 * прототипом (`static` у прототипа, доступ метода из тела класса)
+	 */
+	private mergePrototypes(
+		symbols: EnhancedCodeSymbol[]
+	): EnhancedCodeSymbol[] {
+		const definitions = new Map<string, EnhancedCodeSymbol>();
+		for (const symbol of symbols) {
+			if (isCallable(symbol) && !isPrototype(symbol)) {
+				definitions.set(overloadKey(symbol), symbol);
+			}
+		}
+		return symbols.filter((symbol) => {
+			if (!(isCallable(symbol) && isPrototype(symbol))) {
+				return true;
+			}
+			const definition = definitions.get(overloadKey(symbol));
+			if (!definition) {
+				return true;
+			}
+			const target = definition.metadata as SymbolMetadata;
+			const source = symbol.metadata as SymbolMetadata;
+			if (!definition.jsDoc && symbol.jsDoc) {
+				definition.jsDoc = symbol.jsDoc;
+				target.docComment = source.docComment;
+			}
+			if (
+				target.visibility === undefined ||
+				source.visibility === "internal"
+			) {
+				target.visibility = source.visibility;
+				target.isExported = source.isExported;
+			}
+			const modifiers = new Set([
+				...(source.modifiers ?? []),
+				...(target.modifiers ?? []),
+			]);
+			modifiers.delete("prototype");
+			target.modifiers = modifiers.size > 0 ? [...modifiers] : undefined;
+			return false;
+		});
+	}
+
+	/**
+	 * Видимость объявления вне тела класса: static и анонимный namespace
+	 * дают внутреннее связывание
+	 */
+	private scopeVisibility(scope: Scope, node: Parser.SyntaxNode): Visibility {
+		if (scope.owner !== undefined) {
+			return scope.access ?? "public";
+		}
+		const isStatic = node.children.some(
+			(c) =>
+				c.type === "storage_class_specifier" && this.getText(c) === "static"
+		);
+		return scope.internal || isStatic ? "internal" : "public";
+	}
+
+	/**
+	 * Деклараторы объявления: `int a, *b;` — два. Узел поля type
+	 * исключается: в `typedef Foo Bar;` оба — type_identifier
+	 */
+	private declaratorsOf(node: Parser.SyntaxNode): Parser.SyntaxNode[] {
+		const typeNode = this.getChild(node, "type");
+		return node.namedChildren.filter(
+			(c) =>
+				c.startIndex !== typeNode?.startIndex &&
+				(NAME_TYPES.has(c.type) || c.type.endsWith("_declarator"))
+		);
+	}
+
+	/**
+	 * function_declarator под обёртками и то, что они добавляют к типу
+	 * результата: `char **name(...)` -> `**`
+	 */
+	private functionDeclarator(declarator: Parser.SyntaxNode): {
+		fn?: Parser.SyntaxNode;
+		suffix: string;
+	} {
+		let suffix = "";
+		let current: Parser.SyntaxNode | null = declarator;
+		while (current && current.type !== "function_declarator") {
+			if (current.type === "pointer_declarator") {
+				suffix += "*";
+			} else if (current.type === "reference_declarator") {
+				suffix += current.children[0]?.type === "&&" ? "&&" : "&";
+			} else if (!WRAPPER_DECLARATORS.has(current.type)) {
+				return { suffix };
+			}
+			current =
+				this.getChild(current, "declarator") ??
+				current.namedChildren.find((c) => c.type !== "type_qualifier") ??
+				null;
+		}
+		return { fn: current ?? undefined, suffix };
+	}
+
+	/**
+	 * Идентификатор под обёртками декларатора
+	 */
+	private declaratorName(
+		declarator: Parser.SyntaxNode | null
+	): Parser.SyntaxNode | null {
+		let current = declarator;
+		while (current && WRAPPER_DECLARATORS.has(current.type)) {
+			current =
+				this.getChild(current, "declarator") ??
+				current.namedChildren.find(
+					(c) =>
+						c.type !== "type_qualifier" && c.type !== "attribute_declaration"
+				) ??
+				null;
+		}
+		if (current?.type === "function_declarator") {
+			return this.declaratorName(this.getChild(current, "declarator"));
+		}
+		// Абстрактный декларатор безымянного параметра: `char *`
+		return current && NAME_TYPES.has(current.type) ? current : null;
+	}
+
+	/**
+	 * Части квалифицированного имени: `ns::Buffer::clear` -> [ns, Buffer, clear]
+	 */
+	private nameSegments(node: Parser.SyntaxNode): string[] {
+		if (node.type !== "qualified_identifier") {
+			return [this.getText(node)];
+		}
+		const scope = this.getChild(node, "scope");
+		const name = this.getChild(node, "name");
+		return [
+			...(scope ? this.nameSegments(scope) : []),
+			...(name ? this.nameSegments(name) : []),
+		];
+	}
+
+	/**
+	 * Тип объявления вместе с квалификаторами: `const char`
+	 */
+	private baseType(node: Parser.SyntaxNode): string {
+		const typeNode = this.getChild(node, "type");
+		if (!typeNode) {
+			return "";
+		}
+		return node.children
+			.filter(
+				(c) =>
+					c.type === "type_qualifier" || c.startIndex === typeNode.startIndex
+			)
+			.map((c) => this.getText(c))
+			.join(" ");
+	}
+
+	/**
+	 * Тип с частями декларатора: `char` + `*name[4]` -> `char *[4]`
+	 */
+	private declaredType(
+		node: Parser.SyntaxNode,
+		declarator: Parser.SyntaxNode | null,
+		nameNode: Parser.SyntaxNode | null
+	): string {
+		return this.declaredTypeText(this.baseType(node), declarator, nameNode);
+	}
+
+	private declaredTypeText(
+		typeText: string,
+		declarator: Parser.SyntaxNode | null,
+		nameNode: Parser.SyntaxNode | null
+	): string {
+		if (!declarator) {
+			return typeText;
+		}
+		// Значение по умолчанию и инициализатор к типу не относятся
+		const target =
+			declarator.type === "init_declarator"
+				? (this.getChild(declarator, "declarator") ?? declarator)
+				: declarator;
+		const text = this.getText(target);
+		const rest = (nameNode ? text.replace(this.getText(nameNode), "") : text)
+			.replace(/\s+/g, " ")
+			.trim();
+		return joinType(typeText, rest);
+	}
+
+	private extractParameters(
+		parametersNode: Parser.SyntaxNode | null
+	): FunctionParameter[] {
+		const params: FunctionParameter[] = [];
+		for (const param of parametersNode?.children ?? []) {
+			if (param.type === "variadic_parameter" || param.type === "...") {
+				params.push({ name: "", type: "...", variadic: true });
+				continue;
+			}
+			if (!param.type.endsWith("parameter_declaration")) {
+				continue;
+			}
+			const typeNode = this.getChild(param, "type");
+			const declarator = this.getChild(param, "declarator");
+			const nameNode = this.declaratorName(declarator);
+			// f(void) — параметров нет
+			if (!declarator && typeNode && this.getText(typeNode) === "void") {
+				continue;
+			}
+			const defaultValue = this.getChild(param, "default_value");
+			const variadic =
+				param.type === "variadic_parameter_declaration" ||
+				declarator?.type === "variadic_declarator";
+			params.push({
+				name: nameNode ? this.getText(nameNode) : "",
+				type: typeNode
+					? this.declaredType(param, declarator, nameNode)
+					: undefined,
+				defaultValue: defaultValue ? this.getText(defaultValue) : undefined,
+				isOptional: defaultValue ? true : undefined,
+				variadic: variadic || undefined,
+			});
+		}
+		return params;
+	}
+
+	/**
+	 * static/inline/virtual/explicit объявления и const/override/noexcept
+	 * после списка параметров
+	 */
+	private extractSpecifiers(
+		node: Parser.SyntaxNode,
+		fn: Parser.SyntaxNode
+	): string[] {
+		const modifiers: string[] = [];
+		for (const child of node.children) {
+			if (SPECIFIER_TYPES.has(child.type)) {
+				modifiers.push(this.getText(child));
+			}
+		}
+		for (const child of fn.children) {
+			if (
+				child.type === "type_qualifier" ||
+				child.type === "virtual_specifier" ||
+				child.type === "noexcept"
+			) {
+				modifiers.push(this.getText(child));
+			}
+		}
+		return modifiers;
+	}
+
+	/**
+	 * Атрибуты `[[nodiscard]]`, `[[deprecated("use g")]]`, `[[gnu::cold]]`
+	 */
+	private extractAttributes(
+		...nodes: Parser.SyntaxNode[]
+	): Decorator[] | undefined {
+		const decorators: Decorator[] = [];
+		for (const node of new Set(nodes)) {
+			for (const declaration of this.getChildrenOfType(
+				node,
+				"attribute_declaration"
+			)) {
+				for (const attribute of declaration.namedChildren) {
+					const nameNode = this.getChild(attribute, "name");
+					if (!nameNode) {
+						continue;
+					}
+					const prefix = this.getChild(attribute, "prefix");
+					const args = this.getChild(attribute, "arguments");
+					decorators.push({
+						name: prefix
+							? `${this.getText(prefix)}::${this.getText(nameNode)}`
+							: this.getText(nameNode),
+						arguments: args?.namedChildren.map((arg) => this.getText(arg)),
+					});
+				}
+			}
+		}
+		return decorators.length > 0 ? decorators : undefined;
+	}
+
+	/**
+	 * Doxygen над объявлением: `/** ... *\/`, `/*! ... *\/` или подряд
+	 * идущие строки `///` и `//!`. Комментарий в конце строки предыдущего
+	 * объявления (`int x; ///< size`) к следующему не относится.
+	 */
+	private extractDocComment(node: Parser.SyntaxNode): string {
+		const lines: string[] = [];
+		let row = node.startPosition.row;
+		let prev = node.previousSibling;
+		while (
+			prev?.type === "comment" &&
+			prev.endPosition.row >= row - 1 &&
+			prev.startPosition.row !== prev.previousSibling?.endPosition.row
+		) {
+			const text = this.getText(prev);
+			if (/^\/\/[/!](?!<)/.test(text)) {
+				lines.unshift(text.slice(3).replace(/^ /, "").trimEnd());
+			} else if (/^\/\*[*!](?!<)/.test(text) && lines.length === 0) {
+				return text
+					.replace(/^\/\*[*!]/, "")
+					.replace(/\*\/$/, "")
+					.split("\n")
+					.map((line) => line.replace(DOC_LINE_RE, "").trimEnd())
+					.join("\n")
+					.trim();
+			} else {
+				break;
+			}
+			row = prev.startPosition.row;
+			prev = prev.previousSibling;
+		}
+		return lines.join("\n").trim();
+	}
+
+	/**
+	 * Имена вызванных функций: `helper()`, `buf.size()`, `std::move()` -> move
+	 */
+	private extractCalls(node: Parser.SyntaxNode): string[] {
+		const calls = new Set<string>();
+		for (const call of this.findNodesOfType(node, "call_expression")) {
+			let callee = this.getChild(call, "function");
+			while (callee) {
+				const next =
+					this.getChild(callee, "field") ?? this.getChild(callee, "name");
+				if (!next) {
+					break;
+				}
+				callee = next;
+			}
+			if (
+				callee?.type === "identifier" ||
+				callee?.type === "field_identifier"
+			) {
+				calls.add(this.getText(callee));
+			}
+		}
+		return [...calls].slice(0, MAX_CALLS);
+	}
+
+	/**
+	 * Диапазон по тексту узла без завершающего перевода строки
+	 */
+	private textRange(node: Parser.SyntaxNode, text: string): SourceRange {
+		const lines = text.split("\n");
+		const last = lines.at(-1) ?? "";
+		return {
+			startLine: this.getLineNumber(node.startPosition),
+			startCol: node.startPosition.column,
+			endLine: this.getLineNumber(node.startPosition) + lines.length - 1,
+			endCol:
+				lines.length === 1
+					? node.startPosition.column + last.length
+					: last.length,
+		};
+	}
+}
+
+function isExportedVisibility(visibility: Visibility): boolean {
+	return visibility === "public" || visibility === "protected";
+}
+
+function isCallable(symbol: EnhancedCodeSymbol): boolean {
+	return symbol.symbolType === "function" || symbol.symbolType === "method";
+}
+
+function isPrototype(symbol: EnhancedCodeSymbol): boolean {
+	return symbol.metadata?.modifiers?.includes("prototype") ?? false;
+}
+
+/**
+ * Ключ перегрузки: пространство имён, имя и типы параметров
+ */
+function overloadKey(symbol: EnhancedCodeSymbol): string {
+	const types = (symbol.metadata?.parameters ?? []).map((p) => p.type ?? "");
+	const pkg = symbol.metadata?.packageName ?? "";
+	return `${pkg}::${symbol.name}(${types.join(",")})`;
+}
+
+/**
+ * `char` + `*` -> `char *`, `int` + `[4]` -> `int[4]`
+ */
+function joinType(base: string, rest: string): string {
+	if (!rest) {
+		return base;
+	}
+	return rest.startsWith("[") ? `${base}${rest}` : `${base} ${rest}`;
+}
+
+function parseInteger(text: string): number | null {
+	const match = text.match(INTEGER_RE);
+	return match ? Number(match[1]) : null;
+}
+
+/**
+ * `defined(X)` <-> `!defined(X)`, иначе `!(cond)`
+ */
+function negateCondition(condition: string): string {
+	if (/^!defined\([^()]*\)$/.test(condition)) {
+		return condition.slice(1);
+	}
+	if (/^defined\([^()]*\)$/.test(condition)) {
+		return `!${condition}`;
+	}
+	return `!(${condition})`;
+}
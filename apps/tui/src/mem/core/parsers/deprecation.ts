@@ -0,0 +1,179 @@
+/**
+ * Распознавание устаревших символов по конвенциям языков
+ *
+ * Каждый язык помечает deprecation по-своему: абзац `Deprecated:` в Go,
+ * тег `@deprecated` в JSDoc и Javadoc, аннотация `@Deprecated` в Java,
+ * декоратор `@deprecated` или директива `.. deprecated::` в Python,
+ * атрибут `[[deprecated]]` и команда Doxygen `\deprecated` в C/C++.
+ * Парсеры сводят это к metadata.deprecated и deprecationMessage.
+ */
+
+import type { Decorator, EnhancedCodeSymbol } from "./types.ts";
+
+export interface Deprecation {
+	/** Текст после маркера; нет, если маркер без пояснения */
+	message?: string;
+}
+
+const GO_DEPRECATED_RE = /^Deprecated:\s*(.*)$/;
+const DOC_TAG_RE = /^@deprecated\b\s*(.*)$/;
+const NEXT_TAG_RE = /^@\w/;
+// `deprecated`, `typing_extensions.deprecated("...")`, `warnings.deprecated`
+const PYTHON_DECORATOR_RE = /^(?:[\w.]+\.)?deprecated\b(?:\(([\s\S]*)\))?$/;
+const PYTHON_DIRECTIVE_RE = /^\s*\.\.\s+deprecated::\s*(.*)$/;
+const JAVA_ANNOTATIONS = new Set(["Deprecated", "java.lang.Deprecated"]);
+const CPP_ATTRIBUTES = new Set(["deprecated", "gnu::deprecated"]);
+// Doxygen принимает команды и через `@`, и через `\`
+const DOXYGEN_DEPRECATED_RE = /^[@\\]deprecated\b\s*(.*)$/;
+const DOXYGEN_COMMAND_RE = /^[@\\]\w/;
+const STRING_LITERAL_RE = /^[rRbBuUfF]*("""|'''|"|')([\s\S]*?)\1/;
+
+/**
+ * Собрать строки пояснения в одну строку; пустое пояснение — без message
+ */
+function deprecation(lines: string[]): Deprecation {
+	const message = lines
+		.map((line) => line.trim())
+		.filter(Boolean)
+		.join(" ");
+	return message ? { message } : {};
+}
+
+/**
+ * Строки, начиная с маркера и до конца абзаца (или условия stop)
+ */
+function paragraphAfter(
+	lines: string[],
+	start: number,
+	first: string,
+	stop: (line: string) => boolean = () => false
+): string[] {
+	const paragraph = [first];
+	for (const line of lines.slice(start + 1)) {
+		if (!line.trim() || stop(line.trim())) {
+			break;
+		}
+		paragraph.push(line);
+	}
+	return paragraph;
+}
+
+/**
+ * Go: абзац doc comment, начинающийся с `Deprecated:`
+ */
+export function goDeprecation(doc: string | undefined): Deprecation | null {
+	const lines = (doc ?? "").split("\n");
+	for (const [i, line] of lines.entries()) {
+		const match = line.match(GO_DEPRECATED_RE);
+		// Маркер действует только в начале абзаца
+		if (match && (i === 0 || !lines[i - 1]?.trim())) {
+			return deprecation(paragraphAfter(lines, i, match[1] as string));
+		}
+	}
+	return null;
+}
+
+/**
+ * JSDoc и Javadoc: тег `@deprecated`, пояснение — до следующего тега
+ */
+export function docTagDeprecation(doc: string | undefined): Deprecation | null {
+	const lines = (doc ?? "").split("\n");
+	for (const [i, line] of lines.entries()) {
+		const match = line.trim().match(DOC_TAG_RE);
+		if (match) {
+			const rest = lines.slice(i + 1);
+			const end = rest.findIndex((l) => NEXT_TAG_RE.test(l.trim()));
+			return deprecation([
+				match[1] as string,
+				...(end === -1 ? rest : rest.slice(0, end)),
+			]);
+		}
+	}
+	return null;
+}
+
+/**
+ * Java: аннотация `@Deprecated`; пояснение берётся из Javadoc-тега
+ * `@deprecated`, который и сам по себе помечает символ устаревшим
+ */
+export function javaDeprecation(
+	decorators: Decorator[] | undefined,
+	doc: string | undefined
+): Deprecation | null {
+	const fromDoc = docTagDeprecation(doc);
+	if (fromDoc) {
+		return fromDoc;
+	}
+	const annotated = decorators?.some((d) => JAVA_ANNOTATIONS.has(d.name));
+	return annotated ? {} : null;
+}
+
+/**
+ * Python: декоратор `@deprecated("...")` (PEP 702) или директива
+ * `.. deprecated:: version` в docstring
+ */
+export function pythonDeprecation(
+	decorators: Decorator[] | undefined,
+	docstring: string | undefined
+): Deprecation | null {
+	for (const decorator of decorators ?? []) {
+		const match = decorator.name.trim().match(PYTHON_DECORATOR_RE);
+		if (match) {
+			const literal = match[1]?.trim().match(STRING_LITERAL_RE);
+			return deprecation(literal ? [literal[2] as string] : []);
+		}
+	}
+	const lines = (docstring ?? "").split("\n");
+	for (const [i, line] of lines.entries()) {
+		const match = line.match(PYTHON_DIRECTIVE_RE);
+		if (match) {
+			return deprecation(paragraphAfter(lines, i, match[1] as string));
+		}
+	}
+	return null;
+}
+
+/**
+ * C/C++: атрибут `[[deprecated("...")]]` или команда Doxygen
+ * `@deprecated` / `\deprecated` (пояснение — до следующей команды)
+ */
+export function cppDeprecation(
+	decorators: Decorator[] | undefined,
+	doc: string | undefined
+): Deprecation | null {
+	for (const decorator of decorators ?? []) {
+		if (CPP_ATTRIBUTES.has(decorator.name)) {
+			const literal = decorator.arguments?.[0]?.match(STRING_LITERAL_RE);
+			return deprecation(literal ? [literal[2] as string] : []);
+		}
+	}
+	const lines = (doc ?? "").split("\n");
+	for (const [i, line] of lines.entries()) {
+		const match = line.trim().match(DOXYGEN_DEPRECATED_RE);
+		if (match) {
+			return deprecation(
+				paragraphAfter(lines, i, match[1] as string, (l) =>
+					DOXYGEN_COMMAND_RE.test(l)
+				)
+			);
+		}
+	}
+	return null;
+}
+
+/**
+ * Записать deprecation в metadata символа
+ */
+export function applyDeprecation(
+	symbol: EnhancedCodeSymbol,
+	found: Deprecation | null
+): void {
+	if (!found) {
+		return;
+	}
+	symbol.metadata = {
+		...symbol.metadata,
+		deprecated: true,
+		...(found.message && { deprecationMessage: found.message }),
+	};
+}
@@ -0,0 +1,285 @@
+/**
+ * Build constraints Go: `//go:build`, `// +build` и суффиксы имени файла
+ * (`_linux.go`, `_windows_amd64.go`)
+ */
+
+import { basename } from "node:path";
+import type { BuildConstraints } from "./types.ts";
+
+const KNOWN_GOOS = new Set([
+	"aix",
+	"android",
+	"darwin",
+	"dragonfly",
+	"freebsd",
+	"hurd",
+	"illumos",
+	"ios",
+	"js",
+	"linux",
+	"nacl",
+	"netbsd",
+	"openbsd",
+	"plan9",
+	"solaris",
+	"wasip1",
+	"windows",
+	"zos",
+]);
+
+const KNOWN_GOARCH = new Set([
+	"386",
+	"amd64",
+	"amd64p32",
+	"arm",
+	"armbe",
+	"arm64",
+	"arm64be",
+	"loong64",
+	"mips",
+	"mipsle",
+	"mips64",
+	"mips64le",
+	"mips64p32",
+	"mips64p32le",
+	"ppc",
+	"ppc64",
+	"ppc64le",
+	"riscv",
+	"riscv64",
+	"s390",
+	"s390x",
+	"sparc",
+	"sparc64",
+	"wasm",
+]);
+
+// GOOS, для которых выполняется тег `unix`
+const UNIX_GOOS = new Set([
+	"aix",
+	"android",
+	"darwin",
+	"dragonfly",
+	"freebsd",
+	"hurd",
+	"illumos",
+	"ios",
+	"linux",
+	"netbsd",
+	"openbsd",
+	"solaris",
+]);
+
+const GO_BUILD_RE = /^\/\/go:build\s+(.+)$/;
+const PLUS_BUILD_RE = /^\/\/\s*\+build\s+(.+)$/;
+const GO_VERSION_TAG_RE = /^go1\.\d+$/;
+const TOKEN_RE = /\s*(\|\||&&|!|\(|\)|[\w.]+)/y;
+
+const NODE_PLATFORMS: Record<string, string> = { win32: "windows" };
+const NODE_ARCHS: Record<string, string> = {
+	ia32: "386",
+	x64: "amd64",
+};
+
+/**
+ * Целевая платформа для фильтрации файлов
+ */
+export interface BuildContext {
+	goarch?: string;
+	goos?: string;
+	/** Дополнительные теги (`-tags`), например "integration" */
+	tags?: string[];
+}
+
+/**
+ * Считать build constraints из заголовка файла и его имени
+ *
+ * Constraint-комментарии учитываются только до package clause.
+ * `//go:build` имеет приоритет; строки `// +build` собираются в
+ * эквивалентное выражение (строки — AND, пробел — OR, запятая — AND).
+ */
+export function parseBuildConstraints(
+	source: string,
+	filePath: string
+): BuildConstraints | undefined {
+	let expression: string | undefined;
+	const plusLines: string[] = [];
+
+	for (const rawLine of source.split("\n")) {
+		const line = rawLine.trim();
+		if (line === "") {
+			continue;
+		}
+		if (!line.startsWith("//")) {
+			break;
+		}
+		const goBuild = line.match(GO_BUILD_RE);
+		if (goBuild?.[1]) {
+			expression ??= goBuild[1].trim();
+			continue;
+		}
+		const plusBuild = line.match(PLUS_BUILD_RE);
+		if (plusBuild?.[1]) {
+			plusLines.push(plusBuild[1].trim());
+		}
+	}
+
+	if (!expression && plusLines.length > 0) {
+		expression = plusBuildToExpression(plusLines);
+	}
+
+	const { goos, goarch } = fileNameConstraints(filePath);
+	if (!(expression || goos || goarch)) {
+		return undefined;
+	}
+	return { expression, goos, goarch };
+}
+
+function plusBuildToExpression(lines: string[]): string {
+	const clauses = lines.map((line) => {
+		const options = line
+			.split(/\s+/)
+			.map((option) => option.split(",").join(" && "));
+		return options.length > 1 ? `(${options.join(" || ")})` : options[0];
+	});
+	return clauses.join(" && ");
+}
+
+/**
+ * GOOS/GOARCH из суффиксов имени: name_GOOS_GOARCH.go, name_GOOS.go, name_GOARCH.go
+ */
+export function fileNameConstraints(filePath: string): {
+	goarch?: string;
+	goos?: string;
+} {
+	const parts = basename(filePath)
+		.replace(/\.go$/, "")
+		.replace(/_test$/, "")
+		.split("_");
+	// Файл `linux.go` (без префикса) не ограничен
+	if (parts.length < 2) {
+		return {};
+	}
+
+	const last = parts.at(-1) ?? "";
+	const prev = parts.length > 2 ? (parts.at(-2) ?? "") : "";
+	if (KNOWN_GOARCH.has(last) && KNOWN_GOOS.has(prev)) {
+		return { goos: prev, goarch: last };
+	}
+	if (KNOWN_GOOS.has(last)) {
+		return { goos: last };
+	}
+	if (KNOWN_GOARCH.has(last)) {
+		return { goarch: last };
+	}
+	return {};
+}
+
+/**
+ * Платформа текущего процесса в терминах GOOS/GOARCH
+ */
+export function hostBuildContext(): Required<Omit<BuildContext, "tags">> {
+	return {
+		goos: NODE_PLATFORMS[process.platform] ?? process.platform,
+		goarch: NODE_ARCHS[process.arch] ?? process.arch,
+	};
+}
+
+/**
+ * Проверить, попадает ли файл с данными constraints в сборку
+ *
+ * Незаданные goos/goarch берутся с хоста, как у `go build`.
+ */
+export function matchesBuildContext(
+	constraints: BuildConstraints | undefined,
+	context: BuildContext
+): boolean {
+	if (!constraints) {
+		return true;
+	}
+	const host = hostBuildContext();
+	const goos = context.goos ?? host.goos;
+	const goarch = context.goarch ?? host.goarch;
+
+	if (constraints.goos && constraints.goos !== goos) {
+		return false;
+	}
+	if (constraints.goarch && constraints.goarch !== goarch) {
+		return false;
+	}
+	if (!constraints.expression) {
+		return true;
+	}
+
+	const tags = new Set([goos, goarch, ...(context.tags ?? [])]);
+	if (UNIX_GOOS.has(goos)) {
+		tags.add("unix");
+	}
+	return evaluateExpression(constraints.expression, tags);
+}
+
+/**
+ * Вычислить `//go:build` выражение: ||, &&, !, скобки
+ *
+ * Теги версии (`go1.21`) считаются выполненными. Некорректное выражение
+ * не исключает файл.
+ */
+export function evaluateExpression(expression: string, tags: Set<string>): boolean {
+	const tokens = tokenize(expression);
+	if (!tokens) {
+		return true;
+	}
+
+	let pos = 0;
+	const peek = () => tokens[pos];
+
+	const parseOr = (): boolean => {
+		let value = parseAnd();
+		while (peek() === "||") {
+			pos++;
+			const right = parseAnd();
+			value = value || right;
+		}
+		return value;
+	};
+	const parseAnd = (): boolean => {
+		let value = parseNot();
+		while (peek() === "&&") {
+			pos++;
+			const right = parseNot();
+			value = value && right;
+		}
+		return value;
+	};
+	const parseNot = (): boolean => {
+		if (peek() === "!") {
+			pos++;
+			return !parseNot();
+		}
+		if (peek() === "(") {
+			pos++;
+			const value = parseOr();
+			pos++; // ")"
+			return value;
+		}
+		const tag = tokens[pos++] ?? "";
+		return tags.has(tag) || GO_VERSION_TAG_RE.test(tag);
+	};
+
+	return parseOr();
+}
+
+function tokenize(expression: string): string[] | null {
+	const tokens: string[] = [];
+	let pos = 0;
+	while (pos < expression.length) {
+		TOKEN_RE.lastIndex = pos;
+		const match = TOKEN_RE.exec(expression);
+		if (!match) {
+			return expression.slice(pos).trim() ? null : tokens;
+		}
+		tokens.push(match[1] as string);
+		pos = TOKEN_RE.lastIndex;
+	}
+	return tokens;
+}
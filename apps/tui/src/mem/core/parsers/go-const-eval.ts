@@ -0,0 +1,179 @@
+/**
+ * Статическое вычисление целочисленных константных выражений Go
+ *
+ * Поддерживает iota, целые литералы (dec/hex/octal/binary, `_`),
+ * ссылки на уже вычисленные константы, конверсии `T(expr)` и операторы
+ * с приоритетами Go. Всё остальное (строки, float, вызовы функций)
+ * даёт null.
+ */
+
+const TOKEN_RE =
+	/\s*(0[xX][\da-fA-F_]+|0[bB][01_]+|0[oO]?[0-7_]+|\d[\d_]*|[\p{L}_][\p{L}\p{N}_]*(?:\.[\p{L}_][\p{L}\p{N}_]*)?|<<|>>|&\^|[-+*/%&|^()!])/uy;
+
+// Бинарные операторы Go: 5 — мультипликативные, 4 — аддитивные
+const PRECEDENCE: Record<string, number> = {
+	"*": 5,
+	"/": 5,
+	"%": 5,
+	"<<": 5,
+	">>": 5,
+	"&": 5,
+	"&^": 5,
+	"+": 4,
+	"-": 4,
+	"|": 4,
+	"^": 4,
+};
+
+class NotConstant extends Error {}
+
+function tokenize(expression: string): string[] | null {
+	const tokens: string[] = [];
+	let pos = 0;
+	while (pos < expression.length) {
+		TOKEN_RE.lastIndex = pos;
+		const match = TOKEN_RE.exec(expression);
+		if (!match) {
+			return expression.slice(pos).trim() ? null : tokens;
+		}
+		tokens.push(match[1] as string);
+		pos = TOKEN_RE.lastIndex;
+	}
+	return tokens;
+}
+
+function parseIntLiteral(token: string): bigint | null {
+	const clean = token.replace(/_/g, "");
+	if (/^0[0-7]+$/.test(clean)) {
+		return BigInt(`0o${clean.slice(1)}`);
+	}
+	try {
+		return BigInt(clean);
+	} catch {
+		return null;
+	}
+}
+
+function applyBinary(op: string, a: bigint, b: bigint): bigint {
+	switch (op) {
+		case "*":
+			return a * b;
+		case "/":
+			if (b === 0n) {
+				throw new NotConstant();
+			}
+			return a / b;
+		case "%":
+			if (b === 0n) {
+				throw new NotConstant();
+			}
+			return a % b;
+		case "<<":
+			return a << b;
+		case ">>":
+			return a >> b;
+		case "&":
+			return a & b;
+		case "&^":
+			return a & ~b;
+		case "+":
+			return a + b;
+		case "-":
+			return a - b;
+		case "|":
+			return a | b;
+		case "^":
+			return a ^ b;
+		default:
+			throw new NotConstant();
+	}
+}
+
+/**
+ * Вычислить выражение или вернуть null, если оно не вычисляется статически
+ * или не помещается в безопасное целое JS
+ *
+ * @param known - значения ранее объявленных констант
+ */
+export function evaluateConstExpression(
+	expression: string,
+	iota: number,
+	known: ReadonlyMap<string, number | null> = new Map()
+): number | null {
+	const tokens = tokenize(expression);
+	if (!tokens || tokens.length === 0) {
+		return null;
+	}
+
+	let pos = 0;
+
+	const parsePrimary = (): bigint => {
+		const token = tokens[pos++];
+		if (token === undefined) {
+			throw new NotConstant();
+		}
+		if (token === "(") {
+			const value = parseBinary(0);
+			if (tokens[pos++] !== ")") {
+				throw new NotConstant();
+			}
+			return value;
+		}
+		if (token === "-" || token === "+" || token === "^") {
+			const operand = parsePrimary();
+			if (token === "-") {
+				return -operand;
+			}
+			return token === "^" ? ~operand : operand;
+		}
+		if (/^\d/.test(token)) {
+			const literal = parseIntLiteral(token);
+			if (literal === null) {
+				throw new NotConstant();
+			}
+			return literal;
+		}
+		// Конверсия типа: Weekday(iota), uint8(1 << 3)
+		if (tokens[pos] === "(") {
+			pos++;
+			const value = parseBinary(0);
+			if (tokens[pos++] !== ")") {
+				throw new NotConstant();
+			}
+			return value;
+		}
+		if (token === "iota") {
+			return BigInt(iota);
+		}
+		const value = known.get(token);
+		if (value === undefined || value === null) {
+			throw new NotConstant();
+		}
+		return BigInt(value);
+	};
+
+	const parseBinary = (minPrecedence: number): bigint => {
+		let left = parsePrimary();
+		while (true) {
+			const op = tokens[pos];
+			const precedence = op ? PRECEDENCE[op] : undefined;
+			if (precedence === undefined || precedence <= minPrecedence) {
+				return left;
+			}
+			pos++;
+			const right = parseBinary(precedence);
+			left = applyBinary(op as string, left, right);
+		}
+	};
+
+	try {
+		const result = parseBinary(0);
+		if (pos !== tokens.length) {
+			return null;
+		}
+		const asNumber = Number(result);
+		return Number.isSafeInteger(asNumber) ? asNumber : null;
+	} catch {
+		return null;
+	}
+}
@@ -0,0 +1,70 @@
+/**
+ * Директивы инструментов в комментариях Go: `//go:generate`, `//go:embed`,
+ * `//nolint`, `//export`, `//line`
+ *
+ * Директива — строчный комментарий без пробела после `//`, как в go/ast:
+ * `// go:embed` и `//TODO: fix` остаются обычными комментариями.
+ * Исключение — legacy `// +build`.
+ */
+
+import type { GoDirective } from "./types.ts";
+
+// `//nolint`, `//nolint:errcheck,gosec // причина`
+const NOLINT_RE = /^\/\/nolint(?::(\S+))?(?:\s.*)?$/;
+// go:generate, go:embed, lint:ignore: `//[a-z0-9]+:[a-z0-9]`
+const NAMESPACED_RE = /^\/\/([a-z0-9]+:[a-z0-9]\w*)(?:\s+(.*))?$/;
+const KEYWORD_RE = /^\/\/(export|extern|line)\s+(\S.*)$/;
+const PLUS_BUILD_RE = /^\/\/ \+build(?:\s+(.*))?$/;
+const ARG_RE = /"(?:[^"\\]|\\.)*"|`[^`]*`|\S+/g;
+
+/**
+ * Директива в тексте строчного комментария или undefined
+ *
+ * @param text - комментарий целиком, с `//`
+ * @param line - строка комментария (1-based)
+ */
+export function parseGoDirective(
+	text: string,
+	line: number
+): GoDirective | undefined {
+	const comment = text.trimEnd();
+	const nolint = NOLINT_RE.exec(comment);
+	if (nolint) {
+		return {
+			name: "nolint",
+			args: nolint[1]?.split(",").filter(Boolean) ?? [],
+			line,
+		};
+	}
+	const plusBuild = PLUS_BUILD_RE.exec(comment);
+	if (plusBuild) {
+		return { name: "+build", args: splitArgs(plusBuild[1] ?? ""), line };
+	}
+	const match = NAMESPACED_RE.exec(comment) ?? KEYWORD_RE.exec(comment);
+	if (!match?.[1]) {
+		return undefined;
+	}
+	return { name: match[1], args: splitArgs(match[2] ?? ""), line };
+}
+
+/**
+ * Аргументы директивы: по пробелам, строки в кавычках — одним аргументом
+ * без кавычек (`//go:generate sh -c "echo hi"`)
+ */
+function splitArgs(text: string): string[] {
+	return [...text.matchAll(ARG_RE)].map(([arg]) => unquote(arg));
+}
+
+function unquote(arg: string): string {
+	if (arg.length >= 2 && arg.startsWith("`") && arg.endsWith("`")) {
+		return arg.slice(1, -1);
+	}
+	if (arg.length >= 2 && arg.startsWith('"') && arg.endsWith('"')) {
+		try {
+			return JSON.parse(arg) as string;
+		} catch {
+			return arg.slice(1, -1);
+		}
+	}
+	return arg;
+}
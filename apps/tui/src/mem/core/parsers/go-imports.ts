@@ -0,0 +1,127 @@
+/**
+ * Разрешение алиасов импортов на уровне пакета Go
+ *
+ * Разные файлы пакета могут импортировать один путь под разными именами
+ * (`ctx "context"` и просто `context`). Resolver сводит любое локальное
+ * имя к каноническому пути импорта и сообщает о конфликтах: одно имя,
+ * указывающее в разных файлах на разные пути.
+ *
+ * dot-импорты (`import . "fmt"`) локального имени не вводят и учитываются
+ * отдельно, как источник неквалифицированных имён. blank-импорты
+ * (`import _ "embed"`) нужны только ради side effects и никогда не
+ * разрешаются.
+ */
+
+import type { GoImport } from "./types.ts";
+
+/**
+ * Одно локальное имя импорта с разными путями в разных файлах
+ */
+export interface GoImportConflict {
+	name: string;
+	/** Путь импорта по файлам, где встречается имя */
+	paths: Map<string, string>;
+}
+
+export class GoImportResolver {
+	readonly conflicts: GoImportConflict[] = [];
+	private readonly byFile = new Map<string, Map<string, string>>();
+	private readonly packageLevel = new Map<string, string>();
+	private readonly aliases = new Map<string, Set<string>>();
+	private readonly dotByFile = new Map<string, string[]>();
+	private readonly blankByFile = new Map<string, string[]>();
+
+	/**
+	 * @param imports - импорты по файлам пакета (как GoPackage.imports)
+	 */
+	constructor(imports: Map<string, GoImport[]>) {
+		const pathsByName = new Map<string, Map<string, string>>();
+		for (const [file, fileImports] of imports) {
+			const names = new Map<string, string>();
+			const dots: string[] = [];
+			const blanks: string[] = [];
+			for (const { kind, name, path } of fileImports) {
+				if (kind === "dot") {
+					dots.push(path);
+					continue;
+				}
+				if (kind === "blank") {
+					blanks.push(path);
+					continue;
+				}
+				names.set(name, path);
+				let paths = pathsByName.get(name);
+				if (!paths) {
+					paths = new Map();
+					pathsByName.set(name, paths);
+				}
+				paths.set(file, path);
+				let aliases = this.aliases.get(path);
+				if (!aliases) {
+					aliases = new Set();
+					this.aliases.set(path, aliases);
+				}
+				aliases.add(name);
+			}
+			this.byFile.set(file, names);
+			this.dotByFile.set(file, dots);
+			this.blankByFile.set(file, blanks);
+		}
+
+		for (const [name, paths] of pathsByName) {
+			if (new Set(paths.values()).size > 1) {
+				this.conflicts.push({ name, paths });
+			} else {
+				const [path] = paths.values();
+				if (path) {
+					this.packageLevel.set(name, path);
+				}
+			}
+		}
+	}
+
+	/**
+	 * Канонический путь импорта для локального имени
+	 *
+	 * С file — по импортам этого файла. Без file — по пакету целиком;
+	 * для конфликтующего имени возвращается undefined, а не один из путей.
+	 */
+	resolve(name: string, file?: string): string | undefined {
+		if (file !== undefined) {
+			return this.byFile.get(file)?.get(name);
+		}
+		return this.packageLevel.get(name);
+	}
+
+	/**
+	 * Пути dot-импортов файла (без file — всего пакета): отсюда могут
+	 * прийти неквалифицированные имена
+	 */
+	dotImports(file?: string): string[] {
+		return collectPaths(this.dotByFile, file);
+	}
+
+	/**
+	 * Пути blank-импортов (только side effects) файла или всего пакета
+	 */
+	sideEffectImports(file?: string): string[] {
+		return collectPaths(this.blankByFile, file);
+	}
+
+	/**
+	 * Все локальные имена, под которыми путь импортируется в пакете
+	 */
+	aliasesOf(path: string): string[] {
+		return [...(this.aliases.get(path) ?? [])].sort();
+	}
+}
+
+function collectPaths(
+	byFile: Map<string, string[]>,
+	file: string | undefined
+): string[] {
+	if (file !== undefined) {
+		return byFile.get(file) ?? [];
+	}
+	return [...new Set([...byFile.values()].flat())].sort();
+}
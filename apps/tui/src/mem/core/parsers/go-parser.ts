@@ -1,42 +1,1072 @@
 // @ts-nocheck
-import type Parser from "tree-sitter";
+import { readdir } from "node:fs/promises";
+import { basename, join } from "node:path";
+import Parser from "tree-sitter";
 import Go from "tree-sitter-go";
+import type { ParserCapability } from "./base-parser.ts";
+import { assertKnownOptions } from "./base-parser.ts";
+import { applyDeprecation, goDeprecation } from "./deprecation.ts";
+import type { BuildContext } from "./go-build.ts";
+import { matchesBuildContext, parseBuildConstraints } from "./go-build.ts";
+import { evaluateConstExpression } from "./go-const-eval.ts";
+import { parseGoDirective } from "./go-directives.ts";
+import { GoImportResolver } from "./go-imports.ts";
+import { normalizeSource, readSource } from "./source-text.ts";
 import {
 	BaseNodeExtractor,
 	type NodeExtractor,
+	parseTree,
+	parseWithDepthLimit,
 	TreeSitterParser,
 } from "./tree-sitter-parser.ts";
 import type {
+	CallReference,
+	CodeMarker,
 	EnhancedCodeSymbol,
 	FunctionParameter,
+	FunctionResult,
+	GenericParameter,
+	GoDirective,
+	GoImport,
+	GoImportKind,
+	InterfaceMethod,
+	ParseResult,
+	SourceRange,
+	StructField,
 	SymbolMetadata,
+	SymbolMetrics,
+	TestKind,
+	TypeKind,
+	TypeMention,
+	TypeReference,
 } from "./types.ts";
 
+/**
+ * Опции Go парсера
+ */
+export interface GoParserOptions {
+	/**
+	 * Размер файла (в символах), начиная с которого тела функций не
+	 * анализируются: calls, callRefs и локальные символы пусты, объявления
+	 * верхнего уровня извлекаются как обычно. По умолчанию 512 KiB
+	 */
+	bodyAnalysisLimit?: number;
+	/**
+	 * Извлекать локальные типы и именованные замыкания внутри тел функций
+	 * (`helper := func() {}`); выключено по умолчанию ради скорости
+	 */
+	captureLocals?: boolean;
+	/**
+	 * parsePackage берёт и `_test.go` файлы (они попадают в пакет по своему
+	 * package clause); по умолчанию тестовые файлы пропускаются
+	 */
+	includeTests?: boolean;
+	/**
+	 * Маркеры тех. долга, которые ищутся в комментариях символа (doc comment
+	 * и тело). По умолчанию DEFAULT_MARKER_KINDS; пустой список отключает
+	 */
+	markers?: string[];
+	/**
+	 * Максимальная вложенность узлов объявления; более глубокие объявления
+	 * пропускаются с диагностикой. По умолчанию DEFAULT_MAX_DEPTH
+	 */
+	maxDepth?: number;
+	/**
+	 * Добавлять синтетический символ `package` (symbolType "package") с doc
+	 * comment над package clause; parsePackage включает его всегда
+	 */
+	packageSymbol?: boolean;
+	/** Включать doc comment в metadata.range (по умолчанию только объявление) */
+	rangeIncludesDocComment?: boolean;
+	/**
+	 * Записывать комментарий в конце строки (`Age int // years`)
+	 * в trailingComment полей структур и spec'ов const/var/type. Doc comment
+	 * следующего объявления такой комментарий не становится и без опции
+	 */
+	trailingComments?: boolean;
+}
+
+// Record гарантирует, что список ключей не отстанет от GoParserOptions
+const GO_OPTION_KEYS: Record<keyof GoParserOptions, true> = {
+	bodyAnalysisLimit: true,
+	captureLocals: true,
+	includeTests: true,
+	markers: true,
+	maxDepth: true,
+	packageSymbol: true,
+	rangeIncludesDocComment: true,
+	trailingComments: true,
+};
+
+/**
+ * Фильтр parsePackage по целевой платформе
+ *
+ * Без goos/goarch/tags в пакет попадают все файлы независимо от constraints.
+ */
+export type GoPackageOptions = BuildContext;
+
+/**
+ * Пакет Go: символы всех файлов с одинаковым package clause
+ */
+export interface GoPackage {
+	dir: string;
+	/** Директивы инструментов по файлам пакета */
+	directives: Map<string, GoDirective[]>;
+	/** Документация пакета: doc.go, иначе doc comments всех файлов */
+	doc?: string;
+	files: string[];
+	/** Импорты по файлам пакета */
+	imports: Map<string, GoImport[]>;
+	/** Алиасы импортов всех файлов -> канонический путь, с конфликтами */
+	importResolver: GoImportResolver;
+	name: string;
+	/** Символ пакета: из doc.go, иначе из первого файла; doc общий */
+	packageSymbol?: EnhancedCodeSymbol;
+	symbols: EnhancedCodeSymbol[];
+}
+
+const DEFAULT_BODY_ANALYSIS_LIMIT = 512 * 1024;
+
+export const DEFAULT_MARKER_KINDS = ["TODO", "FIXME", "HACK", "XXX"];
+
+const TERMINATOR_TYPES = new Set(["\n", ";"]);
+// Объявление верхнего уровня (gofmt всегда ставит его с нулевой колонки)
+const TOP_LEVEL_DECL_RE = /^(?:func|type|var|const)\b/;
+const IOTA_RE = /\biota\b/;
+// Имя типа без пакета и параметров: Color, но не pkg.Color или []Color
+const GO_TYPE_NAME_RE = /^[\p{L}_][\p{L}\p{N}_]*$/u;
+// Экспортируется имя с заглавной буквы Unicode (класс Lu по спецификации Go)
+const EXPORTED_NAME_RE = /^\p{Lu}/u;
+// Ветвления для оценки сложности: default-ветки switch/select не считаются
+const BRANCH_NODE_TYPES = new Set([
+	"if_statement",
+	"for_statement",
+	"expression_case",
+	"type_case",
+	"communication_case",
+]);
+// Идентификаторы в выражении типа: pkg.Type или Type
+const TYPE_IDENT_RE = /[\p{L}_][\p{L}\p{N}_]*(?:\.[\p{L}_][\p{L}\p{N}_]*)?/gu;
+// Идентификаторы типов и квадратные скобки инстанциаций
+const TYPE_TOKEN_RE = new RegExp(`${TYPE_IDENT_RE.source}|[[\\]]`, "gu");
+const TYPE_KEYWORDS = new Set(["chan", "func", "interface", "map", "struct"]);
+// Суффикс версии модуля: .../v2 или gopkg.in/yaml.v3
+const IMPORT_VERSION_RE = /^v\d+$/;
+const GOPKG_VERSION_RE = /\.v\d+$/;
+const TYPE_KIND_BY_NODE: Record<string, TypeKind> = {
+	struct_type: "struct",
+	interface_type: "interface",
+	function_type: "func",
+};
+const INTERFACE_ELEMENT_TYPES = new Set(["type_elem", "constraint_elem"]);
+const GO_BUILTIN_TYPES = new Set([
+	"any",
+	"bool",
+	"byte",
+	"comparable",
+	"complex64",
+	"complex128",
+	"error",
+	"float32",
+	"float64",
+	"int",
+	"int8",
+	"int16",
+	"int32",
+	"int64",
+	"rune",
+	"string",
+	"uint",
+	"uint8",
+	"uint16",
+	"uint32",
+	"uint64",
+	"uintptr",
+]);
+// Встроенные интерфейсы: встраиваются и в обычные интерфейсы
+const GO_BUILTIN_INTERFACES = new Set(["any", "error"]);
+
 /**
  * Go парсер на основе Tree-sitter
  */
 export class GoParser extends TreeSitterParser {
+	readonly capabilities: ReadonlySet<ParserCapability> = new Set([
+		"docComments",
+		"ranges",
+		"callGraph",
+		"generics",
+		"fields",
+	]);
+
+	private readonly options: GoParserOptions;
+
+	constructor(options: GoParserOptions = {}) {
+		super();
+		assertKnownOptions("go", options, Object.keys(GO_OPTION_KEYS));
+		this.options = options;
+		if (options.maxDepth !== undefined) {
+			this.setMaxDepth(options.maxDepth);
+		}
+	}
+
+	override get optionsKey(): string {
+		return Object.keys(this.options).length > 0
+			? JSON.stringify(this.options)
+			: "";
+	}
+
 	protected getLanguage(): unknown {
 		return Go;
 	}
 
 	protected getNodeExtractor(): NodeExtractor {
-		return new GoNodeExtractor();
+		return new GoNodeExtractor(this.options);
+	}
+
+	/**
+	 * Выдавать символы файла по мере обхода объявлений верхнего уровня
+	 *
+	 * Массив всех символов не строится, так что первый результат доступен
+	 * сразу. Для файлов больше bodyAnalysisLimit тела функций пропускаются.
+	 * Восстановления после синтаксических ошибок нет — это делает parse.
+	 */
+	async *parseFileStreaming(
+		filePath: string
+	): AsyncGenerator<EnhancedCodeSymbol, void, undefined> {
+		const source = await readSource(filePath);
+		const parser = new Parser();
+		parser.setLanguage(Go);
+		const guarded = parseWithDepthLimit(parser, source, this.maxDepth);
+		const extractor = new GoNodeExtractor(this.options);
+		yield* extractor.iterateSymbols(guarded.tree, filePath, guarded.source);
+	}
+
+	/**
+	 * Импорты Go-файла (без извлечения символов)
+	 */
+	parseImports(source: string): GoImport[] {
+		const parser = new Parser();
+		parser.setLanguage(Go);
+		const tree = parseTree(parser, normalizeSource(source));
+		return new GoNodeExtractor(this.options).extractFileImports(tree.rootNode);
+	}
+
+	/**
+	 * Директивы инструментов Go-файла (`//go:generate`, `//nolint`, ...)
+	 * в порядке исходника, включая директивы внутри тел функций
+	 */
+	parseDirectives(source: string): GoDirective[] {
+		const parser = new Parser();
+		parser.setLanguage(Go);
+		const tree = parseTree(parser, normalizeSource(source));
+		const extractor = new GoNodeExtractor(this.options);
+		return extractor.extractDirectives(tree.rootNode).directives;
+	}
+
+	/**
+	 * Парсинг с восстановлением после синтаксических ошибок
+	 *
+	 * Если дерево содержит ошибки, каждое объявление верхнего уровня
+	 * перепарсивается отдельно (остальные строки заменяются пустыми, так что
+	 * позиции не сдвигаются). Символы, которых нет в общем дереве,
+	 * добавляются к результату: ошибка в теле одной функции не скрывает
+	 * соседние объявления.
+	 */
+	protected async doParseSourceWithDiagnostics(
+		filePath: string,
+		source: string
+	): Promise<ParseResult> {
+		const result = await super.doParseSourceWithDiagnostics(filePath, source);
+		if (!result.diagnostics.some((d) => d.severity === "error")) {
+			return result;
+		}
+
+		const key = (s: EnhancedCodeSymbol) => `${s.symbolType}:${s.name}`;
+		const seen = new Set(result.symbols.map(key));
+		const recovered: EnhancedCodeSymbol[] = [];
+		for (const isolated of isolateTopLevelDeclarations(source)) {
+			const partial = await super.doParseSourceWithDiagnostics(
+				filePath,
+				isolated
+			);
+			for (const symbol of partial.symbols) {
+				if (!seen.has(key(symbol))) {
+					seen.add(key(symbol));
+					recovered.push(symbol);
+				}
+			}
+		}
+
+		if (recovered.length === 0) {
+			return result;
+		}
+		return {
+			symbols: [...result.symbols, ...recovered].sort(
+				(a, b) => a.startLine - b.startLine
+			),
+			diagnostics: result.diagnostics,
+		};
+	}
+
+	/**
+	 * Распарсить все не-тестовые .go файлы директории (с includeTests —
+	 * и тестовые), сгруппировав по пакетам
+	 *
+	 * Файлы с разными package clause (например `package sample_test`)
+	 * попадают в разные пакеты, а не смешиваются. С goos/goarch/tags
+	 * остаются только файлы, чьи build constraints выполняются, так что
+	 * `_linux.go` и `_windows.go` варианты одного символа не смешиваются.
+	 *
+	 * Символы package clause файлов не попадают в symbols, а сводятся в
+	 * packageSymbol: его doc — doc comment из doc.go, если он есть, иначе
+	 * doc comments всех файлов по порядку.
+	 *
+	 * Каждый файл читается и парсится один раз (с препроцессором): имя
+	 * пакета, импорты и директивы берутся из его package clause.
+	 */
+	async parsePackage(
+		dir: string,
+		options: GoPackageOptions = {}
+	): Promise<Map<string, GoPackage>> {
+		const filterByBuild = !!(options.goos || options.goarch || options.tags);
+		const entries = await readdir(dir, { withFileTypes: true });
+		const files = entries
+			.filter(
+				(e) =>
+					e.isFile() &&
+					e.name.endsWith(".go") &&
+					(this.options.includeTests || !e.name.endsWith("_test.go"))
+			)
+			.map((e) => join(dir, e.name))
+			.sort();
+
+		const packages = new Map<string, GoPackage>();
+		const clauses = new Map<string, EnhancedCodeSymbol[]>();
+		const parser = this.options.packageSymbol
+			? this
+			: new GoParser({ ...this.options, packageSymbol: true });
+		parser.setPreprocessor(this.preprocessor ?? null);
+
+		for (const file of files) {
+			const source = await readSource(file);
+			if (
+				filterByBuild &&
+				!matchesBuildContext(parseBuildConstraints(source, file), options)
+			) {
+				continue;
+			}
+			// Имя пакета — из package clause дерева, а не регуляркой по тексту
+			const symbols = await parser.parseSource(file, source);
+			const clause = symbols.find((s) => s.symbolType === "package");
+			if (!clause) {
+				continue;
+			}
+			const packageName = clause.name;
+
+			let pkg = packages.get(packageName);
+			if (!pkg) {
+				pkg = {
+					name: packageName,
+					dir,
+					directives: new Map(),
+					files: [],
+					imports: new Map(),
+					importResolver: new GoImportResolver(new Map()),
+					symbols: [],
+				};
+				packages.set(packageName, pkg);
+			}
+
+			pkg.files.push(file);
+			const language = clause.metadata?.language;
+			pkg.imports.set(file, language?.goImports ?? []);
+			pkg.directives.set(file, language?.goDirectives ?? []);
+			for (const symbol of symbols) {
+				if (symbol.symbolType === "package") {
+					clauses.set(packageName, [
+						...(clauses.get(packageName) ?? []),
+						symbol,
+					]);
+				} else {
+					pkg.symbols.push(symbol);
+				}
+			}
+		}
+
+		for (const [name, pkg] of packages) {
+			pkg.importResolver = new GoImportResolver(pkg.imports);
+			const packageSymbol = mergePackageSymbols(clauses.get(name) ?? []);
+			if (packageSymbol) {
+				pkg.packageSymbol = packageSymbol;
+				pkg.doc = packageSymbol.metadata?.docComment;
+			}
+		}
+
+		return packages;
 	}
 }
 
+// Имя тестовой функции: префикс, за которым не идёт строчная буква
+const TEST_FUNC_RE = /^(Test|Benchmark|Example|Fuzz)(?![\p{Ll}])/u;
+const TEST_PARAM_BY_KIND: Record<TestKind, string | null> = {
+	test: "*testing.T",
+	benchmark: "*testing.B",
+	example: null,
+	fuzz: "*testing.F",
+};
+
+/**
+ * Вид тестовой функции по соглашениям `go test`
+ *
+ * Test/Benchmark/Fuzz принимают ровно один *testing.T/B/F, Example —
+ * без параметров и результатов. `Testify` тестом не считается.
+ */
+function goTestKind(symbol: EnhancedCodeSymbol): TestKind | undefined {
+	if (symbol.symbolType !== "function" || symbol.metadata?.parent) {
+		return undefined;
+	}
+	const prefix = symbol.name.match(TEST_FUNC_RE)?.[1];
+	if (!prefix) {
+		return undefined;
+	}
+	const kind = prefix.toLowerCase() as TestKind;
+	const params = symbol.metadata?.parameters ?? [];
+	const expected = TEST_PARAM_BY_KIND[kind];
+	if (expected === null) {
+		return params.length === 0 && !symbol.metadata?.returns?.length
+			? kind
+			: undefined;
+	}
+	return params.length === 1 &&
+		params[0]?.type?.replace(/\s+/g, "") === expected
+		? kind
+		: undefined;
+}
+
+/**
+ * Директивы, относящиеся к объявлению: в непрерывном блоке комментариев
+ * сразу над ним или в конце его первой строки (`//go:embed` над var)
+ */
+function attachedDirectives(
+	symbol: EnhancedCodeSymbol,
+	directives: GoDirective[],
+	commentLines: Set<number>
+): GoDirective[] {
+	let first = symbol.startLine;
+	while (commentLines.has(first - 1)) {
+		first--;
+	}
+	return directives.filter(
+		(d) => d.line >= first && d.line <= symbol.startLine
+	);
+}
+
+/**
+ * Тип в одну строку: переводы строк и лишние пробелы из исходника убраны,
+ * так что переформатирование не меняет результат
+ */
+function canonicalGoType(type: string): string {
+	return type
+		.replace(/\s+/g, " ")
+		.replace(/,\s*([)\]])/g, "$1")
+		.replace(/([([]) /g, "$1")
+		.replace(/ ([)\]])/g, "$1")
+		.replace(/ ?, ?/g, ", ")
+		.replace(/ \{/g, "{")
+		.replace(/\{ \}/g, "{}")
+		.trim();
+}
+
+/**
+ * Список параметров или результатов: соседние именованные с одинаковым
+ * типом сгруппированы, как в gofmt — `a, b T`
+ */
+function goParameterList(
+	params: { name: string | null; type?: string | null; variadic?: boolean }[]
+): string {
+	const types = params.map((p) => canonicalGoType(p.type ?? ""));
+	return params
+		.map((param, i) => {
+			const type = param.variadic ? `...${types[i]}` : types[i];
+			const next = params[i + 1];
+			if (!param.name) {
+				return type;
+			}
+			const grouped =
+				!!next?.name && !next.variadic && types[i + 1] === types[i];
+			return grouped ? param.name : `${param.name} ${type}`;
+		})
+		.join(", ");
+}
+
+/**
+ * Каноническая сигнатура функции из структурированных данных, а не из
+ * текста исходника: `func Sum[T Number](a, b T) T`
+ *
+ * @param head - всё до списка type parameters: `func (u *User) Save`
+ */
+function goSignature(
+	head: string,
+	parameters: FunctionParameter[],
+	returns: FunctionResult[],
+	genericParams: GenericParameter[] = []
+): string {
+	const generics =
+		genericParams.length > 0
+			? `[${goParameterList(
+					genericParams.map((g) => ({ name: g.name, type: g.constraint }))
+				)}]`
+			: "";
+	const results =
+		returns.length === 1 && !returns[0]?.name
+			? ` ${canonicalGoType(returns[0]?.type ?? "")}`
+			: returns.length > 0
+				? ` (${goParameterList(returns)})`
+				: "";
+	return `${head}${generics}(${goParameterList(parameters)})${results}`;
+}
+
+function comparePoints(a: Parser.Point, b: Parser.Point): number {
+	return a.row === b.row ? a.column - b.column : a.row - b.row;
+}
+
 /**
  * Extractor для Go AST
  */
 class GoNodeExtractor extends BaseNodeExtractor {
+	private readonly options: GoParserOptions;
+	/** Файл больше bodyAnalysisLimit: тела функций не анализируются */
+	private skipBodies = false;
+
+	constructor(options: GoParserOptions) {
+		super();
+		this.options = options;
+	}
+
 	extractSymbols(
 		tree: Parser.Tree,
 		filePath: string,
 		source: string
 	): EnhancedCodeSymbol[] {
-		const symbols: EnhancedCodeSymbol[] = [];
-		this.visitNode(tree.rootNode, filePath, source, symbols);
-		return symbols;
+		return [...this.iterateSymbols(tree, filePath, source)];
+	}
+
+	/**
+	 * Символы файла по одному объявлению верхнего уровня за раз
+	 */
+	*iterateSymbols(
+		tree: Parser.Tree,
+		filePath: string,
+		source: string
+	): Generator<EnhancedCodeSymbol, void, undefined> {
+		const limit = this.options.bodyAnalysisLimit ?? DEFAULT_BODY_ANALYSIS_LIMIT;
+		this.skipBodies = source.length > limit;
+		if (this.skipBodies) {
+			this.diagnostics.push({
+				message: `Body analysis skipped: file exceeds ${limit} characters`,
+				range: this.getRange(tree.rootNode),
+				severity: "warning",
+			});
+		}
+
+		// package clause относится ко всем символам файла
+		const packageName = this.extractPackageName(tree.rootNode);
+		const buildConstraints = parseBuildConstraints(source, filePath);
+		const isTestFile = filePath.endsWith("_test.go");
+		const imports = this.extractFileImports(tree.rootNode);
+		const { commentLines, directives } = this.extractDirectives(
+			tree.rootNode
+		);
+		const markerRe = markerPattern(
+			this.options.markers ?? DEFAULT_MARKER_KINDS
+		);
+
+		const finish = (
+			symbol: EnhancedCodeSymbol,
+			node?: Parser.SyntaxNode,
+			batch: EnhancedCodeSymbol[] = []
+		): EnhancedCodeSymbol => {
+			if (packageName) {
+				symbol.metadata = { ...symbol.metadata, packageName };
+			}
+			// Go: видимость определяется регистром первой буквы
+			symbol.metadata = {
+				...symbol.metadata,
+				visibility: symbol.metadata?.isExported ? "public" : "private",
+			};
+			if (buildConstraints) {
+				symbol.metadata = { ...symbol.metadata, buildConstraints };
+			}
+			const attached = attachedDirectives(symbol, directives, commentLines);
+			if (attached.length > 0) {
+				symbol.metadata = { ...symbol.metadata, directives: attached };
+			}
+			if (isTestFile) {
+				const testKind = goTestKind(symbol);
+				symbol.metadata = {
+					...symbol.metadata,
+					isTest: true,
+					...(testKind && { testKind }),
+				};
+			}
+			const callRefs = symbol.metadata?.callRefs;
+			if (callRefs?.length) {
+				symbol.metadata = {
+					...symbol.metadata,
+					callRefs: this.resolveCallImports(symbol, callRefs, imports),
+				};
+			}
+			const typeRefs = this.resolveTypeRefs(symbol, imports, packageName);
+			if (typeRefs.length > 0) {
+				symbol.metadata = { ...symbol.metadata, typeRefs };
+			}
+			const typeMentions = node
+				? this.collectTypeMentions(node, symbol, imports)
+				: [];
+			if (typeMentions.length > 0) {
+				symbol.metadata = { ...symbol.metadata, typeMentions };
+			}
+			// Комментарии локальных символов (captureLocals) достаются им
+			const nested = batch.filter(
+				(other) =>
+					other !== symbol &&
+					other.startLine >= symbol.startLine &&
+					other.endLine <= symbol.endLine
+			);
+			const markers =
+				node && markerRe
+					? this.collectMarkers(node, symbol, markerRe, nested)
+					: [];
+			if (markers.length > 0) {
+				symbol.metadata = { ...symbol.metadata, markers };
+			}
+			// Deprecated-абзац комментария группы относится ко всем её членам
+			applyDeprecation(
+				symbol,
+				goDeprecation(symbol.metadata?.docComment) ??
+					goDeprecation(symbol.metadata?.groupDocComment)
+			);
+			return symbol;
+		};
+
+		if (this.options.packageSymbol) {
+			const clause = this.extractPackageClause(
+				tree.rootNode,
+				filePath,
+				source
+			);
+			if (clause) {
+				// Импорты и директивы файла: parsePackage берёт их отсюда
+				clause.metadata = {
+					...clause.metadata,
+					language: {
+						...clause.metadata?.language,
+						goDirectives: directives,
+						goImports: imports,
+					},
+				};
+				yield finish(clause);
+			}
+		}
+
+		for (const node of tree.rootNode.children) {
+			const batch: EnhancedCodeSymbol[] = [];
+			this.visitNode(node, filePath, source, batch);
+			for (const symbol of batch) {
+				yield finish(symbol, node, batch);
+			}
+		}
+	}
+
+	/**
+	 * Директивы всех строчных комментариев файла и строки, целиком занятые
+	 * комментариями (по ним директива привязывается к объявлению под ней)
+	 */
+	extractDirectives(root: Parser.SyntaxNode): {
+		commentLines: Set<number>;
+		directives: GoDirective[];
+	} {
+		const commentLines = new Set<number>();
+		const directives: GoDirective[] = [];
+		const visit = (node: Parser.SyntaxNode) => {
+			if (node.type !== "comment") {
+				for (const child of node.children) {
+					visit(child);
+				}
+				return;
+			}
+			const line = this.getLineNumber(node.startPosition);
+			const previous = this.previousSignificant(node);
+			if (!previous || previous.endPosition.row < node.startPosition.row) {
+				for (let row = line; row <= node.endPosition.row + 1; row++) {
+					commentLines.add(row);
+				}
+			}
+			const text = this.getText(node);
+			const directive = text.startsWith("//")
+				? parseGoDirective(text, line)
+				: undefined;
+			if (directive) {
+				directives.push(directive);
+			}
+		};
+		visit(root);
+		return { commentLines, directives };
+	}
+
+	/**
+	 * Импорты файла (включая алиасы, dot и blank импорты)
+	 */
+	extractFileImports(root: Parser.SyntaxNode): GoImport[] {
+		const imports: GoImport[] = [];
+		for (const decl of root.children) {
+			if (decl.type !== "import_declaration") {
+				continue;
+			}
+			for (const spec of this.findNodesOfType(decl, "import_spec")) {
+				const pathNode = this.getChild(spec, "path");
+				if (!pathNode) {
+					continue;
+				}
+				const path = this.getText(pathNode).replace(/^"|"$/g, "");
+				const aliasNode = this.getChild(spec, "name");
+				const alias = aliasNode ? this.getText(aliasNode) : undefined;
+				imports.push({
+					alias,
+					kind: importKind(alias),
+					name: alias ?? defaultImportName(path),
+					path,
+				});
+			}
+		}
+		return imports;
+	}
+
+	/**
+	 * Проставить importPath вызовам `pkg.Func()`, где pkg — импорт файла
+	 *
+	 * Квалификатор, совпавший с receiver'ом, — переменная, а не пакет.
+	 */
+	private resolveCallImports(
+		symbol: EnhancedCodeSymbol,
+		callRefs: CallReference[],
+		imports: GoImport[]
+	): CallReference[] {
+		const importsByName = namedImports(imports);
+		const receiverName = symbol.metadata?.receiverName;
+		return callRefs.map((ref) => {
+			const imported =
+				ref.qualifier && ref.qualifier !== receiverName
+					? importsByName.get(ref.qualifier)
+					: undefined;
+			return imported ? { ...ref, importPath: imported.path } : ref;
+		});
+	}
+
+	/**
+	 * Собрать ссылки на типы из сигнатуры/полей символа и разрешить
+	 * квалификаторы через импорты файла
+	 */
+	private resolveTypeRefs(
+		symbol: EnhancedCodeSymbol,
+		imports: GoImport[],
+		packageName: string | undefined
+	): TypeReference[] {
+		const meta = symbol.metadata;
+		if (!meta) {
+			return [];
+		}
+
+		const typeExprs = [
+			...(meta.parameters ?? []).map((p) => p.type),
+			...(meta.returns ?? []).map((r) => r.type),
+			...(meta.fields ?? []).map((f) => f.type),
+			...(meta.methods ?? []).flatMap((m) => [
+				...(m.parameters ?? []).map((p) => p.type),
+				...(m.returns ?? []).map((r) => r.type),
+				m.embedded ? m.name : undefined,
+			]),
+			...(meta.genericParams ?? []).map((g) => g.constraint),
+			symbol.symbolType === "method" ? meta.receiver : undefined,
+			meta.typeKind === "alias" || meta.typeKind === "named"
+				? meta.underlying
+				: undefined,
+		];
+		const typeParams = goTypeParams(meta);
+		const importsByName = namedImports(imports);
+
+		const refs: TypeReference[] = [];
+		const seen = new Set<string>();
+
+		for (const expr of typeExprs) {
+			if (!expr) {
+				continue;
+			}
+			for (const { ident, typeArgumentOf } of scanTypeIdents(expr)) {
+				if (seen.has(ident) || TYPE_KEYWORDS.has(ident)) {
+					continue;
+				}
+				seen.add(ident);
+				const generic = typeArgumentOf && { typeArgumentOf };
+
+				const dot = ident.indexOf(".");
+				if (dot === -1) {
+					if (typeParams.has(ident)) {
+						continue;
+					}
+					refs.push(
+						GO_BUILTIN_TYPES.has(ident)
+							? { name: ident, builtin: true, ...generic }
+							: { name: ident, package: packageName, ...generic }
+					);
+					continue;
+				}
+
+				const qualifier = ident.slice(0, dot);
+				const imported = importsByName.get(qualifier);
+				refs.push({
+					name: ident.slice(dot + 1),
+					qualifier,
+					package: imported ? defaultImportName(imported.path) : undefined,
+					importPath: imported?.path,
+					...generic,
+				});
+			}
+		}
+
+		return refs;
+	}
+
+	/**
+	 * Упоминания типов внутри range символа с позициями
+	 *
+	 * Объявляемое имя, параметры типа и встроенные типы не входят. Тело
+	 * функции (block, начинающийся после начала символа) просматривается,
+	 * только если анализ тел не отключён. Неквалифицированным именам в файле
+	 * с dot-импортами проставляются пути этих импортов.
+	 */
+	private collectTypeMentions(
+		root: Parser.SyntaxNode,
+		symbol: EnhancedCodeSymbol,
+		imports: GoImport[]
+	): TypeMention[] {
+		const meta = symbol.metadata;
+		if (!meta?.range) {
+			return [];
+		}
+		const start = {
+			row: meta.range.startLine - 1,
+			column: meta.range.startCol,
+		};
+		const end = { row: meta.range.endLine - 1, column: meta.range.endCol };
+		const typeParams = goTypeParams(meta);
+		const importsByName = namedImports(imports);
+		const dotImports = imports
+			.filter((i) => i.kind === "dot")
+			.map((i) => i.path);
+		const isDeclaredName = (node: Parser.SyntaxNode) =>
+			meta.nameRange?.startLine === this.getLineNumber(node.startPosition) &&
+			meta.nameRange.startCol === node.startPosition.column;
+
+		const mentions: TypeMention[] = [];
+		const visit = (
+			node: Parser.SyntaxNode,
+			inBody: boolean,
+			typeArgumentOf?: string
+		): void => {
+			if (
+				comparePoints(node.endPosition, start) <= 0 ||
+				comparePoints(node.startPosition, end) >= 0
+			) {
+				return;
+			}
+			if (
+				node.type === "block" &&
+				comparePoints(node.startPosition, start) > 0
+			) {
+				if (this.skipBodies) {
+					return;
+				}
+				inBody = true;
+			}
+
+			if (node.type === "qualified_type") {
+				const packageNode = this.getChild(node, "package");
+				const nameNode = this.getChild(node, "name");
+				if (packageNode && nameNode) {
+					const qualifier = this.getText(packageNode);
+					mentions.push({
+						name: this.getText(nameNode),
+						qualifier,
+						importPath: importsByName.get(qualifier)?.path,
+						range: this.getRange(node),
+						inBody: inBody || undefined,
+						...(typeArgumentOf && { typeArgumentOf }),
+					});
+				}
+				return;
+			}
+			if (node.type === "type_identifier") {
+				const name = this.getText(node);
+				if (
+					!(
+						GO_BUILTIN_TYPES.has(name) ||
+						typeParams.has(name) ||
+						isDeclaredName(node)
+					)
+				) {
+					mentions.push({
+						name,
+						range: this.getRange(node),
+						inBody: inBody || undefined,
+						...(dotImports.length > 0 && { dotImports }),
+						...(typeArgumentOf && { typeArgumentOf }),
+					});
+				}
+				return;
+			}
+
+			// List[User], New[User](): аргументы типа помечаются базовым именем
+			let base: Parser.SyntaxNode | null = null;
+			if (node.type === "generic_type") {
+				base = this.getChild(node, "type");
+			} else if (node.type === "call_expression") {
+				base = this.getChild(node, "function");
+			}
+			for (const child of node.children) {
+				const args = base && child.type === "type_arguments";
+				visit(child, inBody, args ? this.getText(base) : typeArgumentOf);
+			}
+		};
+
+		visit(root, false);
+		return mentions;
+	}
+
+	/**
+	 * Маркеры TODO/FIXME из doc comment и комментариев внутри символа
+	 *
+	 * Учитываются комментарии в строках символа и сразу над ним (у группы
+	 * const маркер достаётся своей константе), кроме строк вложенных
+	 * символов. Тела функций пропускаются, если файл больше
+	 * bodyAnalysisLimit.
+	 */
+	private collectMarkers(
+		node: Parser.SyntaxNode,
+		symbol: EnhancedCodeSymbol,
+		markerRe: RegExp,
+		nested: EnhancedCodeSymbol[]
+	): CodeMarker[] {
+		const startOf = (n: Parser.SyntaxNode) =>
+			this.getLineNumber(n.startPosition);
+		const endOf = (n: Parser.SyntaxNode) => this.getLineNumber(n.endPosition);
+
+		const candidates: Parser.SyntaxNode[] = [];
+		const visit = (current: Parser.SyntaxNode): void => {
+			if (startOf(current) > symbol.endLine) {
+				return;
+			}
+			if (current.type === "comment") {
+				candidates.push(current);
+				return;
+			}
+			if (current.type === "block" && this.skipBodies) {
+				return;
+			}
+			for (const child of current.children) {
+				visit(child);
+			}
+		};
+		visit(node);
+
+		// Doc comment: у объявления верхнего уровня — соседи узла, у элемента
+		// группы — непрерывный блок комментариев внутри узла над символом
+		const comments = this.collectDocComments(node);
+		let expected = symbol.startLine - 1;
+		const above = candidates.filter((c) => endOf(c) < symbol.startLine);
+		for (const comment of above.reverse()) {
+			if (endOf(comment) !== expected) {
+				break;
+			}
+			comments.push(comment);
+			expected = startOf(comment) - 1;
+		}
+		comments.sort((a, b) => startOf(a) - startOf(b));
+		for (const comment of candidates) {
+			const line = startOf(comment);
+			const inNested = nested.some(
+				(other) => line >= other.startLine && line <= other.endLine
+			);
+			if (line >= symbol.startLine && !inNested) {
+				comments.push(comment);
+			}
+		}
+
+		const markers: CodeMarker[] = [];
+		for (const comment of comments) {
+			const firstLine = this.getLineNumber(comment.startPosition);
+			const lines = this.getText(comment).split("\n");
+			for (const [i, text] of lines.entries()) {
+				const match = markerRe.exec(text);
+				if (match?.[1]) {
+					markers.push({
+						kind: match[1],
+						line: firstLine + i,
+						text: (match[2] ?? "").replace(/\s*\*\/$/, "").trim(),
+					});
+				}
+			}
+		}
+		return markers;
+	}
+
+	/**
+	 * Имя пакета из package clause
+	 */
+	private extractPackageName(root: Parser.SyntaxNode): string | undefined {
+		const clause = root.children.find((c) => c.type === "package_clause");
+		const nameNode = clause?.children.find(
+			(c) => c.type === "package_identifier"
+		);
+		return nameNode ? this.getText(nameNode) : undefined;
+	}
+
+	/**
+	 * Синтетический символ package clause с doc comment пакета
+	 */
+	private extractPackageClause(
+		root: Parser.SyntaxNode,
+		filePath: string,
+		source: string
+	): EnhancedCodeSymbol | null {
+		const clause = root.children.find((c) => c.type === "package_clause");
+		const nameNode = clause?.children.find(
+			(c) => c.type === "package_identifier"
+		);
+		if (!(clause && nameNode)) {
+			return null;
+		}
+		const docComment = this.extractDocComment(clause, source);
+
+		return {
+			name: this.getText(nameNode),
+			symbolType: "package",
+			path: filePath,
+			startLine: this.getLineNumber(clause.startPosition),
+			endLine: this.getLineNumber(clause.endPosition),
+			body: this.getText(clause),
+			jsDoc: docComment,
+			calls: [],
+			imports: [],
+			metadata: {
+				isExported: true,
+				docComment: docComment || undefined,
+				...this.extractRanges(clause, nameNode),
+				language: {
+					goDocComment: docComment,
+				},
+			},
+		};
 	}
 
 	private visitNode(
@@ -45,37 +1075,48 @@ class GoNodeExtractor extends BaseNodeExtractor {
 		source: string,
 		symbols: EnhancedCodeSymbol[]
 	): void {
-		// Function declaration
-		if (node.type === "function_declaration") {
-			const symbol = this.extractFunction(node, filePath, source);
-			if (symbol) {
-				symbols.push(symbol);
-			}
-		}
-
-		// Method declaration
-		if (node.type === "method_declaration") {
-			const symbol = this.extractMethod(node, filePath, source);
+		// Function/method declaration: тело обходится только ради локальных
+		// символов, локальные const/var наружу не попадают
+		if (
+			node.type === "function_declaration" ||
+			node.type === "method_declaration"
+		) {
+			const symbol = this.tryExtract(node, null, () =>
+				node.type === "function_declaration"
+					? this.extractFunction(node, filePath, source)
+					: this.extractMethod(node, filePath, source)
+			);
 			if (symbol) {
 				symbols.push(symbol);
+				const body = this.getChild(node, "body");
+				if (body && this.options.captureLocals && !this.skipBodies) {
+					this.visitLocals(body, symbol, filePath, source, symbols);
+				}
 			}
+			return;
 		}
 
 		// Type declaration (struct, interface, alias)
 		if (node.type === "type_declaration") {
-			const typeSymbols = this.extractTypeDeclaration(node, filePath, source);
+			const typeSymbols = this.tryExtract(node, [], () =>
+				this.extractTypeDeclaration(node, filePath, source)
+			);
 			symbols.push(...typeSymbols);
 		}
 
 		// Const declaration
 		if (node.type === "const_declaration") {
-			const constants = this.extractConstants(node, filePath, source);
+			const constants = this.tryExtract(node, [], () =>
+				this.extractConstants(node, filePath, source)
+			);
 			symbols.push(...constants);
 		}
 
 		// Var declaration
 		if (node.type === "var_declaration") {
-			const variables = this.extractVariables(node, filePath, source);
+			const variables = this.tryExtract(node, [], () =>
+				this.extractVariables(node, filePath, source)
+			);
 			symbols.push(...variables);
 		}
 
@@ -85,6 +1126,127 @@ class GoNodeExtractor extends BaseNodeExtractor {
 		}
 	}
 
+	/**
+	 * Обойти тело функции: локальные типы и замыкания, привязанные к именам
+	 *
+	 * Анонимные замыкания (`go func() {}()`) не извлекаются, но их тела
+	 * обходятся с тем же parent.
+	 */
+	private visitLocals(
+		node: Parser.SyntaxNode,
+		parent: EnhancedCodeSymbol,
+		filePath: string,
+		source: string,
+		symbols: EnhancedCodeSymbol[]
+	): void {
+		if (node.type === "type_declaration") {
+			for (const local of this.extractTypeDeclaration(node, filePath, source)) {
+				local.metadata = {
+					...local.metadata,
+					isExported: false,
+					parent: parent.name,
+				};
+				symbols.push(local);
+			}
+			return;
+		}
+
+		const bound = new Set<Parser.SyntaxNode>();
+		if (
+			node.type === "short_var_declaration" ||
+			node.type === "var_spec" ||
+			node.type === "assignment_statement"
+		) {
+			for (const [nameNode, funcNode] of this.namedFuncLiterals(node)) {
+				const local = this.extractLocalFunction(
+					nameNode,
+					funcNode,
+					node,
+					parent,
+					filePath,
+					source
+				);
+				symbols.push(local);
+				bound.add(funcNode);
+				const body = this.getChild(funcNode, "body");
+				if (body) {
+					this.visitLocals(body, local, filePath, source, symbols);
+				}
+			}
+		}
+
+		for (const child of node.children) {
+			if (!bound.has(child)) {
+				this.visitLocals(child, parent, filePath, source, symbols);
+			}
+		}
+	}
+
+	/**
+	 * Пары (имя, func_literal) из `a, b := func() {}, 1` / `var a = func() {}`
+	 */
+	private namedFuncLiterals(
+		node: Parser.SyntaxNode
+	): [Parser.SyntaxNode, Parser.SyntaxNode][] {
+		const left =
+			node.type === "var_spec"
+				? node.children.filter((c) => c.type === "identifier")
+				: (this.getChild(node, "left")?.namedChildren ?? []);
+		const right =
+			this.getChild(node, node.type === "var_spec" ? "value" : "right")
+				?.namedChildren ?? [];
+
+		const pairs: [Parser.SyntaxNode, Parser.SyntaxNode][] = [];
+		for (const [i, value] of right.entries()) {
+			const name = left[i];
+			if (
+				name?.type === "identifier" &&
+				this.getText(name) !== "_" &&
+				value.type === "func_literal"
+			) {
+				pairs.push([name, value]);
+			}
+		}
+		return pairs;
+	}
+
+	private extractLocalFunction(
+		nameNode: Parser.SyntaxNode,
+		funcNode: Parser.SyntaxNode,
+		declNode: Parser.SyntaxNode,
+		parent: EnhancedCodeSymbol,
+		filePath: string,
+		source: string
+	): EnhancedCodeSymbol {
+		const parameters = this.extractParameters(
+			this.getChild(funcNode, "parameters"),
+			source
+		);
+		const resultNode = this.getChild(funcNode, "result");
+
+		return {
+			name: this.getText(nameNode),
+			symbolType: "function",
+			path: filePath,
+			startLine: this.getLineNumber(declNode.startPosition),
+			endLine: this.getLineNumber(declNode.endPosition),
+			body: this.truncateBody(this.getText(declNode)),
+			jsDoc: "",
+			calls: this.extractCalls(funcNode, source),
+			imports: [],
+			metadata: {
+				parameters,
+				returnType: resultNode ? this.getText(resultNode) : undefined,
+				returns: this.extractReturns(resultNode, source),
+				isExported: false,
+				callRefs: this.extractCallRefs(funcNode),
+				metrics: this.extractMetrics(funcNode, parameters),
+				parent: parent.name,
+				...this.extractRanges(declNode, nameNode),
+			},
+		};
+	}
+
 	/**
 	 * Извлечь function
 	 */
@@ -107,23 +1269,40 @@ class GoNodeExtractor extends BaseNodeExtractor {
 		// Return type
 		const resultNode = this.getChild(node, "result");
 		const returnType = resultNode ? this.getText(resultNode) : undefined;
+		const returns = this.extractReturns(resultNode, source);
+
+		// Generics: func Sum[T Number](...)
+		const genericParams = this.extractTypeParameters(node, source);
 
 		// Comment (Go doc comment)
 		const docComment = this.extractDocComment(node, source);
 
 		// Calls
 		const calls = this.extractCalls(node, source);
+		const callRefs = this.extractCallRefs(node);
 
 		// Imports
 		const imports = this.extractImports(node, source);
 
-		// Exported (starts with uppercase)
-		const isExported = /^[A-Z]/.test(name);
+		// Exported (starts with an uppercase Unicode letter)
+		const isExported = EXPORTED_NAME_RE.test(name);
 
 		const metadata: SymbolMetadata = {
 			parameters,
 			returnType,
+			returns,
+			signature: goSignature(
+				`func ${name}`,
+				parameters,
+				returns,
+				genericParams
+			),
 			isExported,
+			genericParams: genericParams.length > 0 ? genericParams : undefined,
+			docComment: docComment || undefined,
+			callRefs,
+			metrics: this.extractMetrics(node, parameters),
+			...this.extractRanges(node, nameNode),
 			language: {
 				goDocComment: docComment,
 			},
@@ -177,20 +1356,35 @@ class GoNodeExtractor extends BaseNodeExtractor {
 		// Return type
 		const resultNode = this.getChild(node, "result");
 		const returnType = resultNode ? this.getText(resultNode) : undefined;
+		const returns = this.extractReturns(resultNode, source);
 
 		// Comment
 		const docComment = this.extractDocComment(node, source);
 
 		// Calls
 		const calls = this.extractCalls(node, source);
+		const callRefs = this.extractCallRefs(node);
 
 		// Exported
-		const isExported = /^[A-Z]/.test(methodName);
+		const isExported = EXPORTED_NAME_RE.test(methodName);
 
 		const metadata: SymbolMetadata = {
 			parameters,
 			returnType,
+			returns,
+			signature: goSignature(
+				`func ${canonicalGoType(receiver.text)} ${methodName}`,
+				parameters,
+				returns
+			),
 			isExported,
+			docComment: docComment || undefined,
+			callRefs,
+			metrics: this.extractMetrics(node, parameters),
+			...this.extractRanges(node, nameNode),
+			receiver: receiver.typeName,
+			receiverIsPointer: receiver.isPointer,
+			receiverName: receiver.name,
 			language: {
 				goReceiver: receiver.text,
 				goReceiverPointer: receiver.isPointer,
@@ -264,10 +1458,31 @@ class GoNodeExtractor extends BaseNodeExtractor {
 			}
 
 			// Comment
-			const docComment = this.extractDocComment(spec.parent ?? node, source);
+			const { docComment, groupDocComment } = this.extractSpecDocs(
+				spec,
+				node,
+				source
+			);
 
 			// Exported
-			const isExported = /^[A-Z]/.test(name);
+			const isExported = EXPORTED_NAME_RE.test(name);
+
+			// Generics: type List[T any] struct
+			const genericParams = this.extractTypeParameters(spec, source);
+
+			// Поля структуры
+			const fields =
+				typeText === "struct_type" ? this.extractStructFields(typeNode) : [];
+
+			// Constraint интерфейс: interface { int | int64 }
+			const unionTypes =
+				typeText === "interface_type"
+					? this.extractConstraintUnion(typeNode)
+					: [];
+			const methods =
+				typeText === "interface_type"
+					? this.extractInterfaceMethods(typeNode, source)
+					: [];
 
 			const symbol = {
 				name,
@@ -281,6 +1496,19 @@ class GoNodeExtractor extends BaseNodeExtractor {
 				imports: [],
 				metadata: {
 					isExported,
+					typeKind: TYPE_KIND_BY_NODE[typeText] ?? "named",
+					underlying: this.getText(typeNode),
+					fields: fields.length > 0 ? fields : undefined,
+					methods: methods.length > 0 ? methods : undefined,
+					isConstraint: unionTypes.length > 0 || undefined,
+					unionTypes: unionTypes.length > 0 ? unionTypes : undefined,
+					genericParams: genericParams.length > 0 ? genericParams : undefined,
+					docComment: docComment || undefined,
+					groupDocComment,
+					trailingComment: this.extractTrailingComment(
+						this.rangeNode(spec, node)
+					),
+					...this.extractRanges(this.rangeNode(spec, node), nameNode),
 					language: {
 						goDocComment: docComment,
 					},
@@ -299,12 +1527,17 @@ class GoNodeExtractor extends BaseNodeExtractor {
 			}
 
 			const name = this.getText(nameNode);
+			const typeNode = this.getChild(alias, "type");
 
 			// Comment
-			const docComment = this.extractDocComment(alias.parent ?? node, source);
+			const { docComment, groupDocComment } = this.extractSpecDocs(
+				alias,
+				node,
+				source
+			);
 
 			// Exported
-			const isExported = /^[A-Z]/.test(name);
+			const isExported = EXPORTED_NAME_RE.test(name);
 
 			symbols.push({
 				name,
@@ -318,6 +1551,14 @@ class GoNodeExtractor extends BaseNodeExtractor {
 				imports: [],
 				metadata: {
 					isExported,
+					typeKind: "alias",
+					underlying: typeNode ? this.getText(typeNode) : undefined,
+					docComment: docComment || undefined,
+					groupDocComment,
+					trailingComment: this.extractTrailingComment(
+						this.rangeNode(alias, node)
+					),
+					...this.extractRanges(this.rangeNode(alias, node), nameNode),
 					language: {
 						goDocComment: docComment,
 					},
@@ -353,7 +1594,27 @@ class GoNodeExtractor extends BaseNodeExtractor {
 			}
 		}
 
-		for (const spec of constSpecs) {
+		// iota = индекс spec в группе; spec без значения повторяет
+		// последнее выражение группы (implicit repetition)
+		const usesIota = constSpecs.some((spec) => {
+			const valueNode = this.getChild(spec, "value");
+			return !!valueNode && IOTA_RE.test(this.getText(valueNode));
+		});
+		const values = new Map<string, number | null>();
+		let lastValueExpr: string | undefined;
+		let lastType: string | undefined;
+
+		for (const [iota, spec] of constSpecs.entries()) {
+			const valueNode = this.getChild(spec, "value");
+			const typeNode = this.getChild(spec, "type");
+			if (valueNode) {
+				// A, B = iota, iota * 2 -> выражение первого имени
+				const first = valueNode.namedChildren[0] ?? valueNode;
+				lastValueExpr = this.getText(first);
+				// Тип повторяется вместе с выражением: `Red Color = iota; Green`
+				lastType = typeNode ? this.getText(typeNode) : undefined;
+			}
+
 			// Имя - это первый identifier в spec
 			const nameNode = spec.children.find((c) => c.type === "identifier");
 			if (!nameNode) {
@@ -361,13 +1622,39 @@ class GoNodeExtractor extends BaseNodeExtractor {
 			}
 
 			const name = this.getText(nameNode);
+			const value =
+				usesIota && lastValueExpr !== undefined
+					? evaluateConstExpression(lastValueExpr, iota, values)
+					: undefined;
+			if (value !== undefined) {
+				values.set(name, value);
+			}
+			// `_ = iota` только сдвигает счётчик
+			if (name === "_") {
+				continue;
+			}
 
 			// Type
-			const typeNode = this.getChild(spec, "type");
-			const returnType = typeNode ? this.getText(typeNode) : undefined;
+			const returnType = typeNode ? this.getText(typeNode) : lastType;
+			// Типизированное перечисление: iota-константы своего именованного
+			// типа (`type Color int`), а не встроенного
+			const enumType =
+				usesIota &&
+				returnType &&
+				GO_TYPE_NAME_RE.test(returnType) &&
+				!GO_BUILTIN_TYPES.has(returnType)
+					? returnType
+					: undefined;
+
+			// Comment
+			const { docComment, groupDocComment } = this.extractSpecDocs(
+				spec,
+				node,
+				source
+			);
 
 			// Exported
-			const isExported = /^[A-Z]/.test(name);
+			const isExported = EXPORTED_NAME_RE.test(name);
 
 			symbols.push({
 				name,
@@ -376,16 +1663,35 @@ class GoNodeExtractor extends BaseNodeExtractor {
 				startLine: this.getLineNumber(spec.startPosition),
 				endLine: this.getLineNumber(spec.endPosition),
 				body: this.truncateBody(this.getText(spec)),
-				jsDoc: "",
+				jsDoc: docComment,
 				calls: [],
 				imports: [],
 				metadata: {
 					returnType,
 					isExported,
+					docComment: docComment || undefined,
+					groupDocComment,
+					trailingComment: this.extractTrailingComment(
+						this.rangeNode(spec, node)
+					),
+					...this.extractRanges(this.rangeNode(spec, node), nameNode),
+					...(usesIota ? { value } : {}),
+					enumType,
+					language: {
+						goDocComment: docComment,
+					},
 				},
 			});
 		}
 
+		// Группа с iota — перечисление, названное по первой константе
+		const enumGroup = symbols[0]?.name;
+		if (usesIota && enumGroup) {
+			for (const symbol of symbols) {
+				symbol.metadata = { ...symbol.metadata, enumGroup };
+			}
+		}
+
 		return symbols;
 	}
 
@@ -424,8 +1730,32 @@ class GoNodeExtractor extends BaseNodeExtractor {
 			const typeNode = this.getChild(spec, "type");
 			const returnType = typeNode ? this.getText(typeNode) : undefined;
 
+			// Comment
+			const { docComment, groupDocComment } = this.extractSpecDocs(
+				spec,
+				node,
+				source
+			);
+
 			// Exported
-			const isExported = /^[A-Z]/.test(name);
+			const isExported = EXPORTED_NAME_RE.test(name);
+
+			// var Handler = func(...) — ищется и снипетится как функция
+			const funcNode = this.namedFuncLiterals(spec).find(
+				([literalName]) => literalName === nameNode
+			)?.[1];
+			if (funcNode) {
+				symbols.push(
+					this.extractFuncVariable(spec, node, nameNode, funcNode, {
+						docComment,
+						filePath,
+						groupDocComment,
+						returnType,
+						source,
+					})
+				);
+				continue;
+			}
 
 			symbols.push({
 				name,
@@ -434,12 +1764,21 @@ class GoNodeExtractor extends BaseNodeExtractor {
 				startLine: this.getLineNumber(spec.startPosition),
 				endLine: this.getLineNumber(spec.endPosition),
 				body: this.truncateBody(this.getText(spec)),
-				jsDoc: "",
+				jsDoc: docComment,
 				calls: [],
 				imports: [],
 				metadata: {
 					returnType,
 					isExported,
+					docComment: docComment || undefined,
+					groupDocComment,
+					trailingComment: this.extractTrailingComment(
+						this.rangeNode(spec, node)
+					),
+					...this.extractRanges(this.rangeNode(spec, node), nameNode),
+					language: {
+						goDocComment: docComment,
+					},
 				},
 			});
 		}
@@ -447,8 +1786,72 @@ class GoNodeExtractor extends BaseNodeExtractor {
 		return symbols;
 	}
 
+	/**
+	 * Package var, инициализированная function literal: символ-функция
+	 * с параметрами и результатами литерала и backingKind: "variable"
+	 *
+	 * Объявленный тип var (`var H HandlerFunc = func...`) сохраняется
+	 * в language.goVarType: returnType — результат самого литерала.
+	 */
+	private extractFuncVariable(
+		spec: Parser.SyntaxNode,
+		declNode: Parser.SyntaxNode,
+		nameNode: Parser.SyntaxNode,
+		funcNode: Parser.SyntaxNode,
+		context: {
+			docComment: string;
+			filePath: string;
+			groupDocComment?: string;
+			returnType?: string;
+			source: string;
+		}
+	): EnhancedCodeSymbol {
+		const { docComment, filePath, source } = context;
+		const name = this.getText(nameNode);
+		const parameters = this.extractParameters(
+			this.getChild(funcNode, "parameters"),
+			source
+		);
+		const resultNode = this.getChild(funcNode, "result");
+		const returns = this.extractReturns(resultNode, source);
+		const rangeNode = this.rangeNode(spec, declNode);
+
+		return {
+			name,
+			symbolType: "function",
+			path: filePath,
+			startLine: this.getLineNumber(spec.startPosition),
+			endLine: this.getLineNumber(spec.endPosition),
+			body: this.truncateBody(this.getText(spec)),
+			jsDoc: docComment,
+			calls: this.extractCalls(funcNode, source),
+			imports: [],
+			metadata: {
+				backingKind: "variable",
+				parameters,
+				returnType: resultNode ? this.getText(resultNode) : undefined,
+				returns,
+				signature: goSignature(`var ${name} func`, parameters, returns),
+				isExported: EXPORTED_NAME_RE.test(name),
+				docComment: docComment || undefined,
+				groupDocComment: context.groupDocComment,
+				callRefs: this.extractCallRefs(funcNode),
+				metrics: this.extractMetrics(funcNode, parameters),
+				trailingComment: this.extractTrailingComment(rangeNode),
+				...this.extractRanges(rangeNode, nameNode),
+				language: {
+					goDocComment: docComment,
+					goVarType: context.returnType,
+				},
+			},
+		};
+	}
+
 	/**
 	 * Извлечь параметры функции
+	 *
+	 * Сгруппированные параметры `(a, b T)` раскрываются в отдельные записи,
+	 * безымянные параметры `(string, error)` получают name: "".
 	 */
 	private extractParameters(
 		parametersNode: Parser.SyntaxNode | null,
@@ -460,49 +1863,270 @@ class GoNodeExtractor extends BaseNodeExtractor {
 
 		const params: FunctionParameter[] = [];
 
-		// parameter_declaration содержит имена и тип
-		const paramDecls = this.getChildrenOfType(
-			parametersNode,
-			"parameter_declaration"
-		);
-
-		// variadic_parameter_declaration отдельно
-		const variadicDecls = this.getChildrenOfType(
-			parametersNode,
-			"variadic_parameter_declaration"
-		);
+		for (const decl of parametersNode.children) {
+			if (
+				decl.type !== "parameter_declaration" &&
+				decl.type !== "variadic_parameter_declaration"
+			) {
+				continue;
+			}
 
-		for (const decl of paramDecls) {
 			const nameNodes = decl.children.filter((c) => c.type === "identifier");
 			const typeNode = this.getChild(decl, "type");
 			const typeText = typeNode ? this.getText(typeNode) : undefined;
+			const variadic = decl.type === "variadic_parameter_declaration";
+
+			if (nameNodes.length === 0) {
+				params.push({
+					name: "",
+					type: typeText,
+					variadic: variadic || undefined,
+				});
+				continue;
+			}
 
 			// В Go может быть несколько параметров с одним типом: func f(a, b int)
 			for (const nameNode of nameNodes) {
-				const name = this.getText(nameNode);
 				params.push({
-					name,
+					name: this.getText(nameNode),
 					type: typeText,
+					variadic: variadic || undefined,
 				});
 			}
 		}
 
-		// Обработать variadic parameters
-		for (const decl of variadicDecls) {
-			const nameNodes = decl.children.filter((c) => c.type === "identifier");
+		return params;
+	}
+
+	/**
+	 * Извлечь возвращаемые значения
+	 *
+	 * `error` -> [{ name: null, type: "error" }], `(string, error)` -> два
+	 * безымянных, `(a, b int)` -> два именованных с типом int
+	 */
+	private extractReturns(
+		resultNode: Parser.SyntaxNode | null,
+		source: string
+	): FunctionResult[] {
+		if (!resultNode) {
+			return [];
+		}
+
+		if (resultNode.type === "parameter_list") {
+			return this.extractParameters(resultNode, source).map((p) => ({
+				name: p.name || null,
+				type: p.type ?? undefined,
+			}));
+		}
+
+		return [{ name: null, type: this.getText(resultNode) }];
+	}
+
+	/**
+	 * Извлечь поля структуры
+	 *
+	 * `X, Y int` раскрывается в два поля, встроенные поля (`io.Reader`,
+	 * `*Base`) помечаются embedded и не имеют имени.
+	 */
+	private extractStructFields(structNode: Parser.SyntaxNode): StructField[] {
+		const listNode = structNode.children.find(
+			(c) => c.type === "field_declaration_list"
+		);
+		if (!listNode) {
+			return [];
+		}
+
+		const fields: StructField[] = [];
+
+		for (const decl of listNode.children) {
+			if (decl.type !== "field_declaration") {
+				continue;
+			}
+
 			const typeNode = this.getChild(decl, "type");
-			const typeText = typeNode ? this.getText(typeNode) : undefined;
+			if (!typeNode) {
+				continue;
+			}
+
+			const tagNode = this.getChild(decl, "tag");
+			const tag = tagNode ? this.getText(tagNode) : undefined;
+			const nameNodes = decl.children.filter(
+				(c) => c.type === "field_identifier"
+			);
+
+			if (nameNodes.length === 0) {
+				const isPointer = decl.children.some((c) => c.type === "*");
+				const typeText = this.getText(typeNode);
+				// io.Reader -> Reader, Base[T] -> Base
+				const baseName =
+					typeText.replace(/\[.*$/s, "").split(".").pop() ?? typeText;
+				fields.push({
+					type: isPointer ? `*${typeText}` : typeText,
+					tag,
+					exported: EXPORTED_NAME_RE.test(baseName),
+					embedded: true,
+					range: this.getRange(decl),
+					nameRange: this.getRange(typeNode),
+					trailingComment: this.extractTrailingComment(decl),
+				});
+				continue;
+			}
 
+			const typeText = this.getText(typeNode);
 			for (const nameNode of nameNodes) {
 				const name = this.getText(nameNode);
-				params.push({
+				fields.push({
 					name,
-					type: typeText ? `...${typeText}` : undefined,
+					type: typeText,
+					tag,
+					exported: EXPORTED_NAME_RE.test(name),
+					range: this.getRange(decl),
+					nameRange: this.getRange(nameNode),
+					trailingComment: this.extractTrailingComment(decl),
 				});
 			}
 		}
 
-		return params;
+		return fields;
+	}
+
+	/**
+	 * Извлечь type parameters: [T Number], [K comparable, V any], [A, B any]
+	 */
+	private extractTypeParameters(
+		node: Parser.SyntaxNode,
+		source: string
+	): GenericParameter[] {
+		const listNode = this.getChild(node, "type_parameters");
+		if (!listNode) {
+			return [];
+		}
+
+		const generics: GenericParameter[] = [];
+
+		for (const decl of listNode.children) {
+			// Старые версии грамматики используют parameter_declaration
+			if (
+				decl.type !== "type_parameter_declaration" &&
+				decl.type !== "parameter_declaration"
+			) {
+				continue;
+			}
+
+			const typeNode = this.getChild(decl, "type");
+			const constraint = typeNode ? this.getText(typeNode) : undefined;
+
+			for (const nameNode of decl.children) {
+				if (nameNode.type === "identifier") {
+					generics.push({ name: this.getText(nameNode), constraint });
+				}
+			}
+		}
+
+		return generics;
+	}
+
+	/**
+	 * Извлечь члены union из constraint интерфейса
+	 *
+	 * Возвращает [] если тело интерфейса - обычный method set
+	 * (в т.ч. со встроенными интерфейсами вроде io.Reader или error).
+	 */
+	private extractConstraintUnion(interfaceNode: Parser.SyntaxNode): string[] {
+		const members: string[] = [];
+		let isConstraint = false;
+
+		for (const element of this.interfaceElements(interfaceNode)) {
+			if (!INTERFACE_ELEMENT_TYPES.has(element.type)) {
+				continue;
+			}
+
+			const parts = this.getText(element)
+				.split("|")
+				.map((part) => part.trim())
+				.filter(Boolean);
+
+			if (
+				parts.length > 1 ||
+				parts.some(
+					(part) =>
+						part.startsWith("~") ||
+						(GO_BUILTIN_TYPES.has(part) && !GO_BUILTIN_INTERFACES.has(part))
+				)
+			) {
+				isConstraint = true;
+			}
+			members.push(...parts);
+		}
+
+		return isConstraint ? members : [];
+	}
+
+	/**
+	 * Извлечь методы интерфейса и встроенные интерфейсы
+	 *
+	 * Элементы union (`~int | string`) и встроенные builtin-типы в method
+	 * set не входят.
+	 */
+	private extractInterfaceMethods(
+		interfaceNode: Parser.SyntaxNode,
+		source: string
+	): InterfaceMethod[] {
+		const methods: InterfaceMethod[] = [];
+
+		for (const element of this.interfaceElements(interfaceNode)) {
+			if (element.type === "method_elem" || element.type === "method_spec") {
+				const nameNode = this.getChild(element, "name");
+				if (!nameNode) {
+					continue;
+				}
+				const resultNode = this.getChild(element, "result");
+				methods.push({
+					name: this.getText(nameNode),
+					parameters: this.extractParameters(
+						this.getChild(element, "parameters"),
+						source
+					),
+					returnType: resultNode ? this.getText(resultNode) : undefined,
+					returns: this.extractReturns(resultNode, source),
+					range: this.getRange(element),
+				});
+				continue;
+			}
+
+			const isTypeElement =
+				INTERFACE_ELEMENT_TYPES.has(element.type) ||
+				element.type === "interface_type_name" ||
+				element.type === "qualified_type" ||
+				element.type === "type_identifier";
+			const text = this.getText(element).trim();
+			if (
+				!isTypeElement ||
+				text.includes("|") ||
+				text.startsWith("~") ||
+				GO_BUILTIN_TYPES.has(text)
+			) {
+				continue;
+			}
+			methods.push({
+				name: text,
+				embedded: true,
+				range: this.getRange(element),
+			});
+		}
+
+		return methods;
+	}
+
+	/**
+	 * Элементы тела интерфейса (в старых грамматиках обёрнуты в *_list)
+	 */
+	private interfaceElements(
+		interfaceNode: Parser.SyntaxNode
+	): Parser.SyntaxNode[] {
+		return interfaceNode.children.flatMap((c) =>
+			c.type.endsWith("_list") ? c.children : [c]
+		);
 	}
 
 	/**
@@ -511,7 +2135,12 @@ class GoNodeExtractor extends BaseNodeExtractor {
 	private extractReceiver(
 		receiverNode: Parser.SyntaxNode,
 		source: string
-	): { text: string; typeName: string; isPointer: boolean } | null {
+	): {
+		text: string;
+		typeName: string;
+		isPointer: boolean;
+		name?: string;
+	} | null {
 		// receiver: (u *User) или (u User)
 		// Структура: parameter_list с parameter_declaration внутри
 		const paramDecl = receiverNode.children.find(
@@ -528,59 +2157,202 @@ class GoNodeExtractor extends BaseNodeExtractor {
 
 		const typeText = this.getText(typeNode);
 		const isPointer = typeText.startsWith("*");
-		const typeName = isPointer ? typeText.slice(1) : typeText;
+		// (l *List[T]) -> List
+		const typeName = (isPointer ? typeText.slice(1) : typeText)
+			.replace(/\[.*$/s, "")
+			.trim();
+
+		const nameNode = this.getChild(paramDecl, "name");
 
 		return {
 			text: this.getText(receiverNode),
 			typeName,
 			isPointer,
+			name: nameNode ? this.getText(nameNode) : undefined,
 		};
 	}
 
 	/**
 	 * Извлечь doc comment (line or block comments)
+	 *
+	 * Doc comment — непрерывный блок комментариев сразу над объявлением.
+	 * Пустая строка между комментарием и объявлением означает "нет doc comment".
 	 */
 	private extractDocComment(node: Parser.SyntaxNode, source: string): string {
-		// В Go doc comments идут перед объявлением
-		// Нужно смотреть на предыдущие siblings с типом "comment"
-		const parent = node.parent;
-		if (!parent) {
-			return "";
-		}
-
-		const nodeIndex = parent.children.indexOf(node);
-		if (nodeIndex === 0) {
-			return "";
-		}
+		return this.collectDocComments(node)
+			.map((comment) => this.stripCommentMarkers(this.getText(comment)))
+			.join("\n");
+	}
 
-		const comments: string[] = [];
+	/**
+	 * Собрать узлы комментариев, образующих doc comment узла (в порядке исходника)
+	 */
+	private collectDocComments(node: Parser.SyntaxNode): Parser.SyntaxNode[] {
+		const comments: Parser.SyntaxNode[] = [];
+		let expectedRow = node.startPosition.row - 1;
+		let sibling = this.previousSignificant(node);
 
-		// Собрать все комментарии перед этим узлом
-		for (let i = nodeIndex - 1; i >= 0; i--) {
-			const sibling = parent.children[i];
-			if (!sibling) {
+		while (sibling?.type === "comment") {
+			if (sibling.endPosition.row !== expectedRow) {
 				break;
 			}
 
-			if (sibling.type === "comment") {
-				const commentText = this.getText(sibling)
-					.replace(/^\/\/\s?/, "")
-					.replace(/^\/\*\s?|\s?\*\/$/g, "")
-					.trim();
-				comments.unshift(commentText);
-			} else if (sibling.type !== "comment") {
-				// Если встретили не комментарий, останавливаемся
+			// Комментарий в конце строки предыдущего объявления - не doc comment
+			const before = this.previousSignificant(sibling);
+			if (
+				before &&
+				before.type !== "comment" &&
+				before.endPosition.row === sibling.startPosition.row
+			) {
 				break;
 			}
+
+			comments.unshift(sibling);
+			expectedRow = sibling.startPosition.row - 1;
+			sibling = this.previousSignificant(sibling);
+		}
+
+		return comments;
+	}
+
+	/**
+	 * range и nameRange символа
+	 */
+	private extractRanges(
+		node: Parser.SyntaxNode,
+		nameNode: Parser.SyntaxNode
+	): { range: SourceRange; nameRange: SourceRange } {
+		const firstComment = this.options.rangeIncludesDocComment
+			? this.collectDocComments(node)[0]
+			: undefined;
+
+		return {
+			range: this.getRange(node, firstComment ?? node),
+			nameRange: this.getRange(nameNode),
+		};
+	}
+
+	/**
+	 * Узел, задающий range для spec: в группе - сам spec, иначе всё объявление
+	 */
+	private rangeNode(
+		spec: Parser.SyntaxNode,
+		declaration: Parser.SyntaxNode
+	): Parser.SyntaxNode {
+		return this.isGroupedDeclaration(declaration) ? spec : declaration;
+	}
+
+	/**
+	 * Объявление со скобками: const ( ... ), var ( ... ), type ( ... )
+	 */
+	private isGroupedDeclaration(declaration: Parser.SyntaxNode): boolean {
+		return declaration.children.some(
+			(c) => c.type === "(" || c.type.endsWith("_spec_list")
+		);
+	}
+
+	/**
+	 * Doc comments для spec внутри const/var/type объявления
+	 *
+	 * В группе `const ( ... )` комментарий над группой относится к группе,
+	 * а комментарии над отдельными строками - к каждому элементу.
+	 */
+	private extractSpecDocs(
+		spec: Parser.SyntaxNode,
+		declaration: Parser.SyntaxNode,
+		source: string
+	): { docComment: string; groupDocComment?: string } {
+		if (!this.isGroupedDeclaration(declaration)) {
+			return { docComment: this.extractDocComment(declaration, source) };
 		}
 
-		return comments.join("\n");
+		const groupDocComment = this.extractDocComment(declaration, source);
+		return {
+			docComment: this.extractDocComment(spec, source),
+			groupDocComment: groupDocComment || undefined,
+		};
+	}
+
+	/**
+	 * Убрать маркеры комментария, сохранив переносы строк
+	 *
+	 * У блочного комментария снимаются открывающий и закрывающий маркеры
+	 * и ведущие `* ` строк продолжения (если они есть у всех строк),
+	 * иначе убирается общий отступ.
+	 */
+	private stripCommentMarkers(text: string): string {
+		if (!text.startsWith("/*")) {
+			return text.replace(/^\/\/\s?/, "").trimEnd();
+		}
+
+		const lines = text
+			.replace(/^\/\*+[^\S\n]?/, "")
+			.replace(/\s*\*+\/$/, "")
+			.split("\n");
+		const rest = lines.slice(1).filter((line) => line.trim() !== "");
+		const starred =
+			rest.length > 0 && rest.every((line) => /^\s*\*/.test(line));
+		const indent =
+			starred || rest.length === 0
+				? 0
+				: Math.min(
+						...rest.map((line) => line.length - line.trimStart().length)
+					);
+
+		return lines
+			.map((line, i) => {
+				if (i === 0) {
+					return line.trimEnd();
+				}
+				return (
+					starred ? line.replace(/^\s*\*[^\S\n]?/, "") : line.slice(indent)
+				).trimEnd();
+			})
+			.join("\n")
+			.replace(/^\n+|\n+$/g, "");
+	}
+
+	/**
+	 * Предыдущий sibling, пропуская терминаторы (перевод строки, ";")
+	 */
+	private previousSignificant(
+		node: Parser.SyntaxNode
+	): Parser.SyntaxNode | null {
+		let sibling = node.previousSibling;
+		while (sibling && TERMINATOR_TYPES.has(sibling.type)) {
+			sibling = sibling.previousSibling;
+		}
+		return sibling;
+	}
+
+	/**
+	 * Комментарий, начинающийся на последней строке узла (`Age int // years`);
+	 * только с опцией trailingComments
+	 */
+	private extractTrailingComment(node: Parser.SyntaxNode): string | undefined {
+		if (!this.options.trailingComments) {
+			return undefined;
+		}
+		let sibling = node.nextSibling;
+		while (sibling && TERMINATOR_TYPES.has(sibling.type)) {
+			sibling = sibling.nextSibling;
+		}
+		if (
+			sibling?.type !== "comment" ||
+			sibling.startPosition.row !== node.endPosition.row
+		) {
+			return undefined;
+		}
+		return this.stripCommentMarkers(this.getText(sibling)) || undefined;
 	}
 
 	/**
 	 * Извлечь вызовы функций
 	 */
 	private extractCalls(node: Parser.SyntaxNode, source: string): string[] {
+		if (this.skipBodies) {
+			return [];
+		}
 		const calls = new Set<string>();
 		const callNodes = this.findNodesOfType(node, "call_expression");
 
@@ -601,6 +2373,89 @@ class GoNodeExtractor extends BaseNodeExtractor {
 		return Array.from(calls).slice(0, 30);
 	}
 
+	/**
+	 * Извлечь все вызовы тела с квалификатором и строкой (без лимита)
+	 *
+	 * `u.Save()` -> { qualifier: "u", name: "Save" }; вызовы по сложным
+	 * выражениям (`getHandler()()`, `items[0].Run()`) пропускаются.
+	 */
+	private extractCallRefs(node: Parser.SyntaxNode): CallReference[] {
+		const body = this.getChild(node, "body");
+		if (!body || this.skipBodies) {
+			return [];
+		}
+
+		const refs: CallReference[] = [];
+		const seen = new Set<string>();
+
+		for (const callNode of this.findNodesOfType(body, "call_expression")) {
+			const functionNode = this.getChild(callNode, "function");
+			let ref: CallReference | null = null;
+
+			if (functionNode?.type === "identifier") {
+				ref = {
+					name: this.getText(functionNode),
+					line: this.getLineNumber(callNode.startPosition),
+				};
+			} else if (functionNode?.type === "selector_expression") {
+				const operand = this.getChild(functionNode, "operand");
+				const field = this.getChild(functionNode, "field");
+				if (operand?.type === "identifier" && field) {
+					ref = {
+						name: this.getText(field),
+						qualifier: this.getText(operand),
+						line: this.getLineNumber(callNode.startPosition),
+					};
+				}
+			}
+
+			if (!ref) {
+				continue;
+			}
+			const key = `${ref.qualifier ?? ""}.${ref.name}`;
+			if (!seen.has(key)) {
+				seen.add(key);
+				refs.push(ref);
+			}
+		}
+
+		return refs;
+	}
+
+	/**
+	 * Размер и приближённая цикломатическая сложность функции (см. SymbolMetrics)
+	 */
+	private extractMetrics(
+		node: Parser.SyntaxNode,
+		parameters: FunctionParameter[]
+	): SymbolMetrics {
+		const metrics: SymbolMetrics = {
+			loc: node.endPosition.row - node.startPosition.row + 1,
+			parameterCount: parameters.length,
+		};
+		const body = this.getChild(node, "body");
+		if (!body || this.skipBodies) {
+			return metrics;
+		}
+
+		let complexity = 1;
+		const visit = (n: Parser.SyntaxNode): void => {
+			if (BRANCH_NODE_TYPES.has(n.type)) {
+				complexity++;
+			} else if (n.type === "binary_expression") {
+				const operator = this.getChild(n, "operator")?.type;
+				if (operator === "&&" || operator === "||") {
+					complexity++;
+				}
+			}
+			for (const child of n.namedChildren) {
+				visit(child);
+			}
+		};
+		visit(body);
+		return { ...metrics, complexity };
+	}
+
 	/**
 	 * Извлечь импорты
 	 */
@@ -619,3 +2474,170 @@ class GoNodeExtractor extends BaseNodeExtractor {
 		return imports.slice(0, 30);
 	}
 }
+
+/**
+ * Параметры типа, видимые в символе: свои (`func F[T any]`) и параметры
+ * generic receiver'а (`func (l *List[T]) Push(v T)`)
+ */
+function goTypeParams(meta: SymbolMetadata): Set<string> {
+	const names = (meta.genericParams ?? []).map((g) => g.name);
+	const receiverArgs = /\[([^\]]*)\]/.exec(meta.language?.goReceiver ?? "");
+	for (const arg of receiverArgs?.[1]?.split(",") ?? []) {
+		names.push(arg.trim());
+	}
+	return new Set(names);
+}
+
+/**
+ * Идентификаторы типов в выражении типа с базовым generic типом, если
+ * идентификатор — (часть) аргумента инстанциации
+ *
+ * `map[string]List[*User]` -> string, List, User (typeArgumentOf: List).
+ * `[` сразу после идентификатора открывает аргументы типа, остальные
+ * скобки (`[]T`, `[4]T`, `map[K]V`) — нет; для вложенных инстанциаций
+ * берётся ближайшая.
+ */
+function scanTypeIdents(
+	expr: string
+): { ident: string; typeArgumentOf?: string }[] {
+	const result: { ident: string; typeArgumentOf?: string }[] = [];
+	const stack: (string | null)[] = [];
+	let previous: { end: number; ident: string } | null = null;
+	for (const match of expr.matchAll(TYPE_TOKEN_RE)) {
+		const token = match[0];
+		const index = match.index ?? 0;
+		if (token === "[") {
+			const opensArgs =
+				previous?.end === index && !TYPE_KEYWORDS.has(previous.ident);
+			stack.push(opensArgs && previous ? previous.ident : null);
+		} else if (token === "]") {
+			stack.pop();
+		} else {
+			const typeArgumentOf = stack.findLast((base) => base !== null);
+			result.push(
+				typeArgumentOf ? { ident: token, typeArgumentOf } : { ident: token }
+			);
+			previous = { end: index + token.length, ident: token };
+			continue;
+		}
+		previous = null;
+	}
+	return result;
+}
+
+/**
+ * `TODO`, `TODO:` или `TODO(owner):` целым словом; группа 2 — текст после
+ */
+function markerPattern(kinds: string[]): RegExp | null {
+	if (kinds.length === 0) {
+		return null;
+	}
+	const escaped = kinds.map((k) => k.replace(/[$.*+?^()[\]{}|\\]/g, "\\$&"));
+	return new RegExp(
+		`(?<![\\p{L}\\p{N}_])(${escaped.join("|")})(?![\\p{L}\\p{N}_])(?:\\([^)]*\\))?:?(.*)`,
+		"u"
+	);
+}
+
+function importKind(alias: string | undefined): GoImportKind {
+	if (alias === ".") {
+		return "dot";
+	}
+	if (alias === "_") {
+		return "blank";
+	}
+	return alias ? "aliased" : "normal";
+}
+
+/**
+ * Импорты, доступные по квалификатору (без dot и blank)
+ */
+function namedImports(imports: GoImport[]): Map<string, GoImport> {
+	return new Map(
+		imports
+			.filter((i) => i.kind === "normal" || i.kind === "aliased")
+			.map((i) => [i.name, i])
+	);
+}
+
+/**
+ * Имя пакета по умолчанию для пути импорта (последний сегмент без версии)
+ */
+function defaultImportName(path: string): string {
+	const segments = path.split("/");
+	let last = segments.at(-1) ?? path;
+	if (IMPORT_VERSION_RE.test(last) && segments.length > 1) {
+		last = segments.at(-2) ?? last;
+	}
+	return last.replace(GOPKG_VERSION_RE, "");
+}
+
+/**
+ * Варианты исходника, в каждом из которых оставлены только заголовок файла
+ * (package, imports) и одно объявление верхнего уровня с его doc comment.
+ * Остальные строки пустые, поэтому номера строк совпадают с оригиналом
+ */
+function isolateTopLevelDeclarations(source: string): string[] {
+	const lines = source.split("\n");
+	const starts: number[] = [];
+	for (const [i, line] of lines.entries()) {
+		if (!TOP_LEVEL_DECL_RE.test(line)) {
+			continue;
+		}
+		let start = i;
+		while (start > 0 && lines[start - 1]?.startsWith("//")) {
+			start--;
+		}
+		starts.push(start);
+	}
+	if (starts.length < 2) {
+		return [];
+	}
+
+	const headerEnd = starts[0] as number;
+	return starts.map((start, k) => {
+		const end = starts[k + 1] ?? lines.length;
+		return lines
+			.map((line, i) => (i < headerEnd || (i >= start && i < end) ? line : ""))
+			.join("\n");
+	});
+}
+
+/**
+ * Свести символы package clause файлов пакета в один
+ *
+ * Doc comment из doc.go — каноничная документация пакета и используется
+ * как есть; без него doc comments файлов склеиваются по порядку.
+ */
+function mergePackageSymbols(
+	clauses: EnhancedCodeSymbol[]
+): EnhancedCodeSymbol | undefined {
+	const first = clauses[0];
+	if (!first) {
+		return undefined;
+	}
+	const docOf = (s: EnhancedCodeSymbol) => s.metadata?.docComment ?? "";
+	const docFile = clauses.find(
+		(s) => basename(s.path) === "doc.go" && docOf(s) !== ""
+	);
+	const doc = docFile
+		? docOf(docFile)
+		: clauses.map(docOf).filter(Boolean).join("\n\n");
+	const base = docFile ?? first;
+	// Импорты и директивы одного файла к пакету в целом не относятся
+	const {
+		goDirectives: _directives,
+		goImports: _imports,
+		...language
+	} = base.metadata?.language ?? {};
+
+	return {
+		...base,
+		jsDoc: doc,
+		metadata: {
+			...base.metadata,
+			docComment: doc || undefined,
+			language: { ...language, goDocComment: doc },
+		},
+	};
+}
@@ -0,0 +1,587 @@
+// @ts-nocheck
+import type Parser from "tree-sitter";
+import Java from "tree-sitter-java";
+import type { ParserCapability } from "./base-parser.ts";
+import { applyDeprecation, javaDeprecation } from "./deprecation.ts";
+import {
+	BaseNodeExtractor,
+	type NodeExtractor,
+	TreeSitterParser,
+} from "./tree-sitter-parser.ts";
+import type {
+	Decorator,
+	EnhancedCodeSymbol,
+	FunctionParameter,
+	GenericParameter,
+	StructField,
+	SymbolMetadata,
+	SymbolType,
+} from "./types.ts";
+
+type Visibility = NonNullable<SymbolMetadata["visibility"]>;
+
+// Объявления типов и их symbolType; record — класс с модификатором record
+const TYPE_DECLARATIONS: Record<string, SymbolType> = {
+	annotation_type_declaration: "interface",
+	class_declaration: "class",
+	enum_declaration: "enum",
+	interface_declaration: "interface",
+	record_declaration: "class",
+};
+const VISIBILITY_KEYWORDS = new Set(["public", "protected", "private"]);
+// В 0.21 грамматика различает line_comment и block_comment
+const COMMENT_TYPES = new Set(["block_comment", "comment"]);
+const JAVADOC_LINE_RE = /^\s*\*\s?/;
+const MAX_CALLS = 30;
+
+interface Modifiers {
+	annotations: Decorator[];
+	keywords: string[];
+}
+
+/**
+ * Java парсер на основе Tree-sitter
+ */
+export class JavaParser extends TreeSitterParser {
+	readonly capabilities: ReadonlySet<ParserCapability> = new Set([
+		"docComments",
+		"ranges",
+		"generics",
+		"fields",
+	]);
+
+	protected getLanguage(): unknown {
+		return Java;
+	}
+
+	protected getNodeExtractor(): NodeExtractor {
+		return new JavaNodeExtractor();
+	}
+}
+
+/**
+ * Extractor для Java AST
+ *
+ * Члены типа получают имя с квалификатором владельца: `User.getName`,
+ * вложенный класс — `Outer.Inner` с parent `Outer`. Константы — поля
+ * `static final`, поля интерфейсов и элементы enum.
+ */
+class JavaNodeExtractor extends BaseNodeExtractor {
+	private filePath = "";
+	private packageName?: string;
+
+	extractSymbols(
+		tree: Parser.Tree,
+		filePath: string,
+		_source: string
+	): EnhancedCodeSymbol[] {
+		this.filePath = filePath;
+		const root = tree.rootNode;
+		const pkg = root.children.find((c) => c.type === "package_declaration");
+		const pkgName = pkg?.namedChildren.find(
+			(c) => c.type === "scoped_identifier" || c.type === "identifier"
+		);
+		this.packageName = pkgName ? this.getText(pkgName) : undefined;
+
+		const symbols: EnhancedCodeSymbol[] = [];
+		for (const node of root.namedChildren) {
+			if (TYPE_DECLARATIONS[node.type]) {
+				this.tryExtract(node, undefined, () =>
+					this.extractType(node, symbols)
+				);
+			}
+		}
+		for (const symbol of symbols) {
+			applyDeprecation(
+				symbol,
+				javaDeprecation(symbol.metadata?.decorators, symbol.jsDoc)
+			);
+		}
+		return symbols;
+	}
+
+	/**
+	 * Извлечь объявление типа, его члены и вложенные типы
+	 */
+	private extractType(
+		node: Parser.SyntaxNode,
+		symbols: EnhancedCodeSymbol[],
+		outer?: string,
+		outerIsInterface = false
+	): void {
+		const nameNode = this.getChild(node, "name");
+		if (!nameNode) {
+			return;
+		}
+		const name = outer
+			? `${outer}.${this.getText(nameNode)}`
+			: this.getText(nameNode);
+		const isInterface = TYPE_DECLARATIONS[node.type] === "interface";
+		const { annotations, keywords } = this.extractModifiers(node);
+		const visibility = this.extractVisibility(keywords, outerIsInterface);
+		const docComment = this.extractDocComment(node);
+		const genericParams = this.extractGenerics(node);
+		const body = this.getChild(node, "body");
+		const fields = this.extractFields(node, body, isInterface);
+		const modifiers = this.memberModifiers(keywords);
+		if (node.type === "record_declaration") {
+			modifiers.push("record");
+		}
+
+		symbols.push({
+			name,
+			symbolType: TYPE_DECLARATIONS[node.type] as SymbolType,
+			path: this.filePath,
+			startLine: this.getLineNumber(node.startPosition),
+			endLine: this.getLineNumber(node.endPosition),
+			body: this.truncateBody(this.getText(node)),
+			jsDoc: docComment,
+			calls: [],
+			imports: [],
+			metadata: {
+				visibility,
+				isExported: isExportedVisibility(visibility),
+				genericParams: genericParams.length > 0 ? genericParams : undefined,
+				docComment: docComment || undefined,
+				decorators: annotations.length > 0 ? annotations : undefined,
+				modifiers: modifiers.length > 0 ? modifiers : undefined,
+				fields: fields.length > 0 ? fields : undefined,
+				packageName: this.packageName,
+				parent: outer,
+				range: this.getRange(node),
+				nameRange: this.getRange(nameNode),
+			},
+		});
+
+		if (!body) {
+			return;
+		}
+		for (const member of this.bodyMembers(body)) {
+			this.tryExtract(member, undefined, () =>
+				this.extractMember(member, name, isInterface, symbols)
+			);
+		}
+	}
+
+	/**
+	 * Члены тела типа; у enum — константы и объявления после `;`
+	 */
+	private bodyMembers(body: Parser.SyntaxNode): Parser.SyntaxNode[] {
+		return body.namedChildren.flatMap((child) =>
+			child.type === "enum_body_declarations" ? child.namedChildren : [child]
+		);
+	}
+
+	private extractMember(
+		member: Parser.SyntaxNode,
+		owner: string,
+		inInterface: boolean,
+		symbols: EnhancedCodeSymbol[]
+	): void {
+		if (TYPE_DECLARATIONS[member.type]) {
+			this.extractType(member, symbols, owner, inInterface);
+			return;
+		}
+		switch (member.type) {
+			case "method_declaration":
+			case "annotation_type_element_declaration":
+			case "constructor_declaration":
+			case "compact_constructor_declaration": {
+				const method = this.extractMethod(member, owner, inInterface);
+				if (method) {
+					symbols.push(method);
+				}
+				return;
+			}
+			case "field_declaration":
+			case "constant_declaration":
+				symbols.push(...this.extractConstants(member, owner, inInterface));
+				return;
+			case "enum_constant":
+				symbols.push(this.extractEnumConstant(member, owner));
+				return;
+		}
+	}
+
+	/**
+	 * Извлечь метод или конструктор (конструктор — `User.User`)
+	 */
+	private extractMethod(
+		node: Parser.SyntaxNode,
+		owner: string,
+		inInterface: boolean
+	): EnhancedCodeSymbol | null {
+		const nameNode = this.getChild(node, "name");
+		if (!nameNode) {
+			return null;
+		}
+		const { annotations, keywords } = this.extractModifiers(node);
+		const visibility = this.extractVisibility(keywords, inInterface);
+		const docComment = this.extractDocComment(node);
+		const genericParams = this.extractGenerics(node);
+		const returnTypeNode = this.getChild(node, "type");
+		const modifiers = this.memberModifiers(keywords);
+		const isConstructor = node.type.includes("constructor");
+		if (isConstructor) {
+			modifiers.push("constructor");
+		}
+
+		const metadata: SymbolMetadata = {
+			parameters: this.extractParameters(this.getChild(node, "parameters")),
+			returnType: returnTypeNode ? this.getText(returnTypeNode) : undefined,
+			visibility,
+			isExported: isExportedVisibility(visibility),
+			genericParams: genericParams.length > 0 ? genericParams : undefined,
+			receiver: owner,
+			docComment: docComment || undefined,
+			decorators: annotations.length > 0 ? annotations : undefined,
+			modifiers: modifiers.length > 0 ? modifiers : undefined,
+			packageName: this.packageName,
+			range: this.getRange(node),
+			nameRange: this.getRange(nameNode),
+		};
+
+		return {
+			name: `${owner}.${this.getText(nameNode)}`,
+			symbolType: "method",
+			path: this.filePath,
+			startLine: this.getLineNumber(node.startPosition),
+			endLine: this.getLineNumber(node.endPosition),
+			body: this.truncateBody(this.getText(node)),
+			jsDoc: docComment,
+			calls: this.extractCalls(node),
+			imports: [],
+			metadata,
+		};
+	}
+
+	/**
+	 * Константы из объявления поля: `static final` в классе, любое поле
+	 * интерфейса
+	 */
+	private extractConstants(
+		node: Parser.SyntaxNode,
+		owner: string,
+		inInterface: boolean
+	): EnhancedCodeSymbol[] {
+		const { annotations, keywords } = this.extractModifiers(node);
+		const isConstant =
+			inInterface ||
+			(keywords.includes("static") && keywords.includes("final"));
+		if (!isConstant) {
+			return [];
+		}
+		const visibility = this.extractVisibility(keywords, inInterface);
+		const docComment = this.extractDocComment(node);
+		const typeNode = this.getChild(node, "type");
+		const modifiers = this.memberModifiers(keywords);
+
+		return this.declaratorsOf(node).map(({ declarator, nameNode }) => ({
+			name: `${owner}.${this.getText(nameNode)}`,
+			symbolType: "constant",
+			path: this.filePath,
+			startLine: this.getLineNumber(node.startPosition),
+			endLine: this.getLineNumber(node.endPosition),
+			body: this.truncateBody(this.getText(node)),
+			jsDoc: docComment,
+			calls: [],
+			imports: [],
+			metadata: {
+				visibility,
+				isExported: isExportedVisibility(visibility),
+				returnType: typeNode
+					? this.declaratorType(typeNode, declarator)
+					: undefined,
+				docComment: docComment || undefined,
+				decorators: annotations.length > 0 ? annotations : undefined,
+				modifiers: modifiers.length > 0 ? modifiers : undefined,
+				packageName: this.packageName,
+				parent: owner,
+				range: this.getRange(node),
+				nameRange: this.getRange(nameNode),
+			},
+		}));
+	}
+
+	/**
+	 * Элемент enum: `RED`, `RED("red")`, `RED { ... }`
+	 */
+	private extractEnumConstant(
+		node: Parser.SyntaxNode,
+		owner: string
+	): EnhancedCodeSymbol {
+		const nameNode = this.getChild(node, "name") ?? node;
+		const { annotations } = this.extractModifiers(node);
+		const docComment = this.extractDocComment(node);
+
+		return {
+			name: `${owner}.${this.getText(nameNode)}`,
+			symbolType: "constant",
+			path: this.filePath,
+			startLine: this.getLineNumber(node.startPosition),
+			endLine: this.getLineNumber(node.endPosition),
+			body: this.truncateBody(this.getText(node)),
+			jsDoc: docComment,
+			calls: [],
+			imports: [],
+			metadata: {
+				visibility: "public",
+				isExported: true,
+				returnType: owner,
+				docComment: docComment || undefined,
+				decorators: annotations.length > 0 ? annotations : undefined,
+				packageName: this.packageName,
+				parent: owner,
+				range: this.getRange(node),
+				nameRange: this.getRange(nameNode),
+			},
+		};
+	}
+
+	/**
+	 * Поля типа: компоненты record и все объявления полей тела
+	 */
+	private extractFields(
+		node: Parser.SyntaxNode,
+		body: Parser.SyntaxNode | null,
+		isInterface: boolean
+	): StructField[] {
+		const fields: StructField[] = [];
+		// record Point(int x, int y): компоненты доступны через public accessor
+		for (const param of this.extractParameterNodes(
+			this.getChild(node, "parameters")
+		)) {
+			const nameNode = this.parameterName(param);
+			const typeNode = this.getChild(param, "type");
+			if (nameNode && typeNode) {
+				fields.push({
+					name: this.getText(nameNode),
+					type: this.getText(typeNode),
+					exported: true,
+					range: this.getRange(param),
+					nameRange: this.getRange(nameNode),
+				});
+			}
+		}
+
+		for (const member of body ? this.bodyMembers(body) : []) {
+			if (
+				member.type !== "field_declaration" &&
+				member.type !== "constant_declaration"
+			) {
+				continue;
+			}
+			const { keywords } = this.extractModifiers(member);
+			const exported = isExportedVisibility(
+				this.extractVisibility(keywords, isInterface)
+			);
+			const typeNode = this.getChild(member, "type");
+			for (const { declarator, nameNode } of this.declaratorsOf(member)) {
+				fields.push({
+					name: this.getText(nameNode),
+					type: typeNode ? this.declaratorType(typeNode, declarator) : "",
+					exported,
+					range: this.getRange(member),
+					nameRange: this.getRange(nameNode),
+				});
+			}
+		}
+		return fields;
+	}
+
+	/**
+	 * Объявленные в поле переменные: `int x, y[];`
+	 */
+	private declaratorsOf(
+		node: Parser.SyntaxNode
+	): Array<{ declarator: Parser.SyntaxNode; nameNode: Parser.SyntaxNode }> {
+		const result = [];
+		for (const declarator of this.getChildrenOfType(
+			node,
+			"variable_declarator"
+		)) {
+			const nameNode = this.getChild(declarator, "name");
+			if (nameNode) {
+				result.push({ declarator, nameNode });
+			}
+		}
+		return result;
+	}
+
+	/**
+	 * Тип переменной с учётом размерностей после имени: `int y[]` -> `int[]`
+	 */
+	private declaratorType(
+		typeNode: Parser.SyntaxNode,
+		declarator: Parser.SyntaxNode
+	): string {
+		const dimensions = this.getChild(declarator, "dimensions");
+		return (
+			this.getText(typeNode) + (dimensions ? this.getText(dimensions) : "")
+		);
+	}
+
+	/**
+	 * Ключевые слова модификаторов и аннотации (`@Override`, `@Path("/x")`)
+	 */
+	private extractModifiers(node: Parser.SyntaxNode): Modifiers {
+		const modifiersNode = node.children.find((c) => c.type === "modifiers");
+		const result: Modifiers = { annotations: [], keywords: [] };
+		for (const child of modifiersNode?.children ?? []) {
+			if (child.type === "marker_annotation" || child.type === "annotation") {
+				const nameNode = this.getChild(child, "name");
+				const args = this.getChild(child, "arguments");
+				result.annotations.push({
+					name: nameNode ? this.getText(nameNode) : this.getText(child),
+					arguments: args?.namedChildren.map((arg) => this.getText(arg)),
+				});
+			} else if (!child.isNamed) {
+				result.keywords.push(child.type);
+			}
+		}
+		return result;
+	}
+
+	/**
+	 * Модификаторы кроме видимости: static, final, abstract, default...
+	 */
+	private memberModifiers(keywords: string[]): string[] {
+		return keywords.filter((k) => !VISIBILITY_KEYWORDS.has(k));
+	}
+
+	/**
+	 * Видимость по модификаторам; без них — package-private, а члены
+	 * интерфейса неявно public
+	 */
+	private extractVisibility(
+		keywords: string[],
+		implicitPublic: boolean
+	): Visibility {
+		if (keywords.includes("public")) {
+			return "public";
+		}
+		if (keywords.includes("protected")) {
+			return "protected";
+		}
+		if (keywords.includes("private")) {
+			return "private";
+		}
+		return implicitPublic ? "public" : "package";
+	}
+
+	/**
+	 * Параметры типа: `<T extends Comparable<T> & Serializable>`
+	 */
+	private extractGenerics(node: Parser.SyntaxNode): GenericParameter[] {
+		const typeParams =
+			this.getChild(node, "type_parameters") ??
+			node.children.find((c) => c.type === "type_parameters");
+		if (!typeParams) {
+			return [];
+		}
+		const generics: GenericParameter[] = [];
+		for (const param of this.getChildrenOfType(typeParams, "type_parameter")) {
+			const nameNode = param.namedChildren.find(
+				(c) => c.type === "type_identifier" || c.type === "identifier"
+			);
+			if (!nameNode) {
+				continue;
+			}
+			const bound = param.namedChildren.find((c) => c.type === "type_bound");
+			generics.push({
+				name: this.getText(nameNode),
+				constraint: bound
+					? this.getText(bound).replace(/^extends\s+/, "")
+					: undefined,
+			});
+		}
+		return generics;
+	}
+
+	private extractParameterNodes(
+		parametersNode: Parser.SyntaxNode | null
+	): Parser.SyntaxNode[] {
+		return (parametersNode?.namedChildren ?? []).filter(
+			(c) => c.type === "formal_parameter" || c.type === "spread_parameter"
+		);
+	}
+
+	/**
+	 * Имя параметра; у `String... args` оно внутри variable_declarator
+	 */
+	private parameterName(param: Parser.SyntaxNode): Parser.SyntaxNode | null {
+		const name = this.getChild(param, "name");
+		if (name) {
+			return name;
+		}
+		const declarator = param.namedChildren.find(
+			(c) => c.type === "variable_declarator"
+		);
+		return declarator ? this.getChild(declarator, "name") : null;
+	}
+
+	private extractParameters(
+		parametersNode: Parser.SyntaxNode | null
+	): FunctionParameter[] {
+		const params: FunctionParameter[] = [];
+		for (const param of this.extractParameterNodes(parametersNode)) {
+			const nameNode = this.parameterName(param);
+			const typeNode =
+				this.getChild(param, "type") ??
+				param.namedChildren.find(
+					(c) => c.type !== "modifiers" && c.type !== "variable_declarator"
+				);
+			const variadic = param.type === "spread_parameter";
+			params.push({
+				name: nameNode ? this.getText(nameNode) : "",
+				type: typeNode ? this.getText(typeNode) : undefined,
+				variadic: variadic || undefined,
+			});
+		}
+		return params;
+	}
+
+	/**
+	 * Javadoc `/** ... *\/` непосредственно над объявлением (аннотации
+	 * входят в узел объявления)
+	 */
+	private extractDocComment(node: Parser.SyntaxNode): string {
+		const prev = node.previousNamedSibling;
+		if (
+			!prev ||
+			!COMMENT_TYPES.has(prev.type) ||
+			prev.endPosition.row < node.startPosition.row - 1
+		) {
+			return "";
+		}
+		const text = this.getText(prev);
+		if (!text.startsWith("/**")) {
+			return "";
+		}
+		return text
+			.replace(/^\/\*\*/, "")
+			.replace(/\*\/$/, "")
+			.split("\n")
+			.map((line) => line.replace(JAVADOC_LINE_RE, "").trimEnd())
+			.join("\n")
+			.trim();
+	}
+
+	/**
+	 * Имена вызванных методов: `helper()`, `repo.save()` -> save
+	 */
+	private extractCalls(node: Parser.SyntaxNode): string[] {
+		const calls = new Set<string>();
+		for (const call of this.findNodesOfType(node, "method_invocation")) {
+			const nameNode = this.getChild(call, "name");
+			if (nameNode) {
+				calls.add(this.getText(nameNode));
+			}
+		}
+		return [...calls].slice(0, MAX_CALLS);
+	}
+}
+
+function isExportedVisibility(visibility: Visibility): boolean {
+	return visibility === "public" || visibility === "protected";
+}
@@ -0,0 +1,732 @@
+// @ts-nocheck
+import type Parser from "tree-sitter";
+import JavaScript from "tree-sitter-javascript";
+import type { ParserCapability } from "./base-parser.ts";
+import { applyDeprecation, docTagDeprecation } from "./deprecation.ts";
+import {
+	BaseNodeExtractor,
+	type NodeExtractor,
+	TreeSitterParser,
+} from "./tree-sitter-parser.ts";
+import type {
+	EnhancedCodeSymbol,
+	FunctionParameter,
+	SymbolMetadata,
+	SymbolType,
+} from "./types.ts";
+
+const UPPER_START_RE = /^[A-Z]/;
+const JSX_EXT_RE = /\.jsx$/;
+const JSDOC_PARAM_RE =
+	/@(?:param|arg|argument)\s+\{([^}]+)\}\s+(\[[^\]]+\]|[\p{ID_Continue}$.]+)/gu;
+const JSDOC_RETURNS_RE = /@returns?\s+\{([^}]+)\}/;
+const FUNCTION_VALUE_TYPES = new Set([
+	"arrow_function",
+	"function",
+	"function_expression",
+	"generator_function",
+]);
+const CLASS_VALUE_TYPES = new Set(["class", "class_expression"]);
+
+/**
+ * Тип параметра из JSDoc: `@param {string} [name="x"]`
+ */
+interface JsDocParam {
+	optional: boolean;
+	type: string;
+}
+
+interface JsDocTags {
+	params: Map<string, JsDocParam>;
+	returnType?: string;
+}
+
+/**
+ * JavaScript/JSX парсер на основе Tree-sitter
+ *
+ * Обрабатывает .js, .jsx, .mjs и .cjs: функции, классы с методами,
+ * функции в const, ES export и CommonJS `module.exports`/`exports.x`.
+ * Типов в JS нет, поэтому type параметра — null, если его не задаёт
+ * JSDoc `@param {Type}`.
+ */
+export class JavaScriptParser extends TreeSitterParser {
+	readonly capabilities: ReadonlySet<ParserCapability> = new Set([
+		"docComments",
+	]);
+
+	protected getLanguage(): unknown {
+		return JavaScript;
+	}
+
+	protected getNodeExtractor(): NodeExtractor {
+		return new JavaScriptNodeExtractor();
+	}
+}
+
+/**
+ * Extractor для JavaScript AST
+ */
+class JavaScriptNodeExtractor extends BaseNodeExtractor {
+	/** Локальное имя -> модуль (import и require) */
+	private importMap = new Map<string, string>();
+
+	extractSymbols(
+		tree: Parser.Tree,
+		filePath: string,
+		source: string
+	): EnhancedCodeSymbol[] {
+		const symbols: EnhancedCodeSymbol[] = [];
+		const exportedNames = new Set<string>();
+		this.importMap = this.extractImportsMap(tree.rootNode);
+
+		for (const stmt of tree.rootNode.children) {
+			this.tryExtract(stmt, undefined, () =>
+				this.visitStatement(stmt, filePath, symbols, exportedNames)
+			);
+		}
+
+		// `export { a }` и `module.exports = { a }` экспортируют объявленное выше
+		for (const symbol of symbols) {
+			const metadata = symbol.metadata ?? {};
+			if (symbol.symbolType === "method") {
+				metadata.visibility ??= "public";
+				metadata.isExported = metadata.visibility === "public";
+			} else {
+				metadata.isExported ||= exportedNames.has(symbol.name);
+				metadata.visibility = metadata.isExported ? "public" : "private";
+			}
+			symbol.metadata = metadata;
+			applyDeprecation(symbol, docTagDeprecation(symbol.jsDoc));
+		}
+
+		return symbols;
+	}
+
+	private visitStatement(
+		stmt: Parser.SyntaxNode,
+		filePath: string,
+		symbols: EnhancedCodeSymbol[],
+		exportedNames: Set<string>
+	): void {
+		if (stmt.type === "export_statement") {
+			this.visitExport(stmt, filePath, symbols, exportedNames);
+			return;
+		}
+		if (stmt.type === "expression_statement") {
+			const expression = stmt.namedChildren[0];
+			if (expression?.type === "assignment_expression") {
+				this.visitCommonJsExport(
+					expression,
+					stmt,
+					filePath,
+					symbols,
+					exportedNames
+				);
+			}
+			return;
+		}
+		symbols.push(...this.extractDeclaration(stmt, stmt, filePath, false));
+	}
+
+	/**
+	 * ES export: `export function`, `export default`, `export { a as b }`
+	 */
+	private visitExport(
+		stmt: Parser.SyntaxNode,
+		filePath: string,
+		symbols: EnhancedCodeSymbol[],
+		exportedNames: Set<string>
+	): void {
+		const declaration = this.getChild(stmt, "declaration");
+		if (declaration) {
+			symbols.push(
+				...this.extractDeclaration(declaration, stmt, filePath, true)
+			);
+			return;
+		}
+
+		const value = this.getChild(stmt, "value");
+		if (value) {
+			if (value.type === "identifier") {
+				exportedNames.add(this.getText(value));
+				return;
+			}
+			const name = this.nameOf(value) ?? "default";
+			symbols.push(
+				...this.markExported(this.extractValue(name, value, stmt, filePath))
+			);
+			return;
+		}
+
+		// Re-export из другого модуля (`export { a } from "b"`) символов не даёт
+		if (this.getChild(stmt, "source")) {
+			return;
+		}
+		for (const spec of this.findNodesOfType(stmt, "export_specifier")) {
+			const nameNode = this.getChild(spec, "name");
+			if (nameNode) {
+				exportedNames.add(this.getText(nameNode));
+			}
+		}
+	}
+
+	/**
+	 * CommonJS: `module.exports = ...`, `module.exports.x = ...`, `exports.x = ...`
+	 */
+	private visitCommonJsExport(
+		assignment: Parser.SyntaxNode,
+		stmt: Parser.SyntaxNode,
+		filePath: string,
+		symbols: EnhancedCodeSymbol[],
+		exportedNames: Set<string>
+	): void {
+		const left = this.getChild(assignment, "left");
+		const right = this.getChild(assignment, "right");
+		if (left?.type !== "member_expression" || !right) {
+			return;
+		}
+
+		const target = this.getText(left).replace(/\s+/g, "");
+		if (target === "module.exports") {
+			if (right.type === "identifier") {
+				exportedNames.add(this.getText(right));
+			} else if (right.type === "object") {
+				this.visitExportsObject(right, filePath, symbols, exportedNames);
+			} else {
+				const name = this.nameOf(right) ?? "module.exports";
+				symbols.push(
+					...this.markExported(this.extractValue(name, right, stmt, filePath))
+				);
+			}
+			return;
+		}
+
+		const match = target.match(/^(?:module\.)?exports\.([\p{ID_Continue}$]+)$/u);
+		if (!match) {
+			return;
+		}
+		const name = match[1] as string;
+		if (right.type === "identifier") {
+			exportedNames.add(this.getText(right));
+			return;
+		}
+		const extracted = this.extractValue(name, right, stmt, filePath);
+		symbols.push(
+			...this.markExported(
+				extracted.length > 0
+					? extracted
+					: [this.extractConstant(name, right, stmt, filePath, "constant")]
+			)
+		);
+	}
+
+	/**
+	 * `module.exports = { a, b: () => {}, c() {} }`
+	 */
+	private visitExportsObject(
+		object: Parser.SyntaxNode,
+		filePath: string,
+		symbols: EnhancedCodeSymbol[],
+		exportedNames: Set<string>
+	): void {
+		for (const member of object.namedChildren) {
+			if (member.type === "shorthand_property_identifier") {
+				exportedNames.add(this.getText(member));
+				continue;
+			}
+			if (member.type === "method_definition") {
+				const nameNode = this.getChild(member, "name");
+				if (nameNode) {
+					const symbol = this.extractFunction(
+						this.getText(nameNode),
+						member,
+						member,
+						filePath
+					);
+					symbols.push(...this.markExported([symbol]));
+				}
+				continue;
+			}
+			if (member.type !== "pair") {
+				continue;
+			}
+			const key = this.getChild(member, "key");
+			const value = this.getChild(member, "value");
+			if (!(key && value)) {
+				continue;
+			}
+			if (value.type === "identifier") {
+				exportedNames.add(this.getText(value));
+				continue;
+			}
+			const name = this.getText(key).replace(/^["']|["']$/g, "");
+			symbols.push(
+				...this.markExported(this.extractValue(name, value, member, filePath))
+			);
+		}
+	}
+
+	/**
+	 * Объявление верхнего уровня: function, class, const/let/var
+	 *
+	 * @param outer - узел для позиции и JSDoc (export_statement при экспорте)
+	 */
+	private extractDeclaration(
+		node: Parser.SyntaxNode,
+		outer: Parser.SyntaxNode,
+		filePath: string,
+		isExported: boolean
+	): EnhancedCodeSymbol[] {
+		const symbols: EnhancedCodeSymbol[] = [];
+
+		if (
+			node.type === "function_declaration" ||
+			node.type === "generator_function_declaration"
+		) {
+			const nameNode = this.getChild(node, "name");
+			if (nameNode) {
+				symbols.push(
+					this.extractFunction(this.getText(nameNode), node, outer, filePath)
+				);
+			}
+		} else if (node.type === "class_declaration") {
+			const nameNode = this.getChild(node, "name");
+			if (nameNode) {
+				symbols.push(
+					...this.extractClass(this.getText(nameNode), node, outer, filePath)
+				);
+			}
+		} else if (
+			node.type === "lexical_declaration" ||
+			node.type === "variable_declaration"
+		) {
+			// const -> constant, let/var -> variable
+			const kind: SymbolType = node.children.some((c) => c.type === "const")
+				? "constant"
+				: "variable";
+			const declarators = this.getChildrenOfType(node, "variable_declarator");
+			for (const declarator of declarators) {
+				const nameNode = this.getChild(declarator, "name");
+				const value = this.getChild(declarator, "value");
+				// Деструктуризация и require() — импорты, а не символы
+				if (nameNode?.type !== "identifier" || this.isRequireCall(value)) {
+					continue;
+				}
+				const name = this.getText(nameNode);
+				// Позиция единственного declarator — всё объявление
+				const position = declarators.length === 1 ? outer : declarator;
+				const extracted = value
+					? this.extractValue(name, value, position, filePath)
+					: [];
+				symbols.push(
+					...(extracted.length > 0
+						? extracted
+						: [this.extractConstant(name, value, position, filePath, kind)])
+				);
+			}
+		}
+
+		return isExported ? this.markExported(symbols) : symbols;
+	}
+
+	/**
+	 * Пометить экспортируемыми (методы получают видимость отдельно)
+	 */
+	private markExported(symbols: EnhancedCodeSymbol[]): EnhancedCodeSymbol[] {
+		for (const symbol of symbols) {
+			if (symbol.symbolType !== "method") {
+				symbol.metadata = { ...symbol.metadata, isExported: true };
+			}
+		}
+		return symbols;
+	}
+
+	/**
+	 * Символы функции или класса (с методами) в значении; пусто для прочих
+	 */
+	private extractValue(
+		name: string,
+		value: Parser.SyntaxNode,
+		outer: Parser.SyntaxNode,
+		filePath: string
+	): EnhancedCodeSymbol[] {
+		if (FUNCTION_VALUE_TYPES.has(value.type)) {
+			return [this.extractFunction(name, value, outer, filePath)];
+		}
+		if (CLASS_VALUE_TYPES.has(value.type)) {
+			return this.extractClass(name, value, outer, filePath);
+		}
+		return [];
+	}
+
+	/**
+	 * Извлечь функцию (declaration, arrow, function expression)
+	 */
+	private extractFunction(
+		name: string,
+		node: Parser.SyntaxNode,
+		outer: Parser.SyntaxNode,
+		filePath: string
+	): EnhancedCodeSymbol {
+		const jsDoc = this.extractJsDoc(outer);
+		const tags = this.parseJsDocTags(jsDoc);
+		const calls = this.extractCalls(node);
+		const body = this.truncateBody(this.getText(outer));
+
+		const metadata: SymbolMetadata = {
+			parameters: this.extractParameters(node, tags),
+			returnType: tags.returnType,
+			isAsync: node.children.some((c) => c.type === "async"),
+			docComment: jsDoc || undefined,
+		};
+
+		return {
+			name,
+			symbolType:
+				UPPER_START_RE.test(name) && JSX_EXT_RE.test(filePath)
+					? "component"
+					: "function",
+			path: filePath,
+			startLine: this.getLineNumber(outer.startPosition),
+			endLine: this.getLineNumber(outer.endPosition),
+			body,
+			jsDoc,
+			calls,
+			imports: this.resolveImports(calls, body),
+			metadata,
+		};
+	}
+
+	/**
+	 * Извлечь класс и его методы
+	 */
+	private extractClass(
+		name: string,
+		node: Parser.SyntaxNode,
+		outer: Parser.SyntaxNode,
+		filePath: string
+	): EnhancedCodeSymbol[] {
+		const jsDoc = this.extractJsDoc(outer);
+		const body = this.truncateBody(this.getText(outer));
+		const heritage = node.children.find((c) => c.type === "class_heritage");
+		const superClass = heritage?.namedChildren[0];
+		const baseClasses = superClass ? [this.getText(superClass)] : [];
+
+		const classSymbol: EnhancedCodeSymbol = {
+			name,
+			symbolType: "class",
+			path: filePath,
+			startLine: this.getLineNumber(outer.startPosition),
+			endLine: this.getLineNumber(outer.endPosition),
+			body,
+			jsDoc,
+			calls: [],
+			imports: this.resolveImports(baseClasses, body),
+			metadata: {
+				docComment: jsDoc || undefined,
+			},
+		};
+
+		const methods: EnhancedCodeSymbol[] = [];
+		const classBody = this.getChild(node, "body");
+		for (const member of classBody?.namedChildren ?? []) {
+			if (member.type !== "method_definition") {
+				continue;
+			}
+			const nameNode = this.getChild(member, "name");
+			if (!nameNode) {
+				continue;
+			}
+			const methodName = this.getText(nameNode);
+			const method = this.extractFunction(
+				`${name}.${methodName}`,
+				member,
+				member,
+				filePath
+			);
+			method.symbolType = "method";
+			method.metadata = {
+				...method.metadata,
+				receiver: name,
+				visibility:
+					nameNode.type === "private_property_identifier"
+						? "private"
+						: "public",
+			};
+			methods.push(method);
+		}
+
+		return [classSymbol, ...methods];
+	}
+
+	/**
+	 * Константа или переменная с не-функциональным значением
+	 */
+	private extractConstant(
+		name: string,
+		value: Parser.SyntaxNode | null,
+		outer: Parser.SyntaxNode,
+		filePath: string,
+		symbolType: SymbolType
+	): EnhancedCodeSymbol {
+		const jsDoc = this.extractJsDoc(outer);
+		const calls = value ? this.extractCalls(value) : [];
+		const body = this.truncateBody(this.getText(outer));
+		const typeTag = jsDoc.match(/@type\s+\{([^}]+)\}/);
+
+		return {
+			name,
+			symbolType,
+			path: filePath,
+			startLine: this.getLineNumber(outer.startPosition),
+			endLine: this.getLineNumber(outer.endPosition),
+			body,
+			jsDoc,
+			calls,
+			imports: this.resolveImports(calls, body),
+			metadata: {
+				returnType: typeTag?.[1]?.trim(),
+				docComment: jsDoc || undefined,
+			},
+		};
+	}
+
+	/**
+	 * Параметры функции; тип берётся из JSDoc или равен null
+	 */
+	private extractParameters(
+		node: Parser.SyntaxNode,
+		tags: JsDocTags
+	): FunctionParameter[] {
+		// Arrow function с одним параметром без скобок: `x => x * 2`
+		const single = this.getChild(node, "parameter");
+		const list = single
+			? [single]
+			: (this.getChild(node, "parameters")?.namedChildren ?? []);
+
+		const params: FunctionParameter[] = [];
+		for (const param of list) {
+			if (param.type === "comment") {
+				continue;
+			}
+			let nameNode = param;
+			let defaultValue: string | undefined;
+			let variadic = false;
+			if (param.type === "assignment_pattern") {
+				nameNode = this.getChild(param, "left") ?? param;
+				const right = this.getChild(param, "right");
+				defaultValue = right ? this.getText(right) : undefined;
+			} else if (param.type === "rest_pattern") {
+				nameNode = param.namedChildren[0] ?? param;
+				variadic = true;
+			}
+
+			const name = this.getText(nameNode);
+			const doc = tags.params.get(name);
+			params.push({
+				name,
+				type: doc?.type ?? null,
+				isOptional: defaultValue !== undefined || !!doc?.optional,
+				defaultValue,
+				variadic: variadic || undefined,
+			});
+		}
+		return params;
+	}
+
+	/**
+	 * JSDoc (`/** … *\/`) непосредственно над узлом, без маркеров
+	 */
+	private extractJsDoc(node: Parser.SyntaxNode): string {
+		const comment = node.previousNamedSibling;
+		if (
+			comment?.type !== "comment" ||
+			comment.endPosition.row < node.startPosition.row - 1
+		) {
+			return "";
+		}
+		const text = this.getText(comment);
+		if (!text.startsWith("/**")) {
+			return "";
+		}
+		return text
+			.replace(/^\/\*\*|\*\/$/g, "")
+			.replace(/^\s*\*\s?/gm, "")
+			.trim();
+	}
+
+	/**
+	 * Теги `@param {Type} name` и `@returns {Type}`
+	 */
+	private parseJsDocTags(jsDoc: string): JsDocTags {
+		const params = new Map<string, JsDocParam>();
+		for (const match of jsDoc.matchAll(JSDOC_PARAM_RE)) {
+			let type = (match[1] as string).trim();
+			let name = match[2] as string;
+			let optional = false;
+			if (name.startsWith("[")) {
+				name = name.slice(1, -1).split("=")[0]?.trim() ?? name;
+				optional = true;
+			}
+			if (type.endsWith("=")) {
+				type = type.slice(0, -1);
+				optional = true;
+			}
+			// `@param {string} user.name` описывает поле, а не параметр
+			if (!name.includes(".")) {
+				params.set(name, { optional, type });
+			}
+		}
+		const returnType = jsDoc.match(JSDOC_RETURNS_RE)?.[1]?.trim();
+		return { params, returnType };
+	}
+
+	/**
+	 * Имя функции/класса в выражении (`function foo() {}`, `class Bar {}`)
+	 */
+	private nameOf(node: Parser.SyntaxNode): string | undefined {
+		const nameNode = this.getChild(node, "name");
+		return nameNode ? this.getText(nameNode) : undefined;
+	}
+
+	private isRequireCall(node: Parser.SyntaxNode | null): boolean {
+		if (node?.type !== "call_expression") {
+			return false;
+		}
+		const fn = this.getChild(node, "function");
+		return fn?.type === "identifier" && this.getText(fn) === "require";
+	}
+
+	/**
+	 * Извлечь вызовы функций
+	 */
+	private extractCalls(node: Parser.SyntaxNode): string[] {
+		const calls = new Set<string>();
+		for (const callNode of this.findNodesOfType(node, "call_expression")) {
+			const fn = this.getChild(callNode, "function");
+			if (fn?.type === "identifier") {
+				const name = this.getText(fn);
+				if (name !== "require") {
+					calls.add(name);
+				}
+			} else if (fn?.type === "member_expression") {
+				const property = this.getChild(fn, "property");
+				if (property) {
+					calls.add(this.getText(property));
+				}
+			}
+		}
+		return Array.from(calls).slice(0, 30);
+	}
+
+	/**
+	 * Локальные имена модулей: `import a, { b as c } from "m"`, `const d = require("m")`
+	 */
+	private extractImportsMap(root: Parser.SyntaxNode): Map<string, string> {
+		const importMap = new Map<string, string>();
+		const moduleName = (node: Parser.SyntaxNode | null | undefined) =>
+			node ? this.getText(node).replace(/^["'`]|["'`]$/g, "") : undefined;
+
+		for (const stmt of root.children) {
+			if (stmt.type === "import_statement") {
+				const module = moduleName(this.getChild(stmt, "source"));
+				if (!module) {
+					continue;
+				}
+				const clause = stmt.children.find((c) => c.type === "import_clause");
+				for (const id of clause ? this.localImportNames(clause) : []) {
+					importMap.set(id, module);
+				}
+				continue;
+			}
+
+			if (
+				stmt.type !== "lexical_declaration" &&
+				stmt.type !== "variable_declaration"
+			) {
+				continue;
+			}
+			for (const declarator of this.getChildrenOfType(
+				stmt,
+				"variable_declarator"
+			)) {
+				const value = this.getChild(declarator, "value");
+				if (!this.isRequireCall(value)) {
+					continue;
+				}
+				const args = this.getChild(value, "arguments");
+				const module = moduleName(args?.namedChildren[0]);
+				const nameNode = this.getChild(declarator, "name");
+				if (!(module && nameNode)) {
+					continue;
+				}
+				const names =
+					nameNode.type === "identifier"
+						? [this.getText(nameNode)]
+						: [
+								...this.findNodesOfType(
+									nameNode,
+									"shorthand_property_identifier_pattern"
+								),
+								...this.findNodesOfType(nameNode, "identifier"),
+							].map((n) => this.getText(n));
+				for (const name of names) {
+					importMap.set(name, module);
+				}
+			}
+		}
+
+		return importMap;
+	}
+
+	/**
+	 * Локальные имена import clause (default, namespace, named с алиасом)
+	 */
+	private localImportNames(clause: Parser.SyntaxNode): string[] {
+		const names: string[] = [];
+		for (const child of clause.namedChildren) {
+			if (child.type === "identifier") {
+				names.push(this.getText(child));
+			} else if (child.type === "namespace_import") {
+				const id = child.namedChildren.find((c) => c.type === "identifier");
+				if (id) {
+					names.push(this.getText(id));
+				}
+			} else if (child.type === "named_imports") {
+				for (const spec of this.getChildrenOfType(child, "import_specifier")) {
+					const local =
+						this.getChild(spec, "alias") ?? this.getChild(spec, "name");
+					if (local) {
+						names.push(this.getText(local));
+					}
+				}
+			}
+		}
+		return names;
+	}
+
+	/**
+	 * Импорты символа в формате `name:module` (как у TypeScript парсера)
+	 */
+	private resolveImports(calls: string[], body: string): string[] {
+		const resolved: string[] = [];
+		const added = new Set<string>();
+
+		for (const call of calls) {
+			const source = this.importMap.get(call);
+			if (source && !added.has(call)) {
+				resolved.push(`${call}:${source}`);
+				added.add(call);
+			}
+		}
+
+		for (const [name, source] of this.importMap) {
+			if (body.includes(name) && !added.has(name)) {
+				resolved.push(`${name}:${source}`);
+				added.add(name);
+			}
+		}
+
+		return resolved.slice(0, 30);
+	}
+}
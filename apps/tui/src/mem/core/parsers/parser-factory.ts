@@ -3,30 +3,11 @@
  *
  * Возвращает подходящий парсер для заданного расширения файла.
  * Использует Tree-sitter парсеры где доступно, fallback на regex парсеры.
+ * Диспетчеризация делегируется общему parserRegistry.
  */
 
 import type { BaseParser } from "./base-parser.ts";
-import { GoParser } from "./go-parser.ts";
-import { PythonParser } from "./python-parser.ts";
-import { RustParser } from "./rust-parser.ts";
-
-/**
- * Mapping расширений файлов на парсеры
- *
- * NOTE: TypeScript/JavaScript используют свой parseFileWithTsCompiler()
- * и не включены в ParserFactory
- */
-const PARSER_MAP: Record<string, () => BaseParser> = {
-	// Python
-	".py": () => new PythonParser(),
-	".pyi": () => new PythonParser(),
-
-	// Go
-	".go": () => new GoParser(),
-
-	// Rust
-	".rs": () => new RustParser(),
-};
+import { parserRegistry } from "./parser-registry.ts";
 
 /**
  * Получить парсер для файла по его расширению
@@ -35,12 +16,7 @@ const PARSER_MAP: Record<string, () => BaseParser> = {
  * @returns Парсер для данного типа файла или null если не поддерживается
  */
 export function getParser(fileExtension: string): BaseParser | null {
-	const factory = PARSER_MAP[fileExtension.toLowerCase()];
-	if (!factory) {
-		return null;
-	}
-
-	return factory();
+	return parserRegistry.parserForExtension(fileExtension);
 }
 
 /**
@@ -50,7 +26,7 @@ export function getParser(fileExtension: string): BaseParser | null {
  * @returns true если файл может быть обработан
  */
 export function isSupported(fileExtension: string): boolean {
-	return fileExtension.toLowerCase() in PARSER_MAP;
+	return parserRegistry.isSupported(fileExtension);
 }
 
 /**
@@ -59,5 +35,5 @@ export function isSupported(fileExtension: string): boolean {
  * @returns Массив расширений (с точками)
  */
 export function getSupportedExtensions(): string[] {
-	return Object.keys(PARSER_MAP);
+	return parserRegistry.getSupportedExtensions();
 }
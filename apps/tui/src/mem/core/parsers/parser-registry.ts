@@ -0,0 +1,235 @@
+/**
+ * ParserRegistry - реестр парсеров по расширению файла
+ *
+ * Парсеры регистрируют расширения, которые они обрабатывают. Повторная
+ * регистрация расширения перезаписывает предыдущую (last-wins), так что
+ * сторонний код может подменить встроенный парсер без правки core.
+ *
+ * Препроцессоры регистрируются по расширению отдельно от парсеров и
+ * подключаются к каждому экземпляру парсера, который выдаёт реестр.
+ *
+ * Опции парсеров задаются картой по языку (`{ go: { includeTests: true } }`)
+ * и передаются фабрике языка при каждом создании парсера.
+ */
+
+import { extname } from "node:path";
+import type { BaseParser } from "./base-parser.ts";
+import { assertKnownOptions } from "./base-parser.ts";
+import { CppParser } from "./cpp-parser.ts";
+import type { GoParserOptions } from "./go-parser.ts";
+import { GoParser } from "./go-parser.ts";
+import { JavaParser } from "./java-parser.ts";
+import { JavaScriptParser } from "./javascript-parser.ts";
+import type { Preprocessor } from "./preprocessor.ts";
+import type { PythonParserOptions } from "./python-parser.ts";
+import { PythonParser } from "./python-parser.ts";
+import { RustParser } from "./rust-parser.ts";
+import { TypeScriptAstParser } from "./ts-ast-parser.ts";
+
+/**
+ * Опции парсеров по языку. Без опций каждый парсер работает с умолчаниями:
+ * python — `underscoreIsPrivate: true`, go — тестовые файлы пропускаются,
+ * остальные встроенные парсеры опций не принимают
+ */
+export interface ParserOptionsByLanguage {
+	[language: string]: object | undefined;
+	go?: GoParserOptions;
+	python?: PythonParserOptions;
+}
+
+/**
+ * Фабрика парсера; options — опции языка из ParserOptionsByLanguage.
+ * Неизвестные ключи опций фабрика (конструктор парсера) отклоняет ошибкой
+ */
+export type ParserFactory = (options?: object) => BaseParser;
+
+interface Registration {
+	factory: ParserFactory;
+	language?: string;
+}
+
+export class ParserRegistry {
+	private readonly factories = new Map<string, Registration>();
+	private parserOptions: ParserOptionsByLanguage = {};
+	private readonly preprocessors = new Map<string, Preprocessor>();
+
+	/**
+	 * Зарегистрировать парсер для одного или нескольких расширений
+	 *
+	 * @param extensions - расширения с точкой (".go") или без ("go")
+	 * @param factory - фабрика, создающая экземпляр парсера
+	 * @param language - ключ опций языка в ParserOptionsByLanguage
+	 */
+	register(
+		extensions: string | string[],
+		factory: ParserFactory,
+		language?: string
+	): void {
+		const list = Array.isArray(extensions) ? extensions : [extensions];
+		for (const ext of list) {
+			this.factories.set(normalizeExtension(ext), { factory, language });
+		}
+	}
+
+	/**
+	 * Задать опции парсеров по языку (заменяет предыдущие целиком)
+	 *
+	 * Опции проверяются сразу: неизвестный язык или ключ опции — ошибка,
+	 * а не молчаливый откат к умолчаниям при первом парсинге.
+	 */
+	setParserOptions(options: ParserOptionsByLanguage): void {
+		const languages = new Map<string, ParserFactory>();
+		for (const { factory, language } of this.factories.values()) {
+			if (language) {
+				languages.set(language, factory);
+			}
+		}
+		for (const [language, languageOptions] of Object.entries(options)) {
+			const factory = languages.get(language);
+			if (!factory) {
+				throw new Error(
+					`Unknown parser language: ${language} (registered: ${[...languages.keys()].join(", ")})`
+				);
+			}
+			if (languageOptions !== undefined) {
+				factory(languageOptions);
+			}
+		}
+		this.parserOptions = { ...options };
+	}
+
+	getParserOptions(): ParserOptionsByLanguage {
+		return { ...this.parserOptions };
+	}
+
+	/**
+	 * Зарегистрировать препроцессор для расширения: fn(rawSource) ->
+	 * { source, lineMap } выполняется перед парсингом. Повторная
+	 * регистрация заменяет предыдущий препроцессор
+	 */
+	registerPreprocessor(extension: string, fn: Preprocessor): void {
+		this.preprocessors.set(normalizeExtension(extension), fn);
+	}
+
+	unregisterPreprocessor(extension: string): boolean {
+		return this.preprocessors.delete(normalizeExtension(extension));
+	}
+
+	/**
+	 * Удалить регистрацию расширения
+	 */
+	unregister(extension: string): boolean {
+		return this.factories.delete(normalizeExtension(extension));
+	}
+
+	/**
+	 * Получить парсер для файла или null если расширение не поддерживается
+	 */
+	parserForFile(filePath: string): BaseParser | null {
+		return this.parserForExtension(extname(filePath));
+	}
+
+	/**
+	 * Получить парсер по расширению или null если расширение не поддерживается
+	 */
+	parserForExtension(extension: string): BaseParser | null {
+		if (!extension) {
+			return null;
+		}
+		const normalized = normalizeExtension(extension);
+		const registration = this.factories.get(normalized);
+		if (!registration) {
+			return null;
+		}
+		const { factory, language } = registration;
+		const parser = factory(language ? this.parserOptions[language] : undefined);
+		const preprocessor = this.preprocessors.get(normalized);
+		if (preprocessor) {
+			parser.setPreprocessor(preprocessor);
+		}
+		return parser;
+	}
+
+	/**
+	 * Проверить, поддерживается ли расширение
+	 */
+	isSupported(extension: string): boolean {
+		return this.factories.has(normalizeExtension(extension));
+	}
+
+	/**
+	 * Список всех зарегистрированных расширений (с точками)
+	 */
+	getSupportedExtensions(): string[] {
+		return [...this.factories.keys()];
+	}
+}
+
+function normalizeExtension(extension: string): string {
+	const lower = extension.toLowerCase();
+	return lower.startsWith(".") ? lower : `.${lower}`;
+}
+
+/**
+ * Фабрика парсера без опций: любые переданные ключи — ошибка
+ */
+function withoutOptions(
+	language: string,
+	create: () => BaseParser
+): ParserFactory {
+	return (options) => {
+		assertKnownOptions(language, options ?? {}, []);
+		return create();
+	};
+}
+
+/**
+ * Создать реестр со встроенными парсерами
+ *
+ * @param parserOptions - опции по языку, см. ParserOptionsByLanguage
+ */
+export function createDefaultRegistry(
+	parserOptions?: ParserOptionsByLanguage
+): ParserRegistry {
+	const registry = new ParserRegistry();
+	registry.register(
+		[".ts", ".tsx"],
+		withoutOptions("typescript", () => new TypeScriptAstParser()),
+		"typescript"
+	);
+	registry.register(
+		[".js", ".jsx", ".mjs", ".cjs"],
+		withoutOptions("javascript", () => new JavaScriptParser()),
+		"javascript"
+	);
+	registry.register(
+		[".py", ".pyi"],
+		(options) => new PythonParser(options),
+		"python"
+	);
+	registry.register(".go", (options) => new GoParser(options), "go");
+	registry.register(
+		".rs",
+		withoutOptions("rust", () => new RustParser()),
+		"rust"
+	);
+	registry.register(
+		".java",
+		withoutOptions("java", () => new JavaParser()),
+		"java"
+	);
+	registry.register(
+		[".c", ".h", ".cpp", ".cc", ".cxx", ".hpp", ".hh", ".hxx"],
+		withoutOptions("cpp", () => new CppParser()),
+		"cpp"
+	);
+	if (parserOptions) {
+		registry.setParserOptions(parserOptions);
+	}
+	return registry;
+}
+
+/**
+ * Общий реестр парсеров (сторонний код регистрирует парсеры здесь)
+ */
+export const parserRegistry = createDefaultRegistry();
@@ -0,0 +1,121 @@
+/**
+ * Препроцессоры исходников перед парсингом
+ *
+ * Препроцессор превращает диалект (шаблонные директивы, макросы) в код,
+ * который понимает парсер языка, и сообщает lineMap, чтобы позиции
+ * символов указывали на строки исходного файла.
+ */
+
+import type { ParseResult, SourceRange } from "./types.ts";
+
+export interface PreprocessResult {
+	/**
+	 * Оригинальная строка (1-based) для каждой строки преобразованного
+	 * исходника: lineMap[i] — строка исходника для строки i + 1. Без
+	 * lineMap строки считаются совпадающими
+	 */
+	lineMap?: number[];
+	source: string;
+}
+
+export type Preprocessor = (rawSource: string) => PreprocessResult;
+
+function mapLine(lineMap: number[], line: number): number {
+	return lineMap[line - 1] ?? line;
+}
+
+function mapRange(lineMap: number[], range: SourceRange): SourceRange {
+	return {
+		...range,
+		startLine: mapLine(lineMap, range.startLine),
+		endLine: mapLine(lineMap, range.endLine),
+	};
+}
+
+function mapOptionalRange<T extends { range?: SourceRange }>(
+	lineMap: number[],
+	item: T
+): T {
+	return item.range ? { ...item, range: mapRange(lineMap, item.range) } : item;
+}
+
+/**
+ * Перевести позиции результата парсинга преобразованного исходника
+ * в строки оригинала
+ *
+ * Колонки не меняются: lineMap описывает только строки.
+ */
+export function remapParseResult(
+	result: ParseResult,
+	lineMap: number[] | undefined
+): ParseResult {
+	if (!lineMap) {
+		return result;
+	}
+	const symbols = result.symbols.map((symbol) => {
+		const meta = symbol.metadata;
+		return {
+			...symbol,
+			startLine: mapLine(lineMap, symbol.startLine),
+			endLine: mapLine(lineMap, symbol.endLine),
+			metadata: meta && {
+				...meta,
+				...(meta.range && { range: mapRange(lineMap, meta.range) }),
+				...(meta.nameRange && {
+					nameRange: mapRange(lineMap, meta.nameRange),
+				}),
+				...(meta.fields && {
+					fields: meta.fields.map((field) => ({
+						...mapOptionalRange(lineMap, field),
+						...(field.nameRange && {
+							nameRange: mapRange(lineMap, field.nameRange),
+						}),
+					})),
+				}),
+				...(meta.methods && {
+					methods: meta.methods.map((m) => mapOptionalRange(lineMap, m)),
+				}),
+				...(meta.callRefs && {
+					callRefs: meta.callRefs.map((ref) => ({
+						...ref,
+						line: mapLine(lineMap, ref.line),
+					})),
+				}),
+				...(meta.markers && {
+					markers: meta.markers.map((marker) => ({
+						...marker,
+						line: mapLine(lineMap, marker.line),
+					})),
+				}),
+				...(meta.directives && {
+					directives: meta.directives.map((directive) => ({
+						...directive,
+						line: mapLine(lineMap, directive.line),
+					})),
+				}),
+				...(meta.language?.goDirectives && {
+					language: {
+						...meta.language,
+						goDirectives: meta.language.goDirectives.map((directive) => ({
+							...directive,
+							line: mapLine(lineMap, directive.line),
+						})),
+					},
+				}),
+				...(meta.typeMentions && {
+					typeMentions: meta.typeMentions.map((mention) => ({
+						...mention,
+						range: mapRange(lineMap, mention.range),
+					})),
+				}),
+			},
+		};
+	});
+	return {
+		symbols,
+		diagnostics: result.diagnostics.map((d) => ({
+			...d,
+			range: mapRange(lineMap, d.range),
+		})),
+	};
+}
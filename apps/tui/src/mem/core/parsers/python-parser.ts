@@ -1,6 +1,9 @@
 // @ts-nocheck
 import type Parser from "tree-sitter";
 import Python from "tree-sitter-python";
+import type { ParserCapability } from "./base-parser.ts";
+import { assertKnownOptions } from "./base-parser.ts";
+import { applyDeprecation, pythonDeprecation } from "./deprecation.ts";
 import {
 	BaseNodeExtractor,
 	type NodeExtractor,
@@ -13,16 +16,51 @@ import type {
 	SymbolMetadata,
 } from "./types.ts";
 
+const CONSTANT_NAME_RE = /^_*[A-Z][A-Z0-9_]*$/;
+
+/**
+ * Опции Python парсера
+ */
+export interface PythonParserOptions {
+	/**
+	 * Считать `_name` приватным (а `_method` — protected) по конвенции
+	 * Python. По умолчанию true; false делает такие символы публичными
+	 */
+	underscoreIsPrivate?: boolean;
+}
+
+const PYTHON_OPTION_KEYS: Record<keyof PythonParserOptions, true> = {
+	underscoreIsPrivate: true,
+};
+
 /**
  * Python парсер на основе Tree-sitter
  */
 export class PythonParser extends TreeSitterParser {
+	readonly capabilities: ReadonlySet<ParserCapability> = new Set([
+		"docComments",
+	]);
+
+	private readonly options: PythonParserOptions;
+
+	constructor(options: PythonParserOptions = {}) {
+		super();
+		assertKnownOptions("python", options, Object.keys(PYTHON_OPTION_KEYS));
+		this.options = options;
+	}
+
+	override get optionsKey(): string {
+		return Object.keys(this.options).length > 0
+			? JSON.stringify(this.options)
+			: "";
+	}
+
 	protected getLanguage(): unknown {
 		return Python;
 	}
 
 	protected getNodeExtractor(): NodeExtractor {
-		return new PythonNodeExtractor();
+		return new PythonNodeExtractor(this.options.underscoreIsPrivate ?? true);
 	}
 }
 
@@ -30,6 +68,13 @@ export class PythonParser extends TreeSitterParser {
  * Extractor для Python AST
  */
 class PythonNodeExtractor extends BaseNodeExtractor {
+	private readonly underscoreIsPrivate: boolean;
+
+	constructor(underscoreIsPrivate: boolean) {
+		super();
+		this.underscoreIsPrivate = underscoreIsPrivate;
+	}
+
 	extractSymbols(
 		tree: Parser.Tree,
 		filePath: string,
@@ -37,6 +82,27 @@ class PythonNodeExtractor extends BaseNodeExtractor {
 	): EnhancedCodeSymbol[] {
 		const symbols: EnhancedCodeSymbol[] = [];
 		this.visitNode(tree.rootNode, filePath, source, symbols);
+
+		// Конвенция Python: _name — не часть публичного API модуля
+		for (const symbol of symbols) {
+			const visibility =
+				symbol.metadata?.visibility ??
+				(this.underscoreIsPrivate && symbol.name.startsWith("_")
+					? "private"
+					: "public");
+			symbol.metadata = {
+				...symbol.metadata,
+				visibility,
+				isExported: visibility === "public",
+			};
+			applyDeprecation(
+				symbol,
+				pythonDeprecation(
+					symbol.metadata.decorators,
+					symbol.metadata.docComment
+				)
+			);
+		}
 		return symbols;
 	}
 
@@ -69,6 +135,15 @@ class PythonNodeExtractor extends BaseNodeExtractor {
 				source
 			);
 			symbols.push(...methods);
+
+			// Методы уже обработаны - обходим только остальное (вложенные классы)
+			const bodyNode = this.getChild(node, "body");
+			for (const child of bodyNode?.children ?? []) {
+				if (!this.methodDefinition(child)) {
+					this.visitNode(child, filePath, source, symbols);
+				}
+			}
+			return;
 		}
 
 		// Expression statement на уровне модуля может содержать assignment (константу)
@@ -136,6 +211,7 @@ class PythonNodeExtractor extends BaseNodeExtractor {
 			parameters,
 			returnType,
 			isAsync,
+			docComment: docstring || undefined,
 			decorators: decorators.length > 0 ? decorators : undefined,
 			language: {
 				pythonDecorators: decorators.map((d) => d.name),
@@ -199,6 +275,7 @@ class PythonNodeExtractor extends BaseNodeExtractor {
 			calls: [],
 			imports: baseClasses.length > 0 ? baseClasses : [],
 			metadata: {
+				docComment: docstring || undefined,
 				decorators: decorators.length > 0 ? decorators : undefined,
 				language: {
 					pythonDecorators: decorators.map((d) => d.name),
@@ -225,9 +302,10 @@ class PythonNodeExtractor extends BaseNodeExtractor {
 			return methods;
 		}
 
-		// Найти все function_definition внутри body
-		for (const child of bodyNode.children) {
-			if (child.type === "function_definition") {
+		// Найти все function_definition внутри body (включая декорированные)
+		for (const member of bodyNode.children) {
+			const child = this.methodDefinition(member);
+			if (child) {
 				const nameNode = this.getChild(child, "name");
 				if (!nameNode) {
 					continue;
@@ -261,7 +339,11 @@ class PythonNodeExtractor extends BaseNodeExtractor {
 
 				// Visibility (Python convention: _private, __very_private)
 				let visibility: "public" | "private" | "protected" = "public";
-				if (methodName.startsWith("__") && !methodName.endsWith("__")) {
+				const isDunder =
+					methodName.startsWith("__") && methodName.endsWith("__");
+				if (!this.underscoreIsPrivate || isDunder) {
+					visibility = "public";
+				} else if (methodName.startsWith("__")) {
 					visibility = "private";
 				} else if (methodName.startsWith("_")) {
 					visibility = "protected";
@@ -272,6 +354,8 @@ class PythonNodeExtractor extends BaseNodeExtractor {
 					returnType,
 					isAsync,
 					visibility,
+					receiver: className,
+					docComment: docstring || undefined,
 					decorators: decorators.length > 0 ? decorators : undefined,
 					language: {
 						pythonDecorators: decorators.map((d) => d.name),
@@ -297,6 +381,22 @@ class PythonNodeExtractor extends BaseNodeExtractor {
 		return methods;
 	}
 
+	/**
+	 * function_definition члена класса (с учётом decorated_definition)
+	 */
+	private methodDefinition(
+		member: Parser.SyntaxNode
+	): Parser.SyntaxNode | null {
+		if (member.type === "function_definition") {
+			return member;
+		}
+		if (member.type === "decorated_definition") {
+			const definition = this.getChild(member, "definition");
+			return definition?.type === "function_definition" ? definition : null;
+		}
+		return null;
+	}
+
 	/**
 	 * Извлечь константу (module-level assignment)
 	 */
@@ -315,7 +415,7 @@ class PythonNodeExtractor extends BaseNodeExtractor {
 		const name = this.getText(leftNode);
 
 		// По соглашению Python: UPPER_CASE = константа
-		if (name !== name.toUpperCase()) {
+		if (!CONSTANT_NAME_RE.test(name)) {
 			return null;
 		}
 
@@ -335,7 +435,6 @@ class PythonNodeExtractor extends BaseNodeExtractor {
 			imports: [],
 			metadata: {
 				returnType,
-				isExported: true, // Python exports всё на module level
 			},
 		};
 	}
@@ -1,6 +1,7 @@
 // @ts-nocheck
 import type Parser from "tree-sitter";
 import Rust from "tree-sitter-rust";
+import type { ParserCapability } from "./base-parser.ts";
 import {
 	BaseNodeExtractor,
 	type NodeExtractor,
@@ -17,6 +18,11 @@ import type {
  * Rust парсер на основе Tree-sitter
  */
 export class RustParser extends TreeSitterParser {
+	readonly capabilities: ReadonlySet<ParserCapability> = new Set([
+		"docComments",
+		"generics",
+	]);
+
 	protected getLanguage(): unknown {
 		return Rust;
 	}
@@ -165,8 +171,9 @@ class RustNodeExtractor extends BaseNodeExtractor {
 			returnType,
 			isAsync,
 			visibility,
-			isExported: visibility === "public",
+			isExported: visibility !== "private",
 			genericParams: genericParams.length > 0 ? genericParams : undefined,
+			docComment: docComment || undefined,
 			language: {
 				rustDocComment: docComment,
 			},
@@ -222,8 +229,9 @@ class RustNodeExtractor extends BaseNodeExtractor {
 			imports: [],
 			metadata: {
 				visibility,
-				isExported: visibility === "public",
+				isExported: visibility !== "private",
 				genericParams: genericParams.length > 0 ? genericParams : undefined,
+				docComment: docComment || undefined,
 				language: {
 					rustDocComment: docComment,
 				},
@@ -267,8 +275,9 @@ class RustNodeExtractor extends BaseNodeExtractor {
 			imports: [],
 			metadata: {
 				visibility,
-				isExported: visibility === "public",
+				isExported: visibility !== "private",
 				genericParams: genericParams.length > 0 ? genericParams : undefined,
+				docComment: docComment || undefined,
 				language: {
 					rustDocComment: docComment,
 				},
@@ -312,8 +321,9 @@ class RustNodeExtractor extends BaseNodeExtractor {
 			imports: [],
 			metadata: {
 				visibility,
-				isExported: visibility === "public",
+				isExported: visibility !== "private",
 				genericParams: genericParams.length > 0 ? genericParams : undefined,
+				docComment: docComment || undefined,
 				language: {
 					rustDocComment: docComment,
 					rustTrait: true,
@@ -371,6 +381,8 @@ class RustNodeExtractor extends BaseNodeExtractor {
 		}
 
 		const typeName = this.getText(typeNode);
+		// impl<T> Container<T> -> Container
+		const receiver = typeName.replace(/<.*$/s, "").trim();
 
 		// Найти все function_item в impl block
 		const bodyNode = node.children.find((c) => c.type === "declaration_list");
@@ -422,8 +434,10 @@ class RustNodeExtractor extends BaseNodeExtractor {
 					returnType,
 					isAsync,
 					visibility,
-					isExported: visibility === "public",
+					isExported: visibility !== "private",
 					genericParams: genericParams.length > 0 ? genericParams : undefined,
+					receiver,
+					docComment: docComment || undefined,
 					language: {
 						rustDocComment: docComment,
 						rustTraitImpl: traitName,
@@ -482,7 +496,7 @@ class RustNodeExtractor extends BaseNodeExtractor {
 			imports: [],
 			metadata: {
 				visibility,
-				isExported: visibility === "public",
+				isExported: visibility !== "private",
 				returnType,
 			},
 		};
@@ -522,7 +536,7 @@ class RustNodeExtractor extends BaseNodeExtractor {
 			imports: [],
 			metadata: {
 				visibility,
-				isExported: visibility === "public",
+				isExported: visibility !== "private",
 				returnType,
 				language: {
 					rustStatic: true,
@@ -567,8 +581,9 @@ class RustNodeExtractor extends BaseNodeExtractor {
 			imports: [],
 			metadata: {
 				visibility,
-				isExported: visibility === "public",
+				isExported: visibility !== "private",
 				genericParams: genericParams.length > 0 ? genericParams : undefined,
+				docComment: docComment || undefined,
 				language: {
 					rustDocComment: docComment,
 				},
@@ -655,7 +670,10 @@ class RustNodeExtractor extends BaseNodeExtractor {
 				const boundsNode = child.children.find(
 					(c) => c.type === "trait_bounds"
 				);
-				const constraint = boundsNode ? this.getText(boundsNode) : undefined;
+				// ": Clone + Send" -> "Clone + Send"
+				const constraint = boundsNode
+					? this.getText(boundsNode).replace(/^:\s*/, "")
+					: undefined;
 
 				generics.push({ name, constraint });
 			} else if (child.type === "lifetime") {
@@ -718,7 +736,7 @@ class RustNodeExtractor extends BaseNodeExtractor {
 	}
 
 	/**
-	 * Извлечь doc comment (///)
+	 * Извлечь doc comment (/// и //!)
 	 */
 	private extractDocComment(node: Parser.SyntaxNode, source: string): string {
 		// В Rust doc comments могут быть атрибутами (#[doc = ...]) или line comments (///)
@@ -757,8 +775,8 @@ class RustNodeExtractor extends BaseNodeExtractor {
 				continue;
 			}
 
-			if (line.startsWith("///")) {
-				comments.unshift(line.replace(/^\/\/\/\s?/, ""));
+			if (line.startsWith("///") || line.startsWith("//!")) {
+				comments.unshift(line.replace(/^\/\/[/!]\s?/, ""));
 			} else if (!line.startsWith("//")) {
 				// Не комментарий - останавливаемся
 				break;
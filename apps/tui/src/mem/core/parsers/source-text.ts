@@ -0,0 +1,51 @@
+/**
+ * Чтение и нормализация исходников перед парсингом
+ *
+ * Парсеры получают текст без BOM и с переводами строк `\n`: файл,
+ * сохранённый в Windows (BOM + CRLF), даёт те же символы, тела и
+ * позиции, что и его LF-вариант. Строки позиций 1-based, колонки —
+ * UTF-16 code units нормализованного текста (см. SourceRange): `\r`
+ * стоит только в конце строки, а BOM, как и в редакторах, колонкой
+ * не считается, поэтому колонки совпадают с оригиналом.
+ * Одиночный `\r` переводом строки не считается, как и в Go.
+ */
+
+const BOM = "\uFEFF";
+
+/**
+ * Убрать ведущий BOM и привести CRLF к LF
+ */
+export function normalizeSource(source: string): string {
+	const text = source.startsWith(BOM) ? source.slice(BOM.length) : source;
+	return text.includes("\r\n") ? text.replace(/\r\n/g, "\n") : text;
+}
+
+/**
+ * Декодировать содержимое файла: UTF-16 LE/BE по BOM, иначе UTF-8
+ *
+ * Невалидные UTF-8 последовательности (например, Latin-1) заменяются
+ * на U+FFFD, а не прерывают парсинг.
+ */
+export function decodeSource(bytes: Uint8Array): string {
+	if (bytes[0] === 0xff && bytes[1] === 0xfe) {
+		return normalizeSource(new TextDecoder("utf-16le").decode(bytes));
+	}
+	if (bytes[0] === 0xfe && bytes[1] === 0xff) {
+		// UTF-16 BE декодируется как LE с переставленными байтами пар
+		const swapped = new Uint8Array(bytes.length - (bytes.length % 2));
+		for (let i = 0; i < swapped.length; i += 2) {
+			swapped[i] = bytes[i + 1] as number;
+			swapped[i + 1] = bytes[i] as number;
+		}
+		return normalizeSource(new TextDecoder("utf-16le").decode(swapped));
+	}
+	return normalizeSource(new TextDecoder("utf-8").decode(bytes));
+}
+
+/**
+ * Прочитать исходник с диска в нормализованном виде
+ */
+export async function readSource(filePath: string): Promise<string> {
+	const buffer = await Bun.file(filePath).arrayBuffer();
+	return decodeSource(new Uint8Array(buffer));
+}
@@ -1,14 +1,110 @@
 import Parser from "tree-sitter";
 import { createLogger } from "../../lib/logger.ts";
 import { BaseParser } from "./base-parser.ts";
-import type { EnhancedCodeSymbol } from "./types.ts";
+import { readSource } from "./source-text.ts";
+import type {
+	EnhancedCodeSymbol,
+	ParseDiagnostic,
+	ParseResult,
+	SourceRange,
+} from "./types.ts";
 
 const log = createLogger("tree-sitter");
 
+// Размер фрагмента, которым исходник отдаётся Tree-sitter
+const INPUT_CHUNK_SIZE = 16 * 1024;
+/** Глубина вложенности узлов объявления, после которой оно пропускается */
+export const DEFAULT_MAX_DEPTH = 1024;
+
+/**
+ * Распарсить исходник, отдавая его парсеру фрагментами
+ *
+ * Строка целиком упирается в лимит буфера биндинга на больших файлах,
+ * callback-вход такого ограничения не имеет.
+ */
+export function parseTree(parser: Parser, source: string): Parser.Tree {
+	return parser.parse((index: number) =>
+		index < source.length
+			? source.slice(index, index + INPUT_CHUNK_SIZE)
+			: null
+	);
+}
+
+/**
+ * Вложенность поддерева глубже limit (обход курсором, без рекурсии)
+ */
+function exceedsDepth(node: Parser.SyntaxNode, limit: number): boolean {
+	const cursor = node.walk();
+	let depth = 0;
+	for (;;) {
+		if (cursor.gotoFirstChild()) {
+			depth++;
+			if (depth > limit) {
+				return true;
+			}
+			continue;
+		}
+		while (!cursor.gotoNextSibling()) {
+			if (!cursor.gotoParent()) {
+				return false;
+			}
+			depth--;
+		}
+	}
+}
+
+/**
+ * Заменить строки узлов пустыми: позиции остальных объявлений не сдвигаются
+ */
+function blankNodes(source: string, nodes: Parser.SyntaxNode[]): string {
+	const lines = source.split("\n");
+	for (const node of nodes) {
+		for (let row = node.startPosition.row; row <= node.endPosition.row; row++) {
+			lines[row] = "";
+		}
+	}
+	return lines.join("\n");
+}
+
+/**
+ * Распарсить исходник, вырезав объявления верхнего уровня глубже maxDepth
+ *
+ * Рекурсивные обходы extractor'ов не переживают патологическую
+ * вложенность (скобки, generics): такие объявления заменяются пустыми
+ * строками, исходник парсится заново, а на каждое пропущенное
+ * объявление выдаётся warning.
+ */
+export function parseWithDepthLimit(
+	parser: Parser,
+	source: string,
+	maxDepth: number
+): { diagnostics: ParseDiagnostic[]; source: string; tree: Parser.Tree } {
+	const tree = parseTree(parser, source);
+	const tooDeep = tree.rootNode.children.filter((node) =>
+		exceedsDepth(node, maxDepth)
+	);
+	if (tooDeep.length === 0) {
+		return { diagnostics: [], source, tree };
+	}
+
+	const blanked = blankNodes(source, tooDeep);
+	return {
+		diagnostics: tooDeep.map((node) => ({
+			message: `Nesting exceeds maxDepth ${maxDepth}: declaration skipped`,
+			range: nodeRange(node),
+			severity: "warning",
+		})),
+		source: blanked,
+		tree: parseTree(parser, blanked),
+	};
+}
+
 /**
  * Интерфейс для извлечения символов из AST узлов
  */
 export interface NodeExtractor {
+	/** Ошибки извлечения отдельных узлов (узел пропущен, обход продолжен) */
+	readonly diagnostics?: ParseDiagnostic[];
 	extractSymbols(
 		tree: Parser.Tree,
 		filePath: string,
@@ -20,6 +116,16 @@ export interface NodeExtractor {
  * Абстрактный парсер на основе Tree-sitter
  */
 export abstract class TreeSitterParser extends BaseParser {
+	protected maxDepth = DEFAULT_MAX_DEPTH;
+
+	/**
+	 * Ограничить глубину вложенности: объявления верхнего уровня глубже
+	 * depth пропускаются с диагностикой, остальные извлекаются как обычно
+	 */
+	setMaxDepth(depth: number): void {
+		this.maxDepth = depth;
+	}
+
 	/**
 	 * Получить грамматику языка для Tree-sitter
 	 */
@@ -34,45 +140,149 @@ export abstract class TreeSitterParser extends BaseParser {
 	 * Парсинг файла с помощью Tree-sitter
 	 */
 	protected async doParse(filePath: string): Promise<EnhancedCodeSymbol[]> {
+		// Читаем исходный код (без BOM, с LF)
+		const sourceCode = await readSource(filePath);
+		return this.doParseSource(filePath, sourceCode);
+	}
+
+	/**
+	 * Парсинг исходного кода с помощью Tree-sitter
+	 */
+	protected async doParseSource(
+		filePath: string,
+		sourceCode: string
+	): Promise<EnhancedCodeSymbol[]> {
+		const result = await this.doParseSourceWithDiagnostics(
+			filePath,
+			sourceCode
+		);
+		return result.symbols;
+	}
+
+	protected async doParseSourceWithDiagnostics(
+		filePath: string,
+		sourceCode: string
+	): Promise<ParseResult> {
 		const parser = new Parser();
 		const language = this.getLanguage();
 
 		parser.setLanguage(language);
 
-		// Читаем исходный код
-		const sourceCode = await Bun.file(filePath).text();
-
 		// Парсим с помощью Tree-sitter
-		const tree = parser.parse(sourceCode);
-
-		if (tree.rootNode.hasError) {
-			log.warn("Parse tree contains errors", { file: filePath });
-			// Продолжаем даже с ошибками - частичный парсинг лучше чем ничего
-		}
+		const {
+			tree,
+			source,
+			diagnostics: depthDiagnostics,
+		} = parseWithDepthLimit(parser, sourceCode, this.maxDepth);
 
 		// Извлекаем символы с помощью extractor'а
 		const extractor = this.getNodeExtractor();
-		const symbols = extractor.extractSymbols(tree, filePath, sourceCode);
+		const symbols = extractor.extractSymbols(tree, filePath, source);
+
+		// Продолжаем даже с ошибками - частичный парсинг лучше чем ничего
+		const diagnostics = [
+			...depthDiagnostics,
+			...collectSyntaxDiagnostics(tree.rootNode),
+			...(extractor.diagnostics ?? []),
+		];
+		if (diagnostics.length > 0) {
+			log.warn("Parse tree contains errors", {
+				file: filePath,
+				count: diagnostics.length,
+			});
+		}
 
 		log.debug("Extracted symbols", {
 			file: filePath,
 			count: symbols.length,
 		});
 
-		return symbols;
+		return { symbols, diagnostics };
 	}
 }
 
+function nodeRange(node: Parser.SyntaxNode): SourceRange {
+	return {
+		startLine: node.startPosition.row + 1,
+		startCol: node.startPosition.column,
+		endLine: node.endPosition.row + 1,
+		endCol: node.endPosition.column,
+	};
+}
+
+/**
+ * Диагностики по ERROR и MISSING узлам дерева
+ *
+ * Вложенные ERROR узлы не дублируются: сообщается только самый внешний.
+ */
+export function collectSyntaxDiagnostics(
+	root: Parser.SyntaxNode
+): ParseDiagnostic[] {
+	const diagnostics: ParseDiagnostic[] = [];
+
+	const visit = (node: Parser.SyntaxNode): void => {
+		if (node.type === "ERROR") {
+			diagnostics.push({
+				message: "Syntax error",
+				range: nodeRange(node),
+				severity: "error",
+			});
+			return;
+		}
+		if (node.isMissing) {
+			diagnostics.push({
+				message: `Missing ${node.type}`,
+				range: nodeRange(node),
+				severity: "error",
+			});
+			return;
+		}
+		for (const child of node.children) {
+			if (child.hasError || child.isMissing) {
+				visit(child);
+			}
+		}
+	};
+
+	if (root.hasError) {
+		visit(root);
+	}
+	return diagnostics;
+}
+
 /**
  * Базовый класс для NodeExtractor с общими утилитами
  */
 export abstract class BaseNodeExtractor implements NodeExtractor {
+	readonly diagnostics: ParseDiagnostic[] = [];
+
 	abstract extractSymbols(
 		tree: Parser.Tree,
 		filePath: string,
 		source: string
 	): EnhancedCodeSymbol[];
 
+	/**
+	 * Выполнить извлечение узла; исключение превращается в диагностику,
+	 * чтобы один неразобранный узел не ронял весь файл
+	 */
+	protected tryExtract<T>(
+		node: Parser.SyntaxNode,
+		fallback: T,
+		extract: () => T
+	): T {
+		try {
+			return extract();
+		} catch (err) {
+			this.diagnostics.push({
+				message: `Failed to extract ${node.type}: ${String(err)}`,
+				range: nodeRange(node),
+				severity: "warning",
+			});
+			return fallback;
+		}
+	}
+
 	/**
 	 * Получить текст узла
 	 */
@@ -133,6 +343,21 @@ export abstract class BaseNodeExtractor implements NodeExtractor {
 		return position.row + 1; // Tree-sitter использует 0-based, нам нужен 1-based
 	}
 
+	/**
+	 * Получить диапазон узла (строки 1-based, колонки 0-based)
+	 */
+	protected getRange(
+		node: Parser.SyntaxNode,
+		startNode: Parser.SyntaxNode = node
+	): SourceRange {
+		return {
+			startLine: this.getLineNumber(startNode.startPosition),
+			startCol: startNode.startPosition.column,
+			endLine: this.getLineNumber(node.endPosition),
+			endCol: node.endPosition.column,
+		};
+	}
+
 	/**
 	 * Обрезать текст до максимальной длины
 	 */
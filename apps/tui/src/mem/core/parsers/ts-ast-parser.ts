@@ -1,7 +1,9 @@
 // @ts-nocheck
-import { readFileSync } from "node:fs";
 import ts from "typescript";
+import type { ParserCapability } from "./base-parser.ts";
 import { BaseParser } from "./base-parser.ts";
+import { applyDeprecation, docTagDeprecation } from "./deprecation.ts";
+import { readSource } from "./source-text.ts";
 import type {
 	Decorator,
 	EnhancedCodeSymbol,
@@ -19,10 +21,25 @@ const HOOK_RE = /^use[A-Z]/;
 
 /**
  * Улучшенный TypeScript/JavaScript парсер с расширенными метаданными
+ *
+ * Обрабатывает .ts и .tsx: функции, классы (с методами), интерфейсы, типы,
+ * enum и top-level const/let, помечая экспортируемые символы.
  */
 export class TypeScriptAstParser extends BaseParser {
+	readonly capabilities: ReadonlySet<ParserCapability> = new Set([
+		"docComments",
+		"generics",
+	]);
+
 	protected async doParse(filePath: string): Promise<EnhancedCodeSymbol[]> {
-		const content = readFileSync(filePath, "utf-8");
+		const content = await readSource(filePath);
+		return this.doParseSource(filePath, content);
+	}
+
+	protected async doParseSource(
+		filePath: string,
+		content: string
+	): Promise<EnhancedCodeSymbol[]> {
 		const sourceFile = ts.createSourceFile(
 			filePath,
 			content,
@@ -78,6 +95,19 @@ export class TypeScriptAstParser extends BaseParser {
 			}
 		}
 
+		// Top-level: export => public; методы: модификатор, по умолчанию public
+		for (const symbol of symbols) {
+			const metadata = symbol.metadata ?? {};
+			if (symbol.symbolType === "method") {
+				metadata.visibility ??= "public";
+				metadata.isExported = metadata.visibility === "public";
+			} else {
+				metadata.visibility = metadata.isExported ? "public" : "private";
+			}
+			symbol.metadata = metadata;
+			applyDeprecation(symbol, docTagDeprecation(symbol.jsDoc));
+		}
+
 		return symbols;
 	}
 
@@ -468,6 +498,11 @@ export class TypeScriptAstParser extends BaseParser {
 			}
 		}
 
+		metadata.isExported = this.hasExportModifier(stmt);
+		if (jsDoc) {
+			metadata.docComment = jsDoc;
+		}
+
 		return {
 			name,
 			symbolType,
@@ -483,7 +518,19 @@ export class TypeScriptAstParser extends BaseParser {
 	}
 
 	/**
-	 * Извлечь константу (export const)
+	 * Проверить наличие export modifier
+	 */
+	private hasExportModifier(node: ts.Node): boolean {
+		const modifiers = ts.canHaveModifiers(node)
+			? ts.getModifiers(node)
+			: undefined;
+		return (
+			modifiers?.some((m) => m.kind === ts.SyntaxKind.ExportKeyword) ?? false
+		);
+	}
+
+	/**
+	 * Извлечь top-level const/let (экспортируемые и нет)
 	 */
 	private extractConstant(
 		stmt: ts.VariableStatement,
@@ -495,27 +542,11 @@ export class TypeScriptAstParser extends BaseParser {
 			return null;
 		}
 
-		// Проверить наличие export modifier
-		const modifiers = ts.canHaveModifiers(stmt)
-			? ts.getModifiers(stmt)
-			: undefined;
-		const hasExport = modifiers?.some(
-			(m) => m.kind === ts.SyntaxKind.ExportKeyword
-		);
-
-		// Проверить const (не let/var)
+		// const -> constant, let/var -> variable
 		const isConst = stmt.declarationList.flags & ts.NodeFlags.Const;
 
-		if (!(hasExport && isConst)) {
-			return null;
-		}
-
-		// Пропустить функции (они обрабатываются отдельно)
-		if (
-			decl.initializer &&
-			(ts.isArrowFunction(decl.initializer) ||
-				ts.isFunctionExpression(decl.initializer))
-		) {
+		// Пропустить функции и компоненты (они обрабатываются отдельно)
+		if (this.classifyVariableStatement(stmt, sourceFile)) {
 			return null;
 		}
 
@@ -531,7 +562,7 @@ export class TypeScriptAstParser extends BaseParser {
 
 		return {
 			name,
-			symbolType: "constant",
+			symbolType: isConst ? "constant" : "variable",
 			path: sourceFile.fileName,
 			startLine: start.line + 1,
 			endLine: end.line + 1,
@@ -540,8 +571,9 @@ export class TypeScriptAstParser extends BaseParser {
 			calls,
 			imports,
 			metadata: {
-				isExported: true,
+				isExported: this.hasExportModifier(stmt),
 				returnType: decl.type?.getText(sourceFile),
+				docComment: jsDoc || undefined,
 			},
 		};
 	}
@@ -597,12 +629,10 @@ export class TypeScriptAstParser extends BaseParser {
 			hookDependencies,
 		};
 
-		const modifiers = ts.canHaveModifiers(stmt)
-			? ts.getModifiers(stmt)
-			: undefined;
-		metadata.isExported = modifiers?.some(
-			(m) => m.kind === ts.SyntaxKind.ExportKeyword
-		);
+		metadata.isExported = this.hasExportModifier(stmt);
+		if (jsDoc) {
+			metadata.docComment = jsDoc;
+		}
 
 		return {
 			name,
@@ -646,6 +676,10 @@ export class TypeScriptAstParser extends BaseParser {
 			const imports = this.resolveImports(calls, body, importMap);
 
 			const metadata = this.extractFunctionMetadata(member, sourceFile);
+			metadata.receiver = className;
+			if (jsDoc) {
+				metadata.docComment = jsDoc;
+			}
 
 			methods.push({
 				name: fullName,
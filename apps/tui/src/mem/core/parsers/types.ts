@@ -3,16 +3,157 @@ import type {
 	CodeSymbol as BaseCodeSymbol,
 } from "../code-chunker.ts";
 
+/**
+ * Размер и сложность функции
+ *
+ * complexity — цикломатическая сложность по McCabe в приближении: 1 плюс
+ * число ветвлений (`if`, `for`, `case` в switch/select, `&&`, `||`).
+ * `default` и `else` не считаются. Не заполняется, если тела функций
+ * не анализировались (файл больше bodyAnalysisLimit).
+ */
+export interface SymbolMetrics {
+	complexity?: number;
+	loc: number; // строк объявления, включая сигнатуру и закрывающую скобку
+	parameterCount: number;
+}
+
 /**
  * Расширенная информация о параметре функции
  */
 export interface FunctionParameter {
 	defaultValue?: string;
 	isOptional?: boolean;
-	name: string;
+	name: string; // "" для безымянных параметров (Go)
+	type?: string | null; // null — тип не указан (JavaScript без JSDoc)
+	variadic?: boolean; // ...T (Go), *args (Python), ...rest (TS)
+}
+
+/**
+ * Возвращаемое значение функции (Go): `(n int, err error)` — именованные,
+ * `(string, error)` и `error` — безымянные
+ */
+export interface FunctionResult {
+	name: string | null; // null для безымянного результата
 	type?: string;
 }
 
+/**
+ * Позиция в исходнике: строки 1-based, колонки 0-based в UTF-16 code units
+ * (как индексы строк JS, а не байты UTF-8); BOM и `\r` перед переводом
+ * строки не учитываются (см. source-text.ts)
+ */
+export interface SourceRange {
+	endCol: number;
+	endLine: number;
+	startCol: number;
+	startLine: number;
+}
+
+/**
+ * Поле структуры (Go struct, Rust struct, поля класса)
+ */
+export interface StructField {
+	embedded?: boolean; // встроенное поле без имени: io.Reader
+	exported: boolean;
+	name?: string;
+	nameRange?: SourceRange; // имя поля (для встроенного — тип)
+	promotedFrom?: string; // fieldsFor: тип встроенной структуры, где объявлено поле
+	range?: SourceRange; // вся декларация поля, общая для `X, Y int`
+	tag?: string; // сырой tag как в исходнике: `json:"id"`
+	trailingComment?: string; // Go: `Age int // years` (опция trailingComments)
+	type: string;
+}
+
+/**
+ * Элемент method set интерфейса: объявленный метод или встроенный интерфейс
+ */
+export interface InterfaceMethod {
+	embedded?: boolean; // встроенный интерфейс (io.Reader): name — тип, без сигнатуры
+	name: string;
+	parameters?: FunctionParameter[];
+	range?: SourceRange;
+	returnType?: string;
+	returns?: FunctionResult[];
+}
+
+/**
+ * Build constraints Go-файла: `//go:build` выражение и суффиксы имени
+ */
+export interface BuildConstraints {
+	expression?: string; // linux && (amd64 || arm64); `// +build` приводится к нему
+	goarch?: string; // из имени файла: foo_amd64.go
+	goos?: string; // из имени файла: foo_linux.go
+}
+
+/**
+ * Директива инструмента в комментарии Go: `//go:generate stringer -type=Color`
+ */
+export interface GoDirective {
+	args: string[]; // stringer, -type=Color; аргументы в кавычках без кавычек
+	line: number;
+	name: string; // go:generate, go:embed, nolint, export, line, +build
+}
+
+/**
+ * Вид импорта
+ *
+ * - normal — `import "context"`, пакет виден под именем по умолчанию
+ * - aliased — `import ctx "context"`
+ * - dot — `import . "fmt"`, имена пакета видны без квалификатора
+ * - blank — `import _ "embed"`, только ради side effects; имён не вводит
+ */
+export type GoImportKind = "normal" | "dot" | "blank" | "aliased";
+
+/**
+ * Импорт файла: `import ctx "context"` ->
+ * { alias: "ctx", kind: "aliased", name: "ctx", path: "context" }
+ *
+ * name — локальное имя, под которым пакет виден в файле; для dot (`.`)
+ * и blank (`_`) импортов совпадает с alias.
+ */
+export interface GoImport {
+	alias?: string;
+	kind: GoImportKind;
+	name: string;
+	path: string;
+}
+
+/**
+ * Вызов внутри тела функции: `helper()`, `u.Save()`, `fmt.Sprintf()`
+ */
+export interface CallReference {
+	importPath?: string; // путь импорта, если qualifier — пакет из импортов файла
+	line: number;
+	name: string;
+	qualifier?: string; // операнд селектора: переменная, пакет или тип
+}
+
+/**
+ * Ссылка на тип из сигнатуры или поля: `context.Context`, `User`, `int`
+ */
+export interface TypeReference {
+	builtin?: boolean;
+	importPath?: string; // путь импорта, если qualifier совпал с импортом файла
+	name: string;
+	package?: string; // пакет, где объявлен тип (текущий для неквалифицированных)
+	qualifier?: string; // как написано в коде: ctx в ctx.Context
+	typeArgumentOf?: string; // List для User в List[User]
+}
+
+/**
+ * Упоминание типа в объявлении символа с позицией: `*User` в сигнатуре,
+ * `User{}` в теле
+ */
+export interface TypeMention {
+	dotImports?: string[]; // dot-импорты файла, откуда может прийти имя
+	importPath?: string; // путь импорта, если qualifier совпал с импортом файла
+	inBody?: boolean; // внутри тела функции
+	name: string;
+	qualifier?: string; // models в models.User
+	range: SourceRange;
+	typeArgumentOf?: string; // List для User в List[User]
+}
+
 /**
  * Информация о generic параметре
  */
@@ -45,8 +186,11 @@ export interface LanguageSpecificMetadata {
 	goDocComment?: string;
 
 	// Go
+	goDirectives?: GoDirective[]; // package clause: все директивы файла
+	goImports?: GoImport[]; // package clause: импорты файла
 	goReceiver?: string;
 	goReceiverPointer?: boolean;
+	goVarType?: string; // объявленный тип var с function literal: HandlerFunc
 	// Python
 	pythonDecorators?: string[];
 	pythonDocstring?: string;
@@ -60,31 +204,105 @@ export interface LanguageSpecificMetadata {
 	rustTraits?: string[];
 }
 
+/**
+ * Вид объявления типа
+ *
+ * alias - `type A = B`, named - `type A B` с не-struct/interface/func базой
+ */
+export type TypeKind = "alias" | "struct" | "interface" | "func" | "named";
+
+/**
+ * Маркер тех. долга в комментарии: `// TODO(alice): retry on timeout`
+ */
+export interface CodeMarker {
+	kind: string; // TODO, FIXME, HACK, XXX
+	line: number;
+	text: string; // текст после маркера: retry on timeout
+}
+
+/**
+ * Вид тестовой функции Go: TestXxx(*testing.T), BenchmarkXxx(*testing.B),
+ * ExampleXxx(), FuzzXxx(*testing.F)
+ */
+export type TestKind = "test" | "benchmark" | "example" | "fuzz";
+
 /**
  * Расширенные метаданные для символа кода
  */
 export interface SymbolMetadata {
+	backingKind?: "variable"; // Go: функция — значение package var (var F = func...)
+	// Go: constraints файла, в котором объявлен символ
+	buildConstraints?: BuildConstraints;
+
+	// Body-level calls (для call graph)
+	callRefs?: CallReference[];
+
 	// Decorators/Annotations
 	decorators?: Decorator[];
 
+	// Deprecation, нормализованная по конвенциям языков (см. deprecation.ts)
+	deprecated?: boolean;
+	deprecationMessage?: string; // текст после маркера
+	directives?: GoDirective[]; // Go: директивы над объявлением и в конце его строки
+
+	// Documentation
+	docComment?: string;
+
+	// Go: iota-перечисление, к которому относится константа
+	enumGroup?: string;
+	enumType?: string; // Go: именованный тип константы перечисления (Color)
+
+	// Struct fields
+	fields?: StructField[];
+
 	// Generic/Template parameters
 	genericParams?: GenericParameter[];
+	groupDocComment?: string; // комментарий группы (const/var/type блоки)
 	isAsync?: boolean;
+	isConstraint?: boolean; // Go: интерфейс-ограничение (union типов, не method set)
 	isExported?: boolean;
 	isGenerator?: boolean;
+	isTest?: boolean; // символ из тестового файла (_test.go)
 
 	// Language-specific
 	language?: LanguageSpecificMetadata;
+	markers?: CodeMarker[]; // TODO/FIXME в doc comment и теле
+	methods?: InterfaceMethod[]; // Go: методы интерфейса в порядке объявления
+	metrics?: SymbolMetrics; // функции и методы Go
 	modifiers?: string[]; // static, abstract, readonly, etc.
+	// Position
+	nameRange?: SourceRange; // только идентификатор
+	packageName?: string; // Go: имя из package clause
+	parent?: string; // объемлющая функция локального символа; Java: внешний тип
+	preprocessorCondition?: string; // C/C++: условие ветки #if/#ifdef вокруг объявления
+	promotedFrom?: string; // methodSetsFor: метод встроенной структуры этого типа
 	// Function/Method metadata
 	parameters?: FunctionParameter[];
+	range?: SourceRange; // всё объявление (без doc comment по умолчанию)
 
 	// React-specific
 	react?: ReactMetadata;
+
+	// Methods: базовое имя типа-владельца (Go receiver, класс, impl)
+	receiver?: string;
+	receiverIsPointer?: boolean;
+	receiverName?: string; // имя переменной receiver'а: u в (u *User)
 	returnType?: string;
+	returns?: FunctionResult[]; // структурированные возвращаемые значения
+	signature?: string; // Go: каноническая сигнатура в одну строку из parameters/returns
+	testKind?: TestKind; // только для функций тестового файла
+	trailingComment?: string; // Go: комментарий в конце строки spec'а (опция trailingComments)
+
+	// Type declarations
+	typeKind?: TypeKind;
+	typeMentions?: TypeMention[]; // все упоминания типов с позициями (Go)
+	typeRefs?: TypeReference[]; // типы из сигнатуры и полей с разрешёнными импортами
+	underlying?: string; // выражение базового типа: map[string]interface{}
+	unionTypes?: string[]; // члены union в constraint интерфейсе: int | int64
+	value?: number | null; // вычисленное значение константы (null — не вычисляется)
 
 	// Visibility & modifiers
-	visibility?: "public" | "private" | "protected" | "internal";
+	visibility?: "public" | "private" | "protected" | "internal" | "package"; // package — Java package-private
 }
 
 /**
@@ -94,6 +312,23 @@ export interface EnhancedCodeSymbol extends BaseCodeSymbol {
 	metadata?: SymbolMetadata;
 }
 
+/**
+ * Диагностика парсинга: синтаксическая ошибка или пропущенный токен
+ */
+export interface ParseDiagnostic {
+	message: string;
+	range: SourceRange;
+	severity: "error" | "warning";
+}
+
+/**
+ * Результат парсинга вместе с диагностиками (символы могут быть частичными)
+ */
+export interface ParseResult {
+	diagnostics: ParseDiagnostic[];
+	symbols: EnhancedCodeSymbol[];
+}
+
 /**
  * Расширенный тип chunk с метаданными
  */
@@ -114,4 +349,5 @@ export type SymbolType =
 	| "component"
 	| "constant"
 	| "variable"
-	| "hook";
+	| "hook"
+	| "package";
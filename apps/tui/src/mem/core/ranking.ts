@@ -95,7 +95,7 @@ function computeExactMatchScore(symbolName: string, query: string): number {
 	}
 
 	// Camel case match (напр. "getData" matches "gd")
-	const initials = symbolName.replace(/[a-z]/g, "").toLowerCase();
+	const initials = symbolName.replace(/\p{Ll}/gu, "").toLowerCase();
 	if (initials === lowerQuery) {
 		return 0.6;
 	}
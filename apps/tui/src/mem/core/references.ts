@@ -0,0 +1,387 @@
+import { basename, dirname } from "node:path";
+import type {
+	CallEdge,
+	CallGraph,
+	CallGraphBuilder,
+} from "./call-graph.ts";
+import { packageKey } from "./call-graph.ts";
+import type {
+	EnhancedCodeSymbol,
+	SourceRange,
+	TypeMention,
+} from "./parsers/types.ts";
+import { symbolId } from "./symbol-id.ts";
+
+const TYPE_SYMBOL_TYPES = new Set(["class", "interface", "type"]);
+
+/**
+ * Упоминание символа в другом символе
+ *
+ * - type — тип в сигнатуре, receiver, поле, встроенный тип или тело
+ * - call — вызов функции или метода в теле (по call graph)
+ */
+export interface SymbolReference {
+	inBody: boolean;
+	kind: "call" | "type";
+	range: SourceRange;
+	/** Символ, в котором находится упоминание */
+	symbol: EnhancedCodeSymbol;
+	/** Упоминание — аргумент инстанциации generic типа (`List[User]`) */
+	typeArgumentOf?: string;
+}
+
+/**
+ * Обратный индекс "кто ссылается на символ"
+ */
+export class ReferenceIndex {
+	private readonly byTarget = new Map<string, SymbolReference[]>();
+
+	add(targetId: string, reference: SymbolReference): void {
+		const list = this.byTarget.get(targetId);
+		if (list) {
+			list.push(reference);
+		} else {
+			this.byTarget.set(targetId, [reference]);
+		}
+	}
+
+	/**
+	 * Ссылки на символ: сначала упоминания типов, затем вызовы
+	 */
+	referencesTo(id: string): SymbolReference[] {
+		return this.byTarget.get(id) ?? [];
+	}
+}
+
+/**
+ * Построить индекс ссылок по typeMentions символов и рёбрам call graph
+ *
+ * Неквалифицированный тип разрешается в тип того же пакета, `pkg.Type` —
+ * в тип пакета, директория которого совпадает с последним сегментом
+ * пути импорта. Если кандидатов нет или их несколько (например, варианты
+ * под разные build constraints), упоминание пропускается.
+ *
+ * Неквалифицированное имя без типа в своём пакете ищется (best-effort)
+ * в пакетах dot-импортов файла. blank-импорты целью не бывают.
+ *
+ * Упоминания внешних пакетов, которых нет среди символов, индексируются
+ * по ключу `<import path>.<name>` (`context.Context`), так что разные
+ * алиасы одного импорта попадают в одну группу.
+ */
+export function buildReferenceIndex(
+	symbols: EnhancedCodeSymbol[],
+	graph: CallGraph
+): ReferenceIndex {
+	// Имя типа -> объявления во всех пакетах (без локальных типов)
+	const typesByName = new Map<string, EnhancedCodeSymbol[]>();
+	for (const symbol of declaredTypes(symbols)) {
+		pushTo(typesByName, symbol.name, symbol);
+	}
+
+	const index = new ReferenceIndex();
+	const byId = new Map<string, EnhancedCodeSymbol>();
+	for (const symbol of symbols) {
+		byId.set(symbol.id ?? symbolId(symbol), symbol);
+	}
+	for (const [key, reference] of typeReferences(symbols, typesByName)) {
+		index.add(key, reference);
+	}
+	for (const [key, reference] of callReferences(byId, graph.edges)) {
+		index.add(key, reference);
+	}
+	return index;
+}
+
+/**
+ * Индекс ссылок, который поддерживается по файлам
+ *
+ * Упоминание типа зависит только от объявлений типов с тем же именем,
+ * поэтому после правки файла заново разрешаются упоминания из него и из
+ * файлов, которые упоминают имена его типов (старых и новых): ссылки на
+ * удалённый тип пропадают или становятся внешними. Ссылки-вызовы
+ * пересчитываются для файлов, рёбра которых пересчитал CallGraphBuilder.
+ * Результат совпадает с buildReferenceIndex, включая порядок ссылок.
+ */
+export class ReferenceIndexBuilder {
+	private readonly typesByName = new Map<string, EnhancedCodeSymbol[]>();
+	private readonly typesByFile = new Map<string, EnhancedCodeSymbol[]>();
+	private readonly mentionsByFile = new Map<string, Set<string>>();
+	private readonly filesByMention = new Map<string, Set<string>>();
+	private readonly typeRefs = new Map<string, TargetedReference[]>();
+	private readonly callRefs = new Map<string, TargetedReference[]>();
+
+	/**
+	 * Обновить ссылки после изменения файлов
+	 *
+	 * @param files - символы всех файлов в порядке индекса
+	 * @param calls - рёбра по файлам, уже обновлённые для этих изменений
+	 * @param changed - файлы с изменёнными символами (и удалённые); без
+	 * него индекс строится заново
+	 * @param callsChanged - файлы, рёбра которых пересчитаны
+	 */
+	update(
+		files: ReadonlyMap<string, EnhancedCodeSymbol[]>,
+		calls: CallGraphBuilder,
+		changed?: Iterable<string>,
+		callsChanged: Iterable<string> = []
+	): ReferenceIndex {
+		if (!changed) {
+			this.typesByName.clear();
+			this.typesByFile.clear();
+			this.mentionsByFile.clear();
+			this.filesByMention.clear();
+			this.typeRefs.clear();
+			this.callRefs.clear();
+		}
+		const paths = new Set(changed ?? files.keys());
+		const names = new Set<string>();
+		for (const path of paths) {
+			for (const type of this.typesByFile.get(path) ?? []) {
+				names.add(type.name);
+			}
+			this.unlink(path);
+			const symbols = files.get(path);
+			if (symbols) {
+				for (const type of this.link(path, symbols)) {
+					names.add(type.name);
+				}
+			}
+		}
+
+		const retype = new Set([...paths].filter((path) => files.has(path)));
+		for (const name of names) {
+			for (const path of this.filesByMention.get(name) ?? []) {
+				retype.add(path);
+			}
+		}
+		for (const path of retype) {
+			const symbols = files.get(path) ?? [];
+			this.typeRefs.set(path, typeReferences(symbols, this.typesByName));
+		}
+		const recall = new Set([
+			...(changed ? callsChanged : files.keys()),
+			...[...paths].filter((path) => files.has(path)),
+		]);
+		for (const path of recall) {
+			const byId = new Map<string, EnhancedCodeSymbol>();
+			for (const symbol of files.get(path) ?? []) {
+				byId.set(symbol.id ?? symbolId(symbol), symbol);
+			}
+			this.callRefs.set(path, callReferences(byId, calls.edgesOf(path)));
+		}
+
+		const index = new ReferenceIndex();
+		for (const refs of [this.typeRefs, this.callRefs]) {
+			for (const path of files.keys()) {
+				for (const [key, reference] of refs.get(path) ?? []) {
+					index.add(key, reference);
+				}
+			}
+		}
+		return index;
+	}
+
+	private link(
+		path: string,
+		symbols: EnhancedCodeSymbol[]
+	): EnhancedCodeSymbol[] {
+		const types = declaredTypes(symbols);
+		this.typesByFile.set(path, types);
+		for (const type of types) {
+			pushTo(this.typesByName, type.name, type);
+		}
+		const mentions = new Set(
+			symbols.flatMap((symbol) =>
+				(symbol.metadata?.typeMentions ?? []).map((mention) => mention.name)
+			)
+		);
+		this.mentionsByFile.set(path, mentions);
+		for (const name of mentions) {
+			const files = this.filesByMention.get(name) ?? new Set();
+			files.add(path);
+			this.filesByMention.set(name, files);
+		}
+		return types;
+	}
+
+	private unlink(path: string): void {
+		for (const type of this.typesByFile.get(path) ?? []) {
+			const rest = (this.typesByName.get(type.name) ?? []).filter(
+				(candidate) => candidate !== type
+			);
+			if (rest.length > 0) {
+				this.typesByName.set(type.name, rest);
+			} else {
+				this.typesByName.delete(type.name);
+			}
+		}
+		for (const name of this.mentionsByFile.get(path) ?? []) {
+			const files = this.filesByMention.get(name);
+			files?.delete(path);
+			if (files?.size === 0) {
+				this.filesByMention.delete(name);
+			}
+		}
+		this.typesByFile.delete(path);
+		this.mentionsByFile.delete(path);
+		this.typeRefs.delete(path);
+		this.callRefs.delete(path);
+	}
+}
+
+// Ссылка вместе с ключом цели: id символа или `<import path>.<name>`
+type TargetedReference = [string, SymbolReference];
+
+/**
+ * Объявления типов верхнего уровня (локальные типы целью не бывают)
+ */
+function declaredTypes(symbols: EnhancedCodeSymbol[]): EnhancedCodeSymbol[] {
+	return symbols.filter(
+		(symbol) =>
+			TYPE_SYMBOL_TYPES.has(symbol.symbolType) && !symbol.metadata?.parent
+	);
+}
+
+function pushTo<K, V>(map: Map<K, V[]>, key: K, value: V): void {
+	const list = map.get(key);
+	if (list) {
+		list.push(value);
+	} else {
+		map.set(key, [value]);
+	}
+}
+
+/**
+ * Ссылки-упоминания типов из typeMentions символов
+ */
+function typeReferences(
+	symbols: EnhancedCodeSymbol[],
+	typesByName: ReadonlyMap<string, EnhancedCodeSymbol[]>
+): TargetedReference[] {
+	const resolveType = (
+		from: EnhancedCodeSymbol,
+		mention: TypeMention
+	): EnhancedCodeSymbol | null => {
+		const candidates = typesByName.get(mention.name) ?? [];
+		if (!mention.qualifier) {
+			const pkg = packageKey(from);
+			const local = candidates.filter((t) => packageKey(t) === pkg);
+			if (local.length > 0 || !mention.dotImports) {
+				return unique(local);
+			}
+			const dots = mention.dotImports;
+			return unique(
+				candidates.filter((t) => dots.some((path) => isImportOf(t, path)))
+			);
+		}
+		const importPath = mention.importPath;
+		if (!importPath) {
+			return null;
+		}
+		return unique(candidates.filter((t) => isImportOf(t, importPath)));
+	};
+
+	// Ключ внешнего типа: по пути импорта или по единственному dot-импорту,
+	// если в своём пакете такого типа нет
+	const externalKey = (
+		from: EnhancedCodeSymbol,
+		mention: TypeMention
+	): string | undefined => {
+		if (mention.importPath) {
+			return `${mention.importPath}.${mention.name}`;
+		}
+		const pkg = packageKey(from);
+		const candidates = typesByName.get(mention.name) ?? [];
+		const isLocal = candidates.some((t) => packageKey(t) === pkg);
+		const [dot, ...rest] = mention.dotImports ?? [];
+		return dot && rest.length === 0 && !isLocal
+			? `${dot}.${mention.name}`
+			: undefined;
+	};
+
+	const references: TargetedReference[] = [];
+	for (const symbol of symbols) {
+		for (const mention of symbol.metadata?.typeMentions ?? []) {
+			const target = resolveType(symbol, mention);
+			const key = target
+				? (target.id ?? symbolId(target))
+				: externalKey(symbol, mention);
+			if (!key) {
+				continue;
+			}
+			references.push([
+				key,
+				{
+					inBody: mention.inBody ?? false,
+					kind: "type",
+					range: mention.range,
+					symbol,
+					...(mention.typeArgumentOf && {
+						typeArgumentOf: mention.typeArgumentOf,
+					}),
+				},
+			]);
+		}
+	}
+	return references;
+}
+
+/**
+ * Ссылки-вызовы по рёбрам call graph (разрешённым и внешним)
+ */
+function callReferences(
+	byId: ReadonlyMap<string, EnhancedCodeSymbol>,
+	edges: CallEdge[]
+): TargetedReference[] {
+	const references: TargetedReference[] = [];
+	for (const edge of edges) {
+		const caller = byId.get(edge.caller);
+		const key = edge.callee ?? edge.external;
+		if (!(key && caller)) {
+			continue;
+		}
+		references.push([
+			key,
+			{
+				inBody: true,
+				kind: "call",
+				range: lineRange(caller, edge.line, edge.reference),
+				symbol: caller,
+			},
+		]);
+	}
+	return references;
+}
+
+/**
+ * Тип лежит в пакете, директория которого совпадает с последним сегментом
+ * пути импорта
+ */
+function isImportOf(symbol: EnhancedCodeSymbol, importPath: string): boolean {
+	const dir = importPath.slice(importPath.lastIndexOf("/") + 1);
+	return basename(dirname(symbol.path)) === dir;
+}
+
+function unique(candidates: EnhancedCodeSymbol[]): EnhancedCodeSymbol | null {
+	return candidates.length === 1 ? (candidates[0] ?? null) : null;
+}
+
+/**
+ * Позиция вызова: у callRefs есть только строка, колонка ищется
+ * по тексту строки в теле символа
+ */
+function lineRange(
+	symbol: EnhancedCodeSymbol,
+	line: number,
+	reference: string
+): SourceRange {
+	const text = symbol.body.split("\n")[line - symbol.startLine] ?? "";
+	const call = text.indexOf(`${reference}(`);
+	const startCol = Math.max(call === -1 ? text.indexOf(reference) : call, 0);
+	return {
+		startLine: line,
+		startCol,
+		endLine: line,
+		endCol: startCol + reference.length,
+	};
+}
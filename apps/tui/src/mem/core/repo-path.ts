@@ -0,0 +1,85 @@
+import { posix, resolve } from "node:path";
+import type { EnhancedCodeSymbol } from "./parsers/types.ts";
+
+/**
+ * Пути символов относительно корня репозитория
+ *
+ * Парсер записывает в symbol.path путь в том виде, в каком его передали:
+ * `./a/b.go`, `/repo/a/b.go` или `a\b.go` из Windows-инструмента. Для id,
+ * кеша и дедупликации путь приводится к одному виду — относительно
+ * repoRoot с разделителем `/` (symbol.relativePath). Абсолютный путь
+ * вычисляется по нему при необходимости (absolutePathOf).
+ */
+
+const DRIVE_RE = /^([a-zA-Z]):\//;
+
+/**
+ * Разделители `\` -> `/`, `.` и `..` свёрнуты, буква диска в верхнем регистре
+ */
+export function toPosixPath(path: string): string {
+	const slashed = path
+		.replace(/\\/g, "/")
+		.replace(DRIVE_RE, (_, drive: string) => `${drive.toUpperCase()}:/`);
+	const normalized = posix.normalize(slashed);
+	return normalized.length > 1 && normalized.endsWith("/")
+		? normalized.slice(0, -1)
+		: normalized;
+}
+
+/**
+ * Абсолютный путь POSIX (`/a`) или Windows с буквой диска (`C:\a`, `C:/a`)
+ */
+export function isAbsolutePath(path: string): boolean {
+	const posixPath = toPosixPath(path);
+	return posixPath.startsWith("/") || DRIVE_RE.test(posixPath);
+}
+
+/**
+ * Путь относительно repoRoot с разделителем `/`
+ *
+ * Относительный путь считается заданным от repoRoot и только
+ * нормализуется. Абсолютный путь вне repoRoot (или на другом диске)
+ * остаётся абсолютным.
+ */
+export function repoRelativePath(path: string, repoRoot: string): string {
+	const target = toPosixPath(path);
+	if (!isAbsolutePath(target)) {
+		return target === "." ? "" : target;
+	}
+	const root = toPosixPath(repoRoot);
+	const targetDrive = DRIVE_RE.exec(target)?.[1];
+	if (targetDrive !== DRIVE_RE.exec(root)?.[1]) {
+		return target;
+	}
+	// Буква диска у обоих путей одна: сравниваются пути без неё
+	const strip = (p: string) => (targetDrive ? p.slice(2) : p);
+	const relative = posix.relative(strip(root), strip(target));
+	return relative.startsWith("../") || relative === ".." ? target : relative;
+}
+
+/**
+ * Абсолютный путь файла: относительный путь разрешается от repoRoot
+ */
+export function resolveRepoPath(path: string, repoRoot: string): string {
+	return resolve(repoRoot, toPosixPath(path));
+}
+
+/**
+ * Путь символа относительно repoRoot (relativePath, если уже проставлен)
+ */
+export function relativePathOf(
+	symbol: EnhancedCodeSymbol,
+	repoRoot: string
+): string {
+	return symbol.relativePath ?? repoRelativePath(symbol.path, repoRoot);
+}
+
+/**
+ * Абсолютный путь файла символа
+ */
+export function absolutePathOf(
+	symbol: EnhancedCodeSymbol,
+	repoRoot: string
+): string {
+	return resolveRepoPath(relativePathOf(symbol, repoRoot), repoRoot);
+}
@@ -0,0 +1,147 @@
+import type { EnhancedCodeSymbol } from "./parsers/types.ts";
+import { DEFAULT_SCORING } from "./scoring.ts";
+import type { ListSymbolsOptions } from "./symbol-query.ts";
+import { listSymbols } from "./symbol-query.ts";
+import type {
+	SymbolSearchOptions,
+	SymbolSearchResult,
+} from "./symbol-search.ts";
+import { searchSymbols } from "./symbol-search.ts";
+
+/**
+ * Именованные запросы к индексу
+ *
+ * Запрос — фильтры listSymbols плюс параметры searchSymbols, только
+ * JSON-значения, поэтому набор запросов можно хранить в конфиге
+ * (toJSON) и загружать обратно (new SavedQueries(json)).
+ */
+
+export interface SavedQuery
+	extends ListSymbolsOptions,
+		Omit<SymbolSearchOptions, "editedAt"> {
+	/** Нечёткий поиск по имени среди отфильтрованных; без него — листинг */
+	query?: string;
+}
+
+const isBoolean = (v: unknown) => typeof v === "boolean";
+const isString = (v: unknown) => typeof v === "string";
+const isNumber = (v: unknown) => typeof v === "number" && Number.isFinite(v);
+const isScoring = (v: unknown) =>
+	typeof v === "object" &&
+	v !== null &&
+	Object.entries(v).every(([key, weight]) =>
+		key === "kind"
+			? typeof weight === "object" &&
+				weight !== null &&
+				Object.values(weight).every(isNumber)
+			: key in DEFAULT_SCORING && isNumber(weight)
+	);
+const oneOf =
+	(...values: string[]) =>
+	(v: unknown) =>
+		values.includes(v as string);
+
+const SAVED_QUERY_FIELDS: Record<keyof SavedQuery, (v: unknown) => boolean> = {
+	deprecatedOnly: isBoolean,
+	excludeConstraints: isBoolean,
+	exportedOnly: isBoolean,
+	includeDocs: isBoolean,
+	includeTests: isBoolean,
+	kinds: (v) => Array.isArray(v) && v.every(isString),
+	limit: (v) => Number.isInteger(v) && (v as number) > 0,
+	order: oneOf("source", "name", "kind-then-name"),
+	query: isString,
+	scope: isString,
+	scoring: isScoring,
+	sortBy: oneOf("complexity", "loc"),
+};
+
+/**
+ * Проверить запрос: известные поля с допустимыми значениями
+ */
+export function assertSavedQuery(name: string, query: SavedQuery): void {
+	if (!name.trim()) {
+		throw new Error("Saved query name must not be empty");
+	}
+	const fields = SAVED_QUERY_FIELDS as Record<string, (v: unknown) => boolean>;
+	for (const [key, value] of Object.entries(query)) {
+		const valid = fields[key];
+		if (!valid) {
+			throw new Error(`Unknown option "${key}" in saved query "${name}"`);
+		}
+		if (value !== undefined && !valid(value)) {
+			throw new Error(
+				`Invalid value for "${key}" in saved query "${name}": ${JSON.stringify(value)}`
+			);
+		}
+	}
+}
+
+/**
+ * Выполнить запрос над символами
+ *
+ * Сначала фильтры и порядок listSymbols, затем, если задан query, поиск
+ * searchSymbols — тогда порядок определяет релевантность. Без query
+ * каждый отфильтрованный символ — полное совпадение (score 1, без
+ * matches), limit обрезает листинг. editedAt из context нужен весу
+ * recency в scoring.
+ */
+export function runSavedQuery(
+	symbols: EnhancedCodeSymbol[],
+	saved: SavedQuery,
+	context: Pick<SymbolSearchOptions, "editedAt"> = {}
+): SymbolSearchResult[] {
+	const { includeDocs, limit, query, scoring, ...filters } = saved;
+	const listed = listSymbols(symbols, filters);
+	if (query?.trim()) {
+		return searchSymbols(listed, query, {
+			...context,
+			includeDocs,
+			limit,
+			scoring,
+		});
+	}
+	return listed
+		.slice(0, limit)
+		.map((symbol) => ({ symbol, score: 1, matches: [] }));
+}
+
+export class SavedQueries {
+	private readonly queries = new Map<string, SavedQuery>();
+
+	constructor(initial: Record<string, SavedQuery> = {}) {
+		for (const [name, query] of Object.entries(initial)) {
+			this.define(name, query);
+		}
+	}
+
+	/**
+	 * Сохранить запрос; повторный вызов с тем же именем заменяет его
+	 */
+	define(name: string, query: SavedQuery): void {
+		assertSavedQuery(name, query);
+		this.queries.set(name, structuredClone(query));
+	}
+
+	get(name: string): SavedQuery | undefined {
+		const query = this.queries.get(name);
+		return query && structuredClone(query);
+	}
+
+	delete(name: string): boolean {
+		return this.queries.delete(name);
+	}
+
+	names(): string[] {
+		return [...this.queries.keys()].sort();
+	}
+
+	/**
+	 * Все запросы в виде для JSON (ключи по алфавиту)
+	 */
+	toJSON(): Record<string, SavedQuery> {
+		return Object.fromEntries(
+			this.names().map((name) => [name, this.get(name) as SavedQuery])
+		);
+	}
+}
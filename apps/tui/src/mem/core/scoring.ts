@@ -0,0 +1,71 @@
+import type { EnhancedCodeSymbol } from "./parsers/types.ts";
+import { kindOf } from "./symbol-kind.ts";
+
+/**
+ * Веса ранжирования для searchSymbols и приоритета packContext
+ *
+ * Балл поиска — лучшее из взвешенных совпадений имени и документации
+ * плюс надбавки символа: за вид, экспорт и свежую правку файла. В
+ * packContext надбавки прибавляются к переданной релевантности. С весами
+ * по умолчанию надбавок нет, а совпадение только в документации слабее
+ * любого совпадения имени.
+ */
+export interface ScoringConfig {
+	/** Множитель балла совпадения в документации (сам балл — 0.1) */
+	doc: number;
+	/** Надбавка экспортированному символу */
+	exported: number;
+	/** Надбавка по каноническому виду (kindOf): `{ function: 0.2 }` */
+	kind: Record<string, number>;
+	/** Множитель балла совпадения имени (от 0.2 до 1) */
+	name: number;
+	/** Надбавка за правку файла, умножается на свежесть от 0 до 1 */
+	recency: number;
+	/** Через сколько миллисекунд после правки свежесть падает вдвое */
+	recencyHalfLife: number;
+}
+
+export const DEFAULT_SCORING: ScoringConfig = {
+	doc: 1,
+	exported: 0,
+	kind: {},
+	name: 1,
+	recency: 0,
+	recencyHalfLife: 24 * 60 * 60 * 1000,
+};
+
+/**
+ * Веса по умолчанию с переопределениями вызова
+ */
+export function resolveScoring(
+	overrides: Partial<ScoringConfig> = {}
+): ScoringConfig {
+	return {
+		...DEFAULT_SCORING,
+		...overrides,
+		kind: { ...DEFAULT_SCORING.kind, ...overrides.kind },
+	};
+}
+
+/**
+ * Надбавка символа, не зависящая от запроса
+ *
+ * @param editedAt - время последней правки по пути файла (мс с эпохи)
+ */
+export function symbolBonus(
+	symbol: EnhancedCodeSymbol,
+	config: ScoringConfig,
+	editedAt: Record<string, number> = {},
+	now = Date.now()
+): number {
+	let bonus = config.kind[kindOf(symbol)] ?? 0;
+	if (symbol.metadata?.isExported) {
+		bonus += config.exported;
+	}
+	const edited = editedAt[symbol.path];
+	if (config.recency !== 0 && edited !== undefined) {
+		const age = Math.max(0, now - edited);
+		bonus += config.recency * 0.5 ** (age / config.recencyHalfLife);
+	}
+	return bonus;
+}
@@ -0,0 +1,148 @@
+import type { EnhancedCodeSymbol } from "./parsers/types.ts";
+import { declarationHead, symbolHashes } from "./symbol-hash.ts";
+import { kindFilter, kindOf } from "./symbol-kind.ts";
+import type {
+	SymbolSearchOptions,
+	SymbolSearchResult,
+} from "./symbol-search.ts";
+import { highlightMatches, searchSymbols } from "./symbol-search.ts";
+
+const DEFAULT_LIMIT = 20;
+const DEFAULT_BATCH_SIZE = 64;
+
+/**
+ * Функция embedding: пакет текстов -> векторы в том же порядке
+ *
+ * Подходит embedTexts из embedder.ts.
+ */
+export type SymbolEmbedder = (texts: string[]) => Promise<number[][]>;
+
+export interface SemanticSearchOptions {
+	/** Сколько текстов передавать embedder'у за один вызов */
+	batchSize?: number;
+	/** Оставить только символы этих видов (канонических, см. kindOf) */
+	kinds?: string[];
+	limit?: number;
+}
+
+export interface SemanticSearchResponse {
+	/** embedder не задан: results получены нечётким поиском по имени */
+	fallback: boolean;
+	results: SymbolSearchResult[];
+}
+
+/**
+ * Текст символа для embedding: имя, документация и сигнатура
+ */
+export function embeddingText(symbol: EnhancedCodeSymbol): string {
+	return [symbol.name, symbol.jsDoc, declarationHead(symbol)]
+		.filter(Boolean)
+		.join("\n");
+}
+
+/**
+ * Семантический поиск символов по embeddings
+ *
+ * Векторы символов кешируются по bodyHash и пересчитываются только при
+ * изменении сигнатуры или тела. Без embedder'а поиск откатывается на
+ * searchSymbols с флагом fallback.
+ */
+export class SemanticSearch {
+	private embedder: SymbolEmbedder | null = null;
+	private readonly vectors = new Map<string, number[]>();
+
+	setEmbedder(embedder: SymbolEmbedder | null): void {
+		if (embedder !== this.embedder) {
+			this.vectors.clear();
+		}
+		this.embedder = embedder;
+	}
+
+	async search(
+		symbols: EnhancedCodeSymbol[],
+		query: string,
+		options: SemanticSearchOptions = {}
+	): Promise<SemanticSearchResponse> {
+		const limit = options.limit ?? DEFAULT_LIMIT;
+		if (!this.embedder) {
+			const fuzzy: SymbolSearchOptions = {
+				includeDocs: true,
+				kinds: options.kinds,
+				limit,
+			};
+			return { fallback: true, results: searchSymbols(symbols, query, fuzzy) };
+		}
+		if (!query.trim()) {
+			return { fallback: false, results: [] };
+		}
+
+		const kinds = kindFilter(options.kinds);
+		const candidates = kinds
+			? symbols.filter((s) => kinds.has(kindOf(s)))
+			: symbols;
+		const [queryVector] = await this.embedder([query]);
+		await this.embedMissing(candidates, options.batchSize);
+
+		const results: SymbolSearchResult[] = [];
+		for (const symbol of candidates) {
+			const vector = this.vectors.get(hashOf(symbol));
+			if (vector && queryVector) {
+				const score = cosine(queryVector, vector);
+				results.push({ symbol, score, matches: [] });
+			}
+		}
+		results.sort((a, b) => b.score - a.score);
+		const top = results.slice(0, limit);
+		for (const result of top) {
+			result.matches = highlightMatches(result.symbol, query);
+		}
+		return { fallback: false, results: top };
+	}
+
+	/**
+	 * Посчитать недостающие векторы пакетами по batchSize
+	 */
+	private async embedMissing(
+		symbols: EnhancedCodeSymbol[],
+		batchSize = DEFAULT_BATCH_SIZE
+	): Promise<void> {
+		const pending = new Map<string, string>();
+		for (const symbol of symbols) {
+			const hash = hashOf(symbol);
+			if (!(this.vectors.has(hash) || pending.has(hash))) {
+				pending.set(hash, embeddingText(symbol));
+			}
+		}
+
+		const entries = [...pending];
+		for (let i = 0; i < entries.length; i += batchSize) {
+			const batch = entries.slice(i, i + batchSize);
+			const vectors = await this.embedder?.(batch.map(([, text]) => text));
+			batch.forEach(([hash], j) => {
+				const vector = vectors?.[j];
+				if (vector) {
+					this.vectors.set(hash, vector);
+				}
+			});
+		}
+	}
+}
+
+function hashOf(symbol: EnhancedCodeSymbol): string {
+	return symbol.bodyHash ?? symbolHashes(symbol).bodyHash;
+}
+
+function cosine(a: number[], b: number[]): number {
+	let dot = 0;
+	let magA = 0;
+	let magB = 0;
+	for (let i = 0; i < a.length; i++) {
+		const ai = a[i] ?? 0;
+		const bi = b[i] ?? 0;
+		dot += ai * bi;
+		magA += ai * ai;
+		magB += bi * bi;
+	}
+	const denom = Math.sqrt(magA) * Math.sqrt(magB);
+	return denom === 0 ? 0 : dot / denom;
+}
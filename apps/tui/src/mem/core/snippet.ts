@@ -0,0 +1,202 @@
+import { extname } from "node:path";
+import { packageKey } from "./call-graph.ts";
+import type { EnhancedCodeSymbol } from "./parsers/types.ts";
+
+export interface SnippetOptions {
+	/** Тело функции целиком вместо одной сигнатуры */
+	body?: boolean;
+	/** Строк исходника до и после символа (нужен source) */
+	contextLines?: number;
+	/** Выводить doc comment (по умолчанию true) */
+	docs?: boolean;
+	/** Максимум строк объявления; остальное заменяется маркером */
+	maxLines?: number;
+	/** Максимум полей/методов в теле struct/interface/class */
+	maxMembers?: number;
+}
+
+const DEFAULT_MAX_LINES = 40;
+const DEFAULT_MAX_MEMBERS = 12;
+const CALLABLE_TYPES = new Set(["function", "method", "hook", "component"]);
+const CONTAINER_TYPES = new Set(["class", "interface", "enum"]);
+const HASH_COMMENT_EXTS = new Set([".py", ".pyi"]);
+const COMMENT_LINE_RE = /^\s*(?:\/\/|\/\*|\*|#)/;
+// Go spec (`User struct {`, `MaxRetries = 3`) не содержит ключевого слова
+const GO_DECL_KEYWORDS: Record<string, string> = {
+	class: "type",
+	constant: "const",
+	enum: "type",
+	interface: "type",
+	type: "type",
+	variable: "var",
+};
+
+function commentPrefix(symbol: EnhancedCodeSymbol): string {
+	return HASH_COMMENT_EXTS.has(extname(symbol.path)) ? "#" : "//";
+}
+
+function omitted(symbol: EnhancedCodeSymbol, indent: string, count: number) {
+	return `${indent}${commentPrefix(symbol)} … ${count} more`;
+}
+
+/**
+ * Текст объявления; для Go spec восстанавливается ключевое слово
+ */
+function declarationText(symbol: EnhancedCodeSymbol): string {
+	const keyword =
+		extname(symbol.path) === ".go"
+			? GO_DECL_KEYWORDS[symbol.symbolType]
+			: undefined;
+	if (!keyword || symbol.body.startsWith(`${keyword} `)) {
+		return symbol.body;
+	}
+	return `${keyword} ${symbol.body}`;
+}
+
+/**
+ * Сигнатура функции: строки объявления до открывающей `{` или `:` тела
+ */
+function signatureLines(symbol: EnhancedCodeSymbol): string[] {
+	const lines = symbol.body.split("\n");
+	const opener = HASH_COMMENT_EXTS.has(extname(symbol.path))
+		? /:\s*$/
+		: /\{\s*$/;
+	const end = lines.findIndex((line) => opener.test(line));
+	if (end === -1) {
+		return lines.slice(0, 1);
+	}
+	const signature = lines.slice(0, end + 1);
+	signature[end] = (signature[end] as string).replace(opener, "").trimEnd();
+	return signature;
+}
+
+/**
+ * Тело контейнера с не более чем maxMembers строками-членами
+ */
+function containerLines(
+	symbol: EnhancedCodeSymbol,
+	maxMembers: number
+): string[] {
+	const lines = declarationText(symbol).split("\n");
+	if (lines.length <= 2) {
+		return lines;
+	}
+	const head = lines[0] as string;
+	const tail = lines.at(-1) as string;
+	const members = lines.slice(1, -1).filter((line) => line.trim() !== "");
+	if (members.length <= maxMembers) {
+		return [head, ...members, tail];
+	}
+	const indent = members[0]?.match(/^\s*/)?.[0] ?? "\t";
+	return [
+		head,
+		...members.slice(0, maxMembers),
+		omitted(symbol, indent, members.length - maxMembers),
+		tail,
+	];
+}
+
+/**
+ * Заголовок типа-владельца метода: `type User struct { … }`
+ */
+function receiverHeader(
+	symbol: EnhancedCodeSymbol,
+	symbols: EnhancedCodeSymbol[]
+): string[] {
+	const receiver = symbol.metadata?.receiver;
+	if (!receiver) {
+		return [];
+	}
+	const owner = symbols.find(
+		(s) =>
+			s.name === receiver &&
+			!CALLABLE_TYPES.has(s.symbolType) &&
+			packageKey(s) === packageKey(symbol)
+	);
+	if (!owner) {
+		return [];
+	}
+	const first = declarationText(owner).split("\n")[0] ?? "";
+	return first.trimEnd().endsWith("{") ? [`${first} … }`] : [first];
+}
+
+function docLines(symbol: EnhancedCodeSymbol): string[] {
+	const doc = (symbol.metadata?.docComment ?? symbol.jsDoc ?? "").trim();
+	if (!doc) {
+		return [];
+	}
+	const prefix = commentPrefix(symbol);
+	return doc.split("\n").map((line) => `${prefix} ${line.trim()}`.trimEnd());
+}
+
+/**
+ * Компактный фрагмент кода символа для контекста модели
+ *
+ * Первая строка — расположение (`path:line`), дальше doc comment и
+ * объявление: сигнатура для функций (с body — всё тело), тело
+ * struct/interface/class с ограничением числа членов, для прочих — текст
+ * целиком. Методу предшествует заголовок его receiver-типа. Объявление
+ * длиннее maxLines обрезается маркером `… N more`.
+ *
+ * @param symbols - символы индекса (для поиска receiver-типа)
+ * @param source - содержимое файла, нужно только для contextLines
+ */
+export function renderSnippet(
+	symbol: EnhancedCodeSymbol,
+	symbols: EnhancedCodeSymbol[] = [],
+	source?: string,
+	options: SnippetOptions = {}
+): string {
+	const maxLines = options.maxLines ?? DEFAULT_MAX_LINES;
+	const maxMembers = options.maxMembers ?? DEFAULT_MAX_MEMBERS;
+	const prefix = commentPrefix(symbol);
+
+	const signatureOnly =
+		CALLABLE_TYPES.has(symbol.symbolType) && !options.body;
+	let declaration: string[];
+	if (signatureOnly) {
+		declaration = signatureLines(symbol);
+	} else if (CONTAINER_TYPES.has(symbol.symbolType)) {
+		declaration = containerLines(symbol, maxMembers);
+	} else {
+		declaration = declarationText(symbol).split("\n");
+	}
+	if (declaration.length > maxLines) {
+		declaration = [
+			...declaration.slice(0, maxLines),
+			omitted(symbol, "", declaration.length - maxLines),
+		];
+	}
+
+	const contextLines = options.contextLines ?? 0;
+	const fileLines = source && contextLines > 0 ? source.split("\n") : [];
+	// Doc comment над объявлением уже выведен, контекст начинается выше него
+	let firstLine = symbol.startLine;
+	while (
+		firstLine > 1 &&
+		COMMENT_LINE_RE.test(fileLines[firstLine - 2] ?? "")
+	) {
+		firstLine--;
+	}
+	const before = fileLines.slice(
+		Math.max(0, firstLine - 1 - contextLines),
+		firstLine - 1
+	);
+	const after = fileLines.slice(symbol.endLine, symbol.endLine + contextLines);
+
+	const out = [
+		`${prefix} ${symbol.path}:${symbol.startLine}`,
+		...receiverHeader(symbol, symbols),
+		...before,
+		...(options.docs === false ? [] : docLines(symbol)),
+		...declaration,
+	];
+	if (after.length > 0) {
+		// Тело функции опущено, поэтому контекст после отделяется маркером
+		if (signatureOnly) {
+			out.push(`${prefix} …`);
+		}
+		out.push(...after);
+	}
+	return out.join("\n");
+}
@@ -0,0 +1,69 @@
+import { createHash } from "node:crypto";
+import { extname } from "node:path";
+import type { EnhancedCodeSymbol } from "./parsers/types.ts";
+
+const CALLABLE_TYPES = new Set(["function", "method", "hook", "component"]);
+const COLON_BLOCK_EXTS = new Set([".py", ".pyi"]);
+
+/**
+ * Текст без форматирования: пробельные последовательности схлопываются,
+ * пробелы вокруг пунктуации убираются
+ *
+ * `func F(a int)  {` и `func F( a int ) {` дают одно и то же. Отступы
+ * тоже не различаются, в том числе в Python.
+ */
+export function normalizeTokens(text: string): string {
+	return text
+		.replace(/\s+/g, " ")
+		.replace(/ ?([^\p{L}\p{N}_$\s]) ?/gu, "$1")
+		.trim();
+}
+
+/**
+ * Заголовок объявления: для функций — строки до открывающей `{` или `:`
+ * тела, для остальных символов — объявление целиком
+ */
+export function declarationHead(symbol: EnhancedCodeSymbol): string {
+	if (!CALLABLE_TYPES.has(symbol.symbolType)) {
+		return symbol.body;
+	}
+	const lines = symbol.body.split("\n");
+	const opener = COLON_BLOCK_EXTS.has(extname(symbol.path))
+		? /:\s*$/
+		: /\{\s*$/;
+	const end = lines.findIndex((line) => opener.test(line));
+	return lines.slice(0, end === -1 ? 1 : end + 1).join("\n");
+}
+
+function shortHash(text: string): string {
+	return createHash("sha256").update(text).digest("hex").slice(0, 16);
+}
+
+/**
+ * Хеши содержимого символа для определения, что именно изменилось
+ *
+ * bodyHash — по заголовку объявления (сигнатуре), fullHash — по всему
+ * объявлению с телом. Оба считаются по normalizeTokens, так что правка
+ * только форматирования хеши не меняет. Doc comment в хеши не входит.
+ */
+export function symbolHashes(symbol: EnhancedCodeSymbol): {
+	bodyHash: string;
+	fullHash: string;
+} {
+	return {
+		bodyHash: shortHash(normalizeTokens(declarationHead(symbol))),
+		fullHash: shortHash(normalizeTokens(symbol.body)),
+	};
+}
+
+/**
+ * Проставить bodyHash и fullHash символам
+ */
+export function assignSymbolHashes(
+	symbols: EnhancedCodeSymbol[]
+): EnhancedCodeSymbol[] {
+	for (const symbol of symbols) {
+		Object.assign(symbol, symbolHashes(symbol));
+	}
+	return symbols;
+}
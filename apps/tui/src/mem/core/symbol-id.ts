@@ -0,0 +1,55 @@
+import type { EnhancedCodeSymbol } from "./parsers/types.ts";
+import { repoRelativePath, toPosixPath } from "./repo-path.ts";
+
+/**
+ * Стабильный идентификатор символа для перекрёстных ссылок
+ *
+ * Формат: `<file>#<kind>:<qualified name>`, где
+ * - file — путь относительно root (см. repoRelativePath), без root —
+ *   нормализованный путь как есть; разделитель всегда `/`;
+ * - kind — symbolType;
+ * - qualified name — непустые части `package.parent.receiver.name` через
+ *   точку (name без префикса receiver'а, parent — только у локальных).
+ *
+ * Например `store/file.go#method:store.File.Close`. Позиция в файле и тело
+ * в id не входят, поэтому перенос функции внутри файла id не меняет.
+ */
+export function symbolId(symbol: EnhancedCodeSymbol, root?: string): string {
+	const meta = symbol.metadata;
+	const receiver = meta?.receiver;
+	const name =
+		receiver && symbol.name.startsWith(`${receiver}.`)
+			? symbol.name.slice(receiver.length + 1)
+			: symbol.name;
+	const qualified = [meta?.packageName, meta?.parent, receiver, name]
+		.filter(Boolean)
+		.join(".");
+	const path = root
+		? (symbol.relativePath ?? repoRelativePath(symbol.path, root))
+		: toPosixPath(symbol.path);
+	return `${path}#${symbol.symbolType}:${qualified}`;
+}
+
+/**
+ * Проставить `id` (и с root — `relativePath`) символам одного
+ * или нескольких файлов
+ *
+ * Символы с одинаковым id (несколько `func init()` в одном файле)
+ * получают суффикс `@2`, `@3`… в порядке появления.
+ */
+export function assignSymbolIds(
+	symbols: EnhancedCodeSymbol[],
+	root?: string
+): EnhancedCodeSymbol[] {
+	const seen = new Map<string, number>();
+	for (const symbol of symbols) {
+		if (root) {
+			symbol.relativePath = repoRelativePath(symbol.path, root);
+		}
+		const id = symbolId(symbol, root);
+		const count = (seen.get(id) ?? 0) + 1;
+		seen.set(id, count);
+		symbol.id = count > 1 ? `${id}@${count}` : id;
+	}
+	return symbols;
+}
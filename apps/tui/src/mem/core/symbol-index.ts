@@ -0,0 +1,1372 @@
+import { createHash } from "node:crypto";
+import { resolve } from "node:path";
+import type { Writable } from "node:stream";
+import { createLogger } from "../lib/logger.ts";
+import type { Annotations, AnnotationStoreOptions } from "./annotations.ts";
+import { AnnotationStore } from "./annotations.ts";
+import type { CallEdge, CallGraph } from "./call-graph.ts";
+import { CallGraphBuilder } from "./call-graph.ts";
+import type { PackContextOptions, PackedContext } from "./context-pack.ts";
+import { packContext } from "./context-pack.ts";
+import type { FileLruStats, SymbolIndexCapacity } from "./file-lru.ts";
+import { FileLru } from "./file-lru.ts";
+import type {
+	InterfaceImplementation,
+	MethodSets,
+} from "./implementations.ts";
+import {
+	fieldsFor,
+	implementationsOf,
+	methodSetsFor,
+} from "./implementations.ts";
+import type { IndexEvent } from "./index-events.ts";
+import {
+	diffSymbol,
+	eventSymbolId,
+	IndexEventEmitter,
+} from "./index-events.ts";
+import type { IndexRecordSource } from "./index-ndjson.ts";
+import { readIndexRecords, writeIndexRecord } from "./index-ndjson.ts";
+import type { IndexStats } from "./index-stats.ts";
+import { IndexStatsAccumulator } from "./index-stats.ts";
+import type { DocumentSymbol } from "./lsp-symbols.ts";
+import { toDocumentSymbols } from "./lsp-symbols.ts";
+import type { MarkdownOutlineOptions } from "./markdown-outline.ts";
+import { toMarkdownOutline } from "./markdown-outline.ts";
+import type {
+	DuplicateNameGroup,
+	FindDuplicateNamesOptions,
+} from "./name-collisions.ts";
+import { findDuplicateNames } from "./name-collisions.ts";
+import type { ParseCache } from "./parse-cache.ts";
+import { hashSource } from "./parse-cache.ts";
+import type { ParserRegistry } from "./parsers/parser-registry.ts";
+import { parserRegistry } from "./parsers/parser-registry.ts";
+import { readSource } from "./parsers/source-text.ts";
+import type {
+	EnhancedCodeSymbol,
+	ParseDiagnostic,
+	ParseResult,
+	StructField,
+} from "./parsers/types.ts";
+import type { ReferenceIndex, SymbolReference } from "./references.ts";
+import { ReferenceIndexBuilder } from "./references.ts";
+import { resolveRepoPath } from "./repo-path.ts";
+import type { SavedQuery } from "./saved-queries.ts";
+import { runSavedQuery, SavedQueries } from "./saved-queries.ts";
+import type { ScoringConfig } from "./scoring.ts";
+import type {
+	SemanticSearchOptions,
+	SemanticSearchResponse,
+	SymbolEmbedder,
+} from "./semantic-search.ts";
+import { SemanticSearch } from "./semantic-search.ts";
+import type { SnippetOptions } from "./snippet.ts";
+import { renderSnippet } from "./snippet.ts";
+import { assignSymbolHashes, symbolHashes } from "./symbol-hash.ts";
+import { assignSymbolIds } from "./symbol-id.ts";
+import { symbolsAtPosition } from "./symbol-position.ts";
+import type {
+	ListMarkersOptions,
+	ListSymbolsOptions,
+	SymbolMarker,
+} from "./symbol-query.ts";
+import { listMarkers, listSymbols } from "./symbol-query.ts";
+import type {
+	SymbolSearchOptions,
+	SymbolSearchResult,
+} from "./symbol-search.ts";
+import { searchSymbols } from "./symbol-search.ts";
+
+const log = createLogger("symbol-index");
+
+export type SymbolChangeType = "added" | "removed" | "changed";
+
+/**
+ * Что изменилось в символе с событием "changed"
+ *
+ * signature — заголовок объявления (bodyHash), body — только тело
+ * (fullHash), doc — только doc comment. Изменение сигнатуры, которое
+ * меняет identityKey (параметры, возвращаемый тип), приходит не как
+ * "changed", а как пара removed + added.
+ */
+export type SymbolChangeScope = "signature" | "body" | "doc";
+
+export interface SymbolChange {
+	path: string;
+	/** Только для "changed" после переиндексации файла */
+	scope?: SymbolChangeScope;
+	symbol: EnhancedCodeSymbol;
+	type: SymbolChangeType;
+}
+
+export type SymbolChangeListener = (changes: SymbolChange[]) => void;
+
+/**
+ * Версия схемы дампа индекса. Увеличивается при несовместимых изменениях
+ * формата; старые дампы мигрируются в migrateIndexDocument()
+ */
+export const INDEX_SCHEMA_VERSION = 1;
+
+export interface IndexDocumentFile {
+	path: string;
+	symbols: EnhancedCodeSymbol[];
+}
+
+export interface IndexDocument {
+	files: IndexDocumentFile[];
+	relationships: {
+		calls: CallEdge[];
+	};
+	schemaVersion: number;
+}
+
+export interface SymbolIndexOptions {
+	/** Настройки хранилища заметок (grace period для удалённых символов) */
+	annotations?: AnnotationStoreOptions;
+	/** Дисковый кеш парсинга; без него файл парсится каждый раз */
+	cache?: ParseCache;
+	/**
+	 * Лимит памяти: при превышении символы давно не использованных файлов
+	 * вытесняются и загружаются заново через ensureFile()
+	 */
+	capacity?: SymbolIndexCapacity;
+	/** Сохранённые запросы, например из конфига (см. SavedQueries) */
+	queries?: Record<string, SavedQuery>;
+	registry?: ParserRegistry;
+	/** Корень репозитория для путей в `id` символов (по умолчанию cwd) */
+	root?: string;
+}
+
+/**
+ * Хеш сигнатуры символа: имя, вид, receiver, параметры и возвращаемый тип.
+ * Тело и позиция в файле в хеш не входят
+ */
+export function signatureHash(symbol: EnhancedCodeSymbol): string {
+	const meta = symbol.metadata;
+	const signature = JSON.stringify([
+		symbol.name,
+		symbol.symbolType,
+		meta?.receiver ?? "",
+		(meta?.parameters ?? []).map((p) => [p.name, p.type ?? "", !!p.variadic]),
+		meta?.returnType ?? "",
+		(meta?.genericParams ?? []).map((g) => [g.name, g.constraint ?? ""]),
+	]);
+	return createHash("sha256").update(signature).digest("hex").slice(0, 16);
+}
+
+function identityKey(symbol: EnhancedCodeSymbol): string {
+	return `${symbol.symbolType}:${symbol.name}:${signatureHash(symbol)}`;
+}
+
+/**
+ * Что изменилось между версиями символа (null — ничего)
+ *
+ * Сравнение по хешам, поэтому правка форматирования изменением не считается.
+ */
+export function changeScope(
+	a: EnhancedCodeSymbol,
+	b: EnhancedCodeSymbol
+): SymbolChangeScope | null {
+	const before = a.fullHash && a.bodyHash ? a : { ...a, ...symbolHashes(a) };
+	const after = b.fullHash && b.bodyHash ? b : { ...b, ...symbolHashes(b) };
+	if (before.bodyHash !== after.bodyHash) {
+		return "signature";
+	}
+	if (before.fullHash !== after.fullHash) {
+		return "body";
+	}
+	return a.jsDoc === b.jsDoc ? null : "doc";
+}
+
+/**
+ * Инкрементальный индекс символов по файлам
+ *
+ * При обновлении файла перепарсивается только он, а новые символы
+ * сравниваются со старыми по имени, виду и хешу сигнатуры. Совпавшие
+ * символы сохраняют идентичность объекта (обновляются на месте), поэтому
+ * привязанные к ним эмбеддинги и аннотации не теряются.
+ *
+ * С capacity индекс держит в памяти только часть файлов. Запросы по всему
+ * индексу (getSymbols, поиск, call graph, экспорт) берут символы
+ * вытесненных файлов из кеша парсинга, не загружая их обратно; без кеша
+ * (или до его чтения, см. refresh()) видны только загруженные файлы.
+ * Вытесненный файл загружается обратно через ensureFile().
+ *
+ * Асинхронные обновления (updateFile, renameFile, ensureFile) выполняются
+ * строго по очереди, а результат каждого применяется синхронно одним шагом.
+ * Поэтому запросы, которые сами синхронны, никогда не видят частично
+ * применённое обновление. dispose() завершает работу индекса.
+ */
+export class SymbolIndex {
+	private readonly annotationStore: AnnotationStore;
+	private readonly files = new Map<string, EnhancedCodeSymbol[]>();
+	private readonly diagnostics = new Map<string, ParseDiagnostic[]>();
+	private readonly hashes = new Map<string, string>();
+	/** Когда символы файла последний раз менялись (мс), для веса recency */
+	private readonly editTimes = new Map<string, number>();
+	/** Файлы, символы которых вытеснены LRU (хеш содержимого сохраняется) */
+	private readonly evicted = new Set<string>();
+	private readonly lru?: FileLru;
+	private readonly summary = new IndexStatsAccumulator();
+	private readonly listeners = new Set<SymbolChangeListener>();
+	private readonly emitter = new IndexEventEmitter();
+	private readonly registry: ParserRegistry;
+	private readonly cache?: ParseCache;
+	private readonly root: string;
+	private readonly callGraphs = new CallGraphBuilder();
+	private readonly referenceBuilder = new ReferenceIndexBuilder();
+	private graph: CallGraph | null = null;
+	private references: ReferenceIndex | null = null;
+	/** Файлы, изменённые с последней сборки call graph и ссылок */
+	private readonly graphDirty = new Set<string>();
+	private readonly referencesDirty = new Set<string>();
+	/** Файлы, рёбра которых пересчитаны после сборки ссылок */
+	private readonly referenceCallsDirty = new Set<string>();
+	private readonly semantic = new SemanticSearch();
+	/** Хвост очереди асинхронных обновлений; никогда не отклоняется */
+	private writes: Promise<unknown> = Promise.resolve();
+	private readonly disposers = new Set<() => void | Promise<void>>();
+	private readonly refreshers = new Set<() => Promise<unknown>>();
+	private readonly queries: SavedQueries;
+	private disposing: Promise<void> | null = null;
+	private closed = false;
+
+	constructor(options: SymbolIndexOptions = {}) {
+		this.annotationStore = new AnnotationStore(options.annotations);
+		this.cache = options.cache;
+		this.lru = options.capacity && new FileLru(options.capacity);
+		this.queries = new SavedQueries(options.queries);
+		this.registry = options.registry ?? parserRegistry;
+		this.root = resolve(options.root ?? process.cwd());
+	}
+
+	/**
+	 * Подписаться на изменения индекса. Возвращает функцию отписки
+	 */
+	onChange(listener: SymbolChangeListener): () => void {
+		this.listeners.add(listener);
+		return () => {
+			this.listeners.delete(listener);
+		};
+	}
+
+	/**
+	 * События индекса (fileIndexed, symbolChanged, indexError и т.д.)
+	 * пачками по операциям, см. IndexEventEmitter
+	 */
+	events(): IndexEventEmitter {
+		return this.emitter;
+	}
+
+	/**
+	 * Выполнить действие при dispose() (остановить watcher и т.п.).
+	 * Возвращает функцию отписки
+	 */
+	onDispose(disposer: () => void | Promise<void>): () => void {
+		this.disposers.add(disposer);
+		return () => {
+			this.disposers.delete(disposer);
+		};
+	}
+
+	/**
+	 * Зарегистрировать источник отложенных обновлений (watcher с debounce):
+	 * refresh() вызывает его перед запросами, которым нужен свежий индекс.
+	 * Возвращает функцию отписки
+	 */
+	onRefresh(refresher: () => Promise<unknown>): () => void {
+		this.refreshers.add(refresher);
+		return () => {
+			this.refreshers.delete(refresher);
+		};
+	}
+
+	/**
+	 * Применить отложенные обновления: накопленные события onRefresh
+	 * и уже поставленные в очередь updateFile/renameFile. Ошибка
+	 * источника только логируется — запрос получит текущее состояние.
+	 * Заодно читается кеш парсинга, чтобы запросы видели вытесненные файлы
+	 */
+	async refresh(): Promise<void> {
+		await Promise.all(
+			[...this.refreshers].map((refresher) =>
+				refresher().catch((err) => {
+					log.warn("Index refresh failed", { error: String(err) });
+				})
+			)
+		);
+		await this.writes;
+		if (this.cache && this.evicted.size > 0) {
+			await this.cache.ready();
+			// До чтения кеша вытесненных файлов не было в call graph
+			for (const path of this.evicted) {
+				this.markDirty(path);
+			}
+		}
+	}
+
+	/**
+	 * Ключ файла в индексе: абсолютный путь (относительный разрешается
+	 * от root, разделители `\` приводятся к `/`), см. resolveRepoPath
+	 */
+	private resolvePath(filePath: string): string {
+		return resolveRepoPath(filePath, this.root);
+	}
+
+	get disposed(): boolean {
+		return this.closed;
+	}
+
+	/**
+	 * Завершить работу индекса
+	 *
+	 * Новые обновления сразу отклоняются. Выполняются действия onDispose
+	 * (остановка watcher), затем уже поставленные в очередь обновления
+	 * дожидаются, кеш парсинга записывается на диск и подписчики
+	 * отключаются. Повторный вызов возвращает тот же промис.
+	 */
+	dispose(): Promise<void> {
+		if (!this.disposing) {
+			this.closed = true;
+			this.disposing = this.shutdown();
+		}
+		return this.disposing;
+	}
+
+	/**
+	 * Перепарсить один файл и применить разницу к индексу
+	 *
+	 * Если в файле есть синтаксические ошибки, старые символы, попадающие
+	 * на строки с ошибками и не найденные заново, остаются в индексе до
+	 * следующего успешного парсинга.
+	 *
+	 * @param filePath - путь к файлу
+	 * @param content - новое содержимое (если не передано, читается с диска)
+	 */
+	updateFile(filePath: string, content?: string): Promise<SymbolChange[]> {
+		const path = this.resolvePath(filePath);
+		return this.enqueue(async () => {
+			if (this.evicted.has(path)) {
+				await this.restore(path);
+			}
+			return this.reindex(path, content, path);
+		});
+	}
+
+	/**
+	 * Перенести файл на новый путь, сопоставив символы со старыми
+	 *
+	 * Символы с неизменённой сигнатурой сохраняют идентичность объекта
+	 * и приходят как "changed" с новым путём, а не как removed + added.
+	 */
+	renameFile(
+		from: string,
+		to: string,
+		content?: string
+	): Promise<SymbolChange[]> {
+		const fromPath = this.resolvePath(from);
+		return this.enqueue(async () => {
+			if (this.evicted.has(fromPath)) {
+				await this.restore(fromPath);
+			}
+			return this.reindex(this.resolvePath(to), content, fromPath);
+		});
+	}
+
+	/**
+	 * Вставить или заменить уже распарсенные символы (например из parseWorkspace)
+	 *
+	 * Символы группируются по файлу, и каждый затронутый файл заменяется
+	 * целиком: повторная индексация того же файла другим путём кода не
+	 * дублирует записи, а совпавшие символы сохраняют идентичность.
+	 * Переданные объекты не изменяются.
+	 */
+	upsert(symbols: EnhancedCodeSymbol[]): SymbolChange[] {
+		this.assertOpen();
+		const byFile = new Map<string, EnhancedCodeSymbol[]>();
+		for (const symbol of symbols) {
+			const path = this.resolvePath(symbol.path);
+			const group = byFile.get(path);
+			const copy = { ...structuredClone(symbol), path };
+			if (group) {
+				group.push(copy);
+			} else {
+				byFile.set(path, [copy]);
+			}
+		}
+
+		const changes: SymbolChange[] = [];
+		const events: IndexEvent[] = [];
+		for (const [path, group] of byFile) {
+			const parsed = assignSymbolHashes(assignSymbolIds(group, this.root));
+			const previous = this.files.get(path) ?? this.cachedSymbols(path) ?? [];
+			const diff = diffSymbols(path, previous, parsed);
+			this.evicted.delete(path);
+			this.setFileSymbols(path, diff.symbols);
+			if (diff.changes.length > 0) {
+				this.editTimes.set(path, Date.now());
+			}
+			// Содержимое файла неизвестно: хеш для определения переименований сброшен
+			this.hashes.delete(path);
+			changes.push(...diff.changes);
+			events.push(
+				{
+					type: "fileIndexed",
+					diagnostics: this.diagnostics.get(path) ?? [],
+					path,
+					symbolIds: diff.symbols.map(eventSymbolId),
+				},
+				...symbolEvents(diff.changes, diff.before)
+			);
+		}
+		this.evictOverCapacity();
+		this.emit(changes, events);
+		return changes;
+	}
+
+	/**
+	 * Удалить из индекса все символы файла (и только их)
+	 *
+	 * Символы вытесненного файла берутся из кеша парсинга; если записи
+	 * нет, приходит только fileRemoved без id символов.
+	 */
+	removeFile(filePath: string): SymbolChange[] {
+		this.assertOpen();
+		return this.drop(this.resolvePath(filePath));
+	}
+
+	/**
+	 * Символы одного файла в порядке их появления
+	 *
+	 * Для вытесненного файла — пустой список; загрузить его можно через
+	 * ensureFile().
+	 */
+	getFileSymbols(filePath: string): EnhancedCodeSymbol[] {
+		const path = this.resolvePath(filePath);
+		this.lru?.touch(path, this.evicted.has(path));
+		return this.files.get(path) ?? [];
+	}
+
+	/**
+	 * Символы файла с загрузкой вытесненного: из кеша парсинга по хешу
+	 * содержимого, а если записи нет — перепарсингом файла с диска
+	 */
+	async ensureFile(filePath: string): Promise<EnhancedCodeSymbol[]> {
+		const path = this.resolvePath(filePath);
+		if (!this.evicted.has(path)) {
+			return this.getFileSymbols(path);
+		}
+		this.lru?.touch(path, true);
+		return this.enqueue(async () => {
+			// Файл мог загрузиться, пока запрос ждал в очереди
+			if (!this.evicted.has(path)) {
+				return this.files.get(path) ?? [];
+			}
+			const symbols = await this.restore(path);
+			this.evictOverCapacity(path);
+			return symbols;
+		});
+	}
+
+	/**
+	 * Закрепить файл (например, открытый в TUI): он не вытесняется
+	 */
+	pinFile(filePath: string): void {
+		this.lru?.pin(this.resolvePath(filePath));
+	}
+
+	unpinFile(filePath: string): void {
+		this.lru?.unpin(this.resolvePath(filePath));
+	}
+
+	/**
+	 * Метрики LRU: попадания, промахи, вытеснения и занятая память
+	 */
+	stats(): FileLruStats & { evictedFiles: number } {
+		const stats = this.lru?.stats() ?? {
+			bytes: 0,
+			evictions: 0,
+			hits: 0,
+			misses: 0,
+			symbols: this.symbolCount(),
+		};
+		return { ...stats, evictedFiles: this.evicted.size };
+	}
+
+	/**
+	 * Сводка для обзора: символы по языкам и видам, экспорт, средняя
+	 * длина функций, файлы и ошибки парсинга. Считается инкрементально,
+	 * вытесненные файлы учитываются
+	 */
+	indexStats(): IndexStats {
+		return this.summary.snapshot(this.diagnostics);
+	}
+
+	/**
+	 * Все символы индекса, включая вытесненные файлы из кеша парсинга
+	 */
+	getSymbols(): EnhancedCodeSymbol[] {
+		return [...this.indexedFiles().values()].flat();
+	}
+
+	/**
+	 * Число символов в индексе, как getSymbols().length (загруженные
+	 * символы — в stats().symbols)
+	 */
+	symbolCount(): number {
+		let count = 0;
+		for (const symbols of this.indexedFiles().values()) {
+			count += symbols.length;
+		}
+		return count;
+	}
+
+	/**
+	 * Проверить внутренние инварианты индекса (для тестов и отладки)
+	 *
+	 * Бросает ошибку, если два символа делят один id или символ лежит
+	 * не под путём своего файла.
+	 */
+	assertInvariants(): void {
+		const seen = new Map<string, string>();
+		for (const [path, symbols] of this.files) {
+			for (const symbol of symbols) {
+				if (this.resolvePath(symbol.path) !== path) {
+					throw new Error(
+						`Symbol ${symbol.name} has path ${symbol.path}, indexed under ${path}`
+					);
+				}
+				const id = symbol.id ?? `${path}#${symbol.name}`;
+				const owner = seen.get(id);
+				if (owner) {
+					throw new Error(`Duplicate symbol id ${id} in ${owner} and ${path}`);
+				}
+				seen.set(id, path);
+			}
+		}
+	}
+
+	/**
+	 * Пути всех проиндексированных файлов, включая вытесненные
+	 */
+	getFiles(): string[] {
+		return [...this.files.keys(), ...this.evicted];
+	}
+
+	/**
+	 * Диагностики последнего парсинга файла (пусто, если ошибок не было)
+	 */
+	getDiagnostics(filePath: string): ParseDiagnostic[] {
+		return this.diagnostics.get(this.resolvePath(filePath)) ?? [];
+	}
+
+	/**
+	 * Хеш содержимого, из которого файл был проиндексирован
+	 */
+	getContentHash(filePath: string): string | undefined {
+		return this.hashes.get(this.resolvePath(filePath));
+	}
+
+	hasFile(filePath: string): boolean {
+		const path = this.resolvePath(filePath);
+		return this.files.has(path) || this.evicted.has(path);
+	}
+
+	/**
+	 * Символ на строке файла и его владельцы до пакета (для breadcrumbs)
+	 */
+	symbolsAtPosition(filePath: string, line: number): EnhancedCodeSymbol[] {
+		return symbolsAtPosition(
+			this.getSymbols(),
+			this.resolvePath(filePath),
+			line
+		);
+	}
+
+	/**
+	 * Символы индекса, отфильтрованные по виду, экспорту и области
+	 */
+	listSymbols(options: ListSymbolsOptions = {}): EnhancedCodeSymbol[] {
+		return listSymbols(this.getSymbols(), options);
+	}
+
+	/**
+	 * Сохранить именованный запрос (фильтры listSymbols и параметры
+	 * searchSymbols); повторный вызов с тем же именем заменяет его
+	 */
+	defineQuery(name: string, query: SavedQuery): void {
+		this.queries.define(name, query);
+	}
+
+	/**
+	 * Выполнить сохранённый запрос на свежем индексе (после refresh())
+	 */
+	async runQuery(name: string): Promise<SymbolSearchResult[]> {
+		const query = this.queries.get(name);
+		if (!query) {
+			throw new Error(`Unknown saved query: ${name}`);
+		}
+		await this.refresh();
+		const { editedAt } = this.withEditTimes({ scoring: query.scoring });
+		return runSavedQuery(this.getSymbols(), query, { editedAt });
+	}
+
+	/**
+	 * Сохранённые запросы (список, удаление, toJSON для конфига)
+	 */
+	savedQueries(): SavedQueries {
+		return this.queries;
+	}
+
+	/**
+	 * TODO/FIXME/HACK/XXX маркеры всех символов индекса
+	 */
+	listMarkers(options: ListMarkersOptions = {}): SymbolMarker[] {
+		return listMarkers(this.getSymbols(), options);
+	}
+
+	/**
+	 * Группы символов индекса с одинаковыми именами (затенение, разнобой)
+	 */
+	findDuplicateNames(
+		options: FindDuplicateNamesOptions = {}
+	): DuplicateNameGroup[] {
+		return findDuplicateNames(this.getSymbols(), options);
+	}
+
+	/**
+	 * Нечёткий поиск по символам индекса
+	 *
+	 * Для веса scoring.recency время правки берётся из истории обновлений
+	 * индекса, если editedAt не передан.
+	 */
+	searchSymbols(
+		query: string,
+		options: SymbolSearchOptions = {}
+	): SymbolSearchResult[] {
+		return searchSymbols(
+			this.getSymbols(),
+			query,
+			this.withEditTimes(options)
+		);
+	}
+
+	/**
+	 * Задать функцию embedding для semanticSearch (null — отключить)
+	 */
+	setEmbedder(embedder: SymbolEmbedder | null): void {
+		this.semantic.setEmbedder(embedder);
+	}
+
+	/**
+	 * Поиск по смыслу: cosine similarity embeddings запроса и символов
+	 * (имя, документация, сигнатура). Без embedder'а — нечёткий поиск
+	 * с `fallback: true`
+	 */
+	semanticSearch(
+		query: string,
+		options: SemanticSearchOptions = {}
+	): Promise<SemanticSearchResponse> {
+		return this.semantic.search(this.getSymbols(), query, options);
+	}
+
+	/**
+	 * Сериализовать индекс в версионированный JSON-документ
+	 */
+	exportIndex(): IndexDocument {
+		return {
+			schemaVersion: INDEX_SCHEMA_VERSION,
+			files: [...this.indexedFiles()].map(([path, symbols]) => ({
+				path,
+				symbols: structuredClone(symbols),
+			})),
+			relationships: {
+				calls: structuredClone(this.callGraph().edges),
+			},
+		};
+	}
+
+	/**
+	 * Заменить состояние индекса содержимым дампа
+	 *
+	 * Связи (call graph) пересчитываются из символов, поэтому в дампе
+	 * они нужны только внешним потребителям. Слушатели не уведомляются.
+	 *
+	 * Выполняется в очереди записей: уже поставленные обновления
+	 * применяются к прежнему состоянию, а невалидный дамп его не меняет.
+	 */
+	importIndex(json: string | IndexDocument): Promise<void> {
+		this.assertOpen();
+		return this.enqueue(async () => {
+			const doc = migrateIndexDocument(
+				typeof json === "string" ? JSON.parse(json) : json
+			);
+			this.clearFiles();
+			for (const file of doc.files) {
+				this.setFileSymbols(
+					...this.importedFile(file.path, structuredClone(file.symbols))
+				);
+			}
+			this.evictOverCapacity();
+		});
+	}
+
+	/**
+	 * Записать индекс в writable построчно (JSON Lines): заголовок, строка
+	 * на файл, строка на ребро call graph. Поток не закрывается
+	 *
+	 * Выполняется в очереди записей: изменения, начатые во время экспорта,
+	 * ждут его завершения, и дамп остаётся согласованным.
+	 */
+	exportIndexNDJSON(writable: Writable): Promise<void> {
+		return this.enqueue(async () => {
+			// removeFile синхронен и идёт мимо очереди: снимок до первого await
+			const files = [...this.files];
+			const evicted = [...this.evicted];
+			const edges = this.callGraph().edges;
+			await writeIndexRecord(writable, {
+				kind: "header",
+				schemaVersion: INDEX_SCHEMA_VERSION,
+			});
+			for (const [path, symbols] of files) {
+				await writeIndexRecord(writable, { kind: "file", path, symbols });
+			}
+			// Вытесненные файлы пишутся без загрузки в индекс: из кеша или с диска
+			for (const path of evicted) {
+				const symbols = this.cachedSymbols(path) ?? (await this.load(path));
+				await writeIndexRecord(writable, { kind: "file", path, symbols });
+			}
+			for (const edge of edges) {
+				await writeIndexRecord(writable, { kind: "call", ...edge });
+			}
+		});
+	}
+
+	/**
+	 * Заменить состояние индекса дампом exportIndexNDJSON, применяя файлы
+	 * по мере чтения (с вытеснением при capacity)
+	 *
+	 * Выполняется в очереди записей. Состояние сбрасывается после
+	 * проверки заголовка; при ошибке в середине потока в индексе остаются
+	 * уже прочитанные файлы.
+	 */
+	importIndexNDJSON(source: IndexRecordSource): Promise<void> {
+		return this.enqueue(async () => {
+			let started = false;
+			for await (const record of readIndexRecords(source)) {
+				if (!started) {
+					if (record.kind !== "header") {
+						throw new Error("Invalid index document");
+					}
+					migrateIndexDocument({
+						schemaVersion: record.schemaVersion,
+						files: [],
+					});
+					this.clearFiles();
+					started = true;
+				} else if (record.kind === "file") {
+					this.setFileSymbols(
+						...this.importedFile(record.path, record.symbols)
+					);
+					this.evictOverCapacity();
+				}
+				// Рёбра call graph пересчитываются из символов
+			}
+			if (!started) {
+				throw new Error("Invalid index document");
+			}
+		});
+	}
+
+	/**
+	 * Символы файла в виде иерархии LSP DocumentSymbol
+	 */
+	toDocumentSymbols(filePath: string): DocumentSymbol[] {
+		return toDocumentSymbols(this.getFileSymbols(filePath));
+	}
+
+	/**
+	 * Markdown outline файла или пакета (scope как в listSymbols)
+	 */
+	toMarkdownOutline(
+		scope: string,
+		options: MarkdownOutlineOptions = {}
+	): string {
+		return toMarkdownOutline(this.listSymbols({ scope }), {
+			title: scope,
+			...options,
+		});
+	}
+
+	/**
+	 * Call graph по всем символам индекса
+	 *
+	 * После изменений заново разрешаются только вызовы из пакетов
+	 * изменённых файлов; граф совпадает с buildCallGraph(getSymbols()).
+	 */
+	callGraph(): CallGraph {
+		if (!this.graph || this.graphDirty.size > 0) {
+			const files = this.indexedFiles();
+			const updated = this.callGraphs.update(
+				files,
+				this.graph ? this.graphDirty : undefined
+			);
+			for (const path of updated) {
+				this.referenceCallsDirty.add(path);
+			}
+			this.graphDirty.clear();
+			this.graph = this.callGraphs.graph(files);
+		}
+		return this.graph;
+	}
+
+	callersOf(id: string): CallEdge[] {
+		return this.callGraph().callersOf(id);
+	}
+
+	calleesOf(id: string): CallEdge[] {
+		return this.callGraph().calleesOf(id);
+	}
+
+	/**
+	 * Символы, упоминающие данный: типы в сигнатурах, receiver'ах, полях
+	 * и телах, а также разрешённые вызовы. Неоднозначные упоминания
+	 * не попадают в результат
+	 *
+	 * Кроме id символа принимает `<import path>.<name>` для внешних
+	 * пакетов: `context.Context` объединяет `ctx.Context` и `context.Context`.
+	 */
+	referencesTo(id: string): SymbolReference[] {
+		this.callGraph();
+		if (!this.references || this.referencesDirty.size > 0) {
+			this.references = this.referenceBuilder.update(
+				this.indexedFiles(),
+				this.callGraphs,
+				this.references ? this.referencesDirty : undefined,
+				this.referenceCallsDirty
+			);
+			this.referencesDirty.clear();
+			this.referenceCallsDirty.clear();
+		}
+		return this.references.referencesTo(id);
+	}
+
+	/**
+	 * Компактный фрагмент кода символа по его id (null, если id не найден)
+	 *
+	 * Файл читается с диска только ради contextLines.
+	 */
+	async snippetFor(
+		id: string,
+		options: SnippetOptions = {}
+	): Promise<string | null> {
+		const symbols = this.getSymbols();
+		const symbol = symbols.find((s) => s.id === id);
+		if (!symbol) {
+			return null;
+		}
+		const source = options.contextLines
+			? await readSource(symbol.path).catch(() => undefined)
+			: undefined;
+		return renderSnippet(symbol, symbols, source, options);
+	}
+
+	/**
+	 * Фрагменты символов по id, уложенные в бюджет токенов (см. packContext)
+	 */
+	packContext(
+		symbolIds: string[],
+		tokenBudget: number,
+		options: PackContextOptions = {}
+	): PackedContext {
+		return packContext(
+			this.getSymbols(),
+			symbolIds,
+			tokenBudget,
+			this.withEditTimes(options)
+		);
+	}
+
+	/**
+	 * Прикрепить заметку к символу по его id
+	 *
+	 * Заметки хранятся отдельно от символов и переживают updateFile, пока
+	 * id не меняется. Заметки удалённого символа хранятся в orphaned
+	 * в течение grace period и возвращаются, если символ появится снова.
+	 */
+	annotate(symbolId: string, key: string, value: string): void {
+		this.annotationStore.annotate(symbolId, key, value);
+	}
+
+	annotationsFor(symbolId: string): Annotations {
+		return this.annotationStore.annotationsFor(symbolId);
+	}
+
+	/**
+	 * Хранилище заметок (orphaned, удаление заметок)
+	 */
+	annotations(): AnnotationStore {
+		return this.annotationStore;
+	}
+
+	/**
+	 * Типы индекса, структурно реализующие интерфейс
+	 */
+	implementationsOf(interfaceName: string): InterfaceImplementation[] {
+		return implementationsOf(this.getSymbols(), interfaceName);
+	}
+
+	/**
+	 * Method sets значения и указателя для типа (`User` или `pkg.User`)
+	 */
+	methodSetsFor(typeName: string): MethodSets | null {
+		return methodSetsFor(this.getSymbols(), typeName);
+	}
+
+	/**
+	 * Поля структуры с продвинутыми из встроенных (`Admin` -> `User.Name`)
+	 */
+	fieldsFor(typeName: string): StructField[] | null {
+		return fieldsFor(this.getSymbols(), typeName);
+	}
+
+	/**
+	 * Перепарсить файл path и применить разницу с символами файла from
+	 * (при переименовании from — старый путь)
+	 *
+	 * Парсинг асинхронный, а всё остальное выполняется синхронно после него:
+	 * старые символы берутся в момент применения, а не до парсинга.
+	 */
+	private async reindex(
+		path: string,
+		content: string | undefined,
+		from: string
+	): Promise<SymbolChange[]> {
+		const parser = this.registry.parserForFile(path);
+		if (!parser) {
+			log.debug("No parser for file", { file: path });
+			// Переименование в неподдерживаемый файл — удаление старого
+			return from === path ? [] : this.drop(from);
+		}
+
+		let source: string;
+		let result: ParseResult;
+		try {
+			source = content ?? (await readSource(path));
+			result = this.cache
+				? await this.cache.parseSourceWithDiagnostics(path, source, parser)
+				: await parser.parseSourceWithDiagnostics(path, source);
+		} catch (err) {
+			this.emitter.emit([
+				{ type: "indexError", diagnostics: [], error: String(err), path },
+			]);
+			throw err;
+		}
+		const parsed = assignSymbolHashes(
+			assignSymbolIds(result.symbols, this.root)
+		);
+		const errors = result.diagnostics.filter((d) => d.severity === "error");
+
+		const events: IndexEvent[] = [];
+		const previous = this.files.get(from) ?? [];
+		if (from !== path) {
+			if (this.files.has(from)) {
+				const symbolIds = previous.map(eventSymbolId);
+				events.push({ type: "fileRemoved", path: from, symbolIds });
+			}
+			this.forget(from);
+		}
+		const diff = diffSymbols(path, previous, parsed, errors);
+		this.evicted.delete(path);
+		this.setFileSymbols(path, diff.symbols);
+		if (diff.changes.length > 0) {
+			this.editTimes.set(path, Date.now());
+		}
+		this.hashes.set(path, hashSource(source));
+		if (result.diagnostics.length > 0) {
+			this.diagnostics.set(path, result.diagnostics);
+		} else {
+			this.diagnostics.delete(path);
+		}
+		this.evictOverCapacity(path);
+
+		events.push({
+			type: "fileIndexed",
+			diagnostics: result.diagnostics,
+			path,
+			symbolIds: diff.symbols.map(eventSymbolId),
+		});
+		if (errors.length > 0) {
+			events.push({ type: "indexError", diagnostics: errors, path });
+		}
+		events.push(...symbolEvents(diff.changes, diff.before));
+		this.emit(diff.changes, events);
+		return diff.changes;
+	}
+
+	/**
+	 * Поставить асинхронное обновление в очередь за уже запущенными
+	 */
+	private enqueue<T>(task: () => Promise<T>): Promise<T> {
+		if (this.closed) {
+			return Promise.reject(new Error("SymbolIndex is disposed"));
+		}
+		const run = this.writes.then(task);
+		this.writes = run.catch(() => undefined);
+		return run;
+	}
+
+	private assertOpen(): void {
+		if (this.closed) {
+			throw new Error("SymbolIndex is disposed");
+		}
+	}
+
+	private async shutdown(): Promise<void> {
+		for (const disposer of this.disposers) {
+			try {
+				await disposer();
+			} catch (err) {
+				log.warn("Index disposer failed", { error: String(err) });
+			}
+		}
+		this.disposers.clear();
+		await this.writes;
+		await this.cache?.flush();
+		this.listeners.clear();
+		this.emitter.clear();
+	}
+
+	/**
+	 * Опции с editedAt из истории обновлений, если нужен вес recency
+	 */
+	private withEditTimes<
+		T extends {
+			editedAt?: Record<string, number>;
+			scoring?: Partial<ScoringConfig>;
+		},
+	>(options: T): T {
+		if (options.editedAt || !options.scoring?.recency) {
+			return options;
+		}
+		return { ...options, editedAt: Object.fromEntries(this.editTimes) };
+	}
+
+	/**
+	 * Файл дампа под ключом этого индекса
+	 *
+	 * Дамп мог быть снят с другим root (на другой машине), поэтому путь
+	 * берётся из relativePath символов и разрешается от своего root, как
+	 * в updateFile; файл без символов остаётся под путём из дампа.
+	 */
+	private importedFile(
+		path: string,
+		symbols: EnhancedCodeSymbol[]
+	): [string, EnhancedCodeSymbol[]] {
+		const relative = symbols.find((s) => s.relativePath)?.relativePath;
+		const key = this.resolvePath(relative ?? path);
+		return [key, symbols.map((symbol) => ({ ...symbol, path: key }))];
+	}
+
+	private setFileSymbols(path: string, symbols: EnhancedCodeSymbol[]): void {
+		this.files.set(path, symbols);
+		this.lru?.set(path, symbols);
+		this.summary.set(path, symbols);
+		this.markDirty(path);
+	}
+
+	private markDirty(path: string): void {
+		this.graphDirty.add(path);
+		this.referencesDirty.add(path);
+	}
+
+	private clearFiles(): void {
+		this.files.clear();
+		this.diagnostics.clear();
+		this.hashes.clear();
+		this.editTimes.clear();
+		this.evicted.clear();
+		this.lru?.clear();
+		this.summary.clear();
+		this.graph = null;
+		this.references = null;
+		this.graphDirty.clear();
+		this.referencesDirty.clear();
+		this.referenceCallsDirty.clear();
+	}
+
+	/**
+	 * Забыть файл и сообщить об удалении его символов
+	 */
+	private drop(path: string): SymbolChange[] {
+		const previous =
+			this.files.get(path) ??
+			(this.evicted.has(path) ? (this.cachedSymbols(path) ?? []) : undefined);
+		if (!previous) {
+			this.forget(path);
+			return [];
+		}
+
+		this.forget(path);
+		const changes = previous.map(
+			(symbol): SymbolChange => ({ path, symbol, type: "removed" })
+		);
+		this.emit(changes, [
+			{ type: "fileRemoved", path, symbolIds: previous.map(eventSymbolId) },
+			...symbolEvents(changes),
+		]);
+		return changes;
+	}
+
+	private forget(path: string): void {
+		this.files.delete(path);
+		this.evicted.delete(path);
+		this.diagnostics.delete(path);
+		this.hashes.delete(path);
+		this.editTimes.delete(path);
+		this.lru?.delete(path);
+		this.summary.delete(path);
+		this.markDirty(path);
+	}
+
+	/**
+	 * Вытеснить символы давно не использованных файлов сверх лимита
+	 *
+	 * Хеш содержимого остаётся, чтобы при загрузке взять результат из
+	 * кеша парсинга. Диагностики тоже остаются: они маленькие.
+	 */
+	private evictOverCapacity(except?: string): void {
+		const victims = this.lru?.evict(except) ?? [];
+		for (const path of victims) {
+			this.files.delete(path);
+			this.evicted.add(path);
+			this.markDirty(path);
+		}
+	}
+
+	/**
+	 * Загрузить символы вытесненного файла без событий изменения
+	 */
+	private async restore(path: string): Promise<EnhancedCodeSymbol[]> {
+		const symbols = await this.load(path);
+		this.evicted.delete(path);
+		this.setFileSymbols(path, symbols);
+		return symbols;
+	}
+
+	/**
+	 * Символы вытесненного файла: из кеша парсинга по хешу содержимого,
+	 * а если записи нет — перепарсингом с диска. Индекс не меняется
+	 */
+	private async load(path: string): Promise<EnhancedCodeSymbol[]> {
+		const parser = this.registry.parserForFile(path);
+		const hash = this.hashes.get(path);
+		const cached =
+			parser && hash && this.cache
+				? await this.cache.lookup(path, hash, parser)
+				: null;
+		let result = cached;
+		if (!result && parser) {
+			const source = await readSource(path);
+			this.hashes.set(path, hashSource(source));
+			result = this.cache
+				? await this.cache.parseSourceWithDiagnostics(path, source, parser)
+				: await parser.parseSourceWithDiagnostics(path, source);
+		}
+		return assignSymbolHashes(
+			assignSymbolIds(result?.symbols ?? [], this.root)
+		);
+	}
+
+	/**
+	 * Символы вытесненного файла из уже прочитанного кеша парсинга
+	 * (синхронно; null, если записи нет)
+	 */
+	private cachedSymbols(path: string): EnhancedCodeSymbol[] | null {
+		const parser = this.registry.parserForFile(path);
+		const hash = this.hashes.get(path);
+		const result = parser && hash && this.cache?.peek(path, hash, parser);
+		return result
+			? assignSymbolHashes(assignSymbolIds(result.symbols, this.root))
+			: null;
+	}
+
+	/**
+	 * Символы всех файлов в порядке getFiles(): загруженные и вытесненные,
+	 * которые есть в кеше парсинга
+	 */
+	private indexedFiles(): ReadonlyMap<string, EnhancedCodeSymbol[]> {
+		if (this.evicted.size === 0) {
+			return this.files;
+		}
+		const files = new Map(this.files);
+		for (const path of this.evicted) {
+			const symbols = this.cachedSymbols(path);
+			if (symbols) {
+				files.set(path, symbols);
+			}
+		}
+		return files;
+	}
+
+	/**
+	 * Сообщить об изменениях подписчикам onChange и пачку событий —
+	 * подписчикам events()
+	 */
+	private emit(changes: SymbolChange[], events: IndexEvent[] = []): void {
+		if (changes.length > 0) {
+			this.syncAnnotations(changes);
+			for (const listener of this.listeners) {
+				try {
+					listener(changes);
+				} catch (err) {
+					log.warn("Symbol change listener failed", { error: String(err) });
+				}
+			}
+		}
+		this.emitter.emit(events);
+	}
+
+	/**
+	 * Заметки удалённых символов -> orphaned, вернувшихся -> обратно
+	 */
+	private syncAnnotations(changes: SymbolChange[]): void {
+		for (const change of changes) {
+			if (change.type === "removed" && change.symbol.id) {
+				this.annotationStore.orphan(change.symbol.id);
+			}
+		}
+		for (const change of changes) {
+			if (change.type === "added" && change.symbol.id) {
+				this.annotationStore.restore(change.symbol.id);
+			}
+		}
+		this.annotationStore.prune();
+	}
+}
+
+/**
+ * Сопоставить новые символы файла со старыми
+ *
+ * Совпавшие по identityKey старые объекты переиспользуются и обновляются
+ * на месте; изменение тела или документации даёт событие "changed".
+ * Несовпавшие старые символы на строках с ошибками сохраняются без событий.
+ */
+function diffSymbols(
+	path: string,
+	previous: EnhancedCodeSymbol[],
+	parsed: EnhancedCodeSymbol[],
+	errors: ParseDiagnostic[] = []
+): {
+	/** Копии изменённых символов до обновления на месте */
+	before: Map<EnhancedCodeSymbol, EnhancedCodeSymbol>;
+	changes: SymbolChange[];
+	symbols: EnhancedCodeSymbol[];
+} {
+	const pool = new Map<string, EnhancedCodeSymbol[]>();
+	for (const symbol of previous) {
+		const key = identityKey(symbol);
+		const bucket = pool.get(key);
+		if (bucket) {
+			bucket.push(symbol);
+		} else {
+			pool.set(key, [symbol]);
+		}
+	}
+
+	const changes: SymbolChange[] = [];
+	const symbols: EnhancedCodeSymbol[] = [];
+	const before = new Map<EnhancedCodeSymbol, EnhancedCodeSymbol>();
+
+	for (const fresh of parsed) {
+		const existing = pool.get(identityKey(fresh))?.shift();
+		if (!existing) {
+			symbols.push(fresh);
+			changes.push({ path, symbol: fresh, type: "added" });
+			continue;
+		}
+
+		const scope = changeScope(existing, fresh);
+		const moved = existing.path !== fresh.path;
+		if (scope || moved) {
+			before.set(existing, { ...existing });
+		}
+		Object.assign(existing, fresh);
+		symbols.push(existing);
+		if (scope) {
+			changes.push({ path, scope, symbol: existing, type: "changed" });
+		} else if (moved) {
+			changes.push({ path, symbol: existing, type: "changed" });
+		}
+	}
+
+	let retained = false;
+	for (const bucket of pool.values()) {
+		for (const symbol of bucket) {
+			if (errors.some((error) => overlapsLines(symbol, error))) {
+				symbols.push(symbol);
+				retained = true;
+				continue;
+			}
+			changes.push({ path, symbol, type: "removed" });
+		}
+	}
+
+	if (retained) {
+		symbols.sort((a, b) => a.startLine - b.startLine);
+	}
+	return { before, changes, symbols };
+}
+
+/**
+ * События символов для изменений одной операции
+ */
+function symbolEvents(
+	changes: SymbolChange[],
+	before = new Map<EnhancedCodeSymbol, EnhancedCodeSymbol>()
+): IndexEvent[] {
+	return changes.map(({ path, symbol, type }): IndexEvent => {
+		const id = eventSymbolId(symbol);
+		if (type === "added") {
+			return { type: "symbolAdded", id, path, symbol };
+		}
+		if (type === "removed") {
+			return { type: "symbolRemoved", id, path, symbol };
+		}
+		const diff = diffSymbol(before.get(symbol) ?? symbol, symbol);
+		return { type: "symbolChanged", diff, id, path, symbol };
+	});
+}
+
+function overlapsLines(
+	symbol: EnhancedCodeSymbol,
+	diagnostic: ParseDiagnostic
+): boolean {
+	return (
+		symbol.startLine <= diagnostic.range.endLine &&
+		diagnostic.range.startLine <= symbol.endLine
+	);
+}
+
+/**
+ * Привести дамп индекса к текущей версии схемы
+ */
+export function migrateIndexDocument(doc: unknown): IndexDocument {
+	const candidate = doc as Partial<IndexDocument> | null;
+	const version = candidate?.schemaVersion;
+	if (typeof version !== "number" || !Array.isArray(candidate?.files)) {
+		throw new Error("Invalid index document");
+	}
+	if (version > INDEX_SCHEMA_VERSION) {
+		throw new Error(
+			`Index schema version ${version} is newer than supported ${INDEX_SCHEMA_VERSION}`
+		);
+	}
+	// Пока версия одна; миграции добавляются сюда по мере изменения схемы
+	return {
+		schemaVersion: INDEX_SCHEMA_VERSION,
+		files: candidate.files,
+		relationships: candidate.relationships ?? { calls: [] },
+	};
+}
@@ -0,0 +1,119 @@
+import { extname } from "node:path";
+import type { EnhancedCodeSymbol } from "./parsers/types.ts";
+
+/**
+ * Канонические виды символов, общие для всех языков
+ *
+ * Парсеры выдают symbolType в терминах своего языка (Go struct — "class",
+ * Rust trait — "interface"), kindOf приводит их к этому набору:
+ *
+ * - function — функции, React-компоненты и хуки (TS/JS), `def` (Python)
+ * - method — методы классов (TS/JS/Python), Go-методы с receiver, Rust impl
+ * - struct — Go `type T struct`, Rust `struct`
+ * - class — классы TS/JS/Python
+ * - interface — Go/TS interface
+ * - trait — Rust trait (отдельный вид: это не только контракт, но и
+ *   default-реализации)
+ * - enum — TS/Rust enum
+ * - type-alias — TS `type`, Rust `type`, Go `type A = B` и именованные
+ *   типы `type Celsius float64`
+ * - constant, variable — const и var/let уровня модуля
+ * - field — поле структуры или класса
+ * - package — package clause Go
+ */
+export const SYMBOL_KINDS = [
+	"function",
+	"method",
+	"struct",
+	"interface",
+	"enum",
+	"type-alias",
+	"constant",
+	"variable",
+	"field",
+	"class",
+	"trait",
+	"package",
+] as const;
+
+export type SymbolKind = (typeof SYMBOL_KINDS)[number];
+
+/**
+ * Синонимы видов из разных языков и инструментов -> канонический вид
+ */
+export const KIND_ALIASES: Readonly<Record<string, SymbolKind>> = {
+	alias: "type-alias",
+	component: "function",
+	const: "constant",
+	def: "function",
+	fn: "function",
+	func: "function",
+	hook: "function",
+	let: "variable",
+	property: "field",
+	type: "type-alias",
+	typealias: "type-alias",
+	var: "variable",
+};
+
+const CANONICAL = new Set<string>(SYMBOL_KINDS);
+
+/**
+ * Привести имя вида (`func`, `struct`, `type`) к каноническому
+ *
+ * @param aliases - таблица синонимов, по умолчанию KIND_ALIASES
+ * @returns undefined для неизвестного имени
+ */
+export function normalizeKind(
+	name: string,
+	aliases: Readonly<Record<string, SymbolKind>> = KIND_ALIASES
+): SymbolKind | undefined {
+	const key = name.trim().toLowerCase();
+	if (CANONICAL.has(key)) {
+		return key as SymbolKind;
+	}
+	return aliases[key] ?? aliases[key.replace(/[-_\s]/g, "")];
+}
+
+/**
+ * Канонический вид символа
+ */
+export function kindOf(symbol: EnhancedCodeSymbol): SymbolKind {
+	const meta = symbol.metadata;
+	switch (symbol.symbolType) {
+		case "class":
+			// В Rust нет классов: struct_item выдаётся как "class"
+			return meta?.typeKind === "struct" || extname(symbol.path) === ".rs"
+				? "struct"
+				: "class";
+		case "interface":
+			return meta?.language?.rustTrait ? "trait" : "interface";
+		case "type":
+			return "type-alias";
+		case "hook":
+		case "component":
+			return "function";
+		default:
+			return symbol.symbolType;
+	}
+}
+
+/**
+ * Множество канонических видов фильтра; синонимы нормализуются, неизвестные
+ * имена отбрасываются
+ */
+export function kindFilter(
+	kinds: readonly string[] | undefined
+): Set<SymbolKind> | null {
+	if (!kinds) {
+		return null;
+	}
+	const set = new Set<SymbolKind>();
+	for (const kind of kinds) {
+		const normalized = normalizeKind(kind);
+		if (normalized) {
+			set.add(normalized);
+		}
+	}
+	return set;
+}
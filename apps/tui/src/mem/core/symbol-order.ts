@@ -0,0 +1,65 @@
+import type { EnhancedCodeSymbol, StructField } from "./parsers/types.ts";
+import { kindOf, SYMBOL_KINDS } from "./symbol-kind.ts";
+
+/**
+ * Порядок вывода символов
+ *
+ * - source — порядок объявления: по файлу, затем по строке
+ * - name — по имени
+ * - kind-then-name — по каноническому виду (порядок SYMBOL_KINDS), затем
+ *   по имени; детерминирован независимо от порядка парсинга
+ */
+export type SymbolOrder = "source" | "name" | "kind-then-name";
+
+const KIND_RANK = new Map<string, number>(SYMBOL_KINDS.map((k, i) => [k, i]));
+
+function bySource(a: EnhancedCodeSymbol, b: EnhancedCodeSymbol): number {
+	return a.path.localeCompare(b.path) || a.startLine - b.startLine;
+}
+
+function byName(a: EnhancedCodeSymbol, b: EnhancedCodeSymbol): number {
+	return a.name.localeCompare(b.name) || bySource(a, b);
+}
+
+/**
+ * Компаратор символов для заданного порядка
+ */
+export function symbolComparator(
+	order: SymbolOrder
+): (a: EnhancedCodeSymbol, b: EnhancedCodeSymbol) => number {
+	switch (order) {
+		case "source":
+			return bySource;
+		case "name":
+			return byName;
+		case "kind-then-name":
+			return (a, b) =>
+				(KIND_RANK.get(kindOf(a)) ?? 0) - (KIND_RANK.get(kindOf(b)) ?? 0) ||
+				byName(a, b);
+	}
+}
+
+/**
+ * Отсортированная копия символов
+ */
+export function orderSymbols(
+	symbols: EnhancedCodeSymbol[],
+	order: SymbolOrder
+): EnhancedCodeSymbol[] {
+	return [...symbols].sort(symbolComparator(order));
+}
+
+/**
+ * Поля структуры в заданном порядке: source — как объявлены, иначе по
+ * имени (встроенные поля — по типу)
+ */
+export function orderFields(
+	fields: StructField[],
+	order: SymbolOrder
+): StructField[] {
+	if (order === "source") {
+		return fields;
+	}
+	const key = (f: StructField) => f.name ?? f.type;
+	return [...fields].sort((a, b) => key(a).localeCompare(key(b)));
+}
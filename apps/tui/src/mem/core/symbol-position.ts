@@ -0,0 +1,90 @@
+import { packageKey } from "./call-graph.ts";
+import type { EnhancedCodeSymbol } from "./parsers/types.ts";
+
+function lineSpan(symbol: EnhancedCodeSymbol): [start: number, end: number] {
+	const range = symbol.metadata?.range;
+	return range
+		? [range.startLine, range.endLine]
+		: [symbol.startLine, symbol.endLine];
+}
+
+function containsLine(symbol: EnhancedCodeSymbol, line: number): boolean {
+	const [start, end] = lineSpan(symbol);
+	return start <= line && line <= end;
+}
+
+/**
+ * Символ пакета файла; если парсер его не выдаёт, строится из packageName
+ */
+function packageOf(
+	fileSymbols: EnhancedCodeSymbol[]
+): EnhancedCodeSymbol | undefined {
+	const clause = fileSymbols.find((s) => s.symbolType === "package");
+	if (clause) {
+		return clause;
+	}
+	const first = fileSymbols.find((s) => s.metadata?.packageName);
+	const name = first?.metadata?.packageName;
+	if (!(first && name)) {
+		return undefined;
+	}
+	return {
+		name,
+		symbolType: "package",
+		path: first.path,
+		startLine: 1,
+		endLine: Math.max(...fileSymbols.map((s) => s.endLine)),
+		body: `package ${name}`,
+		calls: [],
+		imports: [],
+		metadata: { isExported: true, packageName: name },
+	};
+}
+
+/**
+ * Символ под курсором и цепочка его владельцев, от внутреннего к внешнему
+ *
+ * Вложенность определяется по диапазонам (замыкание в функции, метод в
+ * классе). Go-метод объявлен вне своего типа, поэтому после него идёт
+ * receiver-тип того же пакета, даже из другого файла. Последний элемент —
+ * пакет файла; строка вне всех символов даёт только его.
+ *
+ * @param symbols - символы индекса (receiver-тип ищется среди всех)
+ * @param line - номер строки, с 1
+ */
+export function symbolsAtPosition(
+	symbols: EnhancedCodeSymbol[],
+	filePath: string,
+	line: number
+): EnhancedCodeSymbol[] {
+	const fileSymbols = symbols.filter((s) => s.path === filePath);
+	const chain = fileSymbols
+		.filter((s) => s.symbolType !== "package" && containsLine(s, line))
+		.sort((a, b) => {
+			const [aStart, aEnd] = lineSpan(a);
+			const [bStart, bEnd] = lineSpan(b);
+			// Внутренний символ короче; при равной длине — объявленный позже
+			return aEnd - aStart - (bEnd - bStart) || bStart - aStart;
+		});
+
+	const outermost = chain.at(-1);
+	const receiver = outermost?.metadata?.receiver;
+	if (outermost && receiver) {
+		const owner = symbols.find(
+			(s) =>
+				s.name === receiver &&
+				s.symbolType !== "method" &&
+				s.symbolType !== "function" &&
+				packageKey(s) === packageKey(outermost)
+		);
+		if (owner && !chain.includes(owner)) {
+			chain.push(owner);
+		}
+	}
+
+	const pkg = packageOf(fileSymbols);
+	if (pkg) {
+		chain.push(pkg);
+	}
+	return chain;
+}
@@ -0,0 +1,140 @@
+import { resolve } from "node:path";
+import type { CodeMarker, EnhancedCodeSymbol } from "./parsers/types.ts";
+import { kindFilter, kindOf } from "./symbol-kind.ts";
+import type { SymbolOrder } from "./symbol-order.ts";
+import { symbolComparator } from "./symbol-order.ts";
+
+const TYPE_SYMBOL_TYPES = new Set(["class", "interface", "type", "enum"]);
+
+/**
+ * Получить объявление типа вместе со всеми его методами
+ *
+ * Методы связываются с типом через metadata.receiver, поэтому для `User`
+ * вернутся и сама структура, и `User.GetName`, `User.SetAge` и т.д.
+ * Для типизированного перечисления (`type Color int` + iota) между
+ * объявлением и методами идут константы с metadata.enumType.
+ */
+export function symbolsForType(
+	symbols: EnhancedCodeSymbol[],
+	typeName: string
+): EnhancedCodeSymbol[] {
+	const declarations = symbols.filter(
+		(s) => s.name === typeName && TYPE_SYMBOL_TYPES.has(s.symbolType)
+	);
+	const members = symbols.filter(
+		(s) => s.symbolType === "constant" && s.metadata?.enumType === typeName
+	);
+	const methods = symbols.filter(
+		(s) => s.symbolType === "method" && s.metadata?.receiver === typeName
+	);
+	return [...declarations, ...members, ...methods];
+}
+
+export interface ListSymbolsOptions {
+	/** Только устаревшие символы (metadata.deprecated) */
+	deprecatedOnly?: boolean;
+	/** Исключить интерфейсы-ограничения дженериков (Go `int | float64`) */
+	excludeConstraints?: boolean;
+	exportedOnly?: boolean;
+	/** Включить символы тестовых файлов (metadata.isTest), по умолчанию нет */
+	includeTests?: boolean;
+	/** Канонические виды или их синонимы (`func`, `struct`), см. kindOf */
+	kinds?: string[];
+	/** Порядок результата; без него — порядок входного списка */
+	order?: SymbolOrder;
+	/** Путь к файлу или имя пакета */
+	scope?: string;
+	/**
+	 * По убыванию metadata.metrics.complexity или loc; символы без метрик
+	 * идут после остальных. Равные по метрике остаются в порядке order
+	 */
+	sortBy?: "complexity" | "loc";
+}
+
+function metricOf(
+	symbol: EnhancedCodeSymbol,
+	sortBy: "complexity" | "loc"
+): number {
+	return symbol.metadata?.metrics?.[sortBy] ?? -1;
+}
+
+/**
+ * Отфильтровать символы по виду, экспорту, deprecation и области (файл
+ * или пакет)
+ *
+ * Символы тестовых файлов исключаются, если не передан includeTests.
+ */
+export function listSymbols(
+	symbols: EnhancedCodeSymbol[],
+	options: ListSymbolsOptions = {}
+): EnhancedCodeSymbol[] {
+	const kinds = kindFilter(options.kinds);
+	const scopePath = options.scope ? resolve(options.scope) : null;
+
+	const filtered = symbols.filter((s) => {
+		if (kinds && !kinds.has(kindOf(s))) {
+			return false;
+		}
+		if (options.exportedOnly && !s.metadata?.isExported) {
+			return false;
+		}
+		if (options.deprecatedOnly && !s.metadata?.deprecated) {
+			return false;
+		}
+		if (!options.includeTests && s.metadata?.isTest) {
+			return false;
+		}
+		if (options.excludeConstraints && s.metadata?.isConstraint) {
+			return false;
+		}
+		if (
+			options.scope &&
+			resolve(s.path) !== scopePath &&
+			s.metadata?.packageName !== options.scope
+		) {
+			return false;
+		}
+		return true;
+	});
+
+	if (options.order) {
+		filtered.sort(symbolComparator(options.order));
+	}
+	const sortBy = options.sortBy;
+	if (!sortBy) {
+		return filtered;
+	}
+	// sort стабилен: при равной метрике сохраняется исходный порядок
+	return filtered.sort((a, b) => metricOf(b, sortBy) - metricOf(a, sortBy));
+}
+
+export interface SymbolMarker {
+	marker: CodeMarker;
+	symbol: EnhancedCodeSymbol;
+}
+
+export interface ListMarkersOptions {
+	/** Только эти виды маркеров (`TODO`, `FIXME`); по умолчанию все */
+	kinds?: string[];
+}
+
+/**
+ * Маркеры тех. долга всех символов по файлам и строкам
+ */
+export function listMarkers(
+	symbols: EnhancedCodeSymbol[],
+	options: ListMarkersOptions = {}
+): SymbolMarker[] {
+	const kinds = options.kinds && new Set(options.kinds);
+	return symbols
+		.flatMap((symbol) =>
+			(symbol.metadata?.markers ?? [])
+				.filter((marker) => !kinds || kinds.has(marker.kind))
+				.map((marker) => ({ marker, symbol }))
+		)
+		.sort(
+			(a, b) =>
+				a.symbol.path.localeCompare(b.symbol.path) ||
+				a.marker.line - b.marker.line
+		);
+}
@@ -0,0 +1,390 @@
+import type { EnhancedCodeSymbol } from "./parsers/types.ts";
+import type { ScoringConfig } from "./scoring.ts";
+import { resolveScoring, symbolBonus } from "./scoring.ts";
+import { kindFilter, kindOf } from "./symbol-kind.ts";
+
+const DEFAULT_LIMIT = 50;
+// Вес символа подпоследовательности в начале слова и сразу за предыдущим
+const BOUNDARY_BONUS = 2;
+const CONSECUTIVE_BONUS = 1;
+const QUERY_SEPARATOR_RE = /[\s_\-.]+/g;
+
+export interface SymbolSearchOptions {
+	/** Время последней правки по пути файла (мс с эпохи) для веса recency */
+	editedAt?: Record<string, number>;
+	/** Искать также по doc-комментариям */
+	includeDocs?: boolean;
+	/** Оставить только символы этих видов (канонических, см. kindOf) */
+	kinds?: string[];
+	limit?: number;
+	/** Веса ранжирования поверх DEFAULT_SCORING */
+	scoring?: Partial<ScoringConfig>;
+}
+
+/**
+ * Совпавший фрагмент: полуоткрытый диапазон [start, end) в поле символа
+ *
+ * Смещения — индексы UTF-16 в исходной строке поля (имя или документация),
+ * их можно передавать в String.slice без пересчёта.
+ */
+export interface SearchMatchRange {
+	end: number;
+	field: "name" | "doc";
+	start: number;
+}
+
+export interface SymbolSearchResult {
+	matches: SearchMatchRange[];
+	score: number;
+	symbol: EnhancedCodeSymbol;
+}
+
+interface NameMatch {
+	ranges: SearchMatchRange[];
+	score: number;
+}
+
+/**
+ * Нечёткий поиск символов по имени
+ *
+ * Ранжирование (от лучшего к худшему): точное совпадение имени, префикс,
+ * подстрока на границе слова (camelCase, `_`), произвольная подстрока,
+ * совпадение по квалификатору (`User.GetName` для "user"), подпоследовательность
+ * символов с приоритетом начал слов camelCase/snake_case ("gn" -> GetName,
+ * "new_user" -> NewUser). При `includeDocs` совпадение в документации даёт
+ * слабый балл, а его фрагменты добавляются в matches и при совпавшем имени.
+ * Веса совпадений и надбавки за вид, экспорт и свежесть задаёт scoring.
+ */
+export function searchSymbols(
+	symbols: EnhancedCodeSymbol[],
+	query: string,
+	options: SymbolSearchOptions = {}
+): SymbolSearchResult[] {
+	const needle = fold(query.trim()).lower;
+	if (!needle) {
+		return [];
+	}
+
+	const kinds = kindFilter(options.kinds);
+	const config = resolveScoring(options.scoring);
+	const now = Date.now();
+	const results: SymbolSearchResult[] = [];
+
+	for (const symbol of symbols) {
+		if (kinds && !kinds.has(kindOf(symbol))) {
+			continue;
+		}
+
+		const name = matchName(symbol.name, needle);
+		const doc = options.includeDocs ? matchDoc(symbol, [needle]) : null;
+		if (name || doc) {
+			const relevance = Math.max(
+				name ? name.score * config.name : Number.NEGATIVE_INFINITY,
+				doc ? doc.score * config.doc : Number.NEGATIVE_INFINITY
+			);
+			const score =
+				relevance + symbolBonus(symbol, config, options.editedAt, now);
+			const matches = [...(name?.ranges ?? []), ...(doc?.ranges ?? [])];
+			results.push({ symbol, score, matches });
+		}
+	}
+
+	results.sort(
+		(a, b) =>
+			b.score - a.score ||
+			a.symbol.name.length - b.symbol.name.length ||
+			a.symbol.name.localeCompare(b.symbol.name) ||
+			a.symbol.path.localeCompare(b.symbol.path)
+	);
+
+	return results.slice(0, options.limit ?? DEFAULT_LIMIT);
+}
+
+/**
+ * Фрагменты имени и документации, совпавшие с запросом
+ *
+ * Для подсветки результатов, найденных не по имени (semanticSearch):
+ * имя сравнивается со всем запросом как в searchSymbols, а если не
+ * совпало — ищутся вхождения отдельных слов запроса.
+ */
+export function highlightMatches(
+	symbol: EnhancedCodeSymbol,
+	query: string
+): SearchMatchRange[] {
+	const needle = fold(query.trim()).lower;
+	if (!needle) {
+		return [];
+	}
+	const terms = [...new Set(needle.split(/\s+/))];
+	const name =
+		matchName(symbol.name, needle)?.ranges ??
+		occurrences(fold(symbol.name), "name", terms);
+	return [...name, ...(matchDoc(symbol, terms)?.ranges ?? [])];
+}
+
+/**
+ * Строка в нижнем регистре и позиции её UTF-16 единиц в исходной
+ *
+ * toLowerCase может менять длину (`İ` -> `i̇`), поэтому поиск идёт по
+ * lower, а найденные диапазоны переводятся обратно через starts/ends —
+ * начало и конец исходного символа для каждой единицы lower.
+ */
+interface FoldedText {
+	ends: number[];
+	lower: string;
+	starts: number[];
+	text: string;
+}
+
+function fold(text: string): FoldedText {
+	const starts: number[] = [];
+	const ends: number[] = [];
+	let lower = "";
+	let offset = 0;
+	for (const ch of text) {
+		const folded = ch.toLowerCase();
+		for (let i = 0; i < folded.length; i++) {
+			starts.push(offset);
+			ends.push(offset + ch.length);
+		}
+		lower += folded;
+		offset += ch.length;
+	}
+	return { ends, lower, starts, text };
+}
+
+/**
+ * Диапазон [start, end) в lower -> диапазон в исходной строке
+ */
+function toRange(
+	folded: FoldedText,
+	field: SearchMatchRange["field"],
+	start: number,
+	end: number
+): SearchMatchRange {
+	return {
+		field,
+		start: folded.starts[start] ?? folded.text.length,
+		end: folded.ends[end - 1] ?? folded.text.length,
+	};
+}
+
+function matchName(name: string, needle: string): NameMatch | null {
+	const folded = fold(name);
+	const { lower } = folded;
+	const baseStart = lower.lastIndexOf(".") + 1;
+	const base = lower.slice(baseStart);
+
+	const range = (start: number): SearchMatchRange[] => [
+		toRange(folded, "name", start, start + needle.length),
+	];
+
+	if (base === needle) {
+		return { score: 1, ranges: range(baseStart) };
+	}
+	if (base.startsWith(needle)) {
+		return { score: 0.9, ranges: range(baseStart) };
+	}
+
+	const inBase = findBoundaryMatch(folded, baseStart, needle);
+	if (inBase !== null) {
+		return { score: 0.8, ranges: range(inBase) };
+	}
+
+	const substring = base.indexOf(needle);
+	if (substring !== -1) {
+		return { score: 0.7, ranges: range(baseStart + substring) };
+	}
+
+	// Совпадение в квалификаторе: тип-receiver метода или модуль
+	const qualified = lower.slice(0, baseStart).indexOf(needle);
+	if (qualified !== -1) {
+		return { score: 0.6, ranges: range(qualified) };
+	}
+
+	return matchSubsequence(folded, needle);
+}
+
+/**
+ * Найти вхождение needle после from, которое начинается на границе слова
+ */
+function findBoundaryMatch(
+	folded: FoldedText,
+	from: number,
+	needle: string
+): number | null {
+	let index = folded.lower.indexOf(needle, from + 1);
+	while (index !== -1) {
+		if (isWordBoundary(folded.text, folded.starts[index] ?? 0)) {
+			return index;
+		}
+		index = folded.lower.indexOf(needle, index + 1);
+	}
+	return null;
+}
+
+/**
+ * Начало слова: после `_`, `-`, `.`, переход регистра (`getName`) или
+ * последняя заглавная аббревиатуры перед строчной (`HTTPServer`)
+ */
+function isWordBoundary(name: string, index: number): boolean {
+	const prev = name[index - 1] ?? "";
+	const curr = name[index] ?? "";
+	const next = name[index + 1] ?? "";
+	if (prev === "_" || prev === "-" || prev === ".") {
+		return true;
+	}
+	if (!isUpper(curr)) {
+		return false;
+	}
+	return !isUpper(prev) || (prev !== "" && isLower(next));
+}
+
+function isUpper(ch: string): boolean {
+	return ch !== ch.toLowerCase();
+}
+
+function isLower(ch: string): boolean {
+	return ch !== ch.toUpperCase();
+}
+
+/**
+ * Подпоследовательность с лучшим выравниванием по границам слов
+ *
+ * Символ запроса в начале слова весит больше, чем в середине, подряд
+ * идущие символы — больше разрозненных, поэтому "gn" находит `GetName`
+ * выше `Gain`. Разделители в запросе не учитываются: "new_user"
+ * совпадает с `NewUser`.
+ */
+function matchSubsequence(
+	folded: FoldedText,
+	needle: string
+): NameMatch | null {
+	const chars = [...needle.replace(QUERY_SEPARATOR_RE, "")];
+	const { lower } = folded;
+	if (chars.length === 0) {
+		return null;
+	}
+	const atBoundary = Array.from(
+		{ length: lower.length },
+		(_, j) => j === 0 || isWordBoundary(folded.text, folded.starts[j] ?? 0)
+	);
+
+	// scores[i][j] — лучший балл, если chars[i] совпал с позиции j;
+	// from[i][j] — позиция chars[i - 1] в этом выравнивании
+	const scores: number[][] = [];
+	const from: number[][] = [];
+	for (const [i, ch] of chars.entries()) {
+		const row = new Array<number>(lower.length).fill(-1);
+		const back = new Array<number>(lower.length).fill(-1);
+		const prev = scores[i - 1];
+		// Символ вне BMP занимает две UTF-16 единицы
+		const prevLength = chars[i - 1]?.length ?? 0;
+		let bestPrev = -1;
+		let bestPrevAt = -1;
+		let k = 0;
+		for (let j = 0; j < lower.length; j++) {
+			for (; prev && k + prevLength <= j; k++) {
+				if ((prev[k] ?? -1) > bestPrev) {
+					bestPrev = prev[k] ?? -1;
+					bestPrevAt = k;
+				}
+			}
+			if (!lower.startsWith(ch, j) || (prev && bestPrev < 0)) {
+				continue;
+			}
+			const gain = 1 + (atBoundary[j] ? BOUNDARY_BONUS : 0);
+			const adjacent = prev?.[j - prevLength] ?? -1;
+			if (adjacent >= 0 && adjacent + CONSECUTIVE_BONUS >= bestPrev) {
+				row[j] = adjacent + CONSECUTIVE_BONUS + gain;
+				back[j] = j - prevLength;
+			} else {
+				row[j] = (prev ? bestPrev : 0) + gain;
+				back[j] = bestPrevAt;
+			}
+		}
+		scores.push(row);
+		from.push(back);
+	}
+
+	const last = scores.at(-1) ?? [];
+	let end = -1;
+	for (const [j, score] of last.entries()) {
+		if (score >= 0 && (end === -1 || score > (last[end] ?? -1))) {
+			end = j;
+		}
+	}
+	if (end === -1) {
+		return null;
+	}
+
+	const positions: number[] = [];
+	for (let i = chars.length - 1, j = end; i >= 0; i--) {
+		positions.unshift(j);
+		j = from[i]?.[j] ?? -1;
+	}
+	const spans: [number, number][] = [];
+	for (const [i, start] of positions.entries()) {
+		const stop = start + (chars[i]?.length ?? 1);
+		const span = spans.at(-1);
+		if (span && span[1] === start) {
+			span[1] = stop;
+		} else {
+			spans.push([start, stop]);
+		}
+	}
+
+	// Доля от идеального выравнивания (все символы с начала слов подряд)
+	// и плотность; балл не больше 0.5, ниже любой подстроки
+	const ideal =
+		chars.length * (1 + BOUNDARY_BONUS) +
+		(chars.length - 1) * CONSECUTIVE_BONUS;
+	const quality = (last[end] ?? 0) / ideal;
+	const density = chars.join("").length / folded.text.length;
+	return {
+		score: 0.2 + 0.2 * quality + 0.1 * Math.min(density, 1),
+		ranges: spans.map(([start, stop]) => toRange(folded, "name", start, stop)),
+	};
+}
+
+/**
+ * Все вхождения terms в текст, пересекающиеся вхождения объединены
+ */
+function occurrences(
+	folded: FoldedText,
+	field: SearchMatchRange["field"],
+	terms: string[]
+): SearchMatchRange[] {
+	const spans: [number, number][] = [];
+	for (const term of terms) {
+		let index = term ? folded.lower.indexOf(term) : -1;
+		while (index !== -1) {
+			spans.push([index, index + term.length]);
+			index = folded.lower.indexOf(term, index + term.length);
+		}
+	}
+	spans.sort((a, b) => a[0] - b[0]);
+
+	const merged: [number, number][] = [];
+	for (const [start, end] of spans) {
+		const last = merged.at(-1);
+		if (last && start <= last[1]) {
+			last[1] = Math.max(last[1], end);
+		} else {
+			merged.push([start, end]);
+		}
+	}
+	return merged.map(([start, end]) => toRange(folded, field, start, end));
+}
+
+function matchDoc(
+	symbol: EnhancedCodeSymbol,
+	terms: string[]
+): NameMatch | null {
+	const doc = symbol.metadata?.docComment ?? symbol.jsDoc;
+	if (!doc) {
+		return null;
+	}
+	const ranges = occurrences(fold(doc), "doc", terms);
+	return ranges.length > 0 ? { score: 0.1, ranges } : null;
+}
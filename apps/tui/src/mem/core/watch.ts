@@ -0,0 +1,202 @@
+import { type FSWatcher, type Stats, watch as fsWatch } from "node:fs";
+import { stat } from "node:fs/promises";
+import { join, resolve, sep } from "node:path";
+import { createLogger } from "../lib/logger.ts";
+import { loadIgnoreMatcher } from "./gitignore.ts";
+import { hashSource } from "./parse-cache.ts";
+import type { ParserRegistry } from "./parsers/parser-registry.ts";
+import { parserRegistry } from "./parsers/parser-registry.ts";
+import { readSource } from "./parsers/source-text.ts";
+import type { SymbolChange } from "./symbol-index.ts";
+import { SymbolIndex } from "./symbol-index.ts";
+import { listFiles } from "./workspace.ts";
+
+const log = createLogger("watch");
+
+const DEFAULT_DEBOUNCE_MS = 100;
+
+export interface WatchOptions {
+	/** Пауза после последнего события перед переиндексацией */
+	debounceMs?: number;
+	/** Дополнительные шаблоны в синтаксисе .gitignore: "vendor/", "*.pb.go" */
+	ignore?: string[];
+	/** Индекс для обновления; по умолчанию создаётся новый */
+	index?: SymbolIndex;
+	registry?: ParserRegistry;
+}
+
+export interface WorkspaceWatcher {
+	readonly index: SymbolIndex;
+	close(): void;
+	/** Обработать накопленные события сейчас, не дожидаясь debounce */
+	flush(): Promise<SymbolChange[]>;
+}
+
+/**
+ * Следить за workspace и инкрементально обновлять индекс
+ *
+ * События файловой системы копятся и обрабатываются одной пачкой после
+ * паузы debounceMs. Исчезнувший файл и появившийся файл с тем же
+ * содержимым в одной пачке считаются переименованием (renameFile), так
+ * что символы сохраняют идентичность. onChange получает только разницу
+ * символов пачки; пустые пачки не сообщаются.
+ *
+ * Событие на директорию индексирует её файлы (mkdir, перенос папки
+ * внутрь); удалённым путь считается, только если его больше нет.
+ * Ошибка чтения или парсинга одного файла логируется и не прерывает
+ * пачку.
+ */
+export async function watch(
+	root: string,
+	onChange: (changes: SymbolChange[]) => void,
+	options: WatchOptions = {}
+): Promise<WorkspaceWatcher> {
+	const rootPath = resolve(root);
+	const registry = options.registry ?? parserRegistry;
+	const index = options.index ?? new SymbolIndex({ registry, root: rootPath });
+	const matcher = await loadIgnoreMatcher(rootPath, options.ignore);
+	const debounceMs = options.debounceMs ?? DEFAULT_DEBOUNCE_MS;
+
+	const pending = new Set<string>();
+	let timer: ReturnType<typeof setTimeout> | null = null;
+	let running: Promise<SymbolChange[]> = Promise.resolve([]);
+
+	// Удалён файл или целая директория
+	const indexedUnder = (path: string): string[] => {
+		const prefix = `${path}${sep}`;
+		return index
+			.getFiles()
+			.filter((file) => file === path || file.startsWith(prefix));
+	};
+
+	// Переименование забирает исходный путь из removed
+	const reindex = async (
+		path: string,
+		removed: string[]
+	): Promise<SymbolChange[]> => {
+		const content = await readSource(path);
+		const hash = hashSource(content);
+		const renamedFrom = index.hasFile(path)
+			? -1
+			: removed.findIndex((old) => index.getContentHash(old) === hash);
+		if (renamedFrom === -1) {
+			return index.updateFile(path, content);
+		}
+		const [from] = removed.splice(renamedFrom, 1);
+		return index.renameFile(from as string, path, content);
+	};
+
+	const processBatch = async (): Promise<SymbolChange[]> => {
+		const paths = [...pending].sort();
+		pending.clear();
+
+		const removed: string[] = [];
+		const updated: string[] = [];
+		for (const path of paths) {
+			let info: Stats;
+			try {
+				info = await stat(path);
+			} catch (err) {
+				if ((err as NodeJS.ErrnoException).code !== "ENOENT") {
+					log.warn("Watch stat failed", { path, error: String(err) });
+					continue;
+				}
+				for (const file of indexedUnder(path)) {
+					if (!removed.includes(file)) {
+						removed.push(file);
+					}
+				}
+				continue;
+			}
+			if (info.isDirectory()) {
+				const files = await listFiles(path, { registry });
+				updated.push(...files.filter((file) => !matcher.isIgnored(file)));
+			} else if (
+				info.isFile() &&
+				registry.parserForFile(path) &&
+				!matcher.isIgnored(path)
+			) {
+				updated.push(path);
+			}
+		}
+
+		const changes: SymbolChange[] = [];
+		for (const path of new Set(updated)) {
+			try {
+				changes.push(...(await reindex(path, removed)));
+			} catch (err) {
+				log.warn("Watch reindex failed", { path, error: String(err) });
+			}
+		}
+		for (const path of removed) {
+			try {
+				changes.push(...index.removeFile(path));
+			} catch (err) {
+				log.warn("Watch remove failed", { path, error: String(err) });
+			}
+		}
+
+		if (changes.length > 0) {
+			try {
+				onChange(changes);
+			} catch (err) {
+				log.warn("Watch callback failed", { error: String(err) });
+			}
+		}
+		return changes;
+	};
+
+	const flush = (): Promise<SymbolChange[]> => {
+		if (timer) {
+			clearTimeout(timer);
+			timer = null;
+		}
+		// Пачки обрабатываются строго последовательно
+		running = running.then(processBatch, processBatch);
+		return running;
+	};
+
+	// Запросы к индексу (runQuery) не ждут debounce
+	const stopRefresh = index.onRefresh(() =>
+		pending.size > 0 ? flush() : running
+	);
+
+	const watcher: FSWatcher = fsWatch(
+		rootPath,
+		{ recursive: true },
+		(_event, filename) => {
+			if (!filename) {
+				return;
+			}
+			const path = join(rootPath, filename.toString());
+			if (matcher.isIgnored(path)) {
+				return;
+			}
+			pending.add(path);
+			if (timer) {
+				clearTimeout(timer);
+			}
+			timer = setTimeout(() => {
+				flush().catch((err) => {
+					log.warn("Reindex failed", { error: String(err) });
+				});
+			}, debounceMs);
+		}
+	);
+	watcher.on("error", (err) => {
+		log.warn("Watcher failed", { error: String(err) });
+	});
+
+	return {
+		index,
+		close: () => {
+			watcher.close();
+			stopRefresh();
+			if (timer) {
+				clearTimeout(timer);
+				timer = null;
+			}
+		},
+		flush,
+	};
+}
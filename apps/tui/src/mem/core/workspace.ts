@@ -0,0 +1,260 @@
+import { readdir } from "node:fs/promises";
+import { availableParallelism } from "node:os";
+import { join, relative, resolve, sep } from "node:path";
+import { globToRegExp, IgnoreMatcher } from "./gitignore.ts";
+import type { ParseCache } from "./parse-cache.ts";
+import type { ParserRegistry } from "./parsers/parser-registry.ts";
+import { parserRegistry } from "./parsers/parser-registry.ts";
+import { readSource } from "./parsers/source-text.ts";
+import type { EnhancedCodeSymbol } from "./parsers/types.ts";
+import { resolveRepoPath } from "./repo-path.ts";
+import { assignSymbolHashes } from "./symbol-hash.ts";
+import { assignSymbolIds } from "./symbol-id.ts";
+
+/**
+ * Параметры обхода: что считать файлами workspace
+ */
+export interface ListFilesOptions {
+	/** Учитывать .gitignore (корневой и вложенные), по умолчанию true */
+	gitignore?: boolean;
+	/** Шаблоны в синтаксисе .gitignore, исключаемые поверх .gitignore */
+	ignore?: string[];
+	/** Allowlist: если задан, берутся только файлы, совпавшие с шаблоном */
+	include?: string[];
+	registry?: ParserRegistry;
+}
+
+export interface WorkspaceParseError {
+	error: string;
+	path: string;
+}
+
+export interface WorkspaceParseOptions extends ListFilesOptions {
+	cache?: ParseCache;
+	/** Число одновременно обрабатываемых файлов (по умолчанию число CPU) */
+	concurrency?: number;
+}
+
+export interface WorkspaceParseResult {
+	errors: WorkspaceParseError[];
+	files: string[];
+	symbols: EnhancedCodeSymbol[];
+}
+
+export interface ParseFilesOptions {
+	cache?: ParseCache;
+	/** Число одновременно обрабатываемых файлов (по умолчанию число CPU) */
+	concurrency?: number;
+	registry?: ParserRegistry;
+	/** Корень для путей в `id` символов (по умолчанию cwd) */
+	root?: string;
+}
+
+export interface ParseFilesResult {
+	/** Файлы, которые не удалось прочитать или распарсить */
+	errors: Map<string, Error>;
+	/**
+	 * Символы по путям в том виде, в каком они переданы; symbol.path —
+	 * абсолютный путь, relativePath — от root
+	 */
+	results: Map<string, EnhancedCodeSymbol[]>;
+	/** Файлы без парсера для их расширения */
+	skipped: string[];
+}
+
+/**
+ * Файлы под root, которые будут распарсены (dry-run для parseWorkspace)
+ *
+ * Скрытые файлы и директории, а также DEFAULT_IGNORE_DIRS пропускаются
+ * всегда. Возвращает абсолютные пути в отсортированном порядке.
+ */
+export async function listFiles(
+	root: string,
+	options: ListFilesOptions = {}
+): Promise<string[]> {
+	const rootPath = resolve(root);
+	const registry = options.registry ?? parserRegistry;
+	const matcher = new IgnoreMatcher(rootPath, options.ignore);
+	const include = (options.include ?? []).map(globToRegExp);
+	const results: string[] = [];
+
+	async function walk(dir: string): Promise<void> {
+		let entries;
+		try {
+			entries = await readdir(dir, { withFileTypes: true });
+		} catch {
+			return;
+		}
+		if (options.gitignore !== false) {
+			await matcher.addGitignore(dir);
+		}
+		for (const entry of entries) {
+			const fullPath = join(dir, entry.name);
+			const rel = relative(rootPath, fullPath).split(sep).join("/");
+			// Родитель уже прошёл проверку, достаточно проверить сам entry
+			if (matcher.matches(rel, entry.isDirectory())) {
+				continue;
+			}
+			if (entry.isDirectory()) {
+				await walk(fullPath);
+			} else if (
+				entry.isFile() &&
+				registry.parserForFile(fullPath) &&
+				(include.length === 0 || include.some((re) => re.test(rel)))
+			) {
+				results.push(fullPath);
+			}
+		}
+	}
+
+	await walk(rootPath);
+	return results.sort();
+}
+
+/**
+ * Распарсить все поддерживаемые файлы workspace
+ *
+ * Файлы обрабатываются пулом из `concurrency` асинхронных воркеров, но
+ * символы собираются в порядке отсортированных путей, так что результат
+ * детерминирован. Символы получают `id` с путём относительно root.
+ * Ошибка в одном файле не прерывает обход, а попадает в `errors`.
+ */
+export async function parseWorkspace(
+	root: string,
+	options: WorkspaceParseOptions = {}
+): Promise<WorkspaceParseResult> {
+	const registry = options.registry ?? parserRegistry;
+	const files = await listFiles(root, { ...options, registry });
+	const concurrency = Math.max(
+		1,
+		options.concurrency ?? availableParallelism()
+	);
+
+	const perFile: EnhancedCodeSymbol[][] = new Array(files.length);
+	const failures: (WorkspaceParseError | undefined)[] = new Array(
+		files.length
+	);
+
+	await runPool(files.length, concurrency, async (index) => {
+		const path = files[index] as string;
+		try {
+			perFile[index] = assignSymbolHashes(
+				assignSymbolIds(
+					await parseOne(path, registry, options.cache),
+					resolve(root)
+				)
+			);
+		} catch (err) {
+			perFile[index] = [];
+			failures[index] = {
+				path,
+				error: err instanceof Error ? err.message : String(err),
+			};
+		}
+	});
+
+	return {
+		files,
+		symbols: perFile.flat(),
+		errors: failures.filter((f): f is WorkspaceParseError => !!f),
+	};
+}
+
+/**
+ * Распарсить произвольный список файлов (например, выбранных в picker'е)
+ *
+ * Никогда не бросает из-за отдельного файла: нечитаемые файлы и ошибки
+ * парсинга попадают в `errors`, файлы с неизвестным расширением —
+ * в `skipped`. Порядок results и skipped совпадает с порядком paths.
+ *
+ * Относительные пути разрешаются от root. Один файл под разными
+ * написаниями (`./a.go`, `/repo/a.go`, `a\b.go`) парсится один раз
+ * и попадает в results под первым из них.
+ */
+export async function parseFiles(
+	paths: string[],
+	options: ParseFilesOptions = {}
+): Promise<ParseFilesResult> {
+	const registry = options.registry ?? parserRegistry;
+	const root = resolve(options.root ?? process.cwd());
+	const concurrency = Math.max(
+		1,
+		options.concurrency ?? availableParallelism()
+	);
+	const seen = new Set<string>();
+	const supported = paths.filter((path) => {
+		const absolute = resolveRepoPath(path, root);
+		if (seen.has(absolute) || !registry.parserForFile(path)) {
+			return false;
+		}
+		seen.add(absolute);
+		return true;
+	});
+
+	const perFile: (EnhancedCodeSymbol[] | Error)[] = new Array(
+		supported.length
+	);
+	await runPool(supported.length, concurrency, async (index) => {
+		const path = resolveRepoPath(supported[index] as string, root);
+		try {
+			perFile[index] = assignSymbolHashes(
+				assignSymbolIds(await parseOne(path, registry, options.cache), root)
+			);
+		} catch (err) {
+			perFile[index] = err instanceof Error ? err : new Error(String(err));
+		}
+	});
+
+	const results = new Map<string, EnhancedCodeSymbol[]>();
+	const errors = new Map<string, Error>();
+	supported.forEach((path, index) => {
+		const outcome = perFile[index];
+		if (outcome instanceof Error) {
+			errors.set(path, outcome);
+		} else {
+			results.set(path, outcome ?? []);
+		}
+	});
+
+	return {
+		results,
+		errors,
+		skipped: paths.filter((path) => !registry.parserForFile(path)),
+	};
+}
+
+/**
+ * Обработать индексы [0, count) пулом из `concurrency` асинхронных воркеров
+ */
+async function runPool(
+	count: number,
+	concurrency: number,
+	task: (index: number) => Promise<void>
+): Promise<void> {
+	let next = 0;
+	async function worker(): Promise<void> {
+		while (next < count) {
+			await task(next++);
+		}
+	}
+	await Promise.all(
+		Array.from({ length: Math.min(concurrency, count) }, worker)
+	);
+}
+
+async function parseOne(
+	path: string,
+	registry: ParserRegistry,
+	cache?: ParseCache
+): Promise<EnhancedCodeSymbol[]> {
+	// Реестр создаёт новый экземпляр парсера на каждый вызов,
+	// поэтому воркеры не делят состояние парсера
+	const parser = registry.parserForFile(path);
+	if (!parser) {
+		return [];
+	}
+	const source = await readSource(path);
+	return cache
+		? cache.parseSource(path, source, parser, { rethrow: true })
+		: parser.parseSource(path, source, { rethrow: true });
+}
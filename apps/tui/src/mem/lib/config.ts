@@ -5,6 +5,9 @@ import { z } from "zod";
 const MEM_DIR = ".yep-mem";
 const CONFIG_FILE = "config.json";
 
+// Проверяется по полям в SavedQueries (core/saved-queries.ts)
+const savedQuerySchema = z.record(z.string(), z.unknown());
+
 const configSchema = z.object({
 	createdAt: z.string().default(""),
 	embeddingModel: z.string().nullable().default(null),
@@ -14,6 +17,7 @@ const configSchema = z.object({
 	ollamaBaseUrl: z.string().nullable().default(null),
 	openaiApiKey: z.string().nullable().default(null),
 	provider: z.enum(["openai", "ollama"]).default("openai"),
+	savedQueries: z.record(z.string(), savedQuerySchema).default({}),
 	scope: z.string().default(""),
 	summarizerModel: z.string().nullable().default(null),
 });
@@ -110,6 +114,14 @@ export function setLocalSyncOffset(sessionName: string, offset: number): void {
 	writeConfig(config);
 }
 
+export function getSavedQueries(): MemConfig["savedQueries"] {
+	return readConfig().savedQueries;
+}
+
+export function setSavedQueries(queries: MemConfig["savedQueries"]): void {
+	updateConfig({ savedQueries: queries });
+}
+
 export function getProvider(): ProviderType {
 	return readConfig().provider;
 }